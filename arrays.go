@@ -0,0 +1,240 @@
+package onedb
+
+import (
+	"database/sql"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseArray parses a one-dimensional Postgres array literal like {1,"a b",NULL} into its elements, which are
+// strings, or nil for NULL. Drivers which don't decode arrays return them in this form
+func ParseArray(text string) ([]interface{}, error) {
+	if i := strings.Index(text, "={"); i != -1 && strings.HasPrefix(text, "[") {
+		text = text[i+1:] // skip dimensions like [0:2]=
+	}
+	if len(text) < 2 || text[0] != '{' || text[len(text)-1] != '}' {
+		return nil, errors.Errorf("invalid array literal %q", text)
+	}
+	body := text[1 : len(text)-1]
+	elements := []interface{}{}
+	if strings.TrimSpace(body) == "" {
+		return elements, nil
+	}
+	for i := 0; ; {
+		for i < len(body) && body[i] == ' ' {
+			i++
+		}
+		if i < len(body) && body[i] == '{' {
+			return nil, errors.New("multidimensional arrays are not supported")
+		}
+		var element interface{}
+		if i < len(body) && body[i] == '"' {
+			var b strings.Builder
+			for i++; i < len(body) && body[i] != '"'; i++ {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				b.WriteByte(body[i])
+			}
+			if i == len(body) {
+				return nil, errors.Errorf("unterminated quoted element in array literal %q", text)
+			}
+			i++
+			element = b.String()
+		} else {
+			end := strings.IndexByte(body[i:], ',')
+			if end == -1 {
+				end = len(body) - i
+			}
+			value := strings.TrimSpace(body[i : i+end])
+			if value == "" {
+				return nil, errors.Errorf("empty element in array literal %q", text)
+			}
+			if strings.EqualFold(value, "NULL") {
+				element = nil
+			} else {
+				element = value
+			}
+			i += end
+		}
+		elements = append(elements, element)
+		for i < len(body) && body[i] == ' ' {
+			i++
+		}
+		if i == len(body) {
+			return elements, nil
+		}
+		if body[i] != ',' {
+			return nil, errors.Errorf("invalid array literal %q", text)
+		}
+		i++
+	}
+}
+
+// ScanArray stores the value of an array column in dest, which must point to a slice, converting each element.
+// src can be a slice, as returned by drivers which decode arrays, or a Postgres array literal as text or bytes.
+// Elements are converted between numeric types, parsed from strings or decoded by an encoding.TextUnmarshaler,
+// so a uuid[] column can be scanned into a slice of UUIDs. NULL elements become zero values
+func ScanArray(src interface{}, dest interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Slice {
+		return errors.Errorf("ScanArray requires a pointer to a slice, not %T", dest)
+	}
+	if src == nil {
+		value.Elem().Set(reflect.Zero(value.Elem().Type()))
+		return nil
+	}
+	elements, ok, err := arrayElements(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("Unable to scan %T as an array", src)
+	}
+	return setArray(value.Elem(), elements)
+}
+
+// arrayElements returns the elements of an array column's value, reporting false if it isn't an array
+func arrayElements(src interface{}) (reflect.Value, bool, error) {
+	var text string
+	switch v := src.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		value := reflect.ValueOf(src)
+		return value, value.Kind() == reflect.Slice, nil
+	}
+	if !strings.HasPrefix(text, "{") && !strings.HasPrefix(text, "[") {
+		return reflect.Value{}, false, nil
+	}
+	elements, err := ParseArray(text)
+	if err != nil {
+		// a JSON array is left for setJSON
+		return reflect.Value{}, false, nil
+	}
+	return reflect.ValueOf(elements), true, nil
+}
+
+// isArrayField reports whether a field is populated from an array column by converting its elements. Slices of
+// structs and maps are populated from JSON instead, unless their elements decode themselves from text
+func isArrayField(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() == reflect.Uint8 || reflect.PtrTo(t).Implements(scannerType) {
+		return false
+	}
+	switch t.Elem().Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice:
+		return reflect.PtrTo(t.Elem()).Implements(textUnmarshalerType)
+	}
+	return true
+}
+
+// setArray sets dest, a slice, to the converted elements
+func setArray(dest reflect.Value, elements reflect.Value) error {
+	slice := reflect.MakeSlice(dest.Type(), elements.Len(), elements.Len())
+	for i := 0; i < elements.Len(); i++ {
+		if err := convertElement(slice.Index(i), elements.Index(i).Interface()); err != nil {
+			return errors.Wrapf(err, "Unable to convert array element %d", i+1)
+		}
+	}
+	dest.Set(slice)
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func convertElement(dest reflect.Value, src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	value := reflect.ValueOf(src)
+	t := dest.Type()
+	if value.Type().AssignableTo(t) {
+		dest.Set(value)
+		return nil
+	}
+	s, isString := src.(string)
+	if isString && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return dest.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if isString && reflect.PtrTo(t).Implements(scannerType) {
+		return dest.Addr().Interface().(sql.Scanner).Scan(s)
+	}
+	if isUUIDField(t) {
+		id, ok, err := uuidValue(src)
+		if ok {
+			setUUID(dest, id)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isString {
+			n, err := strconv.ParseInt(s, 10, t.Bits())
+			dest.SetInt(n)
+			return err
+		}
+		if isNumber(value.Kind()) {
+			dest.Set(value.Convert(t))
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if isString {
+			n, err := strconv.ParseUint(s, 10, t.Bits())
+			dest.SetUint(n)
+			return err
+		}
+		if isNumber(value.Kind()) {
+			dest.Set(value.Convert(t))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if isString {
+			n, err := strconv.ParseFloat(s, t.Bits())
+			dest.SetFloat(n)
+			return err
+		}
+		if isNumber(value.Kind()) {
+			dest.Set(value.Convert(t))
+			return nil
+		}
+	case reflect.Bool:
+		if isString {
+			switch strings.ToLower(s) {
+			case "t", "true":
+				dest.SetBool(true)
+			case "f", "false":
+				dest.SetBool(false)
+			default:
+				return errors.Errorf("cannot convert %q to %s", s, t)
+			}
+			return nil
+		}
+	case reflect.String:
+		if value.Kind() == reflect.String {
+			dest.SetString(value.String())
+			return nil
+		}
+		if stringer, ok := src.(fmt.Stringer); ok {
+			dest.SetString(stringer.String())
+			return nil
+		}
+	case reflect.Ptr:
+		elem := reflect.New(t.Elem())
+		if err := convertElement(elem.Elem(), src); err != nil {
+			return err
+		}
+		dest.Set(elem)
+		return nil
+	}
+	return errors.Errorf("cannot convert %T to %s", src, t)
+}
@@ -0,0 +1,109 @@
+package onedb
+
+import (
+	enchex "encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArray(t *testing.T) {
+	elements, err := ParseArray(`{1, "a b","quote \" and \\ slash",NULL,"NULL",}`)
+	if err == nil {
+		t.Error("expected error for a trailing comma", elements)
+	}
+	elements, err = ParseArray(`{1, "a b","quote \" and \\ slash",NULL,"NULL"}`)
+	if err != nil || !reflect.DeepEqual(elements, []interface{}{"1", "a b", `quote " and \ slash`, nil, "NULL"}) {
+		t.Error("expected quoted, unquoted and NULL elements", elements, err)
+	}
+	if elements, err := ParseArray("[0:1]={a,b}"); err != nil || !reflect.DeepEqual(elements, []interface{}{"a", "b"}) {
+		t.Error("expected dimensions to be skipped", elements, err)
+	}
+	if elements, err := ParseArray("{}"); err != nil || len(elements) != 0 {
+		t.Error("expected empty array", elements, err)
+	}
+	for _, text := range []string{"", "a,b", `{"a}`, "{{1,2},{3,4}}", `{"a"b}`} {
+		if _, err := ParseArray(text); err == nil {
+			t.Error("expected error", text)
+		}
+	}
+}
+
+type arrayID [2]byte
+
+func (id *arrayID) UnmarshalText(text []byte) error {
+	_, err := enchex.Decode(id[:], text)
+	return err
+}
+
+func TestScanArray(t *testing.T) {
+	var ints []int64
+	if err := ScanArray([]int32{1, 2}, &ints); err != nil || !reflect.DeepEqual(ints, []int64{1, 2}) {
+		t.Error("expected numeric elements to be converted", ints, err)
+	}
+	if err := ScanArray([]byte("{3,NULL}"), &ints); err != nil || !reflect.DeepEqual(ints, []int64{3, 0}) {
+		t.Error("expected array literal elements to be parsed", ints, err)
+	}
+	var ptrs []*string
+	if err := ScanArray("{a,NULL}", &ptrs); err != nil || len(ptrs) != 2 || *ptrs[0] != "a" || ptrs[1] != nil {
+		t.Error("expected NULL elements to stay nil", ptrs, err)
+	}
+	var ids []arrayID
+	if err := ScanArray([]interface{}{"0102", "a0b0"}, &ids); err != nil || !reflect.DeepEqual(ids, []arrayID{{1, 2}, {0xa0, 0xb0}}) {
+		t.Error("expected elements decoded as text", ids, err)
+	}
+	var flags []bool
+	if err := ScanArray("{t,f}", &flags); err != nil || !reflect.DeepEqual(flags, []bool{true, false}) {
+		t.Error("expected booleans", flags, err)
+	}
+	if err := ScanArray("{TRUE,false}", &flags); err != nil || !reflect.DeepEqual(flags, []bool{true, false}) {
+		t.Error("expected booleans in any case", flags, err)
+	}
+	if err := ScanArray("{t,yes}", &flags); err == nil || !strings.Contains(err.Error(), `"yes"`) {
+		t.Error("expected error for text which isn't a boolean", err)
+	}
+	if err := ScanArray(nil, &ints); err != nil || ints != nil {
+		t.Error("expected NULL to clear the slice", ints, err)
+	}
+
+	if err := ScanArray("{x}", &ints); err == nil || !strings.Contains(err.Error(), "element 1") {
+		t.Error("expected error naming the element", err)
+	}
+	if err := ScanArray(1, &ints); err == nil {
+		t.Error("expected error for a non-array")
+	}
+	if err := ScanArray([]int{1}, ints); err == nil {
+		t.Error("expected error for a non-pointer destination")
+	}
+}
+
+type arrayRow struct {
+	ID    int
+	Tags  string
+	Ints  []int32
+	Items []interface{}
+}
+
+type arrayResult struct {
+	ID    int
+	Tags  []string
+	Ints  []int64
+	Items []arrayID
+}
+
+func TestGetStructArrays(t *testing.T) {
+	result := []arrayResult{}
+	rows := NewRowsScanner([]arrayRow{{1, `{a,"b c"}`, []int32{1, 2}, []interface{}{"0102"}}})
+	if err := getStruct(rows, &result, StructOptions{}); err != nil || len(result) != 1 {
+		t.Fatal("expected success", err, result)
+	}
+	expected := arrayResult{1, []string{"a", "b c"}, []int64{1, 2}, []arrayID{{1, 2}}}
+	if !reflect.DeepEqual(result[0], expected) {
+		t.Error("expected array columns converted into the fields", result[0])
+	}
+
+	rows = NewRowsScanner([]arrayRow{{Tags: "{a}", Items: []interface{}{"zz"}}})
+	if err := getStruct(rows, &result, StructOptions{}); err == nil {
+		t.Error("expected error for an element which can't be converted")
+	}
+}
@@ -0,0 +1,67 @@
+package onedb
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+// ColumnType describes a column of a query's result so generic tools like exporters and mappers can work with
+// any backend
+type ColumnType struct {
+	Name string
+
+	// DatabaseType is the backend's name for the column's type, like int4 or varchar. It is empty when unknown
+	DatabaseType string
+
+	// OID is the Postgres OID of the column's type, or 0 for other backends
+	OID uint32
+
+	// Nullable is false only when the column is known never to be NULL
+	Nullable bool
+
+	// ScanType is the Go type suggested for scanning the column. It is the type of interface{} when unknown
+	ScanType reflect.Type
+}
+
+// ColumnTyper is implemented by RowsScanners which can describe the types of their columns
+type ColumnTyper interface {
+	ColumnTypes() ([]ColumnType, error)
+}
+
+type sqlColumnTyper interface {
+	ColumnTypes() ([]*sql.ColumnType, error)
+}
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// ColumnTypes describes the columns of rows. Rows from database/sql are described by their sql.ColumnTypes, and
+// rows which don't implement ColumnTyper are described by their column names alone
+func ColumnTypes(rows RowsScanner) ([]ColumnType, error) {
+	switch r := rows.(type) {
+	case ColumnTyper:
+		return r.ColumnTypes()
+	case sqlColumnTyper:
+		sqlTypes, err := r.ColumnTypes()
+		if err != nil {
+			return nil, err
+		}
+		columnTypes := make([]ColumnType, len(sqlTypes))
+		for i, t := range sqlTypes {
+			nullable, ok := t.Nullable()
+			columnTypes[i] = ColumnType{Name: t.Name(), DatabaseType: t.DatabaseTypeName(), Nullable: nullable || !ok, ScanType: t.ScanType()}
+			if columnTypes[i].ScanType == nil {
+				columnTypes[i].ScanType = interfaceType
+			}
+		}
+		return columnTypes, nil
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	columnTypes := make([]ColumnType, len(columns))
+	for i, column := range columns {
+		columnTypes[i] = ColumnType{Name: column, Nullable: true, ScanType: interfaceType}
+	}
+	return columnTypes, nil
+}
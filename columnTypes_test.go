@@ -0,0 +1,27 @@
+package onedb
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type columnTypesRow struct {
+	ID    int
+	Name  *string
+	Email sql.NullString `db:"email_address"`
+}
+
+func TestColumnTypes(t *testing.T) {
+	types, err := ColumnTypes(NewRowsScanner([]columnTypesRow{}))
+	expected := []ColumnType{{Name: "ID", ScanType: reflect.TypeOf(0)}, {Name: "Name", Nullable: true, ScanType: reflect.TypeOf((*string)(nil))},
+		{Name: "email_address", Nullable: true, ScanType: reflect.TypeOf(sql.NullString{})}}
+	if err != nil || !reflect.DeepEqual(types, expected) {
+		t.Error("expected mock columns described by their fields", types, err)
+	}
+
+	types, err = ColumnTypes(&MockRows{})
+	if columns, _ := (&MockRows{}).Columns(); err != nil || len(types) != len(columns) || types[0].Name != columns[0] || !types[0].Nullable || types[0].ScanType != interfaceType {
+		t.Error("expected other rows described by their column names", types, err)
+	}
+}
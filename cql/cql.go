@@ -0,0 +1,236 @@
+package cql
+
+import (
+	"context"
+	"io"
+	"reflect"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/gocql/gocql"
+)
+
+// ErrNotFound occurs when QueryRow finds no rows. It is reexported from gocql
+var ErrNotFound = gocql.ErrNotFound
+
+// CQLer is the interface containing the capability available for a Cassandra or ScyllaDB cluster
+type CQLer interface {
+	Close()
+	Exec(query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) error
+	Query(query string, args ...interface{}) (onedb.RowsScanner, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error)
+	QueryRow(query string, args ...interface{}) onedb.Scanner
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner
+	onedb.Pinger
+	onedb.DBer
+}
+
+type cqlSession interface {
+	iter(ctx context.Context, query string, args []interface{}) cqlIter
+	exec(ctx context.Context, query string, args []interface{}) error
+	Close()
+}
+
+// cqlIter is the part of *gocql.Iter used to read rows
+type cqlIter interface {
+	Columns() []gocql.ColumnInfo
+	Scanner() gocql.Scanner
+}
+
+type gocqlSession struct {
+	*gocql.Session
+}
+
+func (s gocqlSession) iter(ctx context.Context, query string, args []interface{}) cqlIter {
+	return s.Query(query, args...).WithContext(ctx).Iter()
+}
+
+func (s gocqlSession) exec(ctx context.Context, query string, args []interface{}) error {
+	return s.Query(query, args...).WithContext(ctx).Exec()
+}
+
+type cqlBackend struct {
+	session cqlSession
+}
+
+// NewCql returns a CQLer connected to the cluster at hosts using keyspace
+func NewCql(keyspace string, hosts ...string) (CQLer, error) {
+	cluster := gocql.NewCluster(hosts...)
+	cluster.Keyspace = keyspace
+	return NewCqlWithCluster(cluster)
+}
+
+// NewCqlWithCluster returns a CQLer using a gocql cluster configuration. Rows are read one page at a time, so
+// cluster.PageSize sets how many rows are buffered while reading a query's results
+func NewCqlWithCluster(cluster *gocql.ClusterConfig) (CQLer, error) {
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, err
+	}
+	return &cqlBackend{session: gocqlSession{session}}, nil
+}
+
+func (b *cqlBackend) Close() {
+	b.session.Close()
+}
+
+// pingQuery reads from the node's own system table, which exists on every Cassandra and ScyllaDB node
+const pingQuery = "SELECT now() FROM system.local"
+
+// Ping verifies that the cluster answers a query
+func (b *cqlBackend) Ping() error {
+	return b.PingContext(context.Background())
+}
+
+func (b *cqlBackend) PingContext(ctx context.Context) error {
+	return b.session.exec(ctx, pingQuery, nil)
+}
+
+func (b *cqlBackend) Exec(query string, args ...interface{}) error {
+	return b.ExecContext(context.Background(), query, args...)
+}
+
+func (b *cqlBackend) ExecContext(ctx context.Context, query string, args ...interface{}) error {
+	return b.session.exec(ctx, query, args)
+}
+
+func (b *cqlBackend) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return b.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext returns the query's rows. Further pages are fetched from the cluster as Next reaches the end
+// of the current one
+func (b *cqlBackend) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return newCqlRows(b.session.iter(ctx, query, args)), nil
+}
+
+func (b *cqlBackend) QueryRow(query string, args ...interface{}) onedb.Scanner {
+	return b.QueryRowContext(context.Background(), query, args...)
+}
+
+func (b *cqlBackend) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	return &cqlRow{newCqlRows(b.session.iter(ctx, query, args))}
+}
+
+func (b *cqlBackend) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValues(b, query, result...)
+}
+
+func (b *cqlBackend) QueryJSON(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSON(b, query, args...)
+}
+
+func (b *cqlBackend) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRow(b, query, args...)
+}
+
+func (b *cqlBackend) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, b, query, args...)
+}
+
+func (b *cqlBackend) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStruct(b, result, query, args...)
+}
+
+func (b *cqlBackend) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRow(b, result, query, args...)
+}
+
+func (b *cqlBackend) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSV(w, options, b, query, args...)
+}
+
+// cqlRows adapts a gocql.Scanner to onedb.RowsScanner. Tuple columns are expanded into one column per element,
+// matching how gocql scans them
+type cqlRows struct {
+	scanner gocql.Scanner
+	names   []string
+	types   []gocql.TypeInfo
+	err     error
+	closed  bool
+}
+
+func newCqlRows(iter cqlIter) *cqlRows {
+	r := &cqlRows{scanner: iter.Scanner()}
+	for _, column := range iter.Columns() {
+		if tuple, ok := column.TypeInfo.(gocql.TupleTypeInfo); ok {
+			for i, elem := range tuple.Elems {
+				r.names = append(r.names, gocql.TupleColumnName(column.Name, i))
+				r.types = append(r.types, elem)
+			}
+			continue
+		}
+		r.names = append(r.names, column.Name)
+		r.types = append(r.types, column.TypeInfo)
+	}
+	return r
+}
+
+func (r *cqlRows) Columns() ([]string, error) {
+	return r.names, nil
+}
+
+func (r *cqlRows) Next() bool {
+	if r.closed {
+		return false
+	}
+	if r.scanner.Next() {
+		return true
+	}
+	r.Close()
+	return false
+}
+
+// Scan copies the current row into dest. gocql can't unmarshal into *interface{}, so those columns are
+// scanned into a value of the column's Go type first
+func (r *cqlRows) Scan(dest ...interface{}) error {
+	targets := make([]interface{}, len(dest))
+	copy(targets, dest)
+	for i, d := range dest {
+		if _, ok := d.(*interface{}); ok && i < len(r.types) {
+			value, err := r.types[i].NewWithError()
+			if err != nil {
+				return err
+			}
+			targets[i] = value
+		}
+	}
+	if err := r.scanner.Scan(targets...); err != nil {
+		return err
+	}
+	for i, d := range dest {
+		if p, ok := d.(*interface{}); ok && i < len(r.types) {
+			*p = reflect.ValueOf(targets[i]).Elem().Interface()
+		}
+	}
+	return nil
+}
+
+// Err returns the error which ended iteration. gocql's Scanner releases the iterator when asked for its error,
+// so it is only checked once the rows are closed
+func (r *cqlRows) Err() error {
+	return r.err
+}
+
+func (r *cqlRows) Close() error {
+	if !r.closed {
+		r.closed = true
+		r.err = r.scanner.Err()
+	}
+	return r.err
+}
+
+type cqlRow struct {
+	rows *cqlRows
+}
+
+func (r *cqlRow) Scan(dest ...interface{}) error {
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	return r.rows.Scan(dest...)
+}
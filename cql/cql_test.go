@@ -0,0 +1,162 @@
+package cql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+func TestNewCqlRealConnection(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	db, err := NewCql("system", "localhost")
+	if err != nil {
+		t.Fatal("expected connection success", err)
+	}
+	defer db.Close()
+	var version string
+	if err := db.QueryRow("select release_version from local").Scan(&version); err != nil || version == "" {
+		t.Error("expected select to succeed", version, err)
+	}
+}
+
+func TestQueryStruct(t *testing.T) {
+	s := &mockSession{rows: [][]interface{}{{1, "alice"}, {2, "bob"}}}
+	db := &cqlBackend{session: s}
+
+	type user struct {
+		ID   int
+		Name string
+	}
+	var users []user
+	if err := db.QueryStruct(&users, "select id, name from users where org = ?", "acme"); err != nil {
+		t.Fatal("expected success", err)
+	}
+	if len(users) != 2 || users[0].ID != 1 || users[1].Name != "bob" {
+		t.Error("expected rows scanned into structs", users)
+	}
+	if s.query != "select id, name from users where org = ?" || !reflect.DeepEqual(s.args, []interface{}{"acme"}) {
+		t.Error("expected query and args passed to gocql", s.query, s.args)
+	}
+	if !s.scanner.released {
+		t.Error("expected the iterator to be released")
+	}
+}
+
+func TestQueryJSON(t *testing.T) {
+	db := &cqlBackend{session: &mockSession{rows: [][]interface{}{{1, "alice"}}}}
+	json, err := db.QueryJSON("select id, name from users")
+	if err != nil || json != `[{"id":1,"name":"alice"}]` {
+		t.Error("expected json", json, err)
+	}
+}
+
+func TestQueryIterationError(t *testing.T) {
+	db := &cqlBackend{session: &mockSession{rows: [][]interface{}{{1, "alice"}}, err: errors.New("fail")}}
+	rows, _ := db.Query("select id, name from users")
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if count != 1 || rows.Err() == nil {
+		t.Error("expected rows to be read until the error", count, rows.Err())
+	}
+}
+
+func TestQueryRow(t *testing.T) {
+	db := &cqlBackend{session: &mockSession{rows: [][]interface{}{{1, "alice"}}}}
+	var id int
+	var name string
+	if err := db.QueryRow("select id, name from users").Scan(&id, &name); err != nil || id != 1 || name != "alice" {
+		t.Error("expected row", id, name, err)
+	}
+
+	db = &cqlBackend{session: &mockSession{}}
+	if err := db.QueryRow("select id, name from users").Scan(&id, &name); err != ErrNotFound {
+		t.Error("expected ErrNotFound", err)
+	}
+}
+
+func TestExec(t *testing.T) {
+	s := &mockSession{}
+	db := &cqlBackend{session: s}
+	if err := db.Exec("delete from users where id = ?", 1); err != nil || s.query != "delete from users where id = ?" {
+		t.Error("expected exec", s.query, err)
+	}
+}
+
+func TestPing(t *testing.T) {
+	s := &mockSession{}
+	b := &cqlBackend{session: s}
+	if err := b.Ping(); err != nil || s.query != pingQuery {
+		t.Error("expected ping query", s.query, err)
+	}
+	s.err = errors.New("fail")
+	if err := b.PingContext(context.Background()); err != s.err {
+		t.Error("expected ping error", err)
+	}
+}
+
+/***************************** MOCKS ****************************/
+type mockSession struct {
+	rows    [][]interface{}
+	err     error
+	query   string
+	args    []interface{}
+	scanner *mockScanner
+}
+
+func (s *mockSession) iter(ctx context.Context, query string, args []interface{}) cqlIter {
+	s.query, s.args = query, args
+	s.scanner = &mockScanner{rows: s.rows, err: s.err}
+	return s
+}
+
+func (s *mockSession) exec(ctx context.Context, query string, args []interface{}) error {
+	s.query, s.args = query, args
+	return s.err
+}
+
+func (s *mockSession) Close() {}
+
+func (s *mockSession) Columns() []gocql.ColumnInfo {
+	return []gocql.ColumnInfo{
+		{Name: "id", TypeInfo: gocql.NewNativeType(4, gocql.TypeInt, "")},
+		{Name: "name", TypeInfo: gocql.NewNativeType(4, gocql.TypeText, "")},
+	}
+}
+
+func (s *mockSession) Scanner() gocql.Scanner {
+	return s.scanner
+}
+
+type mockScanner struct {
+	rows     [][]interface{}
+	current  []interface{}
+	err      error
+	released bool
+}
+
+func (s *mockScanner) Next() bool {
+	if len(s.rows) == 0 {
+		return false
+	}
+	s.current, s.rows = s.rows[0], s.rows[1:]
+	return true
+}
+
+func (s *mockScanner) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(s.current[i]))
+	}
+	return nil
+}
+
+func (s *mockScanner) Err() error {
+	s.released = true
+	return s.err
+}
@@ -1,15 +1,47 @@
 package onedb
 
 import (
-	"encoding/csv"
+	"bufio"
 	"fmt"
 	"io"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// CSVQuoting sets which fields are quoted in a CSV file
+type CSVQuoting int
+
+const (
+	// QuoteMinimal quotes only fields containing the delimiter, quotes, line breaks or leading space. It is the default
+	QuoteMinimal CSVQuoting = iota
+	// QuoteAll quotes every field, including NULL values
+	QuoteAll
+	// QuoteNonNull quotes every field except NULL values, so NULL can be told apart from an empty string as
+	// PostgreSQL's COPY does
+	QuoteNonNull
 )
 
 // CSVOptions contains specifications for how text should be formatted in a CSV file
 type CSVOptions struct {
 	DateOnly bool
+
+	// Delimiter separates fields. Defaults to a comma
+	Delimiter rune
+
+	// Quote sets which fields are quoted. Defaults to QuoteMinimal
+	Quote CSVQuoting
+
+	// Null is written for NULL values. Defaults to an empty string
+	Null string
+}
+
+// WriteCSV streams rows to w in CSV format with a header row of the column names. rows is not closed
+func WriteCSV(w io.Writer, rows RowsScanner, options CSVOptions) error {
+	return writeCSV(rows, w, options)
 }
 
 func writeCSV(rows RowsScanner, w io.Writer, options CSVOptions) error {
@@ -18,21 +50,87 @@ func writeCSV(rows RowsScanner, w io.Writer, options CSVOptions) error {
 		return err
 	}
 
-	csvWriter := csv.NewWriter(w)
-	if err := csvWriter.Write(headers); err != nil {
+	csvWriter, err := newCSVWriter(w, options)
+	if err != nil {
+		return err
+	}
+	if err := csvWriter.write(headers, nil); err != nil {
 		return err
 	}
+	nulls := make([]bool, len(vals))
 	for rows.Next() {
 		row, err := scanCSV(rows, vals, options)
 		if err != nil {
 			return err
 		}
-		if err = csvWriter.Write(row); err != nil {
+		for i, value := range vals {
+			nulls[i] = *value.(*interface{}) == nil
+		}
+		if err = csvWriter.write(row, nulls); err != nil {
 			return err
 		}
 	}
-	csvWriter.Flush()
-	return csvWriter.Error()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return csvWriter.w.Flush()
+}
+
+// csvWriter writes CSV records like encoding/csv, which can't quote every field
+type csvWriter struct {
+	w       *bufio.Writer
+	options CSVOptions
+}
+
+func newCSVWriter(w io.Writer, options CSVOptions) (*csvWriter, error) {
+	if options.Delimiter == 0 {
+		options.Delimiter = ','
+	}
+	if d := options.Delimiter; d == '"' || d == '\r' || d == '\n' || !utf8.ValidRune(d) || d == utf8.RuneError {
+		return nil, errors.Errorf("invalid CSV delimiter %q", d)
+	}
+	return &csvWriter{w: bufio.NewWriter(w), options: options}, nil
+}
+
+// write writes a record. nulls marks the fields which are NULL and is nil for the header
+func (c *csvWriter) write(record []string, nulls []bool) error {
+	for i, field := range record {
+		if i > 0 {
+			if _, err := c.w.WriteRune(c.options.Delimiter); err != nil {
+				return err
+			}
+		}
+		isNull := nulls != nil && nulls[i]
+		quote := c.needsQuotes(field)
+		switch c.options.Quote {
+		case QuoteAll:
+			quote = true
+		case QuoteNonNull:
+			quote = quote || !isNull
+		}
+		if !quote {
+			if _, err := c.w.WriteString(field); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := c.w.WriteString(`"` + strings.ReplaceAll(field, `"`, `""`) + `"`); err != nil {
+			return err
+		}
+	}
+	return c.w.WriteByte('\n')
+}
+
+// needsQuotes follows encoding/csv's rules so the default output is unchanged
+func (c *csvWriter) needsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if field == `\.` || strings.ContainsRune(field, c.options.Delimiter) || strings.ContainsAny(field, "\"\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(field)
+	return unicode.IsSpace(r)
 }
 
 func scanCSV(s Scanner, vals []interface{}, options CSVOptions) ([]string, error) {
@@ -51,7 +149,7 @@ func scanCSV(s Scanner, vals []interface{}, options CSVOptions) ([]string, error
 func getCSVValue(pval *interface{}, options CSVOptions) string {
 	switch v := (*pval).(type) {
 	case nil:
-		return ""
+		return options.Null
 	case bool:
 		if v {
 			return "true"
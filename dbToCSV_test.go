@@ -1,10 +1,36 @@
 package onedb
 
 import (
+	"bytes"
 	"testing"
 	"time"
 )
 
+type csvRow struct {
+	ID   int
+	Name string
+	Note interface{}
+}
+
+func TestWriteCSV(t *testing.T) {
+	data := []csvRow{{1, "Smith, Jo", nil}, {2, `say "hi"`, ""}, {3, " padded", "x"}}
+	checkWriteCSV(t, data, CSVOptions{}, "ID,Name,Note\n1,\"Smith, Jo\",\n2,\"say \"\"hi\"\"\",\n3,\" padded\",x\n")
+	checkWriteCSV(t, data, CSVOptions{Delimiter: ';', Null: `\N`}, "ID;Name;Note\n1;Smith, Jo;\\N\n2;\"say \"\"hi\"\"\";\n3;\" padded\";x\n")
+	checkWriteCSV(t, data[:2], CSVOptions{Quote: QuoteNonNull}, "\"ID\",\"Name\",\"Note\"\n\"1\",\"Smith, Jo\",\n\"2\",\"say \"\"hi\"\"\",\"\"\n")
+	checkWriteCSV(t, data[:1], CSVOptions{Quote: QuoteAll}, "\"ID\",\"Name\",\"Note\"\n\"1\",\"Smith, Jo\",\"\"\n")
+
+	if err := WriteCSV(&bytes.Buffer{}, NewRowsScanner(data), CSVOptions{Delimiter: '"'}); err == nil {
+		t.Error("expected invalid delimiter error")
+	}
+}
+
+func checkWriteCSV(t *testing.T, data []csvRow, options CSVOptions, expected string) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, NewRowsScanner(data), options); err != nil || buf.String() != expected {
+		t.Errorf("expected %q, got %q %v", expected, buf.String(), err)
+	}
+}
+
 func TestGetCSVValue(t *testing.T) {
 	var options CSVOptions
 	checkCSVValue(t, 10, options, "10")
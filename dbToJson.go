@@ -1,32 +1,57 @@
 package onedb
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"time"
 	"unicode/utf8"
 )
 
+// jsonWriter is satisfied by both *bytes.Buffer and *bufio.Writer
+type jsonWriter interface {
+	io.ByteWriter
+	io.StringWriter
+}
+
 func getJSON(rows RowsScanner) (string, error) {
+	var b bytes.Buffer
+	if err := encodeJSON(rows, &b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// writeJSON encodes each row to w as it is read so the full result never has to be held in memory
+func writeJSON(rows RowsScanner, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if err := encodeJSON(rows, bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func encodeJSON(rows RowsScanner, b jsonWriter) error {
 	columns, vals, err := getColumnNamesAndValues(rows, true)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	var b bytes.Buffer
 	writeComma := false
 	b.WriteByte('[')
 	for rows.Next() {
-		err := scanJSON(rows, columns, vals, writeComma, &b)
+		err := scanJSON(rows, columns, vals, writeComma, b)
 		if err != nil {
-			return "", err
+			return err
 		}
 		writeComma = true
 	}
-	b.WriteByte(']')
-
-	return b.String(), nil
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return b.WriteByte(']')
 }
 
 func getJSONRow(rows RowsScanner) (string, error) {
@@ -46,7 +71,7 @@ func getJSONRow(rows RowsScanner) (string, error) {
 	return b.String(), nil
 }
 
-func scanJSON(s Scanner, columns []string, vals []interface{}, writeComma bool, b *bytes.Buffer) error {
+func scanJSON(s Scanner, columns []string, vals []interface{}, writeComma bool, b jsonWriter) error {
 	if writeComma {
 		b.WriteByte(',')
 	}
@@ -67,8 +92,7 @@ func scanJSON(s Scanner, columns []string, vals []interface{}, writeComma bool,
 			firstColumn = false
 		}
 	}
-	b.WriteByte('}')
-	return nil
+	return b.WriteByte('}') // a buffered writer reports any earlier write error here
 }
 
 func getColumnNamesAndValues(s RowsScanner, isJSON bool) ([]string, []interface{}, error) {
@@ -114,6 +138,8 @@ func getJSONValue(pval *interface{}) string {
 		return encodeByteSlice(v)
 	case time.Time:
 		return v.Format(`"2006-01-02 15:04:05.999"`)
+	case [16]byte:
+		return encodeString(formatUUID(v))
 	case uint8, uint16, uint32, uint64, int, int8, int16, int32, int64, float32, float64, complex64, complex128:
 		return fmt.Sprintf("%v", v) // probably not optimized for speed since Sprintf is relatively slow
 	case string:
@@ -1,6 +1,7 @@
 package onedb
 
 import (
+	"bytes"
 	"errors"
 	"testing"
 	"time"
@@ -166,6 +167,28 @@ func TestGetJsonWithFakeRows(t *testing.T) {
 	}
 }
 
+func TestWriteJSON(t *testing.T) {
+	rows := &MockRows{NumRows: 2}
+	var b bytes.Buffer
+	if err := writeJSON(rows, &b); err != nil {
+		t.Fatal("expected success", err)
+	}
+	json, _ := getJSON(&MockRows{NumRows: 2})
+	if b.String() != json {
+		t.Error("expected streamed json to match", b.String(), json)
+	}
+
+	if err := writeJSON(&MockRows{NumRows: 10000}, &failWriter{}); err == nil {
+		t.Error("expected write error")
+	}
+}
+
+type failWriter struct{}
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("fail")
+}
+
 /******************************* Mocks ***************************************/
 type TestData struct {
 	Nil   interface{}
@@ -0,0 +1,53 @@
+package onedb
+
+import (
+	"github.com/pkg/errors"
+)
+
+func getMaps(rows RowsScanner) ([]map[string]interface{}, error) {
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		item, err := scanMap(rows, columns, vals)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, rows.Err()
+}
+
+func getMap(rows RowsScanner) (map[string]interface{}, error) {
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	if !rows.Next() {
+		return nil, errors.New("Empty result set")
+	}
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return nil, err
+	}
+	return scanMap(rows, columns, vals)
+}
+
+// scanMap scans the current row into a map of column name to value. Bytes are copied since drivers may reuse
+// them for the next row
+func scanMap(s Scanner, columns []string, vals []interface{}) (map[string]interface{}, error) {
+	if err := s.Scan(vals...); err != nil {
+		return nil, err
+	}
+	item := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		v := *(vals[i].(*interface{}))
+		if b, ok := v.([]byte); ok {
+			v = append([]byte(nil), b...)
+		}
+		item[column] = v
+	}
+	return item, nil
+}
@@ -0,0 +1,52 @@
+package onedb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type mapRow struct {
+	ID   int
+	Name interface{}
+	Data []byte
+}
+
+func TestGetMaps(t *testing.T) {
+	data := []mapRow{{1, "alice", []byte("a")}, {2, nil, nil}}
+	result, err := getMaps(NewRowsScanner(data))
+	expected := []map[string]interface{}{{"ID": 1, "Name": "alice", "Data": []byte("a")}, {"ID": 2, "Name": nil, "Data": []byte(nil)}}
+	if err != nil || !reflect.DeepEqual(result, expected) {
+		t.Error("expected a map per row", result, err)
+	}
+	if &result[0]["Data"].([]byte)[0] == &data[0].Data[0] {
+		t.Error("expected bytes copied")
+	}
+
+	if result, err := getMaps(NewRowsScanner([]mapRow{})); err != nil || result == nil || len(result) != 0 {
+		t.Error("expected an empty result", result, err)
+	}
+	rows := NewRowsScanner(data)
+	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	if _, err := getMaps(rows); err == nil {
+		t.Error("expected scan error")
+	}
+	rows = NewRowsScanner(data)
+	rows.(*mockRowsScanner).ColumnsErr = errors.New("fail")
+	if _, err := getMaps(rows); err == nil {
+		t.Error("expected columns error")
+	}
+}
+
+func TestGetMap(t *testing.T) {
+	result, err := getMap(NewRowsScanner([]mapRow{{1, "alice", nil}, {2, "bob", nil}}))
+	if err != nil || !reflect.DeepEqual(result, map[string]interface{}{"ID": 1, "Name": "alice", "Data": []byte(nil)}) {
+		t.Error("expected the first row", result, err)
+	}
+	if _, err := getMap(NewRowsScanner([]mapRow{})); err == nil {
+		t.Error("expected error for an empty result set")
+	}
+	if _, err := getMap(NewRowsScanner(nil)); err == nil {
+		t.Error("expected rows error")
+	}
+}
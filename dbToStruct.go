@@ -1,14 +1,20 @@
 package onedb
 
 import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/pkg/errors"
 )
 
-func getStruct(rows RowsScanner, result interface{}) error {
+func getStruct(rows RowsScanner, result interface{}, options StructOptions) error {
 	columns, vals, err := getColumnNamesAndValues(rows, false)
 	if err != nil {
 		return err
@@ -18,7 +24,7 @@ func getStruct(rows RowsScanner, result interface{}) error {
 	sliceValue := reflect.ValueOf(result).Elem()
 	for rows.Next() {
 		itemValue := reflect.New(itemType)
-		err := scanStruct(rows, vals, dbToStruct, itemValue.Interface())
+		err := scanStruct(rows, vals, dbToStruct, itemValue.Interface(), options.nulls())
 		if err != nil {
 			return err
 		}
@@ -27,7 +33,7 @@ func getStruct(rows RowsScanner, result interface{}) error {
 	return nil
 }
 
-func getStructRow(rows RowsScanner, result interface{}) error {
+func getStructRow(rows RowsScanner, result interface{}, options StructOptions) error {
 	if rows.Err() != nil {
 		return rows.Err()
 	}
@@ -40,25 +46,235 @@ func getStructRow(rows RowsScanner, result interface{}) error {
 	}
 
 	_, dbToStruct := getItemTypeAndMap(columns, reflect.TypeOf(result))
-	err = scanStruct(rows, vals, dbToStruct, result)
+	err = scanStruct(rows, vals, dbToStruct, result, options.nulls())
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func scanStruct(s Scanner, vals []interface{}, dbToStruct []structFieldInfo, result interface{}) error {
+// ScanStruct scans the current row of rows into dest, a pointer to a struct, matching columns to fields the same
+// way QueryStruct does, so callers which call Next themselves can still map each row to a struct. It is a
+// function rather than a method of RowsScanner, so it works with the rows of every backend, including
+// *sql.Rows, and RowsScanner implementations don't need to change. A Scanner from QueryRow has no columns to
+// match, so use QueryStructRow for a single row
+func ScanStruct(rows RowsScanner, dest interface{}) error {
+	if dest == nil || !IsPointer(reflect.TypeOf(dest)) || !IsStruct(reflect.TypeOf(dest).Elem()) || reflect.ValueOf(dest).IsNil() {
+		return ErrRowScannerInvalidData
+	}
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return err
+	}
+	_, dbToStruct := getItemTypeAndMap(columns, reflect.TypeOf(dest))
+	return scanStruct(rows, vals, dbToStruct, dest, StructOptions{}.nulls())
+}
+
+func scanStruct(s Scanner, vals []interface{}, dbToStruct []structFieldInfo, result interface{}, nulls NullPolicy) error {
 	err := s.Scan(vals...)
 	if err != nil {
 		return err
 	}
 	item := reflect.ValueOf(result).Elem()
 	for _, fieldInfo := range dbToStruct {
-		setValue(item.Field(fieldInfo.FieldIndex), vals[fieldInfo.DBIndex].(*interface{}))
+		field := item.Field(fieldInfo.FieldIndex)
+		src := vals[fieldInfo.DBIndex].(*interface{})
+		if *src == nil {
+			if !field.CanSet() || nulls == NullLeave {
+				continue
+			}
+			if !nulls.allows(field.Type()) {
+				return errors.Wrapf(ErrUnexpectedNull, "Unable to scan column %s into %s", fieldInfo.Name, field.Type())
+			}
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		if reflect.TypeOf(*src) != field.Type() {
+			converted, err := convertField(field, *src)
+			if err != nil {
+				return errors.Wrapf(err, "Unable to convert column %s into %s", fieldInfo.Name, field.Type())
+			}
+			if converted {
+				continue
+			}
+		}
+		setValue(field, src)
 	}
 	return nil
 }
 
+// NullPolicy sets which struct fields NULL columns can be stored in by QueryStruct and QueryStructRow
+type NullPolicy int
+
+const (
+	// NullDefault uses the policy set with SetNullPolicy, which is NullLeave unless it has been changed
+	NullDefault NullPolicy = iota
+	// NullLeave leaves the field of a NULL column as it was, whatever its type
+	NullLeave
+	// NullZero stores NULL in any field as its zero value, so NULL can't be told apart from 0 or an empty string
+	// unless the field is a pointer or an sql.Scanner like sql.NullString
+	NullZero
+	// NullPointers stores NULL only in fields which can be nil, like pointers, slices and maps, or in sql.Scanners
+	// like sql.NullString
+	NullPointers
+	// NullTypes stores NULL only in sql.Scanners like sql.NullString and sql.NullInt64
+	NullTypes
+)
+
+// ErrUnexpectedNull occurs when a NULL column is scanned into a field the NullPolicy doesn't allow it in
+var ErrUnexpectedNull = errors.New("NULL value in a field which can't hold NULL")
+
+var (
+	structSettingsMu sync.RWMutex // guards nullPolicy and nameMapper
+	nullPolicy       = NullLeave
+)
+
+// SetNullPolicy changes which fields NULL columns can be stored in for queries which don't choose a policy with
+// StructOptions. NullDefault restores the default of NullLeave. It should be called during initialization since
+// the policy is shared by all queries. It is safe to call while queries run, but those queries may use either policy
+func SetNullPolicy(policy NullPolicy) {
+	if policy == NullDefault {
+		policy = NullLeave
+	}
+	structSettingsMu.Lock()
+	nullPolicy = policy
+	structSettingsMu.Unlock()
+}
+
+// StructOptions changes how QueryStructOptions and QueryStructRowOptions populate structs
+type StructOptions struct {
+	// Nulls sets which fields NULL columns can be stored in. Defaults to the policy set with SetNullPolicy
+	Nulls NullPolicy
+}
+
+func (o StructOptions) nulls() NullPolicy {
+	if o.Nulls == NullDefault {
+		structSettingsMu.RLock()
+		defer structSettingsMu.RUnlock()
+		return nullPolicy
+	}
+	return o.Nulls
+}
+
+// allows reports whether the policy lets NULL be stored in a field of type t
+func (p NullPolicy) allows(t reflect.Type) bool {
+	if p == NullLeave || p == NullZero || p == NullDefault {
+		return true
+	}
+	if reflect.PtrTo(t).Implements(scannerType) {
+		return true
+	}
+	if p == NullTypes {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// convertField stores src in a field of another type which it can be converted to, such as an array in a slice
+// or JSON in a struct. It reports false if src is left for setValue
+func convertField(field reflect.Value, src interface{}) (bool, error) {
+	t := field.Type()
+	switch {
+	case isArrayField(t):
+		elements, ok, err := arrayElements(src)
+		if err == nil && ok {
+			err = setArray(field, elements)
+		}
+		if err != nil || ok {
+			return ok, err
+		}
+	case isUUIDField(t):
+		id, ok, err := uuidValue(src)
+		if ok {
+			setUUID(field, id)
+		}
+		return ok, err
+	case isHstoreField(t):
+		h, ok, err := hstoreValue(src)
+		if ok {
+			setHstore(field, h)
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	case isRatField(t):
+		return setNumeric(field, src)
+	}
+	if isJSONField(t) {
+		return true, setJSON(field, src)
+	}
+	if reflect.PtrTo(t).Implements(scannerType) {
+		if valuer, ok := src.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil {
+				return false, err
+			}
+			src = v
+		}
+		return true, field.Addr().Interface().(sql.Scanner).Scan(src)
+	}
+	return false, nil
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// isJSONField reports whether a field is populated from a json or jsonb column by unmarshaling it. That is the
+// case for structs other than time.Time, maps and slices other than []byte, or pointers to them, unless they
+// implement sql.Scanner
+func isJSONField(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(scannerType) || t.Implements(scannerType) {
+		return false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return t != reflect.TypeOf(time.Time{})
+	case reflect.Map:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// setJSON unmarshals a JSON column into dest. Most drivers return JSON as text or bytes, while some like pgx
+// decode it into maps and slices, which are encoded again first. Text which isn't a JSON object for a struct or
+// map, or a JSON array for a slice, like a Postgres array literal, is left for setValue to ignore
+func setJSON(dest reflect.Value, src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	open := byte('{')
+	if t := dest.Type(); t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Slice {
+		open = '['
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != open && string(trimmed) != "null" {
+		return nil
+	}
+	value := reflect.New(dest.Type())
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return err
+	}
+	dest.Set(value.Elem())
+	return nil
+}
+
 var timeKind = reflect.TypeOf(time.Time{}).Kind()
 var nilType = reflect.TypeOf(nil)
 var nilValue = reflect.ValueOf(nil)
@@ -200,6 +416,83 @@ type structFieldInfo struct {
 	DBIndex    int
 }
 
+// NameMapper converts a struct field name into the name of the column used to populate it
+type NameMapper func(fieldName string) string
+
+var nameMapper NameMapper
+
+// SetNameMapper changes how struct fields without a `db:"column"` tag are matched to columns by QueryStruct
+// and QueryStructRow. Columns are still matched case insensitively. A nil mapper restores the default of
+// matching on the field name. The mapper is shared by all queries, so set it once during initialization: it is
+// safe to call while queries run, but those queries may map their fields with either mapper
+func SetNameMapper(mapper NameMapper) {
+	structSettingsMu.Lock()
+	nameMapper = mapper
+	structSettingsMu.Unlock()
+}
+
+// SnakeCase is a NameMapper which converts a field name like UserID to user_id
+func SnakeCase(fieldName string) string {
+	runes := []rune(fieldName)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// CamelCase is a NameMapper which converts a field name like UserID to userID
+func CamelCase(fieldName string) string {
+	runes := []rune(fieldName)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) && unicode.IsLower(runes[upper]) {
+		upper-- // the last capital starts the next word as in HTTPServer
+	}
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// ColumnName returns the column a struct field maps to, using the `db` tag when present and otherwise the
+// NameMapper. Fields tagged with `db:"-"` return an empty name
+func ColumnName(field reflect.StructField) string {
+	return columnName(field)
+}
+
+// columnName returns the column a struct field maps to, using the `db` tag when present. Fields tagged
+// with `db:"-"` are ignored and return an empty name
+func columnName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("db"); ok {
+		if comma := strings.Index(tag, ","); comma != -1 {
+			tag = tag[:comma]
+		}
+		if tag == "-" {
+			return ""
+		}
+		if tag != "" {
+			return tag
+		}
+	}
+	structSettingsMu.RLock()
+	mapper := nameMapper
+	structSettingsMu.RUnlock()
+	if mapper == nil {
+		return field.Name
+	}
+	return mapper(field.Name)
+}
+
 func getItemTypeAndMap(columns []string, resultType reflect.Type) (reflect.Type, []structFieldInfo) {
 	itemType := resultType.Elem()
 	dbColumnToStruct := []structFieldInfo{}
@@ -211,8 +504,12 @@ func getItemTypeAndMap(columns []string, resultType reflect.Type) (reflect.Type,
 
 	for structIndex := 0; structIndex < itemType.NumField(); structIndex++ {
 		field := itemType.Field(structIndex)
-		if dbIndex := getDBIndex(field.Name, columns); dbIndex != -1 {
-			dbColumnToStruct = append(dbColumnToStruct, structFieldInfo{strings.ToLower(field.Name), field.Type, structIndex, dbIndex})
+		name := columnName(field)
+		if name == "" {
+			continue
+		}
+		if dbIndex := getDBIndex(name, columns); dbIndex != -1 {
+			dbColumnToStruct = append(dbColumnToStruct, structFieldInfo{strings.ToLower(name), field.Type, structIndex, dbIndex})
 		}
 	}
 	return itemType, dbColumnToStruct
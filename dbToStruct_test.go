@@ -1,9 +1,11 @@
 package onedb
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -46,7 +48,7 @@ func TestGetStruct(t *testing.T) {
 	// success
 	result := []SimpleData{}
 	rows := NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})
-	err := getStruct(rows, &result)
+	err := getStruct(rows, &result, StructOptions{})
 	if err != nil || len(result) != 2 || result[0].IntVal != 1 || result[0].StringVal != "hello" || result[1].IntVal != 2 || result[1].StringVal != "world" {
 		t.Error("expected valid result", err, result)
 	}
@@ -55,7 +57,7 @@ func TestGetStruct(t *testing.T) {
 	result = []SimpleData{}
 	rows = NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})
 	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
-	err = getStruct(rows, &result)
+	err = getStruct(rows, &result, StructOptions{})
 	if err == nil {
 		t.Error("expected error")
 	}
@@ -63,7 +65,7 @@ func TestGetStruct(t *testing.T) {
 	// err error
 	result = []SimpleData{}
 	rows = NewRowsScanner(nil)
-	err = getStruct(rows, &result)
+	err = getStruct(rows, &result, StructOptions{})
 	if err == nil {
 		t.Error("expected error")
 	}
@@ -73,7 +75,7 @@ func TestGetStructRow(t *testing.T) {
 	// success
 	result := SimpleData{}
 	rows := NewRowsScanner([]SimpleData{{1, "hello"}})
-	err := getStructRow(rows, &result)
+	err := getStructRow(rows, &result, StructOptions{})
 	if err != nil || result.IntVal != 1 || result.StringVal != "hello" {
 		t.Error("expected valid result", err, result)
 	}
@@ -82,7 +84,7 @@ func TestGetStructRow(t *testing.T) {
 	result = SimpleData{}
 	rows = NewRowsScanner([]SimpleData{{1, "hello"}})
 	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
-	err = getStructRow(rows, &result)
+	err = getStructRow(rows, &result, StructOptions{})
 	if err == nil {
 		t.Error("expected error")
 	}
@@ -90,12 +92,36 @@ func TestGetStructRow(t *testing.T) {
 	// err error
 	result = SimpleData{}
 	rows = NewRowsScanner(nil)
-	err = getStructRow(rows, &result)
+	err = getStructRow(rows, &result, StructOptions{})
 	if err == nil {
 		t.Error("expected error")
 	}
 }
 
+func TestScanStruct(t *testing.T) {
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})
+	var result []SimpleData
+	for rows.Next() {
+		var item SimpleData
+		if err := ScanStruct(rows, &item); err != nil {
+			t.Fatal("expected success", err)
+		}
+		result = append(result, item)
+	}
+	if !reflect.DeepEqual(result, []SimpleData{{1, "hello"}, {2, "world"}}) {
+		t.Error("expected each row scanned into a struct", result)
+	}
+
+	var item SimpleData
+	if err := ScanStruct(rows, item); err != ErrRowScannerInvalidData {
+		t.Error("expected error for a struct which isn't a pointer", err)
+	}
+	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	if err := ScanStruct(rows, &item); err == nil {
+		t.Error("expected scan error")
+	}
+}
+
 func TestStructRow(t *testing.T) {
 	item := TestItem{}
 	rows := &MockRows{NumRows: 1}
@@ -108,12 +134,57 @@ func TestStructRow(t *testing.T) {
 	var col7 []byte
 	vals := []interface{}{&col1, &col2, &col3, &col4, &col5, &col6, &col7}
 	_, dbToStructMap := getItemTypeAndMap([]string{"Nil", "Str", "Int", "Date", "True", "False", "Byte"}, reflect.TypeOf(&item))
-	scanStruct(rows, vals, dbToStructMap, &item)
+	scanStruct(rows, vals, dbToStructMap, &item, NullDefault)
 	if item.Str != expStr || item.Nil != "" || item.Int != 1 || item.True != true {
 		t.Error("expected to contain values", item)
 	}
 }
 
+type jsonRow struct {
+	ID      int
+	Profile string
+	Tags    []byte
+	Meta    map[string]interface{}
+	Array   string
+}
+
+type jsonProfile struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type jsonResult struct {
+	ID      int
+	Profile jsonProfile
+	Tags    []string
+	Meta    *jsonProfile
+	Array   []string
+}
+
+func TestGetStructJSON(t *testing.T) {
+	result := []jsonResult{}
+	rows := NewRowsScanner([]jsonRow{{1, `{"name": "alice", "email": "a@example.com"}`, []byte(`["a", "b"]`), map[string]interface{}{"name": "bob"}, "(a,b)"}})
+	if err := getStruct(rows, &result, StructOptions{}); err != nil || len(result) != 1 {
+		t.Fatal("expected success", err, result)
+	}
+	r := result[0]
+	if r.ID != 1 || r.Profile != (jsonProfile{"alice", "a@example.com"}) || !reflect.DeepEqual(r.Tags, []string{"a", "b"}) {
+		t.Error("expected JSON text and bytes unmarshaled into the fields", r)
+	}
+	if r.Meta == nil || r.Meta.Name != "bob" {
+		t.Error("expected JSON already decoded by the driver to be converted", r.Meta)
+	}
+	if r.Array != nil {
+		t.Error("expected text which isn't JSON or an array to be ignored", r.Array)
+	}
+
+	result = []jsonResult{}
+	rows = NewRowsScanner([]jsonRow{{Profile: `{"name": 1}`}})
+	if err := getStruct(rows, &result, StructOptions{}); err == nil {
+		t.Error("expected error for JSON which doesn't fit the field")
+	}
+}
+
 func TestSetValue(t *testing.T) {
 	v := "hello"
 	i := 0
@@ -217,3 +288,138 @@ func TestGetItemTypeAndMap(t *testing.T) {
 		t.Error("expected different type and field map", itemType, dbToStructMap)
 	}
 }
+
+type TaggedItem struct {
+	UserID    int    `db:"id"`
+	FirstName string `db:"first,omitempty"`
+	Ignored   string `db:"-"`
+	LastName  string
+}
+
+func TestGetItemTypeAndMapTags(t *testing.T) {
+	item := TaggedItem{}
+	_, dbToStructMap := getItemTypeAndMap([]string{"ID", "first", "ignored", "lastname"}, reflect.TypeOf(&item))
+	if len(dbToStructMap) != 3 || dbToStructMap[0].Name != "id" || dbToStructMap[0].DBIndex != 0 ||
+		dbToStructMap[1].Name != "first" || dbToStructMap[1].DBIndex != 1 || dbToStructMap[2].FieldIndex != 3 || dbToStructMap[2].DBIndex != 3 {
+		t.Error("expected tags to be used for mapping", dbToStructMap)
+	}
+}
+
+func TestSetNameMapper(t *testing.T) {
+	defer SetNameMapper(nil)
+	SetNameMapper(SnakeCase)
+
+	item := TaggedItem{}
+	_, dbToStructMap := getItemTypeAndMap([]string{"id", "last_name"}, reflect.TypeOf(&item))
+	if len(dbToStructMap) != 2 || dbToStructMap[1].Name != "last_name" || dbToStructMap[1].FieldIndex != 3 {
+		t.Error("expected snake case mapping", dbToStructMap)
+	}
+
+	SetNameMapper(nil)
+	_, dbToStructMap = getItemTypeAndMap([]string{"id", "last_name"}, reflect.TypeOf(&item))
+	if len(dbToStructMap) != 1 {
+		t.Error("expected default mapping", dbToStructMap)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			getItemTypeAndMap([]string{"id", "last_name"}, reflect.TypeOf(&item))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		SetNameMapper(CamelCase)
+	}
+	<-done
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{"UserID": "user_id", "HTTPServer": "http_server", "IntVal": "int_val", "ID": "id", "Address2Line": "address2_line", "name": "name"}
+	for in, expected := range cases {
+		if actual := SnakeCase(in); actual != expected {
+			t.Errorf("expected %s to be %s. Actual: %s", in, expected, actual)
+		}
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	cases := map[string]string{"UserID": "userID", "HTTPServer": "httpServer", "IntVal": "intVal", "ID": "id", "name": "name"}
+	for in, expected := range cases {
+		if actual := CamelCase(in); actual != expected {
+			t.Errorf("expected %s to be %s. Actual: %s", in, expected, actual)
+		}
+	}
+}
+
+type nullRow struct {
+	Name  interface{}
+	Count interface{}
+	Tags  interface{}
+	Email interface{}
+}
+
+type nullResult struct {
+	Name  string
+	Count *int
+	Tags  []string
+	Email sql.NullString
+}
+
+func isUnexpectedNull(err error) bool {
+	return err != nil && strings.HasSuffix(err.Error(), ErrUnexpectedNull.Error())
+}
+
+func TestNullPolicy(t *testing.T) {
+	row := []nullRow{{nil, nil, nil, nil}}
+	scan := func(policy NullPolicy) (nullResult, error) {
+		count := 1
+		result := nullResult{"name", &count, []string{"a"}, sql.NullString{String: "a@example.com", Valid: true}}
+		err := getStructRow(NewRowsScanner(row), &result, StructOptions{Nulls: policy})
+		return result, err
+	}
+
+	count := 1
+	if r, err := scan(NullDefault); err != nil || !reflect.DeepEqual(r, nullResult{"name", &count, []string{"a"}, sql.NullString{String: "a@example.com", Valid: true}}) {
+		t.Error("expected fields of NULL columns left as they were by default", r, err)
+	}
+	if r, err := scan(NullZero); err != nil || !reflect.DeepEqual(r, nullResult{}) {
+		t.Error("expected NULL stored as zero values", r, err)
+	}
+	if _, err := scan(NullPointers); !isUnexpectedNull(err) || !strings.Contains(err.Error(), "column name into string") {
+		t.Error("expected error for NULL in a string", err)
+	}
+	if _, err := scan(NullTypes); !isUnexpectedNull(err) {
+		t.Error("expected error for NULL in a string", err)
+	}
+
+	row = []nullRow{{"bob", nil, nil, nil}}
+	if r, err := scan(NullPointers); err != nil || r.Count != nil || r.Tags != nil || r.Email.Valid {
+		t.Error("expected NULL stored in pointers, slices and sql.NullString", r, err)
+	}
+	if _, err := scan(NullTypes); !isUnexpectedNull(err) || !strings.Contains(err.Error(), "column count") {
+		t.Error("expected error for NULL in a pointer", err)
+	}
+
+	SetNullPolicy(NullPointers)
+	defer SetNullPolicy(NullDefault)
+	row = []nullRow{{nil, nil, nil, nil}}
+	if _, err := scan(NullDefault); !isUnexpectedNull(err) {
+		t.Error("expected the global policy to be used", err)
+	}
+	if _, err := scan(NullZero); err != nil {
+		t.Error("expected the query's policy to override the global one", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			StructOptions{}.nulls()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		SetNullPolicy(NullPointers)
+	}
+	<-done
+}
@@ -0,0 +1,123 @@
+package onedb
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+var registeredErrors struct {
+	sync.RWMutex
+	retryable []error
+	timeout   []error
+	sentinel  []error
+}
+
+// RegisterRetryableErrors adds errors which IsRetryable reports as retryable, like a driver's dead connection
+// error. Backends register their own
+func RegisterRetryableErrors(errs ...error) {
+	registeredErrors.Lock()
+	defer registeredErrors.Unlock()
+	registeredErrors.retryable = append(registeredErrors.retryable, errs...)
+}
+
+// RegisterTimeoutErrors adds errors which IsTimeout reports as timeouts, like a driver's error for a timed out
+// wait for a connection. Backends register their own
+func RegisterTimeoutErrors(errs ...error) {
+	registeredErrors.Lock()
+	defer registeredErrors.Unlock()
+	registeredErrors.timeout = append(registeredErrors.timeout, errs...)
+}
+
+// RegisterSentinelErrors adds errors which WrapQueryError leaves unwrapped, like a driver's no rows error, so
+// callers can keep comparing them with ==. Backends register their own
+func RegisterSentinelErrors(errs ...error) {
+	registeredErrors.Lock()
+	defer registeredErrors.Unlock()
+	registeredErrors.sentinel = append(registeredErrors.sentinel, errs...)
+}
+
+// IsRetryable reports whether the statement or transaction which failed with err may succeed when run again:
+// serialization failures, deadlocks, lost connections, and servers which are shutting down, starting up or out
+// of connections. An error in the chain with a Retryable() bool method decides for itself, so backends can
+// classify their own errors
+func IsRetryable(err error) bool {
+	registeredErrors.RLock()
+	registered := registeredErrors.retryable
+	registeredErrors.RUnlock()
+	return anyError(err, func(err error) (bool, bool) {
+		if r, ok := err.(interface{ Retryable() bool }); ok {
+			return r.Retryable(), true
+		}
+		if isRegistered(err, registered) || err == io.EOF || err == io.ErrUnexpectedEOF || err == syscall.ECONNRESET ||
+			err == syscall.EPIPE || strings.HasSuffix(err.Error(), "connection reset by peer") {
+			return true, true
+		}
+		switch code := pgErrorCodeOf(err); {
+		case code == CodeSerializationFailure, code == CodeDeadlockDetected, strings.HasPrefix(code, "08"),
+			code == "57P01", code == "57P02", code == "57P03", code == "53300":
+			return true, true
+		}
+		return false, false
+	})
+}
+
+// IsTimeout reports whether err is caused by a deadline or timeout, like a context's deadline, a network
+// timeout, a statement canceled by statement_timeout or a lock not acquired within lock_timeout. An error in the
+// chain with a Timeout() bool method, like a net.Error, decides for itself
+func IsTimeout(err error) bool {
+	registeredErrors.RLock()
+	registered := registeredErrors.timeout
+	registeredErrors.RUnlock()
+	return anyError(err, func(err error) (bool, bool) {
+		if err == context.DeadlineExceeded || isRegistered(err, registered) {
+			return true, true
+		}
+		if t, ok := err.(interface{ Timeout() bool }); ok && t.Timeout() {
+			return true, true
+		}
+		if code := pgErrorCodeOf(err); code == "57014" || code == "55P03" {
+			return true, true
+		}
+		return false, false
+	})
+}
+
+// IsConstraintViolation reports whether err is a violation of an integrity constraint, such as a unique,
+// foreign key, not null, check or exclusion constraint
+func IsConstraintViolation(err error) bool {
+	return strings.HasPrefix(PgErrorCode(err), "23")
+}
+
+// anyError calls check on err and each error it wraps until check decides, following both Unwrap and
+// github.com/pkg/errors' Cause
+func anyError(err error, check func(err error) (result bool, decided bool)) bool {
+	for err != nil {
+		if result, decided := check(err); decided {
+			return result
+		}
+		err = unwrapError(err)
+	}
+	return false
+}
+
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case interface{ Cause() error }:
+		return e.Cause()
+	}
+	return nil
+}
+
+func isRegistered(err error, registered []error) bool {
+	for _, r := range registered {
+		if err == r {
+			return true
+		}
+	}
+	return false
+}
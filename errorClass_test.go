@@ -0,0 +1,82 @@
+package onedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+type retryableError bool
+
+func (e retryableError) Error() string {
+	return "backend error"
+}
+
+func (e retryableError) Retryable() bool {
+	return bool(e)
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, err := range []error{&PgError{Code: CodeSerializationFailure}, &PgError{Code: CodeDeadlockDetected}, &PgError{Code: "08006"},
+		&PgError{Code: "57P01"}, pkgerrors.Wrap(io.ErrUnexpectedEOF, "read"), &net.OpError{Op: "read", Err: syscall.ECONNRESET},
+		retryableError(true), fmt.Errorf("commit: %w", &PgError{Code: CodeSerializationFailure})} {
+		if !IsRetryable(err) {
+			t.Errorf("expected %v to be retryable", err)
+		}
+	}
+	for _, err := range []error{nil, errors.New("fail"), &PgError{Code: CodeUniqueViolation}, retryableError(false),
+		WrapQueryError(retryableError(false), "mock", QueryErrorOptions{}, "select 1")} {
+		if IsRetryable(err) {
+			t.Errorf("expected %v not to be retryable", err)
+		}
+	}
+
+	sentinel := errors.New("dead connection")
+	RegisterRetryableErrors(sentinel)
+	defer func() { registeredErrors.retryable = registeredErrors.retryable[:len(registeredErrors.retryable)-1] }()
+	if !IsRetryable(pkgerrors.Wrap(sentinel, "query")) {
+		t.Error("expected a registered error to be retryable")
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsTimeout(t *testing.T) {
+	for _, err := range []error{context.DeadlineExceeded, pkgerrors.Wrap(context.DeadlineExceeded, "query"), &net.OpError{Op: "read", Err: timeoutError{}},
+		&PgError{Code: "57014"}, &PgError{Code: "55P03"}} {
+		if !IsTimeout(err) {
+			t.Errorf("expected %v to be a timeout", err)
+		}
+	}
+	for _, err := range []error{nil, context.Canceled, errors.New("fail"), &PgError{Code: CodeSerializationFailure}} {
+		if IsTimeout(err) {
+			t.Errorf("expected %v not to be a timeout", err)
+		}
+	}
+
+	sentinel := errors.New("acquire timeout")
+	RegisterTimeoutErrors(sentinel)
+	defer func() { registeredErrors.timeout = registeredErrors.timeout[:len(registeredErrors.timeout)-1] }()
+	if !IsTimeout(sentinel) {
+		t.Error("expected a registered error to be a timeout")
+	}
+}
+
+func TestIsConstraintViolation(t *testing.T) {
+	if !IsConstraintViolation(&PgError{Code: CodeUniqueViolation}) || !IsConstraintViolation(&PgError{Code: CodeExclusionViolation}) {
+		t.Error("expected class 23 errors to be constraint violations")
+	}
+	if IsConstraintViolation(&PgError{Code: CodeSerializationFailure}) || IsConstraintViolation(errors.New("23505")) {
+		t.Error("expected other errors not to be constraint violations")
+	}
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"github.com/EndFirstCorp/onedb"
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+// ArrowType returns the Arrow data type of t. Timestamps are microseconds in UTC
+func (t Type) ArrowType() arrow.DataType {
+	switch t {
+	case Bool:
+		return arrow.FixedWidthTypes.Boolean
+	case Int64:
+		return arrow.PrimitiveTypes.Int64
+	case Float64:
+		return arrow.PrimitiveTypes.Float64
+	case String:
+		return arrow.BinaryTypes.String
+	case Binary:
+		return arrow.BinaryTypes.Binary
+	case Timestamp:
+		return arrow.FixedWidthTypes.Timestamp_us
+	}
+	return arrow.Null
+}
+
+// Arrow returns s as an Arrow schema
+func (s *Schema) Arrow() *arrow.Schema {
+	fields := make([]arrow.Field, len(s.Fields))
+	for i, f := range s.Fields {
+		fields[i] = arrow.Field{Name: f.Name, Type: f.Type.ArrowType(), Nullable: f.Nullable || f.Type == Null}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// Record returns the batch as an Arrow record allocated with mem, memory.DefaultAllocator when nil. The caller
+// must Release it
+func (b *RecordBatch) Record(mem memory.Allocator) array.Record {
+	if mem == nil {
+		mem = memory.DefaultAllocator
+	}
+	builder := array.NewRecordBuilder(mem, b.Schema.Arrow())
+	defer builder.Release()
+	for i, column := range b.Columns {
+		switch field := builder.Field(i).(type) {
+		case *array.BooleanBuilder:
+			field.AppendValues(column.Values.([]bool), column.Valid)
+		case *array.Int64Builder:
+			field.AppendValues(column.Values.([]int64), column.Valid)
+		case *array.Float64Builder:
+			field.AppendValues(column.Values.([]float64), column.Valid)
+		case *array.StringBuilder:
+			field.AppendValues(column.Values.([]string), column.Valid)
+		case *array.BinaryBuilder:
+			field.AppendValues(column.Values.([][]byte), column.Valid)
+		case *array.TimestampBuilder:
+			micros := column.Values.([]int64)
+			timestamps := make([]arrow.Timestamp, len(micros))
+			for j, m := range micros {
+				timestamps[j] = arrow.Timestamp(m)
+			}
+			field.AppendValues(timestamps, column.Valid)
+		case *array.NullBuilder:
+			for j := 0; j < b.Rows; j++ {
+				field.AppendNull()
+			}
+		}
+	}
+	return builder.NewRecord()
+}
+
+// NewArrowWriter returns a BatchWriter which passes each batch to fn as an Arrow record allocated with mem,
+// memory.DefaultAllocator when nil. The record is released once fn returns, so fn must Retain it to keep it
+func NewArrowWriter(mem memory.Allocator, fn func(record array.Record) error) BatchWriter {
+	return BatchWriterFunc(func(batch *RecordBatch) error {
+		record := batch.Record(mem)
+		defer record.Release()
+		return fn(record)
+	})
+}
+
+// StreamArrow reads rows into Arrow records of up to batchSize rows, DefaultBatchSize when 0, and passes each to
+// fn as with NewArrowWriter. It returns the number of rows read
+func StreamArrow(rows onedb.RowsScanner, batchSize int, mem memory.Allocator, fn func(record array.Record) error) (int64, error) {
+	return Stream(rows, batchSize, NewArrowWriter(mem, fn))
+}
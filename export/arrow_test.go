@@ -0,0 +1,49 @@
+package export
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+func TestStreamArrow(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	name := "alice"
+	created := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	rows := onedb.NewRowsScanner([]exportRow{
+		{1, &name, 1.5, true, []byte("hi"), created, sql.NullInt64{}, nil},
+		{2, nil, 2, false, nil, created, sql.NullInt64{}, nil},
+	})
+	var records int
+	count, err := StreamArrow(rows, 0, mem, func(record array.Record) error {
+		records++
+		schema := record.Schema()
+		if record.NumRows() != 2 || record.NumCols() != 8 || schema.Field(0).Type.ID() != arrow.INT64 ||
+			schema.Field(1).Type.ID() != arrow.STRING || schema.Field(5).Type.ID() != arrow.TIMESTAMP || schema.Field(7).Type.ID() != arrow.NULL {
+			t.Error("expected record schema", schema)
+		}
+		if ids := record.Column(0).(*array.Int64); ids.Value(0) != 1 || ids.Value(1) != 2 {
+			t.Error("expected ids", ids)
+		}
+		if names := record.Column(1).(*array.String); names.Value(0) != "alice" || !names.IsNull(1) {
+			t.Error("expected names with a null", names)
+		}
+		if timestamps := record.Column(5).(*array.Timestamp); timestamps.Value(0) != arrow.Timestamp(created.UnixMicro()) {
+			t.Error("expected timestamp in microseconds", timestamps)
+		}
+		if nulls := record.Column(7); nulls.Len() != 2 || nulls.NullN() != 2 {
+			t.Error("expected null column", nulls)
+		}
+		return nil
+	})
+	if err != nil || count != 2 || records != 1 {
+		t.Error("expected a record", count, records, err)
+	}
+}
@@ -0,0 +1,325 @@
+// Package export streams query results into Apache Arrow record batches and Parquet files, so results for
+// analytics tools don't have to go through CSV. Stream reads rows into column oriented batches with types mapped
+// to those Arrow and Parquet use and hands each to a BatchWriter. NewArrowWriter turns the batches into Arrow
+// records and NewParquetWriter writes them as the row groups of a Parquet file
+package export
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+// Type is the type of a column's values
+type Type int
+
+// The types columns are mapped to. A Null column had only NULL values in the first batch and an unknown type
+const (
+	Null      Type = iota
+	Bool           // []bool
+	Int64          // []int64
+	Float64        // []float64
+	String         // []string
+	Binary         // [][]byte
+	Timestamp      // []int64 of microseconds since the Unix epoch, UTC
+)
+
+func (t Type) String() string {
+	switch t {
+	case Bool:
+		return "bool"
+	case Int64:
+		return "int64"
+	case Float64:
+		return "float64"
+	case String:
+		return "utf8"
+	case Binary:
+		return "binary"
+	case Timestamp:
+		return "timestamp[us, UTC]"
+	}
+	return "null"
+}
+
+// Field describes a column
+type Field struct {
+	Name     string
+	Type     Type
+	Nullable bool
+}
+
+// Schema is the fields of each batch of a result, in column order
+type Schema struct {
+	Fields []Field
+}
+
+// Column is the values of a column in a batch. Values is a slice of the Go type given with each Type, or nil for
+// Null columns, and Valid is false for the rows where the value is NULL and Values holds the zero value
+type Column struct {
+	Values interface{}
+	Valid  []bool
+}
+
+// RecordBatch is a run of rows stored by column
+type RecordBatch struct {
+	Schema  *Schema
+	Columns []Column
+	Rows    int
+}
+
+// BatchWriter encodes record batches, such as into Arrow records or Parquet row groups
+type BatchWriter interface {
+	WriteBatch(batch *RecordBatch) error
+}
+
+// BatchWriterFunc is an adapter to allow the use of an ordinary function as a BatchWriter
+type BatchWriterFunc func(batch *RecordBatch) error
+
+// WriteBatch calls f(batch)
+func (f BatchWriterFunc) WriteBatch(batch *RecordBatch) error {
+	return f(batch)
+}
+
+// DefaultBatchSize is the number of rows in each batch when Stream is given 0
+const DefaultBatchSize = 1024
+
+// Stream reads rows into batches of up to batchSize rows and writes each to w as soon as it is full, so a large
+// result is never held in memory at once. It closes rows and returns the number of rows written.
+//
+// Column types come from the rows' onedb.ColumnTypes. Columns whose type isn't known are typed by their first
+// non-NULL value in the first batch. Integers become Int64, floats Float64, time.Time Timestamp, []byte Binary,
+// and strings and any other values, such as numerics, String through fmt.Sprint
+func Stream(rows onedb.RowsScanner, batchSize int, w BatchWriter) (int64, error) {
+	defer rows.Close()
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	columnTypes, err := onedb.ColumnTypes(rows)
+	if err != nil {
+		return 0, err
+	}
+	schema := &Schema{Fields: make([]Field, len(columnTypes))}
+	known := make([]bool, len(columnTypes))
+	for i, c := range columnTypes {
+		schema.Fields[i] = Field{Name: c.Name, Nullable: c.Nullable}
+		schema.Fields[i].Type, known[i] = scanTypeOf(c.ScanType)
+	}
+
+	values := make([]interface{}, len(columnTypes))
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	var count int64
+	var pending [][]interface{}
+	first := true
+	flush := func() error {
+		if first {
+			inferTypes(schema, known, pending)
+			first = false
+		}
+		batch, err := newBatch(schema, pending)
+		if err != nil {
+			return err
+		}
+		if err := w.WriteBatch(batch); err != nil {
+			return err
+		}
+		count += int64(len(pending))
+		pending = pending[:0]
+		return nil
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return count, err
+		}
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			if b, ok := v.([]byte); ok { // drivers may reuse the bytes for the next row
+				v = append([]byte(nil), b...)
+			}
+			row[i] = v
+		}
+		pending = append(pending, row)
+		if len(pending) == batchSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	if len(pending) > 0 || first { // an empty result still has a schema
+		if err := flush(); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// scanTypeOf maps the type a column is scanned into to its Type, reporting false when it isn't known
+func scanTypeOf(t reflect.Type) (Type, bool) {
+	if t == nil {
+		return Null, false
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return Timestamp, true
+	case t == bytesType:
+		return Binary, true
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return Bool, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Int64, true
+	case reflect.Float32, reflect.Float64:
+		return Float64, true
+	case reflect.String:
+		return String, true
+	case reflect.Interface:
+		return Null, false
+	}
+	if t.Implements(reflect.TypeOf((*driver.Valuer)(nil)).Elem()) || reflect.PtrTo(t).Implements(reflect.TypeOf((*driver.Valuer)(nil)).Elem()) {
+		return Null, false // e.g. sql.NullInt64, typed by its values
+	}
+	return String, true
+}
+
+// inferTypes types the columns which aren't known by their first non-NULL value
+func inferTypes(schema *Schema, known []bool, rows [][]interface{}) {
+	for i := range schema.Fields {
+		if known[i] {
+			continue
+		}
+		schema.Fields[i].Nullable = true
+		for _, row := range rows {
+			if v := value(row[i]); v != nil {
+				schema.Fields[i].Type, _ = scanTypeOf(reflect.TypeOf(v))
+				if schema.Fields[i].Type == Null {
+					schema.Fields[i].Type = String
+				}
+				break
+			}
+		}
+	}
+}
+
+// value unwraps driver.Valuers such as sql.NullString
+func value(v interface{}) interface{} {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+			return nil
+		}
+		if unwrapped, err := valuer.Value(); err == nil {
+			return unwrapped
+		}
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		return rv.Elem().Interface()
+	}
+	return v
+}
+
+func newBatch(schema *Schema, rows [][]interface{}) (*RecordBatch, error) {
+	batch := &RecordBatch{Schema: schema, Columns: make([]Column, len(schema.Fields)), Rows: len(rows)}
+	for i, field := range schema.Fields {
+		column := Column{Valid: make([]bool, len(rows))}
+		switch field.Type {
+		case Bool:
+			column.Values = make([]bool, len(rows))
+		case Int64, Timestamp:
+			column.Values = make([]int64, len(rows))
+		case Float64:
+			column.Values = make([]float64, len(rows))
+		case String:
+			column.Values = make([]string, len(rows))
+		case Binary:
+			column.Values = make([][]byte, len(rows))
+		}
+		for j, row := range rows {
+			v := value(row[i])
+			if v == nil {
+				continue
+			}
+			if err := set(column.Values, field.Type, j, v); err != nil {
+				return nil, errors.Wrapf(err, "Unable to export column %s", field.Name)
+			}
+			column.Valid[j] = true
+		}
+		batch.Columns[i] = column
+	}
+	return batch, nil
+}
+
+// set stores v at row j of values, converting it to t
+func set(values interface{}, t Type, j int, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	switch t {
+	case Null:
+		return errors.Errorf("%v in a column with only NULL values in the first batch", v)
+	case Bool:
+		if b, ok := v.(bool); ok {
+			values.([]bool)[j] = b
+			return nil
+		}
+	case Int64:
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			values.([]int64)[j] = rv.Int()
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			values.([]int64)[j] = int64(rv.Uint())
+			return nil
+		}
+	case Float64:
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			values.([]float64)[j] = rv.Float()
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			values.([]float64)[j] = float64(rv.Int())
+			return nil
+		}
+	case String:
+		if b, ok := v.([]byte); ok {
+			values.([]string)[j] = string(b)
+		} else {
+			values.([]string)[j] = fmt.Sprint(v)
+		}
+		return nil
+	case Binary:
+		switch b := v.(type) {
+		case []byte:
+			values.([][]byte)[j] = b
+			return nil
+		case string:
+			values.([][]byte)[j] = []byte(b)
+			return nil
+		}
+	case Timestamp:
+		if tm, ok := v.(time.Time); ok {
+			values.([]int64)[j] = tm.UnixMicro()
+			return nil
+		}
+	}
+	return errors.Errorf("%v (%T) is not a %s", v, v, t)
+}
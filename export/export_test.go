@@ -0,0 +1,78 @@
+package export
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+type exportRow struct {
+	ID      int32
+	Name    *string
+	Score   float32
+	Active  bool
+	Avatar  []byte
+	Created time.Time
+	Parent  sql.NullInt64
+	Extra   interface{}
+}
+
+func TestStream(t *testing.T) {
+	name := "alice"
+	created := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	rows := onedb.NewRowsScanner([]exportRow{
+		{1, &name, 1.5, true, []byte("hi"), created, sql.NullInt64{}, nil},
+		{2, nil, 2, false, nil, created, sql.NullInt64{Int64: 1, Valid: true}, "x"},
+		{3, nil, 0, false, nil, created, sql.NullInt64{}, 7},
+	})
+	var batches []*RecordBatch
+	count, err := Stream(rows, 2, BatchWriterFunc(func(batch *RecordBatch) error {
+		batches = append(batches, batch)
+		return nil
+	}))
+	if err != nil || count != 3 || len(batches) != 2 || batches[0].Rows != 2 || batches[1].Rows != 1 {
+		t.Fatal("expected rows in batches of 2", count, len(batches), err)
+	}
+
+	var types []Type
+	for _, f := range batches[0].Schema.Fields {
+		types = append(types, f.Type)
+	}
+	if !reflect.DeepEqual(types, []Type{Int64, String, Float64, Bool, Binary, Timestamp, Int64, String}) {
+		t.Error("expected mapped types", types)
+	}
+	first := batches[0].Columns
+	if !reflect.DeepEqual(first[0].Values, []int64{1, 2}) || !reflect.DeepEqual(first[1].Values, []string{"alice", ""}) ||
+		!reflect.DeepEqual(first[1].Valid, []bool{true, false}) || !reflect.DeepEqual(first[5].Values, []int64{created.UnixMicro(), created.UnixMicro()}) ||
+		!reflect.DeepEqual(first[6].Values, []int64{0, 1}) || !reflect.DeepEqual(first[6].Valid, []bool{false, true}) {
+		t.Error("expected column values", first)
+	}
+	if last := batches[1].Columns[7]; !reflect.DeepEqual(last.Values, []string{"7"}) {
+		t.Error("expected values of an inferred string column to be formatted", last)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	fail := errors.New("fail")
+	rows := onedb.NewRowsScanner([]exportRow{{ID: 1}})
+	if _, err := Stream(rows, 0, BatchWriterFunc(func(*RecordBatch) error { return fail })); err != fail {
+		t.Error("expected writer error", err)
+	}
+
+	var batches []*RecordBatch
+	rows = onedb.NewRowsScanner([]exportRow{})
+	if count, err := Stream(rows, 0, BatchWriterFunc(func(b *RecordBatch) error { batches = append(batches, b); return nil })); err != nil || count != 0 || len(batches) != 1 || len(batches[0].Schema.Fields) != 8 {
+		t.Error("expected an empty batch with the schema", count, err)
+	}
+
+	if err := set(make([]int64, 1), Int64, 0, "one"); err == nil {
+		t.Error("expected error for a value of another type")
+	}
+	if err := set(nil, Null, 0, 1); err == nil {
+		t.Error("expected error for a value in a null column")
+	}
+}
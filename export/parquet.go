@@ -0,0 +1,279 @@
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+var parquetMagic = []byte("PAR1")
+
+// Parquet physical and converted types, encodings and repetitions from parquet.thrift
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetUTF8            = 0
+	parquetTimestampMicros = 10
+
+	parquetOptional = 1
+
+	parquetPlain = 0
+	parquetRLE   = 3
+)
+
+// physicalType returns the Parquet type t is stored as, and its converted type or -1 when it has none. Null
+// columns are stored as byte arrays with only null values
+func (t Type) physicalType() (int32, int32) {
+	switch t {
+	case Bool:
+		return parquetBoolean, -1
+	case Int64:
+		return parquetInt64, -1
+	case Float64:
+		return parquetDouble, -1
+	case String:
+		return parquetByteArray, parquetUTF8
+	case Timestamp:
+		return parquetInt64, parquetTimestampMicros
+	}
+	return parquetByteArray, -1
+}
+
+// ParquetWriter writes record batches as the row groups of a Parquet file. Columns are optional, PLAIN encoded
+// and uncompressed. The file's footer is written by Close
+type ParquetWriter struct {
+	w         io.Writer
+	offset    int64
+	schema    *Schema
+	rowGroups []parquetRowGroup
+	rows      int64
+	closed    bool
+}
+
+type parquetRowGroup struct {
+	columns []parquetColumnChunk
+	rows    int64
+	size    int64
+}
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+// NewParquetWriter returns a ParquetWriter which writes to w. Every batch must have the schema of the first
+func NewParquetWriter(w io.Writer) *ParquetWriter {
+	return &ParquetWriter{w: w}
+}
+
+// WriteBatch writes batch as a row group. Empty batches only set the schema
+func (p *ParquetWriter) WriteBatch(batch *RecordBatch) error {
+	if p.closed {
+		return errors.New("Unable to write batch to closed Parquet writer")
+	}
+	if p.schema == nil {
+		if err := p.write(parquetMagic); err != nil {
+			return err
+		}
+		p.schema = batch.Schema
+	} else if !sameTypes(p.schema, batch.Schema) {
+		return errors.New("Unable to write batch with a different schema to Parquet")
+	}
+	if batch.Rows == 0 {
+		return nil
+	}
+
+	group := parquetRowGroup{columns: make([]parquetColumnChunk, len(batch.Columns)), rows: int64(batch.Rows)}
+	for i, column := range batch.Columns {
+		page := parquetPage(p.schema.Fields[i].Type, column, batch.Rows)
+		header := &thriftEncoder{}
+		header.begin()
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.beginStruct(5)
+		header.i32(1, int32(batch.Rows))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.end()
+		header.end()
+
+		chunk := parquetColumnChunk{offset: p.offset, size: int64(len(header.buf) + len(page)), values: int64(batch.Rows)}
+		if err := p.write(header.buf); err != nil {
+			return err
+		}
+		if err := p.write(page); err != nil {
+			return err
+		}
+		group.columns[i] = chunk
+		group.size += chunk.size
+	}
+	p.rowGroups = append(p.rowGroups, group)
+	p.rows += group.rows
+	return nil
+}
+
+// Close writes the file's footer. It doesn't close the underlying writer
+func (p *ParquetWriter) Close() error {
+	if p.closed {
+		return nil
+	}
+	if p.schema == nil {
+		if err := p.write(parquetMagic); err != nil {
+			return err
+		}
+		p.schema = &Schema{}
+	}
+	p.closed = true
+
+	meta := &thriftEncoder{}
+	meta.begin()
+	meta.i32(1, 1) // version
+	meta.list(2, thriftStruct, len(p.schema.Fields)+1)
+	meta.begin()
+	meta.string(4, "schema")
+	meta.i32(5, int32(len(p.schema.Fields)))
+	meta.end()
+	for _, f := range p.schema.Fields {
+		physical, converted := f.Type.physicalType()
+		meta.begin()
+		meta.i32(1, physical)
+		meta.i32(3, parquetOptional)
+		meta.string(4, f.Name)
+		if converted >= 0 {
+			meta.i32(6, converted)
+		}
+		meta.end()
+	}
+	meta.i64(3, p.rows)
+	meta.list(4, thriftStruct, len(p.rowGroups))
+	for _, group := range p.rowGroups {
+		meta.begin()
+		meta.list(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			physical, _ := p.schema.Fields[i].Type.physicalType()
+			meta.begin()
+			meta.i64(2, chunk.offset)
+			meta.beginStruct(3)
+			meta.i32(1, physical)
+			meta.list(2, thriftI32, 2)
+			meta.rawI32(parquetPlain)
+			meta.rawI32(parquetRLE)
+			meta.list(3, thriftBinary, 1)
+			meta.rawString(p.schema.Fields[i].Name)
+			meta.i32(4, 0) // UNCOMPRESSED
+			meta.i64(5, chunk.values)
+			meta.i64(6, chunk.size)
+			meta.i64(7, chunk.size)
+			meta.i64(9, chunk.offset)
+			meta.end()
+			meta.end()
+		}
+		meta.i64(2, group.size)
+		meta.i64(3, group.rows)
+		meta.end()
+	}
+	meta.string(6, "github.com/EndFirstCorp/onedb/export")
+	meta.end()
+
+	footer := binary.LittleEndian.AppendUint32(meta.buf, uint32(len(meta.buf)))
+	return p.write(append(footer, parquetMagic...))
+}
+
+func (p *ParquetWriter) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	return errors.Wrap(err, "Unable to write Parquet file")
+}
+
+// StreamParquet reads rows in batches of up to batchSize rows, DefaultBatchSize when 0, and writes them to w as
+// a Parquet file with a row group per batch. It returns the number of rows written
+func StreamParquet(w io.Writer, rows onedb.RowsScanner, batchSize int) (int64, error) {
+	p := NewParquetWriter(w)
+	count, err := Stream(rows, batchSize, p)
+	if err != nil {
+		return count, err
+	}
+	return count, p.Close()
+}
+
+func sameTypes(a, b *Schema) bool {
+	if a == b {
+		return true
+	}
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Type != b.Fields[i].Type {
+			return false
+		}
+	}
+	return true
+}
+
+// parquetPage encodes a column as the body of a data page: its definition levels as a bit packed run, then its
+// non-null values PLAIN encoded
+func parquetPage(t Type, column Column, rows int) []byte {
+	levels := binary.AppendUvarint(nil, uint64((rows+7)/8)<<1|1)
+	levels = append(levels, packBits(column.Valid, rows)...)
+	page := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+	page = append(page, levels...)
+
+	switch t {
+	case Bool:
+		var valid []bool
+		for j, v := range column.Values.([]bool) {
+			if column.Valid[j] {
+				valid = append(valid, v)
+			}
+		}
+		page = append(page, packBits(valid, len(valid))...)
+	case Int64, Timestamp:
+		for j, v := range column.Values.([]int64) {
+			if column.Valid[j] {
+				page = binary.LittleEndian.AppendUint64(page, uint64(v))
+			}
+		}
+	case Float64:
+		for j, v := range column.Values.([]float64) {
+			if column.Valid[j] {
+				page = binary.LittleEndian.AppendUint64(page, math.Float64bits(v))
+			}
+		}
+	case String:
+		for j, v := range column.Values.([]string) {
+			if column.Valid[j] {
+				page = binary.LittleEndian.AppendUint32(page, uint32(len(v)))
+				page = append(page, v...)
+			}
+		}
+	case Binary:
+		for j, v := range column.Values.([][]byte) {
+			if column.Valid[j] {
+				page = binary.LittleEndian.AppendUint32(page, uint32(len(v)))
+				page = append(page, v...)
+			}
+		}
+	}
+	return page
+}
+
+// packBits packs the first n bools least significant bit first, padded to whole bytes
+func packBits(bits []bool, n int) []byte {
+	packed := make([]byte, (n+7)/8)
+	for i := 0; i < n && i < len(bits); i++ {
+		if bits[i] {
+			packed[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return packed
+}
@@ -0,0 +1,144 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+type parquetRow struct {
+	ID   int64
+	Name sql.NullString
+}
+
+func TestStreamParquet(t *testing.T) {
+	buf := &bytes.Buffer{}
+	rows := onedb.NewRowsScanner([]parquetRow{
+		{1, sql.NullString{String: "alice", Valid: true}},
+		{2, sql.NullString{}},
+		{3, sql.NullString{String: "bob", Valid: true}},
+	})
+	count, err := StreamParquet(buf, rows, 2)
+	file := buf.Bytes()
+	if err != nil || count != 3 || !bytes.HasPrefix(file, parquetMagic) || !bytes.HasSuffix(file, parquetMagic) {
+		t.Fatal("expected a Parquet file", count, err)
+	}
+
+	footer := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	meta, _ := decodeThrift(file, len(file)-8-footer)
+	if meta[3] != int64(3) {
+		t.Error("expected number of rows", meta[3])
+	}
+	var names []string
+	for _, element := range meta[2].([]interface{}) {
+		names = append(names, string(element.(map[int16]interface{})[4].([]byte)))
+	}
+	if !reflect.DeepEqual(names, []string{"schema", "ID", "Name"}) {
+		t.Error("expected schema", names)
+	}
+	if name := meta[2].([]interface{})[2].(map[int16]interface{}); name[1] != int64(parquetByteArray) || name[6] != int64(parquetUTF8) {
+		t.Error("expected a UTF8 byte array column", name)
+	}
+	groups := meta[4].([]interface{})
+	if len(groups) != 2 || groups[0].(map[int16]interface{})[3] != int64(2) || groups[1].(map[int16]interface{})[3] != int64(1) {
+		t.Fatal("expected a row group per batch", groups)
+	}
+
+	chunk := groups[0].(map[int16]interface{})[1].([]interface{})[1].(map[int16]interface{})[3].(map[int16]interface{})
+	header, pos := decodeThrift(file, int(chunk[9].(int64)))
+	if header[5].(map[int16]interface{})[1] != int64(2) {
+		t.Error("expected values in page", header)
+	}
+	page := file[pos : pos+int(header[2].(int64))]
+	levels := int(binary.LittleEndian.Uint32(page))
+	if !bytes.Equal(page[4:4+levels], []byte{1<<1 | 1, 1}) {
+		t.Error("expected definition levels of a value and a null", page[4:4+levels])
+	}
+	if values := page[4+levels:]; !bytes.Equal(values, append([]byte{5, 0, 0, 0}, "alice"...)) {
+		t.Error("expected only non-null values", values)
+	}
+}
+
+func TestParquetWriterErrors(t *testing.T) {
+	p := NewParquetWriter(&bytes.Buffer{})
+	if err := p.WriteBatch(&RecordBatch{Schema: &Schema{Fields: []Field{{Name: "a", Type: Int64}}}}); err != nil {
+		t.Fatal("expected empty batch to set the schema", err)
+	}
+	if err := p.WriteBatch(&RecordBatch{Schema: &Schema{Fields: []Field{{Name: "a", Type: String}}}}); err == nil {
+		t.Error("expected error for a batch with another schema")
+	}
+	if err := p.Close(); err != nil {
+		t.Error("expected footer", err)
+	}
+	if err := p.WriteBatch(&RecordBatch{Schema: &Schema{}}); err == nil {
+		t.Error("expected error after Close")
+	}
+
+	if _, err := StreamParquet(&failWriter{}, onedb.NewRowsScanner([]parquetRow{{ID: 1}}), 0); err == nil {
+		t.Error("expected write error")
+	}
+}
+
+/***************************************************************************************
+**************************************** MOCKS *****************************************
+***************************************************************************************/
+
+type failWriter struct{}
+
+func (w *failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("fail")
+}
+
+// decodeThrift reads a Thrift compact protocol struct at pos into its fields by id, returning the position after
+// it. Integers are int64, binaries []byte, lists []interface{} and structs map[int16]interface{}
+func decodeThrift(buf []byte, pos int) (map[int16]interface{}, int) {
+	fields := map[int16]interface{}{}
+	var last int16
+	for {
+		header := buf[pos]
+		pos++
+		if header == 0 {
+			return fields, pos
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			last += delta
+		} else {
+			id, n := binary.Varint(buf[pos:])
+			pos += n
+			last = int16(id)
+		}
+		fields[last], pos = decodeThriftValue(buf, pos, header&0x0f)
+	}
+}
+
+func decodeThriftValue(buf []byte, pos int, valueType byte) (interface{}, int) {
+	switch valueType {
+	case thriftI32, thriftI64:
+		v, n := binary.Varint(buf[pos:])
+		return v, pos + n
+	case thriftBinary:
+		length, n := binary.Uvarint(buf[pos:])
+		pos += n
+		return buf[pos : pos+int(length)], pos + int(length)
+	case thriftList:
+		size, elemType := int(buf[pos]>>4), buf[pos]&0x0f
+		pos++
+		if size == 15 {
+			s, n := binary.Uvarint(buf[pos:])
+			size, pos = int(s), pos+n
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i], pos = decodeThriftValue(buf, pos, elemType)
+		}
+		return list, pos
+	case thriftStruct:
+		return decodeThrift(buf, pos)
+	}
+	panic("unexpected thrift type")
+}
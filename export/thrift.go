@@ -0,0 +1,88 @@
+package export
+
+import "encoding/binary"
+
+// Thrift compact protocol field types used by the Parquet metadata
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftEncoder writes structs with the Thrift compact protocol, which Parquet uses for its page headers and
+// footer
+type thriftEncoder struct {
+	buf  []byte
+	last []int16 // the id of the last field written in each open struct
+}
+
+// begin starts a struct which isn't a field, such as the outermost one or a list element
+func (e *thriftEncoder) begin() {
+	e.last = append(e.last, 0)
+}
+
+// beginStruct starts a struct field
+func (e *thriftEncoder) beginStruct(id int16) {
+	e.field(id, thriftStruct)
+	e.begin()
+}
+
+// end writes the stop byte of the innermost open struct
+func (e *thriftEncoder) end() {
+	e.buf = append(e.buf, 0)
+	e.last = e.last[:len(e.last)-1]
+}
+
+// field writes a field header, as the difference from the previous field's id when it is small enough
+func (e *thriftEncoder) field(id int16, fieldType byte) {
+	last := &e.last[len(e.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		e.buf = append(e.buf, byte(delta)<<4|fieldType)
+	} else {
+		e.buf = append(e.buf, fieldType)
+		e.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (e *thriftEncoder) i32(id int16, v int32) {
+	e.field(id, thriftI32)
+	e.zigzag(int64(v))
+}
+
+func (e *thriftEncoder) i64(id int16, v int64) {
+	e.field(id, thriftI64)
+	e.zigzag(v)
+}
+
+func (e *thriftEncoder) string(id int16, s string) {
+	e.field(id, thriftBinary)
+	e.rawString(s)
+}
+
+// list writes the header of a list field of n elements, which are then written with the raw methods or, for
+// structs, begin and end
+func (e *thriftEncoder) list(id int16, elemType byte, n int) {
+	e.field(id, thriftList)
+	if n < 15 {
+		e.buf = append(e.buf, byte(n)<<4|elemType)
+		return
+	}
+	e.buf = append(e.buf, 0xf0|elemType)
+	e.buf = binary.AppendUvarint(e.buf, uint64(n))
+}
+
+func (e *thriftEncoder) rawI32(v int32) {
+	e.zigzag(int64(v))
+}
+
+func (e *thriftEncoder) rawString(s string) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *thriftEncoder) zigzag(v int64) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(v<<1)^uint64(v>>63))
+}
@@ -0,0 +1,239 @@
+// Package fixtures loads rows from YAML or JSON files and seeds them into a database for tests.
+//
+// A fixture file maps each table to its rows, with the tables inserted in the order they appear so rows can refer
+// to those of earlier tables:
+//
+//	users:
+//	  - id: 1
+//	    name: alice
+//	orders:
+//	  - id: 10
+//	    user_id: 1
+//
+// JSON files have the same shape, and their table order is kept too
+package fixtures
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/EndFirstCorp/onedb/qb"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Table is the rows of a table to seed
+type Table struct {
+	Name string
+	Rows []map[string]interface{}
+}
+
+// Fixtures is the tables loaded from fixture files
+type Fixtures struct {
+	// Cascade truncates with CASCADE, which also empties every table with a foreign key to a fixture table.
+	// Without it, truncating fails while such tables have rows
+	Cascade bool
+
+	tables []*Table
+}
+
+// CopyFunc copies rows into table, for example with pgx's CopyFrom. Each row has a value for each of columns
+type CopyFunc func(table string, columns []string, rows [][]interface{}) error
+
+// Load reads the fixture files in fsys matching patterns, such as "testdata/*.yml", in the order the patterns are
+// given and then by file name. Rows for a table found in more than one file are combined
+func Load(fsys fs.FS, patterns ...string) (*Fixtures, error) {
+	f := &Fixtures{}
+	for _, pattern := range patterns {
+		names, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, errors.Errorf("no fixture files match %s", pattern)
+		}
+		for _, name := range names {
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, err
+			}
+			if err := f.parse(data); err != nil {
+				return nil, errors.Wrapf(err, "Unable to parse fixture file %s", name)
+			}
+		}
+	}
+	return f, nil
+}
+
+// Parse reads fixtures from YAML or JSON data
+func Parse(data []byte) (*Fixtures, error) {
+	f := &Fixtures{}
+	if err := f.parse(data); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Fixtures) parse(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 { // empty file
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return errors.New("fixtures must map table names to rows")
+	}
+	for i := 0; i < len(root.Content); i += 2 {
+		name := root.Content[i].Value
+		var rows []map[string]interface{}
+		if err := root.Content[i+1].Decode(&rows); err != nil {
+			return errors.Wrapf(err, "Unable to read rows of %s", name)
+		}
+		table := f.Table(name)
+		if table == nil {
+			table = &Table{Name: name}
+			f.tables = append(f.tables, table)
+		}
+		table.Rows = append(table.Rows, rows...)
+	}
+	return nil
+}
+
+// Tables returns the tables in the order they are seeded
+func (f *Fixtures) Tables() []*Table {
+	return f.tables
+}
+
+// Table returns the table called name, or nil if there are no fixtures for it
+func (f *Fixtures) Table(name string) *Table {
+	for _, t := range f.tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Seed truncates the tables, restarting their sequences, and inserts the rows through db. Rows without a value
+// for a column get the column's default
+func (f *Fixtures) Seed(db onedb.Backender) error {
+	if err := f.truncate(db); err != nil {
+		return err
+	}
+	for _, t := range f.tables {
+		for _, group := range groupByColumns(t.Rows) {
+			insert := qb.Insert(t.Name).Columns(group.columns...)
+			for _, row := range group.rows {
+				insert.Values(row...)
+			}
+			query, args, err := insert.ToSQL()
+			if err != nil {
+				return err
+			}
+			if err := onedb.Exec(db, query, args...); err != nil {
+				return errors.Wrapf(err, "Unable to seed %s", t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Copy truncates the tables through db like Seed, and then copies the rows with copyRows, which is faster for large
+// fixtures. Every row of a table is copied with the same columns, so a column missing from a row is NULL
+// rather than its default
+func (f *Fixtures) Copy(db onedb.Backender, copyRows CopyFunc) error {
+	if err := f.truncate(db); err != nil {
+		return err
+	}
+	for _, t := range f.tables {
+		if len(t.Rows) == 0 {
+			continue
+		}
+		seen := make(map[string]bool)
+		var columns []string
+		for _, row := range t.Rows {
+			for _, column := range sortedColumns(row) {
+				if !seen[column] {
+					seen[column] = true
+					columns = append(columns, column)
+				}
+			}
+		}
+		rows := make([][]interface{}, len(t.Rows))
+		for i, row := range t.Rows {
+			rows[i] = make([]interface{}, len(columns))
+			for j, column := range columns {
+				rows[i][j] = row[column]
+			}
+		}
+		if err := copyRows(t.Name, columns, rows); err != nil {
+			return errors.Wrapf(err, "Unable to copy %s", t.Name)
+		}
+	}
+	return nil
+}
+
+func (f *Fixtures) truncate(db onedb.Backender) error {
+	if len(f.tables) == 0 {
+		return nil
+	}
+	names := make([]string, len(f.tables))
+	for i, t := range f.tables {
+		names[i] = t.Name
+	}
+	query := "truncate table " + strings.Join(names, ", ") + " restart identity"
+	if f.Cascade {
+		query += " cascade"
+	}
+	return onedb.Exec(db, query)
+}
+
+type rowGroup struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// groupByColumns splits rows into runs with the same columns, so each run can be inserted with one statement
+func groupByColumns(rows []map[string]interface{}) []rowGroup {
+	var groups []rowGroup
+	for _, row := range rows {
+		columns := sortedColumns(row)
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			values[i] = row[column]
+		}
+		if n := len(groups); n > 0 && strings.Join(groups[n-1].columns, ",") == strings.Join(columns, ",") {
+			groups[n-1].rows = append(groups[n-1].rows, values)
+			continue
+		}
+		groups = append(groups, rowGroup{columns: columns, rows: [][]interface{}{values}})
+	}
+	return groups
+}
+
+func sortedColumns(row map[string]interface{}) []string {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// Begin begins a transaction which is rolled back when the test ends, so the fixtures seeded in it and any
+// changes the test makes are not seen by other tests
+func Begin[T onedb.Txer](tb testing.TB, db onedb.TxBeginner[T]) T {
+	tb.Helper()
+	tx, err := db.Begin()
+	if err != nil {
+		tb.Fatal("Unable to begin fixture transaction", err)
+	}
+	tb.Cleanup(func() { tx.Rollback() })
+	return tx
+}
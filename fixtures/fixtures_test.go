@@ -0,0 +1,87 @@
+package fixtures
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+var files = fstest.MapFS{
+	"testdata/1_users.yml": {Data: []byte(`
+users:
+  - id: 1
+    name: alice
+  - id: 2
+    name: bob
+  - name: carol
+orders:
+  - id: 10
+    user_id: 1
+`)},
+	"testdata/2_more.json": {Data: []byte(`{"orders": [{"id": 11, "user_id": 2}], "audit": []}`)},
+}
+
+func TestLoad(t *testing.T) {
+	f, err := Load(files, "testdata/*")
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	var names []string
+	for _, table := range f.Tables() {
+		names = append(names, table.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"users", "orders", "audit"}) || len(f.Table("orders").Rows) != 2 {
+		t.Error("expected tables in file order with rows combined", names, f.Table("orders"))
+	}
+
+	if _, err := Load(files, "missing/*"); err == nil {
+		t.Error("expected error when no files match")
+	}
+	if _, err := Parse([]byte("- 1")); err == nil {
+		t.Error("expected error for fixtures which aren't a mapping")
+	}
+}
+
+func TestSeed(t *testing.T) {
+	f, _ := Load(files, "testdata/*")
+	d := onedb.NewMock(nil, nil)
+	d.ExpectQuery("truncate table users, orders, audit restart identity")
+	d.ExpectQuery("insert into users (id, name) values ($1, $2), ($3, $4)").WithArgs(1, "alice", 2, "bob")
+	d.ExpectQuery("insert into users (name) values ($1)").WithArgs("carol")
+	d.ExpectQuery("insert into orders (id, user_id) values ($1, $2), ($3, $4)").WithArgs(10, 1, 11, 2)
+	if err := f.Seed(d); err != nil {
+		t.Error("expected success", err)
+	}
+	d.VerifyExpectations(t)
+}
+
+func TestCopy(t *testing.T) {
+	f, _ := Load(files, "testdata/1_users.yml")
+	f.Cascade = true
+	d := onedb.NewMock(nil, nil)
+	d.ExpectQuery("truncate table users, orders restart identity cascade")
+	copied := map[string][][]interface{}{}
+	err := f.Copy(d, func(table string, columns []string, rows [][]interface{}) error {
+		if table == "users" && !reflect.DeepEqual(columns, []string{"id", "name"}) {
+			t.Error("expected every column of the table", columns)
+		}
+		copied[table] = rows
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(copied["users"][2], []interface{}{nil, "carol"}) || len(copied["orders"]) != 1 {
+		t.Error("expected rows copied with NULL for missing columns", copied, err)
+	}
+	d.VerifyExpectations(t)
+}
+
+func TestBegin(t *testing.T) {
+	d := onedb.NewMock(nil, nil)
+	d.ExpectBegin()
+	d.ExpectRollback()
+	t.Run("test", func(t *testing.T) {
+		Begin[onedb.MockTxer](t, d)
+	})
+	d.VerifyExpectations(t)
+}
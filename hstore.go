@@ -0,0 +1,215 @@
+package onedb
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Hstore is the value of a Postgres hstore column, with nil for NULL values. It can be passed as a query
+// argument and scanned by drivers which use sql.Scanner
+type Hstore map[string]*string
+
+// Value formats h as hstore text. A nil Hstore is NULL
+func (h Hstore) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		writeHstoreString(&b, key)
+		b.WriteString("=>")
+		if value := h[key]; value == nil {
+			b.WriteString("NULL")
+		} else {
+			writeHstoreString(&b, *value)
+		}
+	}
+	return b.String(), nil
+}
+
+func writeHstoreString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+}
+
+// Scan parses hstore text into h
+func (h *Hstore) Scan(src interface{}) error {
+	return ScanHstore(src, h)
+}
+
+// ParseHstore parses the text of an hstore value like "a"=>"1", "b"=>NULL
+func ParseHstore(text string) (Hstore, error) {
+	h := Hstore{}
+	p := hstoreParser{text: text}
+	p.skipSpaces()
+	for p.i < len(p.text) {
+		key, null, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		if null {
+			return nil, errors.Errorf("NULL key in hstore %q", text)
+		}
+		p.skipSpaces()
+		if !strings.HasPrefix(p.text[p.i:], "=>") {
+			return nil, errors.Errorf("expected => after key %q in hstore %q", key, text)
+		}
+		p.i += 2
+		p.skipSpaces()
+		value, null, err := p.string()
+		if err != nil {
+			return nil, err
+		}
+		if null {
+			h[key] = nil
+		} else {
+			h[key] = &value
+		}
+		p.skipSpaces()
+		if p.i < len(p.text) {
+			if p.text[p.i] != ',' {
+				return nil, errors.Errorf("expected , after the value of %q in hstore %q", key, text)
+			}
+			p.i++
+			p.skipSpaces()
+		}
+	}
+	return h, nil
+}
+
+type hstoreParser struct {
+	text string
+	i    int
+}
+
+func (p *hstoreParser) skipSpaces() {
+	for p.i < len(p.text) && p.text[p.i] == ' ' {
+		p.i++
+	}
+}
+
+// string reads a quoted string, or an unquoted one up to the next separator, which is NULL when it says so
+func (p *hstoreParser) string() (s string, null bool, err error) {
+	if p.i < len(p.text) && p.text[p.i] == '"' {
+		var b strings.Builder
+		for p.i++; p.i < len(p.text) && p.text[p.i] != '"'; p.i++ {
+			if p.text[p.i] == '\\' && p.i+1 < len(p.text) {
+				p.i++
+			}
+			b.WriteByte(p.text[p.i])
+		}
+		if p.i == len(p.text) {
+			return "", false, errors.Errorf("unterminated string in hstore %q", p.text)
+		}
+		p.i++
+		return b.String(), false, nil
+	}
+	start := p.i
+	for p.i < len(p.text) && p.text[p.i] != ',' && p.text[p.i] != ' ' && !strings.HasPrefix(p.text[p.i:], "=>") {
+		p.i++
+	}
+	s = p.text[start:p.i]
+	if s == "" {
+		return "", false, errors.Errorf("expected a string at position %d of hstore %q", start, p.text)
+	}
+	return s, strings.EqualFold(s, "NULL"), nil
+}
+
+// ScanHstore stores the value of an hstore column in dest, which must be a *Hstore, a *map[string]*string or a
+// *map[string]string. A map[string]string can't hold NULL values, so their keys are left out of it. src can be
+// hstore text, as returned by most drivers, or a map
+func ScanHstore(src interface{}, dest interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() || !isHstoreField(value.Elem().Type()) {
+		return errors.Errorf("ScanHstore requires a pointer to a map of strings, not %T", dest)
+	}
+	if src == nil {
+		value.Elem().Set(reflect.Zero(value.Elem().Type()))
+		return nil
+	}
+	h, ok, err := hstoreValue(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("Unable to scan %T as hstore", src)
+	}
+	setHstore(value.Elem(), h)
+	return nil
+}
+
+// isHstoreField reports whether a field is a map of strings, or of pointers to strings, which hstore columns
+// are stored in
+func isHstoreField(t reflect.Type) bool {
+	if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+		return false
+	}
+	elem := t.Elem()
+	return elem.Kind() == reflect.String || elem.Kind() == reflect.Ptr && elem.Elem().Kind() == reflect.String
+}
+
+// hstoreValue converts the value of an hstore column, reporting false if it isn't one. hstore text always
+// starts with a quoted key, which tells it apart from a JSON object
+func hstoreValue(src interface{}) (Hstore, bool, error) {
+	switch v := src.(type) {
+	case Hstore:
+		return v, true, nil
+	case map[string]*string:
+		return Hstore(v), true, nil
+	case map[string]string:
+		h := make(Hstore, len(v))
+		for key, value := range v {
+			value := value
+			h[key] = &value
+		}
+		return h, true, nil
+	case []byte:
+		return hstoreValue(string(v))
+	case string:
+		if trimmed := strings.TrimSpace(v); trimmed != "" && trimmed[0] != '"' {
+			return nil, false, nil
+		}
+		h, err := ParseHstore(v)
+		return h, err == nil, err
+	}
+	return nil, false, nil
+}
+
+// setHstore sets dest, a map of strings or of pointers to strings, to the entries of h
+func setHstore(dest reflect.Value, h Hstore) {
+	t := dest.Type()
+	m := reflect.MakeMapWithSize(t, len(h))
+	for key, value := range h {
+		k := reflect.ValueOf(key).Convert(t.Key())
+		if t.Elem().Kind() == reflect.Ptr {
+			v := reflect.New(t.Elem().Elem())
+			if value == nil {
+				v = reflect.Zero(t.Elem())
+			} else {
+				v.Elem().SetString(*value)
+			}
+			m.SetMapIndex(k, v)
+		} else if value != nil {
+			m.SetMapIndex(k, reflect.ValueOf(*value).Convert(t.Elem()))
+		}
+	}
+	dest.Set(m)
+}
@@ -0,0 +1,89 @@
+package onedb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestParseHstore(t *testing.T) {
+	h, err := ParseHstore(`"a"=>"1", "b c"=>NULL, "quote \" and \\ slash"=>"NULL", d=>e`)
+	expected := Hstore{"a": strPtr("1"), "b c": nil, `quote " and \ slash`: strPtr("NULL"), "d": strPtr("e")}
+	if err != nil || !reflect.DeepEqual(h, expected) {
+		t.Error("expected quoted, unquoted and NULL values", h, err)
+	}
+	if h, err := ParseHstore(""); err != nil || len(h) != 0 {
+		t.Error("expected empty hstore", h, err)
+	}
+	for _, text := range []string{`"a"`, `"a"=>`, `"a"=>"1" "b"=>"2"`, `"a=>"1"`, `NULL=>"1"`} {
+		if _, err := ParseHstore(text); err == nil {
+			t.Error("expected error", text)
+		}
+	}
+}
+
+func TestHstoreValue(t *testing.T) {
+	v, err := Hstore{"b": nil, "a": strPtr(`x"y`)}.Value()
+	if err != nil || v != `"a"=>"x\"y", "b"=>NULL` {
+		t.Error("expected sorted hstore text", v, err)
+	}
+	if v, err := Hstore(nil).Value(); err != nil || v != nil {
+		t.Error("expected NULL", v, err)
+	}
+
+	var h Hstore
+	if err := h.Scan([]byte(v.(string))); err != nil || *h["a"] != `x"y` || h["b"] != nil {
+		t.Error("expected hstore to scan its own text", h, err)
+	}
+}
+
+func TestScanHstore(t *testing.T) {
+	var values map[string]string
+	if err := ScanHstore(`"a"=>"1", "b"=>NULL`, &values); err != nil || !reflect.DeepEqual(values, map[string]string{"a": "1"}) {
+		t.Error("expected NULL values left out of a map[string]string", values, err)
+	}
+	var ptrs map[string]*string
+	if err := ScanHstore(map[string]string{"a": "1"}, &ptrs); err != nil || *ptrs["a"] != "1" {
+		t.Error("expected map converted", ptrs, err)
+	}
+	if err := ScanHstore(nil, &ptrs); err != nil || ptrs != nil {
+		t.Error("expected NULL to clear the map", ptrs, err)
+	}
+	if err := ScanHstore(`{"a": 1}`, &ptrs); err == nil {
+		t.Error("expected error for text which isn't hstore")
+	}
+	var ints map[string]int
+	if err := ScanHstore(`"a"=>"1"`, &ints); err == nil {
+		t.Error("expected error for a map which can't hold strings")
+	}
+}
+
+type hstoreRow struct {
+	Attrs  string
+	Tags   Hstore
+	Config string
+}
+
+type hstoreResult struct {
+	Attrs  map[string]string
+	Tags   map[string]*string
+	Config map[string]string
+}
+
+func TestGetStructHstore(t *testing.T) {
+	result := []hstoreResult{}
+	rows := NewRowsScanner([]hstoreRow{{`"a"=>"1", "b"=>NULL`, Hstore{"c": nil}, `{"d": "2"}`}})
+	if err := getStruct(rows, &result, StructOptions{}); err != nil || len(result) != 1 {
+		t.Fatal("expected success", err, result)
+	}
+	r := result[0]
+	if !reflect.DeepEqual(r.Attrs, map[string]string{"a": "1"}) || !reflect.DeepEqual(r.Tags, map[string]*string{"c": nil}) {
+		t.Error("expected hstore columns converted into maps", r)
+	}
+	if !reflect.DeepEqual(r.Config, map[string]string{"d": "2"}) {
+		t.Error("expected a JSON object still unmarshaled", r.Config)
+	}
+}
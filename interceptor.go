@@ -0,0 +1,154 @@
+package onedb
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// The methods of a Call
+const (
+	MethodQuery    = "Query"
+	MethodQueryRow = "QueryRow"
+	MethodExec     = "Exec"
+	MethodBegin    = "Begin"
+)
+
+// Call is a statement, or the start of a transaction, passing through an interceptor chain. Interceptors may
+// change Query and Args before calling the next handler, e.g. to rewrite the statement
+type Call struct {
+	Method string // MethodQuery, MethodQueryRow, MethodExec or MethodBegin
+	Query  string // empty for MethodBegin
+	Args   []interface{}
+	InTx   bool // the call is made in a transaction, so its results may not be seen by other connections
+}
+
+// Handler runs a call. The result is a RowsScanner for MethodQuery and a Scanner for MethodQueryRow. For
+// MethodExec and MethodBegin it is the backend's own exec result and transaction, e.g. a pgx.CommandTag and a
+// pgx.Txer
+type Handler func(ctx context.Context, call *Call) (interface{}, error)
+
+// Interceptor wraps the handler of the next interceptor, or of the backend, to add behavior such as logging,
+// metrics, caching or rewriting to every call without changing the backend. It can return a result without
+// calling next
+type Interceptor func(next Handler) Handler
+
+// ChainInterceptors combines interceptors into one. The first is the outermost, so it sees each call first and
+// its result last
+func ChainInterceptors(interceptors ...Interceptor) Interceptor {
+	return func(next Handler) Handler {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			next = interceptors[i](next)
+		}
+		return next
+	}
+}
+
+// InterceptedBackender is a Backender whose queries run through an interceptor chain
+type InterceptedBackender interface {
+	Backender
+	ContextBackender
+	DBer
+}
+
+type interceptedBackend struct {
+	backend Backender
+	inTx    bool
+	handler Handler
+}
+
+// Intercept returns a backend running Query and QueryRow, with their Context variants and the DBer methods built
+// on them, through interceptors before backend. Exec and Begin differ between backends, so they are intercepted
+// by backends which take interceptors in their configuration, such as pgx's PoolConfig.Interceptors. When backend
+// is a transaction, its calls are marked InTx
+func Intercept(backend Backender, interceptors ...Interceptor) InterceptedBackender {
+	_, inTx := backend.(Txer)
+	b := &interceptedBackend{backend: backend, inTx: inTx}
+	b.handler = ChainInterceptors(interceptors...)(b.call)
+	return b
+}
+
+// call runs call against the backend at the end of the chain
+func (b *interceptedBackend) call(ctx context.Context, call *Call) (interface{}, error) {
+	ctxBackend, hasContext := b.backend.(ContextBackender)
+	switch call.Method {
+	case MethodQuery:
+		if hasContext {
+			return ctxBackend.QueryContext(ctx, call.Query, call.Args...)
+		}
+		return b.backend.Query(call.Query, call.Args...)
+	case MethodQueryRow:
+		if hasContext {
+			return ctxBackend.QueryRowContext(ctx, call.Query, call.Args...), nil
+		}
+		return b.backend.QueryRow(call.Query, call.Args...), nil
+	}
+	return nil, errors.Errorf("%s isn't supported by the intercepted backend", call.Method)
+}
+
+// InterceptedRows returns the RowsScanner from the result of a MethodQuery call, or an error if an interceptor
+// returned something else
+func InterceptedRows(result interface{}, err error) (RowsScanner, error) {
+	rows, ok := result.(RowsScanner)
+	if err == nil && !ok {
+		err = errors.Errorf("interceptor returned %T rather than RowsScanner for Query", result)
+	}
+	return rows, err
+}
+
+// InterceptedRow returns the Scanner from the result of a MethodQueryRow call. An error is returned from its Scan
+func InterceptedRow(result interface{}, err error) Scanner {
+	row, ok := result.(Scanner)
+	if err == nil && !ok {
+		err = errors.Errorf("interceptor returned %T rather than Scanner for QueryRow", result)
+	}
+	if err != nil {
+		return &errorScanner{err}
+	}
+	return row
+}
+
+func (b *interceptedBackend) Query(query string, args ...interface{}) (RowsScanner, error) {
+	return b.QueryContext(context.Background(), query, args...)
+}
+
+func (b *interceptedBackend) QueryContext(ctx context.Context, query string, args ...interface{}) (RowsScanner, error) {
+	return InterceptedRows(b.handler(ctx, &Call{Method: MethodQuery, Query: query, Args: args, InTx: b.inTx}))
+}
+
+func (b *interceptedBackend) QueryRow(query string, args ...interface{}) Scanner {
+	return b.QueryRowContext(context.Background(), query, args...)
+}
+
+func (b *interceptedBackend) QueryRowContext(ctx context.Context, query string, args ...interface{}) Scanner {
+	return InterceptedRow(b.handler(ctx, &Call{Method: MethodQueryRow, Query: query, Args: args, InTx: b.inTx}))
+}
+
+func (b *interceptedBackend) QueryValues(query *Query, result ...interface{}) error {
+	return QueryValues(b, query, result...)
+}
+
+func (b *interceptedBackend) QueryJSON(query string, args ...interface{}) (string, error) {
+	return QueryJSON(b, query, args...)
+}
+
+func (b *interceptedBackend) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return QueryJSONRow(b, query, args...)
+}
+
+func (b *interceptedBackend) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return QueryJSONWriter(w, b, query, args...)
+}
+
+func (b *interceptedBackend) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return QueryStruct(b, result, query, args...)
+}
+
+func (b *interceptedBackend) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return QueryStructRow(b, result, query, args...)
+}
+
+func (b *interceptedBackend) QueryWriteCSV(w io.Writer, options CSVOptions, query string, args ...interface{}) error {
+	return QueryWriteCSV(w, options, b, query, args...)
+}
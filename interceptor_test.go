@@ -0,0 +1,71 @@
+package onedb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIntercept(t *testing.T) {
+	var order []string
+	logger := func(name string) Interceptor {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) (interface{}, error) {
+				order = append(order, name+" "+call.Method)
+				return next(ctx, call)
+			}
+		}
+	}
+	rewriter := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (interface{}, error) {
+			call.Query = strings.ToLower(call.Query)
+			return next(ctx, call)
+		}
+	}
+	m := NewMock(nil, nil, []SimpleData{{1, "hello"}}, []SimpleData{{2, "world"}})
+	b := Intercept(m, logger("outer"), logger("inner"), rewriter)
+
+	var rows []SimpleData
+	if err := b.QueryStruct(&rows, "SELECT * FROM t"); err != nil || len(rows) != 1 || rows[0].IntVal != 1 {
+		t.Error("expected rows from backend", rows, err)
+	}
+	var id int
+	var value string
+	if err := b.QueryRow("SELECT * FROM t WHERE id = $1", 2).Scan(&id, &value); err != nil || id != 2 || value != "world" {
+		t.Error("expected row from backend", id, value, err)
+	}
+	if strings.Join(order, ",") != "outer Query,inner Query,outer QueryRow,inner QueryRow" {
+		t.Error("expected the first interceptor to run first", order)
+	}
+	m.VerifyNextCommand(t, "QueryContext", "select * from t")
+	m.VerifyNextCommand(t, "QueryRowContext", "select * from t where id = $1", 2)
+}
+
+func TestInterceptShortCircuit(t *testing.T) {
+	fail := errors.New("fail")
+	b := Intercept(NewMock(nil, nil), func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (interface{}, error) {
+			switch call.Query {
+			case "cached":
+				return NewRowsScanner([]SimpleData{{5, "cached"}}), nil
+			case "wrong type":
+				return "rows", nil
+			}
+			return nil, fail
+		}
+	})
+	var rows []SimpleData
+	if err := b.QueryStruct(&rows, "cached"); err != nil || len(rows) != 1 || rows[0].IntVal != 5 {
+		t.Error("expected interceptor's rows", rows, err)
+	}
+	if _, err := b.Query("wrong type"); err == nil {
+		t.Error("expected error for a result which isn't RowsScanner")
+	}
+	if err := b.QueryRow("other").Scan(); err != fail {
+		t.Error("expected interceptor's error from Scan", err)
+	}
+	if err := b.QueryRow("wrong type").Scan(); err == nil {
+		t.Error("expected error for a result which isn't Scanner")
+	}
+}
@@ -1,6 +1,7 @@
 package onedb
 
 import (
+	"context"
 	"io"
 
 	"github.com/pkg/errors"
@@ -12,6 +13,20 @@ type Backender interface {
 	QueryRow(query string, args ...interface{}) Scanner
 }
 
+// ContextBackender is the db interface needed by onedb to enable queries which can be canceled or
+// given a deadline through a context.Context
+type ContextBackender interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (RowsScanner, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) Scanner
+}
+
+// Pinger is implemented by backends which can verify that the database is reachable, e.g. for a health check
+// endpoint, without running an arbitrary query
+type Pinger interface {
+	Ping() error
+	PingContext(ctx context.Context) error
+}
+
 // RowsScanner is the rows interface needed by onedb to enable QueryStruct and QueryJSON capability
 type RowsScanner interface {
 	Close() error
@@ -26,11 +41,23 @@ type Scanner interface {
 	Scan(dest ...interface{}) error
 }
 
+// Txer is the transaction interface needed by WithTx
+type Txer interface {
+	Commit() error
+	Rollback() error
+}
+
+// TxBeginner is the interface needed by WithTx to start a transaction
+type TxBeginner[T Txer] interface {
+	Begin() (T, error)
+}
+
 // DBer is the added interface that onedb can enable for database querying
 type DBer interface {
 	QueryValues(query *Query, result ...interface{}) error
 	QueryJSON(query string, args ...interface{}) (string, error)
 	QueryJSONRow(query string, args ...interface{}) (string, error)
+	QueryJSONWriter(w io.Writer, query string, args ...interface{}) error
 	QueryStruct(result interface{}, query string, args ...interface{}) error
 	QueryStructRow(result interface{}, query string, args ...interface{}) error
 	QueryWriteCSV(w io.Writer, options CSVOptions, query string, args ...interface{}) error
@@ -0,0 +1,78 @@
+package onedb
+
+import (
+	"crypto/tls"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// TLSMode selects how the connection to the directory is encrypted
+type TLSMode int
+
+const (
+	// StartTLS connects in plain text and upgrades the connection with the StartTLS extended operation before binding
+	StartTLS TLSMode = iota
+	// LDAPS connects with TLS from the start, as ldaps:// URLs do
+	LDAPS
+	// NoTLS doesn't encrypt the connection, so binds send the password in plain text. Only use it for testing
+	NoTLS
+)
+
+// Config is the information needed to connect and bind to a directory
+type Config struct {
+	Hostname string
+	Port     int // defaults to 636 for LDAPS and 389 otherwise
+	BindDN   string
+	Password string
+	TLS      TLSMode
+	// TLSConfig is used for StartTLS and LDAPS. When nil, or when its ServerName is empty, the server's
+	// certificate is verified against Hostname
+	TLSConfig *tls.Config
+}
+
+// ParseURL returns a Config for an ldap:// or ldaps:// URL. ldap:// connections are upgraded with StartTLS
+func ParseURL(rawurl string) (*Config, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{Hostname: u.Hostname()}
+	switch u.Scheme {
+	case "ldap":
+		config.TLS = StartTLS
+	case "ldaps":
+		config.TLS = LDAPS
+	default:
+		return nil, errors.Errorf("Invalid URL scheme %q. Must be ldap or ldaps", u.Scheme)
+	}
+	if port := u.Port(); port != "" {
+		if config.Port, err = strconv.Atoi(port); err != nil {
+			return nil, errors.Wrap(err, "Invalid port")
+		}
+	}
+	return config, nil
+}
+
+func (c *Config) address() string {
+	port := c.Port
+	if port == 0 && c.TLS == LDAPS {
+		port = 636
+	} else if port == 0 {
+		port = 389
+	}
+	return net.JoinHostPort(c.Hostname, strconv.Itoa(port))
+}
+
+func (c *Config) tlsConfig() *tls.Config {
+	if c.TLSConfig == nil {
+		return &tls.Config{ServerName: c.Hostname}
+	}
+	config := c.TLSConfig.Clone()
+	if config.ServerName == "" {
+		config.ServerName = c.Hostname
+	}
+	return config
+}
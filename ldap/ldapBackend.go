@@ -1,6 +1,7 @@
 package onedb
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -11,29 +12,39 @@ import (
 	"time"
 
 	"github.com/EndFirstCorp/onedb"
+	"github.com/go-ldap/ldap/v3"
 	"github.com/pkg/errors"
-	ldap "gopkg.in/ldap.v2"
 )
 
 // LDAPer is the interface of an implementation of the Lightweight Directory Access Protocol (LDAP) protocol for generic directory services over the internet.
 type LDAPer interface {
 	Bind(username, password string) error
 	Query(query *ldap.SearchRequest) (*ldap.SearchResult, error)
+	QueryRows(query *ldap.SearchRequest) (onedb.RowsScanner, error)
+	SetPageSize(pageSize uint32)
 
 	QueryJSON(query *ldap.SearchRequest) (string, error)
 	QueryJSONRow(query *ldap.SearchRequest) (string, error)
 	QueryStruct(result interface{}, query *ldap.SearchRequest) error
 	QueryStructRow(result interface{}, query *ldap.SearchRequest) error
 	QueryValues(query *ldap.SearchRequest, result ...interface{}) error
+
+	Execute(request interface{}) error
+	onedb.Pinger
 }
 
+// DefaultPageSize is the number of entries requested in each page of a search. It is kept under the size limit
+// most directories enforce (commonly 1000) so large searches return every entry instead of being truncated
+const DefaultPageSize uint32 = 500
+
 var errInvalidLdapQueryType = errors.New("Invalid query. Must be of type *ldap.SearchRequest")
-var errInvalidLdapExecType = errors.New("Invalid execute request. Must be of type *ldap.AddRequest, *ldap.DelRequest, *ldap.ModifyRequest or *ldap.PasswordModifyRequest")
+var errInvalidLdapExecType = errors.New("Invalid execute request. Must be of type *ldap.AddRequest, *ldap.DelRequest, *ldap.ModifyRequest, *ldap.ModifyDNRequest, *ldap.PasswordModifyRequest or *ldap.SimpleBindRequest from github.com/go-ldap/ldap/v3")
 
 type ldapNewConnFunc func(conn net.Conn, isTLS bool) ldapBackender
 
 var dialTCPFunc onedb.DialFunc = onedb.DialTCP
 var newConnFunc ldapNewConnFunc = (&ldapRealCreator{}).NewConn
+var tlsClientFunc = tlsClient
 
 type ldapRealCreator struct{}
 
@@ -45,10 +56,8 @@ type ldapBackend struct {
 	l          ldapBackender
 	lastRetry  time.Time
 	retryCount int
-	hostname   string
-	port       int
-	binddn     string
-	password   string
+	config     *Config
+	pageSize   uint32
 }
 
 type ldapBackender interface {
@@ -56,46 +65,89 @@ type ldapBackender interface {
 	StartTLS(config *tls.Config) error
 	Bind(username, password string) error
 	// SimpleBind(simpleBindRequest *ldap.SimpleBindRequest) (*ldap.SimpleBindResult, error)
-	Close()
+	Close() error
 	Add(addRequest *ldap.AddRequest) error
 	Del(delRequest *ldap.DelRequest) error
 	Modify(modifyRequest *ldap.ModifyRequest) error
+	ModifyDN(modifyDNRequest *ldap.ModifyDNRequest) error
 	PasswordModify(passwordModifyRequest *ldap.PasswordModifyRequest) (*ldap.PasswordModifyResult, error)
 	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
 	// SearchWithPaging(searchRequest *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error)
 }
 
 // NewLDAP creates a new Lightweight Directory Access Protocol (LDAP) for generic directory services over the internet.
+// The connection is upgraded with StartTLS before binding.
 func NewLDAP(hostname string, port int, binddn string, password string) (LDAPer, error) {
-	l, err := ldapConnect(hostname, port, binddn, password)
+	return NewLDAPWithConfig(&Config{Hostname: hostname, Port: port, BindDN: binddn, Password: password})
+}
+
+// NewLDAPFromURL connects to the directory at an ldap:// (StartTLS) or ldaps:// URL. tlsConfig may be nil
+func NewLDAPFromURL(rawurl string, binddn string, password string, tlsConfig *tls.Config) (LDAPer, error) {
+	config, err := ParseURL(rawurl)
 	if err != nil {
 		return nil, err
 	}
-	return &ldapBackend{l: l, hostname: hostname, port: port, binddn: binddn, password: password}, nil
+	config.BindDN, config.Password, config.TLSConfig = binddn, password, tlsConfig
+	return NewLDAPWithConfig(config)
 }
 
-func ldapConnect(hostname string, port int, binddn string, password string) (ldapBackender, error) {
-	tc, err := dialTCPFunc("tcp", fmt.Sprintf("%s:%d", hostname, port))
+// NewLDAPWithConfig connects and binds to the directory described by config
+func NewLDAPWithConfig(config *Config) (LDAPer, error) {
+	l, err := ldapConnect(config)
 	if err != nil {
 		return nil, err
 	}
-	l := newConnFunc(tc, false)
-	l.Start()
-	if err = l.StartTLS(&tls.Config{ServerName: hostname}); err != nil {
+	return &ldapBackend{l: l, config: config, pageSize: DefaultPageSize}, nil
+}
+
+func ldapConnect(config *Config) (ldapBackender, error) {
+	tc, err := dialTCPFunc("tcp", config.address())
+	if err != nil {
 		return nil, err
 	}
+	isTLS := config.TLS == LDAPS
+	if isTLS {
+		if tc, err = tlsClientFunc(tc, config.tlsConfig()); err != nil {
+			return nil, err
+		}
+	}
+	l := newConnFunc(tc, isTLS)
+	l.Start()
+	if config.TLS == StartTLS {
+		if err = l.StartTLS(config.tlsConfig()); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
 
-	if err := l.Bind(binddn, password); err != nil {
+	if err := l.Bind(config.BindDN, config.Password); err != nil {
+		l.Close()
 		return nil, err
 	}
 
 	return l, nil
 }
 
+// tlsClient completes the TLS handshake on conn so certificate errors are returned before the LDAP connection starts
+func tlsClient(conn net.Conn, config *tls.Config) (net.Conn, error) {
+	c := tls.Client(conn, config)
+	if err := c.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
 func (l *ldapBackend) Bind(username, password string) error {
 	return l.l.Bind(username, password)
 }
 
+// SetPageSize sets the number of entries requested in each page of a search (RFC 2696). A page size of 0 turns
+// paging off, which leaves results subject to the server's size limit
+func (l *ldapBackend) SetPageSize(pageSize uint32) {
+	l.pageSize = pageSize
+}
+
 func (l *ldapBackend) QueryJSON(query *ldap.SearchRequest) (string, error) {
 	res, err := l.Query(query)
 	if err != nil {
@@ -228,11 +280,37 @@ func (l *ldapBackend) Backend() interface{} {
 	return l.l
 }
 
+// Ping reads the root DSE, which every directory serves, without requesting any of its attributes. The
+// connection is reestablished first if it has been closed
+func (l *ldapBackend) Ping() error {
+	_, err := l.search(ldap.NewSearchRequest("", ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 0, false, "(objectClass=*)", []string{"1.1"}, nil), true)
+	return err
+}
+
+// PingContext is Ping, returning the context's error if ctx is done before the directory replies
+func (l *ldapBackend) PingContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Ping()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (l *ldapBackend) Close() error {
-	l.l.Close()
-	return nil
+	return l.l.Close()
 }
 
+// Execute runs a write request against the directory: *ldap.AddRequest, *ldap.DelRequest, *ldap.ModifyRequest,
+// *ldap.ModifyDNRequest (rename or move), *ldap.PasswordModifyRequest or *ldap.SimpleBindRequest. The requests
+// are github.com/go-ldap/ldap/v3 types; those from gopkg.in/ldap.v2, which this package used before, are rejected
 func (l *ldapBackend) Execute(query interface{}) error {
 	var err error
 	switch r := query.(type) {
@@ -242,6 +320,8 @@ func (l *ldapBackend) Execute(query interface{}) error {
 		err = l.l.Del(r)
 	case *ldap.ModifyRequest:
 		err = l.l.Modify(r)
+	case *ldap.ModifyDNRequest:
+		err = l.l.ModifyDN(r)
 	case *ldap.PasswordModifyRequest:
 		_, err = l.l.PasswordModify(r)
 	case *ldap.SimpleBindRequest:
@@ -255,22 +335,258 @@ func (l *ldapBackend) Execute(query interface{}) error {
 	return err
 }
 
+// Query returns all of the entries found by query, requesting them one page at a time
 func (l *ldapBackend) Query(query *ldap.SearchRequest) (*ldap.SearchResult, error) {
 	if query == nil {
 		return nil, onedb.ErrQueryIsNil
 	}
+	result := &ldap.SearchResult{}
+	p := l.newPager(query)
+	for !p.done {
+		res, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		result.Entries = append(result.Entries, res.Entries...)
+		result.Referrals = append(result.Referrals, res.Referrals...)
+		result.Controls = res.Controls
+	}
+	return result, nil
+}
+
+// QueryRows returns the entries found by query as rows. The first page is read right away and each following page
+// is requested as Next reaches the end of the one before it. The columns are query's attributes, or the first
+// entry's attributes when query asks for all of them. Values are scanned as []string, or as a string when the
+// destination is a *string and the attribute has a single value
+func (l *ldapBackend) QueryRows(query *ldap.SearchRequest) (onedb.RowsScanner, error) {
+	if query == nil {
+		return nil, onedb.ErrQueryIsNil
+	}
+	p := l.newPager(query)
+	res, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	rows := newLdapRows(res.Entries)
+	rows.pager = p
+	if columns := requestedAttributes(query); columns != nil {
+		rows.columns = columns
+	}
+	return rows, nil
+}
+
+func (l *ldapBackend) search(query *ldap.SearchRequest, canRetry bool) (*ldap.SearchResult, error) {
 	res, err := l.l.Search(query)
-	if err != nil && strings.HasSuffix(err.Error(), "ldap: connection closed") && l.reconnect() {
-		return l.Query(query)
+	if err != nil && canRetry && strings.HasSuffix(err.Error(), "ldap: connection closed") && l.reconnect() {
+		return l.search(query, canRetry)
+	}
+	return res, err
+}
+
+// ldapPager requests the pages of a search using the paged results control
+type ldapPager struct {
+	l      *ldapBackend
+	query  *ldap.SearchRequest
+	paging *ldap.ControlPaging
+	done   bool
+	conn   ldapBackender   // set when the backend is a pool, since the paging cookie only works on one connection
+	unpin  func(err error) // returns conn to the pool
+}
+
+// connPinner is implemented by backends with several connections. pin runs the first page of a paged search and
+// returns the connection it ran on so the following pages can use it
+type connPinner interface {
+	pin(query *ldap.SearchRequest) (*ldap.SearchResult, ldapBackender, func(err error), error)
+}
+
+// newPager pages query unless paging is turned off or query already has its own paging control
+func (l *ldapBackend) newPager(query *ldap.SearchRequest) *ldapPager {
+	p := &ldapPager{l: l, query: query}
+	if l.pageSize > 0 && ldap.FindControl(query.Controls, ldap.ControlTypePaging) == nil {
+		p.paging = ldap.NewControlPaging(l.pageSize)
+	}
+	return p
+}
+
+// next returns the next page. The search is done once the server stops returning a paging cookie. Only the first
+// page is retried after reconnecting since the cookie isn't valid on a new connection
+func (p *ldapPager) next() (*ldap.SearchResult, error) {
+	first := p.paging == nil || len(p.paging.Cookie) == 0
+	p.done = true
+	res, err := p.search(first)
+	if err == nil && res == nil {
+		res = &ldap.SearchResult{}
+	}
+	if err == nil && p.paging != nil {
+		if c, ok := ldap.FindControl(res.Controls, ldap.ControlTypePaging).(*ldap.ControlPaging); ok && len(c.Cookie) > 0 {
+			p.paging.SetCookie(c.Cookie)
+			p.done = false
+		}
+	}
+	if p.done {
+		p.release(err)
 	}
 	return res, err
 }
 
+func (p *ldapPager) search(first bool) (*ldap.SearchResult, error) {
+	if p.conn != nil {
+		return p.conn.Search(p.request())
+	}
+	if pinner, ok := p.l.l.(connPinner); ok && p.paging != nil && first {
+		res, conn, unpin, err := pinner.pin(p.request())
+		p.conn, p.unpin = conn, unpin
+		return res, err
+	}
+	return p.l.search(p.request(), first)
+}
+
+// release returns a pinned connection once the search is over
+func (p *ldapPager) release(err error) {
+	if p.unpin != nil {
+		p.unpin(err)
+		p.conn, p.unpin = nil, nil
+	}
+}
+
+// abandon releases the server's cursor when a paged search isn't read to the end
+func (p *ldapPager) abandon() {
+	if p.done || p.paging == nil {
+		return
+	}
+	p.done = true
+	p.paging.PagingSize = 0
+	if p.conn != nil {
+		_, err := p.conn.Search(p.request())
+		p.release(err)
+		return
+	}
+	p.l.l.Search(p.request())
+}
+
+// request copies query with the paging control added so the caller's request isn't modified
+func (p *ldapPager) request() *ldap.SearchRequest {
+	if p.paging == nil {
+		return p.query
+	}
+	req := *p.query
+	req.Controls = append(append([]ldap.Control(nil), p.query.Controls...), p.paging)
+	return &req
+}
+
+// requestedAttributes returns query's attributes, or nil if it asks for all user or operational attributes or none
+func requestedAttributes(query *ldap.SearchRequest) []string {
+	if len(query.Attributes) == 0 {
+		return nil
+	}
+	for _, attribute := range query.Attributes {
+		if attribute == "*" || attribute == "+" || attribute == "1.1" {
+			return nil
+		}
+	}
+	return append([]string(nil), query.Attributes...)
+}
+
+var errLdapRowsNoRow = errors.New("No current row. Next must be called before Scan")
+
+// ldapRows exposes search entries as rows, fetching the next page from the pager as needed
+type ldapRows struct {
+	pager   *ldapPager
+	entries []*ldap.Entry
+	current *ldap.Entry
+	columns []string
+	err     error
+}
+
+func newLdapRows(entries []*ldap.Entry) *ldapRows {
+	r := &ldapRows{entries: entries}
+	if len(entries) > 0 {
+		for _, attribute := range entries[0].Attributes {
+			r.columns = append(r.columns, attribute.Name)
+		}
+	}
+	return r
+}
+
+func (r *ldapRows) Columns() ([]string, error) {
+	return append([]string(nil), r.columns...), nil
+}
+
+// ColumnTypes describes the attributes as nullable lists of strings, since an entry may have any number of
+// values for an attribute, including none
+func (r *ldapRows) ColumnTypes() ([]onedb.ColumnType, error) {
+	columnTypes := make([]onedb.ColumnType, len(r.columns))
+	for i, column := range r.columns {
+		columnTypes[i] = onedb.ColumnType{Name: column, Nullable: true, ScanType: reflect.TypeOf([]string(nil))}
+	}
+	return columnTypes, nil
+}
+
+func (r *ldapRows) Next() bool {
+	for len(r.entries) == 0 {
+		if r.pager == nil || r.pager.done || r.err != nil {
+			r.current = nil
+			return false
+		}
+		res, err := r.pager.next()
+		if err != nil {
+			r.err = err
+			continue
+		}
+		r.entries = res.Entries
+	}
+	r.current, r.entries = r.entries[0], r.entries[1:]
+	return true
+}
+
+func (r *ldapRows) Scan(dest ...interface{}) error {
+	if r.current == nil {
+		return errLdapRowsNoRow
+	}
+	for i, d := range dest {
+		if i >= len(r.columns) {
+			break
+		}
+		values := r.current.GetAttributeValues(r.columns[i])
+		switch p := d.(type) {
+		case *interface{}:
+			*p = values
+		case *[]string:
+			*p = values
+		case *string:
+			if len(values) > 1 {
+				return errors.Errorf("Expected single value for attribute: %s, but found %d", r.columns[i], len(values))
+			}
+			*p = ""
+			if len(values) == 1 {
+				*p = values[0]
+			}
+		default:
+			return errors.Errorf("Unsupported Scan destination %T for attribute: %s", d, r.columns[i])
+		}
+	}
+	return nil
+}
+
+func (r *ldapRows) Err() error {
+	return r.err
+}
+
+// Close abandons the rest of a paged search
+func (r *ldapRows) Close() error {
+	r.entries = nil
+	r.current = nil
+	if r.pager != nil {
+		r.pager.abandon()
+	}
+	return nil
+}
+
 func (l *ldapBackend) reconnect() bool {
 	ms := time.Millisecond * time.Duration(math.Pow10(l.retryCount)) // retry every 10^lastRetry milliseconds
-	if time.Since(l.lastRetry) > ms {
+	if l.config != nil && time.Since(l.lastRetry) > ms {
 		l.lastRetry = time.Now()
-		conn, err := ldapConnect(l.hostname, l.port, l.binddn, l.password)
+		conn, err := ldapConnect(l.config)
 		if err == nil {
 			l.retryCount = 0
 			l.l = conn
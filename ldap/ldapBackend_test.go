@@ -1,13 +1,15 @@
 package onedb
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"net"
+	"reflect"
 	"testing"
 
 	"github.com/EndFirstCorp/onedb"
-	ldap "gopkg.in/ldap.v2"
+	"github.com/go-ldap/ldap/v3"
 )
 
 func TestNewLdap(t *testing.T) {
@@ -38,6 +40,67 @@ func TestNewLdap(t *testing.T) {
 	}
 }
 
+func TestNewLdapWithConfig(t *testing.T) {
+	var addr string
+	var handshakeConfig *tls.Config
+	dialTCPFunc = func(network, a string) (net.Conn, error) {
+		addr = a
+		return nil, nil
+	}
+	tlsClientFunc = func(conn net.Conn, config *tls.Config) (net.Conn, error) {
+		handshakeConfig = config
+		return conn, nil
+	}
+	defer func() { tlsClientFunc = tlsClient }()
+	newConnFunc = newMockLDAPCreator(nil, nil)
+
+	l, err := NewLDAPWithConfig(&Config{Hostname: "ldap.example.com", TLS: LDAPS, TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}})
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	m := l.(*ldapBackend).l.(*mockLdapBackend)
+	if addr != "ldap.example.com:636" || !m.IsTLS || len(m.MethodsCalled["StartTLS"]) != 0 {
+		t.Error("expected TLS connection to the ldaps port without StartTLS", addr, m.IsTLS)
+	}
+	if handshakeConfig.ServerName != "ldap.example.com" || handshakeConfig.MinVersion != tls.VersionTLS12 {
+		t.Error("expected custom tls config with the server name filled in", handshakeConfig)
+	}
+
+	l, _ = NewLDAPWithConfig(&Config{Hostname: "ldap.example.com", TLSConfig: &tls.Config{ServerName: "other"}})
+	m = l.(*ldapBackend).l.(*mockLdapBackend)
+	startTLS := m.MethodsCalled["StartTLS"]
+	if addr != "ldap.example.com:389" || m.IsTLS || len(startTLS) != 1 || startTLS[0].([]interface{})[0].(*tls.Config).ServerName != "other" {
+		t.Error("expected StartTLS with the custom config", addr, startTLS)
+	}
+
+	l, _ = NewLDAPWithConfig(&Config{Hostname: "localhost", Port: 10389, TLS: NoTLS})
+	m = l.(*ldapBackend).l.(*mockLdapBackend)
+	if addr != "localhost:10389" || len(m.MethodsCalled["StartTLS"]) != 0 {
+		t.Error("expected plain connection", addr)
+	}
+
+	tlsClientFunc = func(conn net.Conn, config *tls.Config) (net.Conn, error) {
+		return nil, errors.New("fail")
+	}
+	if _, err := NewLDAPFromURL("ldaps://ldap.example.com", "user", "password", nil); err == nil {
+		t.Error("expected handshake error")
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	c, err := ParseURL("ldaps://ldap.example.com:1636")
+	if err != nil || c.Hostname != "ldap.example.com" || c.Port != 1636 || c.TLS != LDAPS {
+		t.Error("expected ldaps config", c, err)
+	}
+	c, err = ParseURL("ldap://ldap.example.com")
+	if err != nil || c.Port != 0 || c.TLS != StartTLS {
+		t.Error("expected StartTLS config", c, err)
+	}
+	if _, err := ParseURL("http://ldap.example.com"); err == nil {
+		t.Error("expected invalid scheme error")
+	}
+}
+
 func TestNewLdapDBRealConnection(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
@@ -59,6 +122,28 @@ func TestLdapClose(t *testing.T) {
 	}
 }
 
+func TestLdapPing(t *testing.T) {
+	m := newMockLdap()
+	d := &ldapBackend{l: m}
+	if err := d.Ping(); err != nil {
+		t.Error("expected ping success", err)
+	}
+	searches := m.MethodsCalled["Search"]
+	if len(searches) != 1 || searches[0].([]interface{})[0].(*ldap.SearchRequest).Scope != ldap.ScopeBaseObject {
+		t.Error("expected a base object search of the root DSE", searches)
+	}
+
+	m.SearchErr = errors.New("fail")
+	if err := d.PingContext(context.Background()); err != m.SearchErr {
+		t.Error("expected search error", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := d.PingContext(ctx); err != context.Canceled {
+		t.Error("expected context error", err)
+	}
+}
+
 func TestLdapBackend(t *testing.T) {
 	m := newMockLdap()
 	d := &ldapBackend{l: m}
@@ -122,7 +207,7 @@ func TestLdapExecute(t *testing.T) {
 	}
 
 	m.AddErr = errors.New("fail")
-	err = l.Execute(ldap.NewAddRequest("Dn"))
+	err = l.Execute(ldap.NewAddRequest("Dn", nil))
 	if err == nil || len(m.MethodsCalled["Add"]) != 1 {
 		t.Error("expected Add method to be called on backend and return err")
 	}
@@ -134,11 +219,17 @@ func TestLdapExecute(t *testing.T) {
 	}
 
 	m.ModifyErr = errors.New("fail")
-	err = l.Execute(ldap.NewModifyRequest("Dn"))
+	err = l.Execute(ldap.NewModifyRequest("Dn", nil))
 	if err == nil || len(m.MethodsCalled["Modify"]) != 1 {
 		t.Error("expected Modify method to be called on backend and return err")
 	}
 
+	m.ModifyDNErr = errors.New("fail")
+	err = l.Execute(ldap.NewModifyDNRequest("uid=rob,ou=people,dc=example,dc=com", "uid=robert", true, ""))
+	if err == nil || len(m.MethodsCalled["ModifyDN"]) != 1 {
+		t.Error("expected ModifyDN method to be called on backend and return err")
+	}
+
 	m.PasswordModifyErr = errors.New("fail")
 	err = l.Execute(ldap.NewPasswordModifyRequest("Dn", "oldPassword", "newPassword"))
 	if err == nil || len(m.MethodsCalled["PasswordModify"]) != 1 {
@@ -146,7 +237,7 @@ func TestLdapExecute(t *testing.T) {
 	}
 }
 
-/*func TestLdapRowsScanAndNext(t *testing.T) {
+func TestLdapRowsScanAndNext(t *testing.T) {
 	var uid, password, uidNumber, gidNumber, home interface{}
 	entries := []*ldap.Entry{
 		&ldap.Entry{DN: "item1", Attributes: []*ldap.EntryAttribute{
@@ -214,7 +305,95 @@ func TestLdapRowsColumns(t *testing.T) {
 	if len(cols) != 5 || cols[0] != "uid" || cols[1] != "userPassword" || cols[2] != "uidNumber" || cols[3] != "gidNumber" || cols[4] != "homeDirectory" {
 		t.Error("expected 5 columns")
 	}
-}*/
+}
+
+func TestLdapRowsColumnTypes(t *testing.T) {
+	r := newLdapRows([]*ldap.Entry{{DN: "item1", Attributes: []*ldap.EntryAttribute{{Name: "uid", Values: []string{"rob"}}}}})
+	types, err := r.ColumnTypes()
+	if err != nil || len(types) != 1 || types[0].Name != "uid" || !types[0].Nullable || types[0].ScanType != reflect.TypeOf([]string(nil)) {
+		t.Error("expected attributes described as nullable lists of strings", types, err)
+	}
+}
+
+func TestLdapQueryPaged(t *testing.T) {
+	m := newMockLdap()
+	m.SearchPages = []*ldap.SearchResult{
+		{Entries: []*ldap.Entry{{DN: "item1"}, {DN: "item2"}}, Controls: []ldap.Control{&ldap.ControlPaging{Cookie: []byte("next")}}},
+		{Entries: []*ldap.Entry{{DN: "item3"}}, Controls: []ldap.Control{&ldap.ControlPaging{}}},
+	}
+	l := &ldapBackend{l: m, pageSize: 2}
+	r := ldap.NewSearchRequest("baseDn", ldap.ScopeSingleLevel, ldap.NeverDerefAliases, 0, 0, false, "filter", []string{"uid"}, nil)
+	res, err := l.Query(r)
+	if err != nil || len(res.Entries) != 3 || res.Entries[2].DN != "item3" {
+		t.Fatal("expected entries from every page", res, err)
+	}
+	queries := m.MethodsCalled["Search"]
+	if len(queries) != 2 || len(r.Controls) != 0 {
+		t.Fatal("expected a search per page without modifying the request", len(queries), r.Controls)
+	}
+	second := queries[1].([]interface{})[0].(*ldap.SearchRequest)
+	paging := ldap.FindControl(second.Controls, ldap.ControlTypePaging).(*ldap.ControlPaging)
+	if paging.PagingSize != 2 || string(paging.Cookie) != "next" {
+		t.Error("expected paging control with the cookie from the previous page", paging)
+	}
+}
+
+func TestLdapQueryRows(t *testing.T) {
+	m := newMockLdap()
+	m.SearchPages = []*ldap.SearchResult{
+		{Entries: []*ldap.Entry{{DN: "item1", Attributes: []*ldap.EntryAttribute{{Name: "uid", Values: []string{"rob"}}}}},
+			Controls: []ldap.Control{&ldap.ControlPaging{Cookie: []byte("next")}}},
+		{Entries: []*ldap.Entry{{DN: "item2", Attributes: []*ldap.EntryAttribute{{Name: "uid", Values: []string{"bob"}}}}}},
+	}
+	l := &ldapBackend{l: m, pageSize: 1}
+	r := ldap.NewSearchRequest("baseDn", ldap.ScopeSingleLevel, ldap.NeverDerefAliases, 0, 0, false, "filter", []string{"uid", "mail"}, nil)
+	rows, err := l.QueryRows(r)
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	if cols, _ := rows.Columns(); len(cols) != 2 || cols[0] != "uid" || cols[1] != "mail" {
+		t.Error("expected requested attributes as columns", cols)
+	}
+	var uids []string
+	for rows.Next() {
+		var uid, mail string
+		if err := rows.Scan(&uid, &mail); err != nil {
+			t.Fatal("expected success", err)
+		}
+		uids = append(uids, uid)
+	}
+	if rows.Err() != nil || len(uids) != 2 || uids[0] != "rob" || uids[1] != "bob" {
+		t.Error("expected rows from both pages", uids, rows.Err())
+	}
+	if len(m.MethodsCalled["Search"]) != 2 {
+		t.Error("expected a search per page")
+	}
+
+	m.SearchErr = errors.New("fail")
+	if _, err := l.QueryRows(r); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestLdapRowsCloseAbandonsSearch(t *testing.T) {
+	m := newMockLdap()
+	m.SearchPages = []*ldap.SearchResult{
+		{Entries: []*ldap.Entry{{DN: "item1"}}, Controls: []ldap.Control{&ldap.ControlPaging{Cookie: []byte("next")}}},
+		{},
+	}
+	l := &ldapBackend{l: m, pageSize: 1}
+	r := ldap.NewSearchRequest("baseDn", ldap.ScopeSingleLevel, ldap.NeverDerefAliases, 0, 0, false, "filter", nil, nil)
+	rows, _ := l.QueryRows(r)
+	rows.Close()
+	queries := m.MethodsCalled["Search"]
+	if len(queries) != 2 {
+		t.Fatal("expected abandon search", len(queries))
+	}
+	last := queries[1].([]interface{})[0].(*ldap.SearchRequest)
+	if paging := ldap.FindControl(last.Controls, ldap.ControlTypePaging).(*ldap.ControlPaging); paging.PagingSize != 0 || string(paging.Cookie) != "next" {
+		t.Error("expected a zero page size with the cookie to abandon the search", paging)
+	}
+}
 
 /***************************** MOCKS ****************************/
 type mockLdapData struct {
@@ -227,19 +406,22 @@ type mockLdapData struct {
 
 func newMockLDAPCreator(startTLSErr, bindErr error) ldapNewConnFunc {
 	return func(conn net.Conn, isTLS bool) ldapBackender {
-		return &mockLdapBackend{MethodsCalled: make(map[string][]interface{}), StartTLSErr: startTLSErr, BindErr: bindErr}
+		return &mockLdapBackend{MethodsCalled: make(map[string][]interface{}), IsTLS: isTLS, StartTLSErr: startTLSErr, BindErr: bindErr}
 	}
 }
 
 type mockLdapBackend struct {
 	MethodsCalled     map[string][]interface{}
+	IsTLS             bool
 	SearchReturn      *ldap.SearchResult
+	SearchPages       []*ldap.SearchResult
 	StartTLSErr       error
 	BindErr           error
 	SearchErr         error
 	AddErr            error
 	DelErr            error
 	ModifyErr         error
+	ModifyDNErr       error
 	PasswordModifyErr error
 }
 
@@ -247,8 +429,9 @@ func newMockLdap() *mockLdapBackend {
 	return &mockLdapBackend{MethodsCalled: make(map[string][]interface{})}
 }
 
-func (l *mockLdapBackend) Close() {
+func (l *mockLdapBackend) Close() error {
 	l.methodCalled("Close", nil)
+	return nil
 }
 func (l *mockLdapBackend) Start() {
 	l.methodCalled("Start", nil)
@@ -270,6 +453,11 @@ func (l *mockLdapBackend) SimpleBind(r *ldap.SimpleBindRequest) (*ldap.SimpleBin
 
 func (l *mockLdapBackend) Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error) {
 	l.methodCalled("Search", searchRequest)
+	if len(l.SearchPages) > 0 && l.SearchErr == nil {
+		page := l.SearchPages[0]
+		l.SearchPages = l.SearchPages[1:]
+		return page, nil
+	}
 	return l.SearchReturn, l.SearchErr
 }
 
@@ -285,6 +473,10 @@ func (l *mockLdapBackend) Modify(modifyRequest *ldap.ModifyRequest) error {
 	l.methodCalled("Modify", modifyRequest)
 	return l.ModifyErr
 }
+func (l *mockLdapBackend) ModifyDN(modifyDNRequest *ldap.ModifyDNRequest) error {
+	l.methodCalled("ModifyDN", modifyDNRequest)
+	return l.ModifyDNErr
+}
 func (l *mockLdapBackend) PasswordModify(passwordModifyRequest *ldap.PasswordModifyRequest) (*ldap.PasswordModifyResult, error) {
 	l.methodCalled("PasswordModify", passwordModifyRequest)
 	return nil, l.PasswordModifyErr
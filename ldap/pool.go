@@ -0,0 +1,267 @@
+package onedb
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+)
+
+// PoolConfig configures a pooled LDAPer
+type PoolConfig struct {
+	Config
+
+	// MaxConnections is the most connections open at once. Defaults to 5
+	MaxConnections int
+
+	// HealthCheckInterval is how long a connection can sit idle before it is checked with a search of the root
+	// DSE when it is next used. Defaults to 30 seconds
+	HealthCheckInterval time.Duration
+
+	// RetryPolicy decides how requests which fail because the connection or server is gone are retried on a new
+	// connection. Defaults to onedb.DefaultRetryPolicy
+	RetryPolicy onedb.RetryPolicy
+}
+
+var errPoolClosed = errors.New("ldap: pool is closed")
+
+// ldapPool is an ldapBackender which runs each request on a connection from a pool. New connections are bound
+// with the configured BindDN, so connections replacing ones lost when a directory server fails over are rebound
+// before they are used
+type ldapPool struct {
+	config *PoolConfig
+	slots  chan struct{}
+
+	mu     sync.Mutex
+	idle   []*pooledConn
+	closed bool
+}
+
+type pooledConn struct {
+	l        ldapBackender
+	lastUsed time.Time
+	broken   bool // set when the connection can't be reused
+	pinned   bool // set when the connection is kept for the rest of a paged search
+}
+
+// NewLDAPPool returns an LDAPer backed by a pool of connections. One connection is opened and bound right away so
+// configuration errors are returned here
+func NewLDAPPool(config *PoolConfig) (LDAPer, error) {
+	p := newLdapPool(config)
+	c, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	p.release(c)
+	return &ldapBackend{l: p, pageSize: DefaultPageSize}, nil
+}
+
+func newLdapPool(config *PoolConfig) *ldapPool {
+	max := config.MaxConnections
+	if max <= 0 {
+		max = 5
+	}
+	return &ldapPool{config: config, slots: make(chan struct{}, max)}
+}
+
+// acquire returns an idle connection, checking its health first if it has been idle too long, or opens and
+// binds a new one. It blocks while MaxConnections are in use
+func (p *ldapPool) acquire() (*pooledConn, error) {
+	p.slots <- struct{}{}
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			<-p.slots
+			return nil, errPoolClosed
+		}
+		var c *pooledConn
+		if n := len(p.idle); n > 0 {
+			c, p.idle = p.idle[n-1], p.idle[:n-1]
+		}
+		p.mu.Unlock()
+
+		if c == nil {
+			l, err := ldapConnect(&p.config.Config)
+			if err != nil {
+				<-p.slots
+				return nil, err
+			}
+			return &pooledConn{l: l}, nil
+		}
+		if time.Since(c.lastUsed) <= p.healthCheckInterval() || p.healthy(c.l) {
+			return c, nil
+		}
+		c.l.Close()
+	}
+}
+
+// release returns c to the pool, or closes it if it is broken. A dead connection usually means the server went
+// away, so the idle connections are closed along with it
+func (p *ldapPool) release(c *pooledConn) {
+	defer func() { <-p.slots }()
+	p.mu.Lock()
+	if c.broken || p.closed {
+		var idle []*pooledConn
+		if c.broken {
+			idle, p.idle = p.idle, nil
+		}
+		p.mu.Unlock()
+		c.l.Close()
+		for _, i := range idle {
+			i.l.Close()
+		}
+		return
+	}
+	c.lastUsed = time.Now()
+	p.idle = append(p.idle, c)
+	p.mu.Unlock()
+}
+
+func (p *ldapPool) healthCheckInterval() time.Duration {
+	if p.config.HealthCheckInterval > 0 {
+		return p.config.HealthCheckInterval
+	}
+	return 30 * time.Second
+}
+
+// healthy reads the root DSE, which every server allows without returning any attributes
+func (p *ldapPool) healthy(l ldapBackender) bool {
+	_, err := l.Search(ldap.NewSearchRequest("", ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 5, false, "(objectClass=*)", []string{"1.1"}, nil))
+	return err == nil
+}
+
+// do runs fn on a pooled connection. While it fails because the connection or server is gone, it is run again on
+// another connection as allowed by the retry policy
+func (p *ldapPool) do(fn func(c *pooledConn) error) error {
+	policy := p.config.RetryPolicy
+	if policy == nil {
+		policy = onedb.DefaultRetryPolicy
+	}
+	return onedb.Retry(context.Background(), policy, isDeadLdapConn, func() error { return p.try(fn) })
+}
+
+func (p *ldapPool) try(fn func(c *pooledConn) error) error {
+	c, err := p.acquire()
+	if err != nil {
+		return err
+	}
+	err = fn(c)
+	if isDeadLdapConn(err) {
+		c.broken = true
+	}
+	if !c.pinned || err != nil {
+		p.release(c)
+	}
+	return err
+}
+
+// isDeadLdapConn matches errors which mean the connection can't be used any more, including failing to connect
+// to the server
+func isDeadLdapConn(err error) bool {
+	if err == nil {
+		return false
+	}
+	_, isNetErr := err.(net.Error)
+	return ldap.IsErrorWithCode(err, ldap.ErrorNetwork) || isNetErr ||
+		onedb.IsEOF(err) || onedb.IsBrokenPipe(err) || strings.HasSuffix(err.Error(), "ldap: connection closed")
+}
+
+// pin runs the first page of a paged search and keeps its connection out of the pool until unpin is called
+func (p *ldapPool) pin(query *ldap.SearchRequest) (*ldap.SearchResult, ldapBackender, func(err error), error) {
+	var res *ldap.SearchResult
+	var pinned *pooledConn
+	err := p.do(func(c *pooledConn) error {
+		var err error
+		res, err = c.l.Search(query)
+		c.pinned, pinned = true, c
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	unpin := func(err error) {
+		pinned.broken = isDeadLdapConn(err)
+		p.release(pinned)
+	}
+	return res, pinned.l, unpin, nil
+}
+
+func (p *ldapPool) Start() {}
+
+// StartTLS isn't supported on a pool. Set TLS in the PoolConfig instead
+func (p *ldapPool) StartTLS(config *tls.Config) error {
+	return errors.New("ldap: StartTLS isn't supported on a pool. Set TLS in the PoolConfig instead")
+}
+
+// Bind checks username and password, for example to authenticate a user, and then binds the connection with the
+// pool's BindDN again before it is reused. A connection which can't be rebound is closed
+func (p *ldapPool) Bind(username, password string) error {
+	return p.do(func(c *pooledConn) error {
+		err := c.l.Bind(username, password)
+		if isDeadLdapConn(err) {
+			return err
+		}
+		if rebindErr := c.l.Bind(p.config.BindDN, p.config.Password); rebindErr != nil {
+			c.broken = true
+			if err == nil {
+				err = errors.Wrap(rebindErr, "Unable to rebind")
+			}
+		}
+		return err
+	})
+}
+
+// Close closes the idle connections. Connections in use are closed when they are released
+func (p *ldapPool) Close() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle, p.closed = nil, true
+	p.mu.Unlock()
+	for _, c := range idle {
+		c.l.Close()
+	}
+	return nil
+}
+
+func (p *ldapPool) Add(addRequest *ldap.AddRequest) error {
+	return p.do(func(c *pooledConn) error { return c.l.Add(addRequest) })
+}
+
+func (p *ldapPool) Del(delRequest *ldap.DelRequest) error {
+	return p.do(func(c *pooledConn) error { return c.l.Del(delRequest) })
+}
+
+func (p *ldapPool) Modify(modifyRequest *ldap.ModifyRequest) error {
+	return p.do(func(c *pooledConn) error { return c.l.Modify(modifyRequest) })
+}
+
+func (p *ldapPool) ModifyDN(modifyDNRequest *ldap.ModifyDNRequest) error {
+	return p.do(func(c *pooledConn) error { return c.l.ModifyDN(modifyDNRequest) })
+}
+
+func (p *ldapPool) PasswordModify(passwordModifyRequest *ldap.PasswordModifyRequest) (*ldap.PasswordModifyResult, error) {
+	var res *ldap.PasswordModifyResult
+	err := p.do(func(c *pooledConn) error {
+		var err error
+		res, err = c.l.PasswordModify(passwordModifyRequest)
+		return err
+	})
+	return res, err
+}
+
+func (p *ldapPool) Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	var res *ldap.SearchResult
+	err := p.do(func(c *pooledConn) error {
+		var err error
+		res, err = c.l.Search(searchRequest)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,127 @@
+package onedb
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestNewLdapPool(t *testing.T) {
+	conns := mockPoolConns()
+	l, err := NewLDAPPool(&PoolConfig{Config: Config{Hostname: "localhost", BindDN: "cn=admin", Password: "secret"}})
+	if err != nil || len(*conns) != 1 {
+		t.Fatal("expected a connection to be opened", err)
+	}
+	l.QueryRows(ldap.NewSearchRequest("dc=example,dc=com", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(uid=*)", nil, nil))
+	l.Execute(ldap.NewDelRequest("uid=rob,dc=example,dc=com", nil))
+	if len(*conns) != 1 || len((*conns)[0].MethodsCalled["Search"]) != 1 || len((*conns)[0].MethodsCalled["Del"]) != 1 {
+		t.Error("expected the connection to be reused")
+	}
+
+	newConnFunc = newMockLDAPCreator(nil, errors.New("fail"))
+	if _, err := NewLDAPPool(&PoolConfig{Config: Config{Hostname: "localhost"}}); err == nil {
+		t.Error("expected bind error")
+	}
+}
+
+func TestLdapPoolReconnect(t *testing.T) {
+	conns := mockPoolConns()
+	p := newLdapPool(&PoolConfig{Config: Config{Hostname: "localhost", BindDN: "cn=admin", Password: "secret"}, RetryPolicy: noWaitRetry})
+	p.release(mustAcquire(t, p))
+	p.release(mustAcquire(t, p))
+	(*conns)[0].SearchErr = ldap.NewError(ldap.ErrorNetwork, errors.New("ldap: connection closed"))
+
+	if _, err := p.Search(ldap.NewSearchRequest("dc=example,dc=com", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(uid=*)", nil, nil)); err != nil {
+		t.Fatal("expected search to be retried on a new connection", err)
+	}
+	if len(*conns) != 2 || len((*conns)[0].MethodsCalled["Close"]) != 1 {
+		t.Fatal("expected dead connection to be closed and replaced", len(*conns))
+	}
+	bind := (*conns)[1].MethodsCalled["Bind"]
+	if len(bind) != 1 || bind[0].([]interface{})[0] != "cn=admin" || len((*conns)[1].MethodsCalled["Search"]) != 1 {
+		t.Error("expected the new connection to be bound before the search", bind)
+	}
+}
+
+func TestLdapPoolHealthCheck(t *testing.T) {
+	conns := mockPoolConns()
+	p := newLdapPool(&PoolConfig{Config: Config{Hostname: "localhost"}, HealthCheckInterval: time.Minute})
+	c := mustAcquire(t, p)
+	p.release(c)
+	c.lastUsed = time.Now().Add(-2 * time.Minute)
+	(*conns)[0].SearchErr = errors.New("fail")
+
+	c = mustAcquire(t, p)
+	if c.l != (*conns)[1] || len((*conns)[0].MethodsCalled["Search"]) != 1 || len((*conns)[0].MethodsCalled["Close"]) != 1 {
+		t.Error("expected unhealthy idle connection to be replaced", len(*conns))
+	}
+	p.release(c)
+	if c := mustAcquire(t, p); c.l != (*conns)[1] || len((*conns)[1].MethodsCalled["Search"]) != 0 {
+		t.Error("expected recently used connection to be reused without a health check")
+	}
+}
+
+func TestLdapPoolBind(t *testing.T) {
+	conns := mockPoolConns()
+	p := newLdapPool(&PoolConfig{Config: Config{Hostname: "localhost", BindDN: "cn=admin", Password: "secret"}})
+	if err := p.Bind("uid=rob,dc=example,dc=com", "password"); err != nil {
+		t.Fatal("expected success", err)
+	}
+	bind := (*conns)[0].MethodsCalled["Bind"]
+	if len(bind) != 3 || bind[1].([]interface{})[0] != "uid=rob,dc=example,dc=com" || bind[2].([]interface{})[0] != "cn=admin" {
+		t.Error("expected user bind followed by rebind with the pool's credentials", bind)
+	}
+}
+
+func TestLdapPoolPagedSearch(t *testing.T) {
+	conns := mockPoolConns()
+	l, _ := NewLDAPPool(&PoolConfig{Config: Config{Hostname: "localhost"}})
+	(*conns)[0].SearchPages = []*ldap.SearchResult{
+		{Entries: []*ldap.Entry{{DN: "item1"}}, Controls: []ldap.Control{&ldap.ControlPaging{Cookie: []byte("next")}}},
+		{Entries: []*ldap.Entry{{DN: "item2"}}},
+	}
+	rows, err := l.QueryRows(ldap.NewSearchRequest("dc=example,dc=com", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(uid=*)", nil, nil))
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	p := l.(*ldapBackend).l.(*ldapPool)
+	if len(p.idle) != 0 {
+		t.Error("expected the connection to be kept for the rest of the search")
+	}
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if count != 2 || len((*conns)[0].MethodsCalled["Search"]) != 2 || len(p.idle) != 1 {
+		t.Error("expected both pages from the same connection and the connection returned", count, len(p.idle))
+	}
+}
+
+/***************************** MOCKS ****************************/
+var noWaitRetry = onedb.RetryPolicyFunc(func(attempt int, elapsed time.Duration) (time.Duration, bool) {
+	return 0, attempt <= 2
+})
+
+// mockPoolConns makes new connections mocks and returns the list of those created
+func mockPoolConns() *[]*mockLdapBackend {
+	conns := &[]*mockLdapBackend{}
+	dialTCPFunc = onedb.NewMockDialer(nil)
+	newConnFunc = func(conn net.Conn, isTLS bool) ldapBackender {
+		m := newMockLdap()
+		*conns = append(*conns, m)
+		return m
+	}
+	return conns
+}
+
+func mustAcquire(t *testing.T, p *ldapPool) *pooledConn {
+	c, err := p.acquire()
+	if err != nil {
+		t.Fatal("expected acquire success", err)
+	}
+	return c
+}
@@ -226,7 +226,7 @@ type Collectioner interface {
 	Indexes() (indexes []mgo.Index, err error)
 	Insert(docs ...interface{}) error
 	NewIter(session *mgo.Session, firstBatch []bson.Raw, cursorId int64, err error) Iterator
-	Pipe(pipeline interface{}) *mgo.Pipe
+	Pipe(pipeline interface{}) Piper // returned *mgo.Pipe before Piper was added, see MgoPipe
 	Remove(selector interface{}) error
 	RemoveId(id interface{}) error
 	RemoveAll(selector interface{}) (info *mgo.ChangeInfo, err error)
@@ -305,8 +305,8 @@ func (c *mcollection) Indexes() (indexes []mgo.Index, err error) {
 func (c *mcollection) NewIter(session *mgo.Session, firstBatch []bson.Raw, cursorId int64, err error) Iterator {
 	return c.c.NewIter(session, firstBatch, cursorId, err)
 }
-func (c *mcollection) Pipe(pipeline interface{}) *mgo.Pipe {
-	return c.c.Pipe(pipeline)
+func (c *mcollection) Pipe(pipeline interface{}) Piper {
+	return &mpipe{c.c.Pipe(pipeline)}
 }
 func (c *mcollection) Repair() Iterator {
 	return c.c.Repair()
@@ -441,3 +441,37 @@ type Piper interface {
 	One(result interface{}) error
 	Explain(result interface{}) error
 }
+
+type mpipe struct {
+	p *mgo.Pipe
+}
+
+// MgoPipe returns the *mgo.Pipe behind a Piper returned by Collectioner.Pipe, for code written when Pipe returned
+// *mgo.Pipe or which needs a method Piper lacks, such as SetMaxTime. It reports false for a fake Piper
+func MgoPipe(p Piper) (*mgo.Pipe, bool) {
+	if pipe, ok := p.(*mpipe); ok {
+		return pipe.p, true
+	}
+	return nil, false
+}
+
+func (p *mpipe) AllowDiskUse() Piper {
+	p.p = p.p.AllowDiskUse()
+	return p
+}
+func (p *mpipe) Batch(n int) Piper {
+	p.p = p.p.Batch(n)
+	return p
+}
+func (p *mpipe) Iter() Iterator {
+	return p.p.Iter()
+}
+func (p *mpipe) All(result interface{}) error {
+	return p.p.All(result)
+}
+func (p *mpipe) One(result interface{}) error {
+	return p.p.One(result)
+}
+func (p *mpipe) Explain(result interface{}) error {
+	return p.p.Explain(result)
+}
@@ -248,9 +248,9 @@ func (c *fakeCollection) NewIter(session *mgo.Session, firstBatch []bson.Raw, cu
 	c.methodsCalled = append(c.methodsCalled, *NewMethodCall("NewIter", session, firstBatch, cursorId, err))
 	return nil
 }
-func (c *fakeCollection) Pipe(pipeline interface{}) *mgo.Pipe {
+func (c *fakeCollection) Pipe(pipeline interface{}) Piper {
 	c.methodsCalled = append(c.methodsCalled, *NewMethodCall("Pipe", pipeline))
-	return nil
+	return &fakePipe{c.find(pipeline).(*fakeQuery)}
 }
 func (c *fakeCollection) Repair() Iterator {
 	c.methodsCalled = append(c.methodsCalled, *NewMethodCall("Repair"))
@@ -285,7 +285,7 @@ func (q *fakeQuery) Distinct(key string, result interface{}) error {
 }
 func (q *fakeQuery) Explain(result interface{}) error { return q.One(result) }
 func (q *fakeQuery) Hint(indexKey ...string) Querier  { return q }
-func (q *fakeQuery) Iter() Iterator                   { return nil }
+func (q *fakeQuery) Iter() Iterator                   { return newFakeIter(q.r) }
 func (q *fakeQuery) Limit(n int) Querier              { return q }
 func (q *fakeQuery) LogReplay() Querier               { return q }
 func (q *fakeQuery) MapReduce(job *mgo.MapReduce, result interface{}) (info *mgo.MapReduceInfo, err error) {
@@ -301,6 +301,67 @@ func (q *fakeQuery) Sort(fields ...string) Querier       { return q }
 func (q *fakeQuery) Skip(n int) Querier                  { return q }
 func (q *fakeQuery) Tail(timeout time.Duration) Iterator { return nil }
 
+// fakePipe returns the results of the FakeMongoQuery whose Query is the pipeline
+type fakePipe struct {
+	q *fakeQuery
+}
+
+func (p *fakePipe) AllowDiskUse() Piper              { return p }
+func (p *fakePipe) Batch(n int) Piper                { return p }
+func (p *fakePipe) Iter() Iterator                   { return p.q.Iter() }
+func (p *fakePipe) All(result interface{}) error     { return p.q.All(result) }
+func (p *fakePipe) One(result interface{}) error     { return p.q.One(result) }
+func (p *fakePipe) Explain(result interface{}) error { return p.q.Explain(result) }
+
+// fakeIter iterates over the elements of a FakeMongoQuery's Return slice. Each element is round tripped through
+// bson so it can be read into any type, just like a document from the server
+type fakeIter struct {
+	docs []interface{}
+	err  error
+}
+
+func newFakeIter(r interface{}) *fakeIter {
+	it := &fakeIter{}
+	if r == nil {
+		return it
+	}
+	v := reflect.ValueOf(r)
+	if v.Kind() != reflect.Slice {
+		it.docs = []interface{}{r}
+		return it
+	}
+	for i := 0; i < v.Len(); i++ {
+		it.docs = append(it.docs, v.Index(i).Interface())
+	}
+	return it
+}
+
+func (it *fakeIter) Err() error   { return it.err }
+func (it *fakeIter) Done() bool   { return len(it.docs) == 0 }
+func (it *fakeIter) Close() error { return it.err }
+func (it *fakeIter) Next(result interface{}) bool {
+	if it.err != nil || len(it.docs) == 0 {
+		return false
+	}
+	data, err := bson.Marshal(it.docs[0])
+	if err == nil {
+		err = bson.Unmarshal(data, result)
+	}
+	it.docs = it.docs[1:]
+	it.err = err
+	return err == nil
+}
+func (it *fakeIter) All(result interface{}) error {
+	slice := reflect.ValueOf(result).Elem()
+	for {
+		item := reflect.New(slice.Type().Elem())
+		if !it.Next(item.Interface()) {
+			return it.err
+		}
+		slice.Set(reflect.Append(slice, item.Elem()))
+	}
+}
+
 var errNilPtr = errors.New("destination pointer is nil") // embedded in descriptive error
 
 // convertAssign copies to dest the value in src, converting it if possible.
@@ -0,0 +1,184 @@
+package mgo
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/EndFirstCorp/onedb"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Mgoer runs finds and aggregation pipelines against the collections of a database, returning documents through
+// the same RowsScanner, QueryStruct and QueryJSON path used by the SQL backends. The query is the collection name
+// and the first argument is either a find selector (e.g. bson.M) or an aggregation pipeline (a slice of stages,
+// e.g. []bson.M)
+type Mgoer interface {
+	Query(collection string, args ...interface{}) (onedb.RowsScanner, error)
+	QueryRow(collection string, args ...interface{}) onedb.Scanner
+	onedb.DBer
+}
+
+type mgoBackend struct {
+	db Databaser
+}
+
+// NewMgo returns a Mgoer for db. Use NewFakeSession's databases in tests
+func NewMgo(db Databaser) Mgoer {
+	return &mgoBackend{db: db}
+}
+
+func (b *mgoBackend) Query(collection string, args ...interface{}) (onedb.RowsScanner, error) {
+	var query interface{}
+	if len(args) > 0 {
+		query = args[0]
+	}
+	c := b.db.C(collection)
+	if isPipeline(query) {
+		return NewRows(c.Pipe(query).Iter()), nil
+	}
+	return NewRows(c.Find(query).Iter()), nil
+}
+
+func (b *mgoBackend) QueryRow(collection string, args ...interface{}) onedb.Scanner {
+	rows, err := b.Query(collection, args...)
+	if err != nil {
+		return &errRow{err}
+	}
+	return &docRow{rows.(*docRows)}
+}
+
+func (b *mgoBackend) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValues(b, query, result...)
+}
+
+func (b *mgoBackend) QueryJSON(collection string, args ...interface{}) (string, error) {
+	return onedb.QueryJSON(b, collection, args...)
+}
+
+func (b *mgoBackend) QueryJSONRow(collection string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRow(b, collection, args...)
+}
+
+func (b *mgoBackend) QueryJSONWriter(w io.Writer, collection string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, b, collection, args...)
+}
+
+func (b *mgoBackend) QueryStruct(result interface{}, collection string, args ...interface{}) error {
+	return onedb.QueryStruct(b, result, collection, args...)
+}
+
+func (b *mgoBackend) QueryStructRow(result interface{}, collection string, args ...interface{}) error {
+	return onedb.QueryStructRow(b, result, collection, args...)
+}
+
+func (b *mgoBackend) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, collection string, args ...interface{}) error {
+	return onedb.QueryWriteCSV(w, options, b, collection, args...)
+}
+
+// isPipeline reports whether query is a list of aggregation stages rather than a find selector. bson.D is a
+// slice too, but it is an ordered document
+func isPipeline(query interface{}) bool {
+	if _, ok := query.(bson.D); ok {
+		return false
+	}
+	if query == nil {
+		return false
+	}
+	kind := reflect.TypeOf(query).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// NewRows returns the documents read by iter as rows. The columns are the fields of the first document, in
+// order; fields missing from later documents are nil and fields only found in later documents are skipped
+func NewRows(iter Iterator) onedb.RowsScanner {
+	return &docRows{iter: iter}
+}
+
+type docRows struct {
+	iter    Iterator
+	columns []string
+	current bson.D
+	pending bson.D
+	peeked  bool
+}
+
+// peek reads the first document so Columns can be called before Next
+func (r *docRows) peek() {
+	if r.peeked {
+		return
+	}
+	r.peeked = true
+	var doc bson.D
+	if r.iter.Next(&doc) {
+		r.pending = doc
+		r.columns = make([]string, len(doc))
+		for i, elem := range doc {
+			r.columns[i] = elem.Name
+		}
+	}
+}
+
+// Columns returns a copy of the column names since callers may modify them
+func (r *docRows) Columns() ([]string, error) {
+	r.peek()
+	return append([]string(nil), r.columns...), nil
+}
+
+func (r *docRows) Next() bool {
+	r.peek()
+	if r.pending != nil {
+		r.current, r.pending = r.pending, nil
+		return true
+	}
+	var doc bson.D
+	if !r.iter.Next(&doc) {
+		return false
+	}
+	r.current = doc
+	return true
+}
+
+func (r *docRows) Scan(dest ...interface{}) error {
+	values := r.current.Map()
+	for i, d := range dest {
+		if i >= len(r.columns) {
+			break
+		}
+		value := values[r.columns[i]]
+		if err := convertAssign(d, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *docRows) Err() error {
+	return r.iter.Err()
+}
+
+func (r *docRows) Close() error {
+	return r.iter.Close()
+}
+
+type docRow struct {
+	rows *docRows
+}
+
+func (r *docRow) Scan(dest ...interface{}) error {
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	return r.rows.Scan(dest...)
+}
+
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
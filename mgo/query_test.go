@@ -0,0 +1,80 @@
+package mgo
+
+import (
+	"testing"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type order struct {
+	Customer string
+	Total    int
+}
+
+func TestQueryStructPipeline(t *testing.T) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"status": "paid"}},
+		{"$group": bson.M{"_id": "$customer", "total": bson.M{"$sum": "$amount"}}},
+		{"$project": bson.M{"_id": 0, "customer": "$_id", "total": 1}},
+	}
+	s := NewFakeSession([]FakeMongoQuery{{DB: "shop", Collection: "orders", Query: pipeline,
+		Return: []bson.D{{{Name: "customer", Value: "alice"}, {Name: "total", Value: 30}}, {{Name: "customer", Value: "bob"}, {Name: "total", Value: 12}}}}})
+	db := NewMgo(s.DB("shop"))
+
+	var orders []order
+	if err := db.QueryStruct(&orders, "orders", pipeline); err != nil {
+		t.Fatal("expected success", err)
+	}
+	if len(orders) != 2 || orders[0].Customer != "alice" || orders[0].Total != 30 || orders[1].Customer != "bob" {
+		t.Error("expected aggregation results", orders)
+	}
+	calls := s.DB("shop").C("orders").MethodCalls()
+	if len(calls) != 1 || calls[0].Name != "Pipe" {
+		t.Error("expected the pipeline to be run with Pipe", calls)
+	}
+}
+
+func TestMgoPipe(t *testing.T) {
+	pipe := &mgo.Pipe{}
+	if p, ok := MgoPipe(&mpipe{pipe}); !ok || p != pipe {
+		t.Error("expected the *mgo.Pipe behind the Piper", p, ok)
+	}
+	fake := NewFakeSession([]FakeMongoQuery{{DB: "shop", Collection: "orders"}}).DB("shop").C("orders").Pipe(nil)
+	if _, ok := MgoPipe(fake); ok {
+		t.Error("expected no *mgo.Pipe behind a fake")
+	}
+}
+
+func TestQueryJSONFind(t *testing.T) {
+	selector := bson.M{"customer": "alice"}
+	s := NewFakeSession([]FakeMongoQuery{{DB: "shop", Collection: "orders", Query: selector,
+		Return: []bson.D{{{Name: "customer", Value: "alice"}, {Name: "total", Value: 30}}}}})
+	db := NewMgo(s.DB("shop"))
+
+	json, err := db.QueryJSON("orders", selector)
+	if err != nil || json != `[{"customer":"alice","total":30}]` {
+		t.Error("expected find results as json", json, err)
+	}
+	calls := s.DB("shop").C("orders").MethodCalls()
+	if len(calls) != 1 || calls[0].Name != "Find" {
+		t.Error("expected the selector to be run with Find", calls)
+	}
+}
+
+func TestQueryStructRow(t *testing.T) {
+	selector := bson.D{{Name: "customer", Value: "bob"}}
+	s := NewFakeSession([]FakeMongoQuery{{DB: "shop", Collection: "orders", Query: selector,
+		Return: []bson.M{{"customer": "bob", "total": 12}}}})
+	db := NewMgo(s.DB("shop"))
+
+	var o order
+	if err := db.QueryStructRow(&o, "orders", selector); err != nil || o.Customer != "bob" || o.Total != 12 {
+		t.Error("expected a single document", o, err)
+	}
+
+	var total int
+	if err := db.QueryRow("orders", bson.M{"customer": "nobody"}).Scan(&total); err != ErrNotFound {
+		t.Error("expected ErrNotFound", err)
+	}
+}
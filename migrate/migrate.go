@@ -0,0 +1,225 @@
+// Package migrate applies ordered SQL migrations to a PostgreSQL database and records which have been applied in a
+// schema_migrations table.
+//
+// Migrations are files named like 0001_create_users.up.sql, with an optional matching 0001_create_users.down.sql
+// to undo them. They are usually embedded in the binary:
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	m, err := migrate.New(migrations, "migrations")
+//	...
+//	err = onedb.WithTx[pgx.Txer](db, func(tx pgx.Txer) error {
+//		_, err := m.Up(tx)
+//		return err
+//	})
+//
+// Migrations are run through onedb.Backender, which must be a transaction. Running them in a single transaction
+// means a failed migration leaves no partial changes behind, and lets a transaction-level advisory lock stop
+// concurrent runners, since the lock is held until the transaction ends
+package migrate
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+// DefaultLockID is the advisory lock key used unless Migrator.LockID is set
+const DefaultLockID int64 = 8203491467713066357
+
+// Migration is a single versioned change to the schema
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string // empty when the migration can't be undone
+}
+
+// Migrator applies migrations in version order
+type Migrator struct {
+	migrations []Migration
+
+	// Table records the applied versions. The default is schema_migrations
+	Table string
+	// LockID is the key of the advisory lock taken while migrating. The default is DefaultLockID
+	LockID int64
+}
+
+// New reads the migrations in dir of fsys. Each file's name must start with its version number followed by an
+// underscore and end with .up.sql or .down.sql. Other files are ignored
+func New(fsys fs.FS, dir string) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read migrations")
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var up bool
+		switch {
+		case entry.IsDir():
+			continue
+		case strings.HasSuffix(name, ".up.sql"):
+			up = true
+		case !strings.HasSuffix(name, ".down.sql"):
+			continue
+		}
+		version, description, err := parseFileName(name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unable to read migration %s", name)
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: description}
+			byVersion[version] = m
+		} else if m.Name != description {
+			return nil, errors.Errorf("migration %d is named both %s and %s", version, m.Name, description)
+		}
+		if up {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, errors.Errorf("migration %d has no .up.sql file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return &Migrator{migrations: migrations}, nil
+}
+
+func parseFileName(name string) (int64, string, error) {
+	base := strings.TrimSuffix(strings.TrimSuffix(name, ".up.sql"), ".down.sql")
+	number, description, _ := strings.Cut(base, "_")
+	version, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", errors.Errorf("migration %s must start with a version number greater than zero", name)
+	}
+	return version, description, nil
+}
+
+// Migrations returns the migrations in version order
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up applies every migration which hasn't been applied yet and returns how many were
+func (m *Migrator) Up(tx onedb.Backender) (int, error) {
+	return m.UpTo(tx, 0)
+}
+
+// UpTo applies the migrations which haven't been applied yet up to and including version, or all of them when
+// version is 0, and returns how many were
+func (m *Migrator) UpTo(tx onedb.Backender, version int64) (int, error) {
+	applied, err := m.prepare(tx)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, migration := range m.migrations {
+		if version != 0 && migration.Version > version {
+			break
+		}
+		if applied[migration.Version] {
+			continue
+		}
+		if err := onedb.Exec(tx, migration.Up); err != nil {
+			return count, errors.Wrapf(err, "Unable to apply migration %d_%s", migration.Version, migration.Name)
+		}
+		if err := onedb.Exec(tx, "insert into "+m.table()+" (version) values ($1)", migration.Version); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Down undoes the last steps applied migrations, newest first, and returns how many were undone
+func (m *Migrator) Down(tx onedb.Backender, steps int) (int, error) {
+	applied, err := m.prepare(tx)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.migrations[i]
+		if !applied[migration.Version] {
+			continue
+		}
+		if migration.Down == "" {
+			return count, errors.Errorf("migration %d_%s has no .down.sql file", migration.Version, migration.Name)
+		}
+		if err := onedb.Exec(tx, migration.Down); err != nil {
+			return count, errors.Wrapf(err, "Unable to undo migration %d_%s", migration.Version, migration.Name)
+		}
+		if err := onedb.Exec(tx, "delete from "+m.table()+" where version = $1", migration.Version); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Applied returns the versions which have been applied, in order
+func (m *Migrator) Applied(tx onedb.Backender) ([]int64, error) {
+	if err := onedb.Exec(tx, "create table if not exists "+m.table()+" (version bigint primary key, applied_at timestamptz not null default now())"); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query("select version from " + m.table() + " order by version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var versions []int64
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// prepare takes the advisory lock, creates the table if needed and returns the applied versions
+func (m *Migrator) prepare(tx onedb.Backender) (map[int64]bool, error) {
+	lockID := m.LockID
+	if lockID == 0 {
+		lockID = DefaultLockID
+	}
+	var locked bool
+	if err := tx.QueryRow("select true from pg_advisory_xact_lock($1)", lockID).Scan(&locked); err != nil {
+		return nil, errors.Wrap(err, "Unable to lock migrations")
+	}
+	versions, err := m.Applied(tx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+	return applied, nil
+}
+
+func (m *Migrator) table() string {
+	if m.Table == "" {
+		return "schema_migrations"
+	}
+	return m.Table
+}
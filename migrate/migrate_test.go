@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+type lockRow struct {
+	Locked bool
+}
+
+type versionRow struct {
+	Version int64
+}
+
+var migrations = fstest.MapFS{
+	"migrations/0001_create_users.up.sql":   {Data: []byte("create table users (id int)")},
+	"migrations/0001_create_users.down.sql": {Data: []byte("drop table users")},
+	"migrations/0002_add_name.up.sql":       {Data: []byte("alter table users add name text")},
+	"migrations/0010_add_email.up.sql":      {Data: []byte("alter table users add email text")},
+	"migrations/README.md":                  {Data: []byte("ignored")},
+}
+
+const createTable = "create table if not exists schema_migrations (version bigint primary key, applied_at timestamptz not null default now())"
+
+func expectPrepare(d onedb.Mocker, applied ...versionRow) {
+	d.ExpectQuery("select true from pg_advisory_xact_lock($1)").WithArgs(DefaultLockID).WillReturnRows([]lockRow{{true}})
+	d.ExpectQuery(createTable)
+	d.ExpectQuery("select version from schema_migrations order by version").WillReturnRows(applied)
+}
+
+func TestNew(t *testing.T) {
+	m, err := New(migrations, "migrations")
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	all := m.Migrations()
+	if len(all) != 3 || all[0].Name != "create_users" || all[0].Down != "drop table users" || all[1].Down != "" || all[2].Version != 10 {
+		t.Error("expected migrations in version order", all)
+	}
+
+	for _, fsys := range []fstest.MapFS{
+		{"m/create.up.sql": {}},
+		{"m/0001_a.down.sql": {}},
+		{"m/0001_a.up.sql": {}, "m/0001_b.down.sql": {}},
+	} {
+		if _, err := New(fsys, "m"); err == nil {
+			t.Error("expected error for invalid migrations", fsys)
+		}
+	}
+}
+
+func TestUp(t *testing.T) {
+	m, _ := New(migrations, "migrations")
+	d := onedb.NewMock(nil, nil)
+	expectPrepare(d, versionRow{1})
+	d.ExpectQuery("alter table users add name text")
+	d.ExpectQuery("insert into schema_migrations (version) values ($1)").WithArgs(int64(2))
+	if n, err := m.UpTo(d, 2); n != 1 || err != nil {
+		t.Error("expected only migration 2 to be applied", n, err)
+	}
+	d.VerifyExpectations(t)
+
+	m.Table, m.LockID = "migrations", 1
+	d = onedb.NewMock(nil, nil)
+	d.ExpectQuery("select true from pg_advisory_xact_lock($1)").WithArgs(int64(1)).WillReturnRows([]lockRow{{true}})
+	d.ExpectQuery("create table if not exists migrations (version bigint primary key, applied_at timestamptz not null default now())")
+	d.ExpectQuery("select version from migrations order by version").WillReturnRows([]versionRow{{1}, {2}})
+	d.ExpectQuery("alter table users add email text")
+	d.ExpectQuery("insert into migrations (version) values ($1)").WithArgs(int64(10))
+	if n, err := m.Up(d); n != 1 || err != nil {
+		t.Error("expected the remaining migration to be applied", n, err)
+	}
+	d.VerifyExpectations(t)
+}
+
+func TestDown(t *testing.T) {
+	m, _ := New(migrations, "migrations")
+	d := onedb.NewMock(nil, nil)
+	expectPrepare(d, versionRow{1})
+	d.ExpectQuery("drop table users")
+	d.ExpectQuery("delete from schema_migrations where version = $1").WithArgs(int64(1))
+	if n, err := m.Down(d, 1); n != 1 || err != nil {
+		t.Error("expected migration 1 to be undone", n, err)
+	}
+	d.VerifyExpectations(t)
+
+	d = onedb.NewMock(nil, nil)
+	expectPrepare(d, versionRow{1}, versionRow{2})
+	if _, err := m.Down(d, 2); err == nil {
+		t.Error("expected error for a migration without a down file")
+	}
+}
@@ -1,6 +1,7 @@
 package onedb
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +14,8 @@ type mockDb struct {
 	methodsRun []MethodsRun
 	closeErr   error
 	execErr    error
+	expectations
+	faults
 }
 
 // MethodsRun contains the name of the method run and a slice of arguments
@@ -26,15 +29,21 @@ type Mocker interface {
 	DBer
 	Query(query string, args ...interface{}) (RowsScanner, error)
 	QueryRow(query string, args ...interface{}) Scanner
+	ContextBackender
+	Pinger
 	QueriesRun() []MethodsRun
 	SaveMethodCall(name string, arguments []interface{})
 	VerifyNextCommand(t *testing.T, name string, expected ...interface{})
+	Expecter
+	Injector
+	MatchExec(query string, args ...interface{}) (expected bool, err error)
+	MatchExecContext(ctx context.Context, query string, args ...interface{}) (expected bool, err error)
+	Begin() (MockTxer, error)
 }
 
 // NewMock will create an instance that implements the Mocker interface
 func NewMock(closeErr, execErr error, data ...interface{}) Mocker {
-	queries := []MethodsRun{}
-	return &mockDb{data, queries, closeErr, execErr}
+	return &mockDb{data: data, methodsRun: []MethodsRun{}, closeErr: closeErr, execErr: execErr}
 }
 
 func (r *mockDb) SaveMethodCall(name string, arguments []interface{}) {
@@ -45,16 +54,43 @@ func (r *mockDb) Backend() interface{} {
 	return nil
 }
 
+func (r *mockDb) Ping() error {
+	return r.PingContext(context.Background())
+}
+
+// PingContext fails with the error or delay injected for "ping"
+func (r *mockDb) PingContext(ctx context.Context) error {
+	r.SaveMethodCall("Ping", []interface{}{})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.injectFault(ctx, "ping")
+}
+
 func (r *mockDb) Query(query string, args ...interface{}) (RowsScanner, error) {
 	r.SaveMethodCall("Query", append([]interface{}{query}, args...))
-	return r.nextScanner()
+	return r.nextScanner(context.Background(), query, args)
 }
 
 func (r *mockDb) QueryRow(query string, args ...interface{}) Scanner {
 	r.SaveMethodCall("QueryRow", append([]interface{}{query}, args...))
-	s, _ := r.nextScanner()
-	s.Next()
-	return s
+	return r.nextRowScanner(context.Background(), query, args)
+}
+
+func (r *mockDb) QueryContext(ctx context.Context, query string, args ...interface{}) (RowsScanner, error) {
+	r.SaveMethodCall("QueryContext", append([]interface{}{query}, args...))
+	if err := ctx.Err(); err != nil {
+		return &mockRowsScanner{ErrErr: err}, err
+	}
+	return r.nextScanner(ctx, query, args)
+}
+
+func (r *mockDb) QueryRowContext(ctx context.Context, query string, args ...interface{}) Scanner {
+	r.SaveMethodCall("QueryRowContext", append([]interface{}{query}, args...))
+	if err := ctx.Err(); err != nil {
+		return &errorScanner{err}
+	}
+	return r.nextRowScanner(ctx, query, args)
 }
 
 func (r *mockDb) QueryValues(query *Query, result ...interface{}) error {
@@ -72,6 +108,11 @@ func (r *mockDb) QueryJSONRow(query string, args ...interface{}) (string, error)
 	return QueryJSONRow(r, query, args...)
 }
 
+func (r *mockDb) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	r.SaveMethodCall("QueryJSONWriter", append([]interface{}{w, query}, args...))
+	return QueryJSONWriter(w, r, query, args...)
+}
+
 func (r *mockDb) QueryStruct(result interface{}, query string, args ...interface{}) error {
 	r.SaveMethodCall("QueryStruct", append([]interface{}{result, query}, args...))
 	return QueryStruct(r, result, query, args...)
@@ -94,6 +135,9 @@ func (r *mockDb) Close() error {
 
 func (r *mockDb) Execute(query string, args ...interface{}) error {
 	r.SaveMethodCall("Execute", append([]interface{}{query}, args...))
+	if expected, err := r.MatchExec(query, args...); expected {
+		return err
+	}
 	return r.execErr
 }
 
@@ -101,7 +145,21 @@ func (r *mockDb) QueriesRun() []MethodsRun {
 	return r.methodsRun
 }
 
-func (r *mockDb) nextScanner() (RowsScanner, error) {
+func (r *mockDb) nextScanner(ctx context.Context, query string, args []interface{}) (RowsScanner, error) {
+	return r.nextScannerTx(ctx, 0, query, args)
+}
+
+func (r *mockDb) nextScannerTx(ctx context.Context, tx int, query string, args []interface{}) (RowsScanner, error) {
+	if err := r.injectFault(ctx, query); err != nil {
+		return &mockRowsScanner{ErrErr: err}, err
+	}
+	return r.scannerTx(tx, query, args)
+}
+
+func (r *mockDb) scannerTx(tx int, query string, args []interface{}) (RowsScanner, error) {
+	if rows, expected, err := r.expectedRows(tx, query, args); expected {
+		return rows, err
+	}
 	if len(r.data) == 0 {
 		err := errors.New("no mock data found to return")
 		return &mockRowsScanner{ErrErr: err}, err
@@ -111,6 +169,22 @@ func (r *mockDb) nextScanner() (RowsScanner, error) {
 	return NewRowsScanner(data), nil
 }
 
+func (r *mockDb) nextRowScanner(ctx context.Context, query string, args []interface{}) Scanner {
+	return r.nextRowScannerTx(ctx, 0, query, args)
+}
+
+func (r *mockDb) nextRowScannerTx(ctx context.Context, tx int, query string, args []interface{}) Scanner {
+	if err := r.injectFault(ctx, query); err != nil {
+		return &errorScanner{err}
+	}
+	s, err := r.scannerTx(tx, query, args)
+	if err != nil && r.expectations.added() {
+		return &errorScanner{err}
+	}
+	s.Next()
+	return s
+}
+
 // ErrNoMethods is an error for when no methods are left to verify.
 var ErrNoMethods = errors.New("No methods found to have been run")
 
@@ -151,8 +225,11 @@ type mockRowsScanner struct {
 	ErrErr     error
 }
 
-// NewRowsScanner returns a RowsScanner that can scan through a slice of data
+// NewRowsScanner returns a RowsScanner that can scan through a slice of data, or through a *MockResult
 func NewRowsScanner(data interface{}) RowsScanner {
+	if result, ok := data.(*MockResult); ok {
+		return &mockResultScanner{result: result}
+	}
 	if data == nil || reflect.TypeOf(data).Kind() != reflect.Slice || reflect.TypeOf(data).Elem().Kind() != reflect.Struct {
 		return &mockRowsScanner{ScanErr: ErrRowsScannerInvalidData, ErrErr: ErrRowsScannerInvalidData}
 	}
@@ -171,11 +248,34 @@ func (r *mockRowsScanner) Columns() ([]string, error) {
 
 	columns := make([]string, r.structLen)
 	for i := 0; i < r.structLen; i++ {
-		columns[i] = r.structType.Field(i).Name
+		field := r.structType.Field(i)
+		if columns[i] = columnName(field); columns[i] == "" {
+			columns[i] = field.Name
+		}
 	}
 	return columns, nil
 }
 
+// ColumnTypes describes the columns by the fields of the struct the rows were made from. Fields which can be
+// nil are nullable
+func (r *mockRowsScanner) ColumnTypes() ([]ColumnType, error) {
+	columns, err := r.Columns()
+	if err != nil {
+		return nil, err
+	}
+	columnTypes := make([]ColumnType, len(columns))
+	for i, column := range columns {
+		t := r.structType.Field(i).Type
+		nullable := false
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			nullable = true
+		}
+		columnTypes[i] = ColumnType{Name: column, Nullable: nullable || reflect.PtrTo(t).Implements(scannerType), ScanType: t}
+	}
+	return columnTypes, nil
+}
+
 func (r *mockRowsScanner) Next() bool {
 	r.currentRow++
 	if r.currentRow >= r.sliceLen {
@@ -1,6 +1,7 @@
 package onedb
 
 import (
+	"context"
 	"errors"
 	"testing"
 )
@@ -91,6 +92,33 @@ func TestMockDBQueryStructRow(t *testing.T) {
 	}
 }
 
+func TestMockDBQueryContext(t *testing.T) {
+	d := NewMock(nil, nil, []SimpleData{{1, "hello"}}, []SimpleData{{2, "world"}})
+	rows, err := d.QueryContext(context.Background(), "select query", "arg1")
+	if err != nil || !rows.Next() {
+		t.Error("expected rows", err)
+	}
+
+	var intVal int
+	var stringVal string
+	err = d.QueryRowContext(context.Background(), "select query2").Scan(&intVal, &stringVal)
+	if err != nil || intVal != 2 || stringVal != "world" {
+		t.Error("expected valid data", intVal, stringVal, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := d.QueryContext(ctx, "select query"); err != context.Canceled {
+		t.Error("expected canceled error", err)
+	}
+	if err := d.QueryRowContext(ctx, "select query").Scan(); err != context.Canceled {
+		t.Error("expected canceled error", err)
+	}
+
+	d.VerifyNextCommand(t, "QueryContext", "select query", "arg1")
+	d.VerifyNextCommand(t, "QueryRowContext", "select query2")
+}
+
 func TestClose(t *testing.T) {
 	err := errors.New("fail")
 	d := &mockDb{closeErr: err}
@@ -99,6 +127,24 @@ func TestClose(t *testing.T) {
 	}
 }
 
+func TestMockPing(t *testing.T) {
+	d := NewMock(nil, nil)
+	if d.Ping() != nil {
+		t.Error("expected success")
+	}
+	fail := errors.New("fail")
+	d.InjectFault("ping").WillReturnError(fail).Times(1)
+	if err := d.PingContext(context.Background()); err != fail {
+		t.Error("expected injected error", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := d.PingContext(ctx); err != context.Canceled {
+		t.Error("expected context error", err)
+	}
+	d.VerifyNextCommand(t, "Ping")
+}
+
 func TestBackend(t *testing.T) {
 	d := &mockDb{}
 	if d.Backend() != nil {
@@ -126,3 +172,11 @@ type SimpleData struct {
 	IntVal    int
 	StringVal string
 }
+
+func TestMockRowsScannerColumnsTags(t *testing.T) {
+	rows := NewRowsScanner([]TaggedItem{{1, "first", "ignored", "last"}})
+	columns, err := rows.Columns()
+	if err != nil || len(columns) != 4 || columns[0] != "id" || columns[1] != "first" || columns[2] != "Ignored" || columns[3] != "LastName" {
+		t.Error("expected tagged column names", columns, err)
+	}
+}
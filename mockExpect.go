@@ -0,0 +1,372 @@
+package onedb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// Expecter sets up the queries and execs a test expects a mock to run, in the style of go-sqlmock. Once any
+// expectation has been added, every Query and Exec must match one and gets its rows or error back; without
+// expectations the mock returns its data in order as before
+type Expecter interface {
+	ExpectQuery(query string) *Expectation
+	ExpectExec(query string) *Expectation
+	ExpectBegin() *Expectation
+	ExpectCommit() *Expectation
+	ExpectRollback() *Expectation
+	MatchExpectationsInOrder(inOrder bool)
+	SetQueryMatcher(matcher QueryMatcher)
+	ExpectationsWereMet() error
+	VerifyExpectations(t *testing.T)
+}
+
+// QueryMatcher reports an error when the query actually run doesn't match the expected query
+type QueryMatcher func(expected, actual string) error
+
+// QueryMatcherEqual matches queries which are equal once runs of whitespace are collapsed. It is the default
+var QueryMatcherEqual QueryMatcher = func(expected, actual string) error {
+	if collapseWhitespace(expected) != collapseWhitespace(actual) {
+		return errors.Errorf("query %q doesn't equal %q", actual, expected)
+	}
+	return nil
+}
+
+// QueryMatcherRegexp treats the expected query as a regular expression which must match the query run
+var QueryMatcherRegexp QueryMatcher = func(expected, actual string) error {
+	re, err := regexp.Compile(expected)
+	if err != nil {
+		return err
+	}
+	if !re.MatchString(actual) {
+		return errors.Errorf("query %q doesn't match %q", actual, expected)
+	}
+	return nil
+}
+
+func describeCall(tx int, method, query string, args []interface{}) string {
+	s := method
+	if method == "Query" || method == "Exec" {
+		s += fmt.Sprintf(" %q with args %v", query, args)
+	}
+	if tx > 0 {
+		s += fmt.Sprintf(" in transaction %d", tx)
+	}
+	return s
+}
+
+func collapseWhitespace(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// Argument matches an argument a query is run with. Pass one to WithArgs in place of a value to match more
+// than one value
+type Argument interface {
+	Match(v interface{}) bool
+}
+
+// ArgumentFunc is an adapter to allow the use of an ordinary function as an Argument
+type ArgumentFunc func(v interface{}) bool
+
+// Match calls f(v)
+func (f ArgumentFunc) Match(v interface{}) bool {
+	return f(v)
+}
+
+type anyArgument struct{}
+
+func (anyArgument) Match(v interface{}) bool {
+	return true
+}
+
+func (anyArgument) String() string {
+	return "Any()"
+}
+
+// Any returns an Argument matching any value
+func Any() Argument {
+	return anyArgument{}
+}
+
+// Expectation is a query or exec a mock expects to be run and what it returns when it is
+type Expectation struct {
+	method    string
+	query     string
+	args      []interface{}
+	withArgs  bool
+	data      interface{}
+	err       error
+	triggered bool
+	anyTimes  bool
+	tx        int // the transaction, numbered by ExpectBegin, the query must run in. 0 is outside a transaction
+}
+
+// WithArgs sets the arguments the query must be run with. Without it any arguments match
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args, e.withArgs = args, true
+	return e
+}
+
+// AnyTimes lets the expectation be met any number of times, including none, and in any order. Add one for each
+// set of arguments to return different rows depending on the arguments a query is run with
+func (e *Expectation) AnyTimes() *Expectation {
+	e.anyTimes = true
+	return e
+}
+
+// WillReturnRows sets the rows returned by the query, as a slice of structs like NewRowsScanner takes
+func (e *Expectation) WillReturnRows(data interface{}) *Expectation {
+	e.data = data
+	return e
+}
+
+// WillReturnError sets the error returned by the query or exec
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) String() string {
+	s := e.method
+	if e.method == "Query" || e.method == "Exec" {
+		s += fmt.Sprintf(" %q", e.query)
+	}
+	if e.withArgs {
+		s += fmt.Sprintf(" with args %v", e.args)
+	}
+	if e.tx > 0 {
+		s += fmt.Sprintf(" in transaction %d", e.tx)
+	}
+	return s
+}
+
+func (e *Expectation) match(matcher QueryMatcher, tx int, method, query string, args []interface{}) error {
+	if e.method != method {
+		return errors.Errorf("expected %s, not %s", e.method, method)
+	}
+	if e.tx != tx && (e.tx > 0 || !e.anyTimes) {
+		if e.tx == 0 {
+			return errors.Errorf("expected to run outside a transaction, not in transaction %d", tx)
+		}
+		return errors.Errorf("expected to run in transaction %d", e.tx)
+	}
+	if method != "Query" && method != "Exec" {
+		return nil
+	}
+	if err := matcher(e.query, query); err != nil {
+		return err
+	}
+	if !e.withArgs {
+		return nil
+	}
+	if len(args) != len(e.args) {
+		return errors.Errorf("expected %d arguments, got %d", len(e.args), len(args))
+	}
+	for i := range args {
+		if arg, ok := e.args[i].(Argument); ok {
+			if !arg.Match(args[i]) {
+				return errors.Errorf("argument %d: %v doesn't match", i, args[i])
+			}
+		} else if !reflect.DeepEqual(args[i], e.args[i]) {
+			return errors.Errorf("argument %d: expected %v, got %v", i, e.args[i], args[i])
+		}
+	}
+	return nil
+}
+
+// expectations holds a mock's expectations and the transactions begun on it. It is safe for concurrent use
+type expectations struct {
+	mu        sync.Mutex
+	list      []*Expectation
+	unordered bool
+	matcher   QueryMatcher
+	begins    int // ExpectBegin calls
+	openTx    int // the transaction expectations are being added to
+	txs       []*txRecord
+}
+
+// txRecord tracks how a transaction begun on the mock ended
+type txRecord struct {
+	ended string // "committed" or "rolled back"
+	extra int    // Commit or Rollback calls after it ended, except a Rollback after Commit
+}
+
+func (x *expectations) add(method, query string) *Expectation {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	e := &Expectation{method: method, query: query, tx: x.openTx}
+	switch method {
+	case "Begin":
+		x.begins++
+		x.openTx = x.begins
+		e.tx = 0
+	case "Commit", "Rollback":
+		x.openTx = 0
+	}
+	x.list = append(x.list, e)
+	return e
+}
+
+func (x *expectations) ExpectQuery(query string) *Expectation {
+	return x.add("Query", query)
+}
+
+func (x *expectations) ExpectExec(query string) *Expectation {
+	return x.add("Exec", query)
+}
+
+// ExpectBegin expects a transaction to be begun. Queries and execs expected after it, until ExpectCommit or
+// ExpectRollback, must run in that transaction
+func (x *expectations) ExpectBegin() *Expectation {
+	return x.add("Begin", "")
+}
+
+// ExpectCommit expects the open transaction to be committed
+func (x *expectations) ExpectCommit() *Expectation {
+	return x.add("Commit", "")
+}
+
+// ExpectRollback expects the open transaction to be rolled back
+func (x *expectations) ExpectRollback() *Expectation {
+	return x.add("Rollback", "")
+}
+
+// MatchExpectationsInOrder sets whether expectations must be met in the order they were added. They are by default
+func (x *expectations) MatchExpectationsInOrder(inOrder bool) {
+	x.mu.Lock()
+	x.unordered = !inOrder
+	x.mu.Unlock()
+}
+
+// SetQueryMatcher sets how expected queries are compared to the queries run. Defaults to QueryMatcherEqual
+func (x *expectations) SetQueryMatcher(matcher QueryMatcher) {
+	x.mu.Lock()
+	x.matcher = matcher
+	x.mu.Unlock()
+}
+
+// ExpectationsWereMet returns an error listing the expectations which haven't been met. It also checks that
+// every transaction begun on the mock was committed or rolled back exactly once
+func (x *expectations) ExpectationsWereMet() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var unmet []string
+	for _, e := range x.list {
+		if !e.triggered && !e.anyTimes {
+			unmet = append(unmet, e.String())
+		}
+	}
+	if len(unmet) > 0 {
+		return errors.Errorf("there are unmet expectations: %s", strings.Join(unmet, ", "))
+	}
+	for i, tx := range x.txs {
+		if tx.ended == "" {
+			return errors.Errorf("transaction %d was neither committed nor rolled back", i+1)
+		}
+		if tx.extra > 0 {
+			return errors.Errorf("transaction %d was %s and then committed or rolled back %d more time(s)", i+1, tx.ended, tx.extra)
+		}
+	}
+	return nil
+}
+
+// VerifyExpectations fails the test if any expectations haven't been met. Defer it at the start of the test
+func (x *expectations) VerifyExpectations(t *testing.T) {
+	t.Helper()
+	if err := x.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func (x *expectations) queryMatcher() QueryMatcher {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.matcher == nil {
+		return QueryMatcherEqual
+	}
+	return x.matcher
+}
+
+func (x *expectations) added() bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return len(x.list) > 0
+}
+
+// find returns the expectation met by running query in transaction tx, or nil when no expectations have been added
+func (x *expectations) find(tx int, method, query string, args []interface{}) (*Expectation, error) {
+	matcher := x.queryMatcher()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if len(x.list) == 0 {
+		return nil, nil
+	}
+	var next error
+	for _, e := range x.list {
+		if e.triggered && !e.anyTimes || next != nil && !e.anyTimes {
+			continue
+		}
+		err := e.match(matcher, tx, method, query, args)
+		if err == nil {
+			e.triggered = true
+			return e, nil
+		}
+		if !x.unordered && !e.anyTimes && next == nil {
+			next = errors.Errorf("%s was not expected, next expectation is %s: %v", describeCall(tx, method, query, args), e, err)
+		}
+	}
+	if next != nil {
+		return nil, next
+	}
+	return nil, errors.Errorf("%s was not expected", describeCall(tx, method, query, args))
+}
+
+// MatchExec checks an exec against the expectations and returns the error it should fail with. expected is false
+// when no expectations have been added. Mocks of other backends call it from their own Exec
+func (r *mockDb) MatchExec(query string, args ...interface{}) (expected bool, err error) {
+	return r.matchExec(context.Background(), 0, query, args)
+}
+
+// MatchExecContext is MatchExec for an exec taking a context, which cuts short a delay injected with InjectFault
+func (r *mockDb) MatchExecContext(ctx context.Context, query string, args ...interface{}) (expected bool, err error) {
+	return r.matchExec(ctx, 0, query, args)
+}
+
+// matchExec also returns the error of a fault injected for the exec, reporting it as expected
+func (r *mockDb) matchExec(ctx context.Context, tx int, query string, args []interface{}) (bool, error) {
+	if err := r.injectFault(ctx, query); err != nil {
+		return true, err
+	}
+	e, err := r.expectations.find(tx, "Exec", query, args)
+	if err != nil {
+		return true, err
+	}
+	if e == nil {
+		return false, nil
+	}
+	return true, e.err
+}
+
+// expectedRows returns the rows for a query when expectations have been added
+func (r *mockDb) expectedRows(tx int, query string, args []interface{}) (RowsScanner, bool, error) {
+	e, err := r.expectations.find(tx, "Query", query, args)
+	if err != nil {
+		return &mockRowsScanner{ErrErr: err}, true, err
+	}
+	if e == nil {
+		return nil, false, nil
+	}
+	if e.err != nil {
+		return &mockRowsScanner{ErrErr: e.err}, true, e.err
+	}
+	if e.data == nil {
+		return &mockRowsScanner{currentRow: -1}, true, nil
+	}
+	return NewRowsScanner(e.data), true, nil
+}
@@ -0,0 +1,121 @@
+package onedb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExpectQuery(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectQuery("select id, name from users where id = $1").WithArgs(1).WillReturnRows([]SimpleData{{1, "hello"}})
+	d.ExpectExec("delete from users where id = $1").WithArgs(1)
+
+	var result []SimpleData
+	if err := d.QueryStruct(&result, "select id, name\n  from users where id = $1", 1); err != nil || len(result) != 1 || result[0].StringVal != "hello" {
+		t.Error("expected rows from the expectation", result, err)
+	}
+	if err := d.ExpectationsWereMet(); err == nil || !strings.Contains(err.Error(), "delete from users") {
+		t.Error("expected the exec to be unmet", err)
+	}
+	if err := d.(*mockDb).Execute("delete from users where id = $1", 1); err != nil {
+		t.Error("expected exec to match", err)
+	}
+	d.VerifyExpectations(t)
+
+	if _, err := d.Query("select 1"); err == nil {
+		t.Error("expected error for a query after all expectations were met")
+	}
+}
+
+func TestExpectQueryMismatch(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectQuery("select * from users").WithArgs("alice").WillReturnRows([]SimpleData{{1, "alice"}})
+	if _, err := d.Query("select * from users", "bob"); err == nil || !strings.Contains(err.Error(), "argument 0") {
+		t.Error("expected argument mismatch", err)
+	}
+	if _, err := d.Query("select * from accounts", "alice"); err == nil {
+		t.Error("expected query mismatch")
+	}
+	var id int
+	var name string
+	if err := d.QueryRow("select * from users", "alice").Scan(&id, &name); err != nil || name != "alice" {
+		t.Error("expected query to match", name, err)
+	}
+}
+
+func TestExpectOrder(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectExec("insert into a")
+	d.ExpectExec("insert into b")
+	if err := d.(*mockDb).Execute("insert into b"); err == nil || !strings.Contains(err.Error(), "next expectation is Exec \"insert into a\"") {
+		t.Error("expected out of order error", err)
+	}
+
+	d = NewMock(nil, nil)
+	d.MatchExpectationsInOrder(false)
+	d.ExpectExec("insert into a")
+	d.ExpectExec("insert into b")
+	if d.(*mockDb).Execute("insert into b") != nil || d.(*mockDb).Execute("insert into a") != nil {
+		t.Error("expected execs to match in any order")
+	}
+	d.VerifyExpectations(t)
+}
+
+func TestExpectRegexpAndErrors(t *testing.T) {
+	fail := errors.New("fail")
+	d := NewMock(nil, nil)
+	d.SetQueryMatcher(QueryMatcherRegexp)
+	d.ExpectQuery(`^select .* from users`).WillReturnError(fail)
+	d.ExpectQuery(`^select count\(\*\)`)
+
+	if _, err := d.Query("select id from users where id = $1", 1); err != fail {
+		t.Error("expected the expectation's error", err)
+	}
+	rows, err := d.Query("select count(*) from users")
+	if err != nil || rows.Next() {
+		t.Error("expected no rows", err)
+	}
+	d.VerifyExpectations(t)
+}
+
+func TestExpectArgumentMatchers(t *testing.T) {
+	d := NewMock(nil, nil)
+	positive := ArgumentFunc(func(v interface{}) bool {
+		i, ok := v.(int)
+		return ok && i > 0
+	})
+	d.ExpectExec("update users set name = $1 where id = $2").WithArgs(Any(), positive)
+	if err := d.(*mockDb).Execute("update users set name = $1 where id = $2", "alice", -1); err == nil || !strings.Contains(err.Error(), "argument 1") {
+		t.Error("expected matcher to reject argument", err)
+	}
+	if err := d.(*mockDb).Execute("update users set name = $1 where id = $2", "alice", 2); err != nil {
+		t.Error("expected matchers to accept arguments", err)
+	}
+}
+
+func TestExpectDispatchByArgs(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectQuery("select id, name from users where id = $1").WithArgs(1).WillReturnRows([]SimpleData{{1, "alice"}}).AnyTimes()
+	d.ExpectQuery("select id, name from users where id = $1").WithArgs(2).WillReturnRows([]SimpleData{{2, "bob"}}).AnyTimes()
+	d.ExpectExec("delete from users where id = $1").WithArgs(2)
+
+	var name string
+	var id int
+	for _, want := range []struct {
+		id   int
+		name string
+	}{{2, "bob"}, {1, "alice"}, {2, "bob"}} {
+		if err := d.QueryRow("select id, name from users where id = $1", want.id).Scan(&id, &name); err != nil || name != want.name {
+			t.Error("expected rows for the arguments", want, name, err)
+		}
+	}
+	if err := d.QueryRow("select id, name from users where id = $1", 3).Scan(&id, &name); err == nil {
+		t.Error("expected error for arguments without an expectation")
+	}
+	if err := d.ExpectationsWereMet(); err == nil {
+		t.Error("expected the exec to be unmet")
+	}
+	d.(*mockDb).Execute("delete from users where id = $1", 2)
+	d.VerifyExpectations(t)
+}
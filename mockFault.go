@@ -0,0 +1,108 @@
+package onedb
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Injector scripts errors and latency for the calls made to a mock so retry and timeout handling can be tested.
+// Faults are checked before expectations, so a call failed by a fault doesn't use up an expectation
+type Injector interface {
+	InjectFault(query string) *Fault
+}
+
+// Fault is an error or delay injected into the calls running a query
+type Fault struct {
+	query string
+	calls map[int]bool
+	times int
+	err   error
+	delay time.Duration
+	seen  int // matching calls
+	fired int // matching calls the fault was injected into
+}
+
+// OnCalls limits the fault to the given matching calls, counted from 1. OnCalls(1, 2) fails the first two calls
+// and lets the third through
+func (f *Fault) OnCalls(n ...int) *Fault {
+	f.calls = make(map[int]bool, len(n))
+	for _, i := range n {
+		f.calls[i] = true
+	}
+	return f
+}
+
+// Times limits the fault to the first n calls it applies to
+func (f *Fault) Times(n int) *Fault {
+	f.times = n
+	return f
+}
+
+// WillReturnError sets the error the call fails with, such as a backend's ErrNoRows, a dead connection error or a
+// driver's error with a particular code
+func (f *Fault) WillReturnError(err error) *Fault {
+	f.err = err
+	return f
+}
+
+// WillDelayFor delays the call by d before it returns. A call taking a context returns the context's error if it is
+// done first
+func (f *Fault) WillDelayFor(d time.Duration) *Fault {
+	f.delay = d
+	return f
+}
+
+type faults struct {
+	mu   sync.Mutex
+	list []*Fault
+}
+
+// InjectFault adds a fault for calls running query, compared with the mock's QueryMatcher. An empty query matches
+// every call
+func (x *faults) InjectFault(query string) *Fault {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	f := &Fault{query: query}
+	x.list = append(x.list, f)
+	return f
+}
+
+// next counts a call running query against the faults and returns the delay and error injected into it. The
+// delays of all matching faults add up and the first error is returned
+func (x *faults) next(matcher QueryMatcher, query string) (time.Duration, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var delay time.Duration
+	var err error
+	for _, f := range x.list {
+		if f.query != "" && matcher(f.query, query) != nil {
+			continue
+		}
+		f.seen++
+		if f.calls != nil && !f.calls[f.seen] || f.times > 0 && f.fired >= f.times {
+			continue
+		}
+		f.fired++
+		delay += f.delay
+		if err == nil {
+			err = f.err
+		}
+	}
+	return delay, err
+}
+
+// injectFault waits out the delay injected for query and returns the injected error
+func (r *mockDb) injectFault(ctx context.Context, query string) error {
+	delay, err := r.faults.next(r.expectations.queryMatcher(), query)
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return err
+}
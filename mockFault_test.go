@@ -0,0 +1,63 @@
+package onedb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInjectFaultOnCalls(t *testing.T) {
+	dead := errors.New("dead")
+	d := NewMock(nil, nil, []SimpleData{{1, "alice"}})
+	d.InjectFault("select id, name from users").WillReturnError(dead).OnCalls(1, 2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := d.Query("select id, name from users"); err != dead {
+			t.Error("expected injected error", i, err)
+		}
+	}
+	var result []SimpleData
+	if err := d.QueryStruct(&result, "select id, name from users"); err != nil || len(result) != 1 {
+		t.Error("expected the third call to get the data", result, err)
+	}
+}
+
+func TestInjectFaultWithExpectations(t *testing.T) {
+	noRows := errors.New("no rows in result set")
+	d := NewMock(nil, nil)
+	d.InjectFault("").WillReturnError(noRows).Times(1)
+	d.ExpectQuery("select name from users where id = $1").WithArgs(1).WillReturnRows([]struct{ Name string }{{"alice"}})
+	d.ExpectExec("delete from users")
+
+	var name string
+	if err := d.QueryRow("select name from users where id = $1", 1).Scan(&name); err != noRows {
+		t.Error("expected injected error from Scan", err)
+	}
+	if err := d.QueryRow("select name from users where id = $1", 1).Scan(&name); err != nil || name != "alice" {
+		t.Error("expected the expectation to still be met", name, err)
+	}
+	if expected, err := d.MatchExec("delete from users"); !expected || err != nil {
+		t.Error("expected exec to match", err)
+	}
+	d.VerifyExpectations(t)
+}
+
+func TestInjectFaultDelay(t *testing.T) {
+	d := NewMock(nil, nil, []SimpleData{{1, "alice"}})
+	d.InjectFault("select 1").WillDelayFor(time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := d.QueryContext(ctx, "select 1"); err != context.DeadlineExceeded {
+		t.Error("expected the delay to be cut short by the context", err)
+	}
+	if expected, err := d.MatchExecContext(ctx, "select 1"); !expected || err != context.DeadlineExceeded {
+		t.Error("expected exec to return the context's error", err)
+	}
+
+	d.InjectFault("select 2").WillDelayFor(5 * time.Millisecond)
+	start := time.Now()
+	if _, err := d.Query("select 2"); err != nil || time.Since(start) < 5*time.Millisecond {
+		t.Error("expected delayed call to succeed", err)
+	}
+}
@@ -0,0 +1,408 @@
+package onedb
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// MockResult is a result set with named and typed columns, for rows too varied or too many to write as a slice
+// of structs. Pass one to NewMock or Expectation.WillReturnRows in place of a slice of structs
+type MockResult struct {
+	Columns []ColumnType
+	Rows    [][]interface{}
+}
+
+// mockFixture is an expected query or exec read from a fixture file
+type mockFixture struct {
+	Query    string          `yaml:"query,omitempty"`
+	Exec     string          `yaml:"exec,omitempty"`
+	Args     fixtureRow      `yaml:"args,flow,omitempty"`
+	AnyTimes bool            `yaml:"any_times,omitempty"`
+	Error    string          `yaml:"error,omitempty"`
+	Columns  []fixtureColumn `yaml:"columns,omitempty"`
+	Rows     []fixtureRow    `yaml:"rows,omitempty"`
+}
+
+type fixtureColumn struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Nullable bool   `yaml:"nullable,omitempty"`
+}
+
+// fixtureRow is written on one line, as are fixtureColumns
+type fixtureRow []interface{}
+
+func (r fixtureRow) MarshalYAML() (interface{}, error) {
+	return flowNode([]interface{}(r))
+}
+
+func (c fixtureColumn) MarshalYAML() (interface{}, error) {
+	type column fixtureColumn // without the MarshalYAML method
+	return flowNode(column(c))
+}
+
+func flowNode(v interface{}) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+	node.Style = yaml.FlowStyle
+	return &node, nil
+}
+
+// ExpectFixtures adds an expectation to e for each query and exec in the fixture files in fsys matching
+// patterns, such as "testdata/*.yml", in the order the patterns are given and then by file name. A fixture file
+// is a YAML or JSON list of expectations, which WriteMockFixture can generate from real query output:
+//
+//	# testdata/users.yml
+//	- query: select id, name, created from users where id = $1
+//	  args: [1]
+//	  columns:
+//	    - {name: id, type: int8}
+//	    - {name: name, type: text, nullable: true}
+//	    - {name: created, type: timestamptz}
+//	  rows:
+//	    - [1, alice, 2024-01-02T03:04:05Z]
+//	- exec: delete from sessions
+//	  any_times: true
+//	- query: select * from missing
+//	  error: relation "missing" does not exist
+//
+// Without args any arguments match. Numeric args match an argument of any integer or float type with the same
+// value, since YAML can't tell which one the query is run with. Values are converted to the Go type of their
+// column: int64 for integer types, float64 for float and numeric types, bool, time.Time for date and time types,
+// []byte from base64 for bytea, and string for text types and any other type named
+func ExpectFixtures(e Expecter, fsys fs.FS, patterns ...string) error {
+	for _, pattern := range patterns {
+		names, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return errors.Errorf("no fixture files match %s", pattern)
+		}
+		for _, name := range names {
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return err
+			}
+			if err := expectFixtures(e, data); err != nil {
+				return errors.Wrapf(err, "Unable to read fixture file %s", name)
+			}
+		}
+	}
+	return nil
+}
+
+func expectFixtures(e Expecter, data []byte) error {
+	var fixtures []mockFixture
+	if err := yaml.Unmarshal(data, &fixtures); err != nil {
+		return err
+	}
+	for i, f := range fixtures {
+		var expectation *Expectation
+		switch {
+		case f.Query != "" && f.Exec == "":
+			result, err := f.result()
+			if err != nil {
+				return errors.Wrapf(err, "fixture %d", i)
+			}
+			expectation = e.ExpectQuery(f.Query).WillReturnRows(result)
+		case f.Exec != "" && f.Query == "":
+			expectation = e.ExpectExec(f.Exec)
+		default:
+			return errors.Errorf("fixture %d must have either a query or an exec", i)
+		}
+		if f.Args != nil {
+			expectation.WithArgs(fixtureArgs(f.Args)...)
+		}
+		if f.Error != "" {
+			expectation.WillReturnError(errors.New(f.Error))
+		}
+		if f.AnyTimes {
+			expectation.AnyTimes()
+		}
+	}
+	return nil
+}
+
+// fixtureArgs replaces the numbers among args with Arguments matching the same value in the argument's type
+func fixtureArgs(args fixtureRow) []interface{} {
+	matchers := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch n := arg.(type) {
+		case int:
+			matchers[i] = fixtureNumber(n)
+		case float64:
+			matchers[i] = fixtureNumber(n)
+		default:
+			matchers[i] = arg
+		}
+	}
+	return matchers
+}
+
+// fixtureNumber is an Argument matching an integer or float argument of the same value
+type fixtureNumber float64
+
+func (n fixtureNumber) Match(v interface{}) bool {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(n) == math.Trunc(float64(n)) && int64(n) == value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return n >= 0 && float64(n) == math.Trunc(float64(n)) && uint64(n) == value.Uint()
+	case reflect.Float32, reflect.Float64:
+		return float64(n) == value.Convert(float64Type).Float()
+	}
+	return false
+}
+
+func (n fixtureNumber) String() string {
+	return strconv.FormatFloat(float64(n), 'g', -1, 64)
+}
+
+func (f *mockFixture) result() (*MockResult, error) {
+	result := &MockResult{Columns: make([]ColumnType, len(f.Columns))}
+	for i, c := range f.Columns {
+		result.Columns[i] = ColumnType{Name: c.Name, DatabaseType: c.Type, Nullable: c.Nullable, ScanType: fixtureScanType(c.Type)}
+	}
+	for i, row := range f.Rows {
+		if len(row) != len(f.Columns) {
+			return nil, errors.Errorf("row %d has %d values for %d columns", i, len(row), len(f.Columns))
+		}
+		values := make([]interface{}, len(row))
+		for j, v := range row {
+			value, err := fixtureValue(f.Columns[j].Type, v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "row %d column %s", i, f.Columns[j].Name)
+			}
+			values[j] = value
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, nil
+}
+
+// fixtureType groups the type names of fixture columns by the Go type their values convert to
+func fixtureType(databaseType string) string {
+	switch t := strings.ToLower(databaseType); t {
+	case "int", "int2", "int4", "int8", "integer", "smallint", "bigint", "serial", "bigserial":
+		return "int"
+	case "float4", "float8", "real", "double precision", "float", "double", "numeric", "decimal":
+		return "float"
+	case "bool", "boolean":
+		return "bool"
+	case "bytea", "blob", "binary", "varbinary":
+		return "bytes"
+	default:
+		if strings.HasPrefix(t, "timestamp") || t == "date" || t == "datetime" || t == "time" || t == "timetz" {
+			return "time"
+		}
+		return "string"
+	}
+}
+
+var (
+	int64Type   = reflect.TypeOf(int64(0))
+	float64Type = reflect.TypeOf(float64(0))
+	boolType    = reflect.TypeOf(false)
+	bytesType   = reflect.TypeOf([]byte(nil))
+	timeType    = reflect.TypeOf(time.Time{})
+	stringType  = reflect.TypeOf("")
+)
+
+func fixtureScanType(databaseType string) reflect.Type {
+	switch fixtureType(databaseType) {
+	case "int":
+		return int64Type
+	case "float":
+		return float64Type
+	case "bool":
+		return boolType
+	case "bytes":
+		return bytesType
+	case "time":
+		return timeType
+	}
+	return stringType
+}
+
+// fixtureValue converts a value decoded from a fixture file to the Go type of its column
+func fixtureValue(databaseType string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch fixtureType(databaseType) {
+	case "int":
+		if i, ok := v.(int); ok {
+			return int64(i), nil
+		}
+	case "float":
+		switch n := v.(type) {
+		case int:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case "bool":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case "bytes":
+		if s, ok := v.(string); ok {
+			return base64.StdEncoding.DecodeString(s)
+		}
+	case "time":
+		switch t := v.(type) {
+		case time.Time:
+			return t, nil
+		case string:
+			return time.Parse(time.RFC3339Nano, t)
+		}
+	default:
+		if t, ok := v.(time.Time); ok { // YAML reads unquoted timestamps as times
+			return t.Format(time.RFC3339Nano), nil
+		}
+		return fmt.Sprint(v), nil
+	}
+	return nil, errors.Errorf("%v is not a valid %s", v, databaseType)
+}
+
+// WriteMockFixture reads rows and writes them to w as a fixture, for ExpectFixtures, which expects query to be
+// run with args. Append the output of several calls to make a file with several fixtures
+func WriteMockFixture(w io.Writer, query string, args []interface{}, rows RowsScanner) error {
+	defer rows.Close()
+	columnTypes, err := ColumnTypes(rows)
+	if err != nil {
+		return err
+	}
+	f := mockFixture{Query: query, Args: args, Columns: make([]fixtureColumn, len(columnTypes))}
+	dest := make([]interface{}, len(columnTypes))
+	values := make([]interface{}, len(columnTypes))
+	for i := range dest {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		row := make(fixtureRow, len(values))
+		for i, v := range values {
+			row[i] = fixtureOutput(v)
+			if f.Columns[i].Type == "" {
+				f.Columns[i].Type = goFixtureType(v)
+			}
+		}
+		f.Rows = append(f.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i, c := range columnTypes {
+		f.Columns[i].Name, f.Columns[i].Nullable = c.Name, c.Nullable
+		if c.DatabaseType != "" {
+			f.Columns[i].Type = c.DatabaseType
+		} else if f.Columns[i].Type == "" {
+			f.Columns[i].Type = "text"
+		}
+	}
+	data, err := yaml.Marshal([]mockFixture{f})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// goFixtureType names the fixture type of a value when its column's database type isn't known
+func goFixtureType(v interface{}) string {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "int8"
+	case float32, float64:
+		return "float8"
+	case bool:
+		return "bool"
+	case []byte:
+		return "bytea"
+	case time.Time:
+		return "timestamptz"
+	case nil:
+		return ""
+	}
+	return "text"
+}
+
+func fixtureOutput(v interface{}) interface{} {
+	switch t := v.(type) {
+	case []byte:
+		return base64.StdEncoding.EncodeToString(t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+// mockResultScanner scans through a MockResult
+type mockResultScanner struct {
+	result *MockResult
+	pos    int
+}
+
+func (r *mockResultScanner) Columns() ([]string, error) {
+	columns := make([]string, len(r.result.Columns))
+	for i, c := range r.result.Columns {
+		columns[i] = c.Name
+	}
+	return columns, nil
+}
+
+func (r *mockResultScanner) ColumnTypes() ([]ColumnType, error) {
+	return r.result.Columns, nil
+}
+
+func (r *mockResultScanner) Next() bool {
+	if r.pos > len(r.result.Rows) {
+		return false
+	}
+	r.pos++
+	return r.pos <= len(r.result.Rows)
+}
+
+func (r *mockResultScanner) Scan(dest ...interface{}) error {
+	if r.pos == 0 || r.pos > len(r.result.Rows) {
+		return errors.New("invalid current row")
+	}
+	values := r.result.Rows[r.pos-1]
+	if len(dest) != len(values) {
+		return errors.Errorf("expected equal number of dest values as source. Expected: %d, Actual: %d", len(values), len(dest))
+	}
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			v = append([]byte(nil), b...)
+		}
+		if err := ScanValue(v, dest[i]); err != nil {
+			return errors.Wrapf(err, "Unable to scan column %s", r.result.Columns[i].Name)
+		}
+	}
+	return nil
+}
+
+func (r *mockResultScanner) Err() error {
+	return nil
+}
+
+func (r *mockResultScanner) Close() error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package onedb
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var mockFixtures = fstest.MapFS{
+	"testdata/users.yml": {Data: []byte(`
+- query: select id, name, created, avatar, score from users where id = $1
+  args: [1]
+  columns:
+    - {name: id, type: int8}
+    - {name: name, type: text, nullable: true}
+    - {name: created, type: timestamptz}
+    - {name: avatar, type: bytea}
+    - {name: score, type: numeric}
+  rows:
+    - [1, alice, 2024-01-02T03:04:05Z, aGk=, 2]
+    - [2, null, "2024-01-03T00:00:00Z", null, 1.5]
+- exec: delete from sessions
+  any_times: true
+`)},
+	"testdata/errors.json": {Data: []byte(`[{"query": "select * from missing", "error": "relation does not exist"}]`)},
+}
+
+type fixtureUser struct {
+	ID      int64
+	Name    *string
+	Created time.Time
+	Avatar  []byte
+	Score   float64
+}
+
+func TestExpectFixtures(t *testing.T) {
+	m := NewMock(nil, nil)
+	if err := ExpectFixtures(m, mockFixtures, "testdata/users.yml", "testdata/*.json"); err != nil {
+		t.Fatal("expected success", err)
+	}
+	var users []fixtureUser
+	if err := m.QueryStruct(&users, "select id, name, created, avatar, score from users where id = $1", 1); err != nil {
+		t.Fatal("expected success", err)
+	}
+	if len(users) != 2 || users[0].ID != 1 || *users[0].Name != "alice" || !users[0].Created.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) ||
+		string(users[0].Avatar) != "hi" || users[0].Score != 2 || users[1].Name != nil || users[1].Avatar != nil || users[1].Score != 1.5 {
+		t.Error("expected typed rows", users)
+	}
+	m.MatchExec("delete from sessions")
+	if _, err := m.Query("select * from missing"); err == nil || err.Error() != "relation does not exist" {
+		t.Error("expected fixture error", err)
+	}
+	m.VerifyExpectations(t)
+
+	if err := ExpectFixtures(m, mockFixtures, "missing/*"); err == nil {
+		t.Error("expected error when no files match")
+	}
+	if err := expectFixtures(m, []byte(`[{query: "select 1", columns: [{name: id, type: int}], rows: [[one]]}]`)); err == nil {
+		t.Error("expected error for a value of the wrong type")
+	}
+	if err := expectFixtures(m, []byte(`[{query: "select 1", exec: "delete"}]`)); err == nil {
+		t.Error("expected error for a fixture with both a query and an exec")
+	}
+}
+
+func TestFixtureArgs(t *testing.T) {
+	m := NewMock(nil, nil)
+	if err := expectFixtures(m, []byte(`[{exec: "update users set score = $2 where id = $1", args: [7, 1.5, a], any_times: true}]`)); err != nil {
+		t.Fatal("expected success", err)
+	}
+	for _, args := range [][]interface{}{{7, 1.5, "a"}, {int64(7), float32(1.5), "a"}, {uint8(7), 1.5, "a"}} {
+		if expected, err := m.MatchExec("update users set score = $2 where id = $1", args...); !expected || err != nil {
+			t.Error("expected numbers to match in any type", args, err)
+		}
+	}
+	for _, args := range [][]interface{}{{7.5, 1.5, "a"}, {7, 1, "a"}, {"7", 1.5, "a"}} {
+		if _, err := m.MatchExec("update users set score = $2 where id = $1", args...); err == nil {
+			t.Error("expected other values not to match", args)
+		}
+	}
+}
+
+func TestWriteMockFixture(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	name := "alice"
+	var buf bytes.Buffer
+	rows := NewRowsScanner([]fixtureUser{{1, &name, created, []byte("hi"), 2.5}, {2, nil, created, nil, 0}})
+	if err := WriteMockFixture(&buf, "select * from users where id > $1", []interface{}{0}, rows); err != nil {
+		t.Fatal("expected success", err)
+	}
+
+	m := NewMock(nil, nil)
+	if err := expectFixtures(m, buf.Bytes()); err != nil {
+		t.Fatal("expected written fixture to load", err, buf.String())
+	}
+	var users []fixtureUser
+	if err := m.QueryStruct(&users, "select * from users where id > $1", 0); err != nil || len(users) != 2 ||
+		*users[0].Name != "alice" || string(users[0].Avatar) != "hi" || !users[0].Created.Equal(created) || users[0].Score != 2.5 || users[1].Name != nil {
+		t.Error("expected rows to survive a round trip", users, err, buf.String())
+	}
+}
@@ -0,0 +1,177 @@
+package onedb
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// ErrTxDone occurs when a transaction is used after it has been committed or rolled back
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// MockTxer is a transaction begun on a Mocker. Its queries and execs are checked against the expectations added
+// between ExpectBegin and ExpectCommit or ExpectRollback
+type MockTxer interface {
+	Txer
+	Backender
+	ContextBackender
+	DBer
+	Execute(query string, args ...interface{}) error
+	MatchExec(query string, args ...interface{}) (expected bool, err error)
+	MatchExecContext(ctx context.Context, query string, args ...interface{}) (expected bool, err error)
+}
+
+type mockDbTx struct {
+	db     *mockDb
+	id     int
+	record *txRecord
+}
+
+// Begin starts a mock transaction, returning the error of the matching ExpectBegin if there is one
+func (r *mockDb) Begin() (MockTxer, error) {
+	r.SaveMethodCall("Begin", nil)
+	if e, err := r.expectations.find(0, "Begin", "", nil); err != nil {
+		return nil, err
+	} else if e != nil && e.err != nil {
+		return nil, e.err
+	}
+	r.expectations.mu.Lock()
+	defer r.expectations.mu.Unlock()
+	record := &txRecord{}
+	r.expectations.txs = append(r.expectations.txs, record)
+	return &mockDbTx{db: r, id: len(r.expectations.txs), record: record}, nil
+}
+
+func (t *mockDbTx) Commit() error {
+	t.db.SaveMethodCall("Commit", nil)
+	return t.end("Commit", "committed")
+}
+
+func (t *mockDbTx) Rollback() error {
+	t.db.SaveMethodCall("Rollback", nil)
+	return t.end("Rollback", "rolled back")
+}
+
+// end records how the transaction ended. A Rollback after Commit, as done by a deferred Rollback, only returns
+// ErrTxDone; any other call after the transaction ended is reported by ExpectationsWereMet
+func (t *mockDbTx) end(method, ended string) error {
+	x := &t.db.expectations
+	x.mu.Lock()
+	if t.record.ended != "" {
+		if method != "Rollback" || t.record.ended != "committed" {
+			t.record.extra++
+		}
+		x.mu.Unlock()
+		return ErrTxDone
+	}
+	t.record.ended = ended
+	x.mu.Unlock()
+
+	e, err := x.find(t.id, method, "", nil)
+	if err != nil {
+		return err
+	}
+	if e != nil {
+		return e.err
+	}
+	return nil
+}
+
+func (t *mockDbTx) done() bool {
+	t.db.expectations.mu.Lock()
+	defer t.db.expectations.mu.Unlock()
+	return t.record.ended != ""
+}
+
+func (t *mockDbTx) Query(query string, args ...interface{}) (RowsScanner, error) {
+	t.db.SaveMethodCall("Query", append([]interface{}{query}, args...))
+	return t.query(context.Background(), query, args)
+}
+
+func (t *mockDbTx) QueryRow(query string, args ...interface{}) Scanner {
+	t.db.SaveMethodCall("QueryRow", append([]interface{}{query}, args...))
+	return t.queryRow(context.Background(), query, args)
+}
+
+func (t *mockDbTx) QueryContext(ctx context.Context, query string, args ...interface{}) (RowsScanner, error) {
+	if err := ctx.Err(); err != nil {
+		t.db.SaveMethodCall("QueryContext", append([]interface{}{query}, args...))
+		return &mockRowsScanner{ErrErr: err}, err
+	}
+	t.db.SaveMethodCall("Query", append([]interface{}{query}, args...))
+	return t.query(ctx, query, args)
+}
+
+func (t *mockDbTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) Scanner {
+	if err := ctx.Err(); err != nil {
+		t.db.SaveMethodCall("QueryRowContext", append([]interface{}{query}, args...))
+		return &errorScanner{err}
+	}
+	t.db.SaveMethodCall("QueryRow", append([]interface{}{query}, args...))
+	return t.queryRow(ctx, query, args)
+}
+
+func (t *mockDbTx) query(ctx context.Context, query string, args []interface{}) (RowsScanner, error) {
+	if t.done() {
+		return &mockRowsScanner{ErrErr: ErrTxDone}, ErrTxDone
+	}
+	return t.db.nextScannerTx(ctx, t.id, query, args)
+}
+
+func (t *mockDbTx) queryRow(ctx context.Context, query string, args []interface{}) Scanner {
+	if t.done() {
+		return &errorScanner{ErrTxDone}
+	}
+	return t.db.nextRowScannerTx(ctx, t.id, query, args)
+}
+
+func (t *mockDbTx) Execute(query string, args ...interface{}) error {
+	t.db.SaveMethodCall("Execute", append([]interface{}{query}, args...))
+	if expected, err := t.MatchExec(query, args...); expected {
+		return err
+	}
+	return t.db.execErr
+}
+
+// MatchExec checks an exec run in the transaction against the expectations. Mocks of other backends call it from
+// their own transaction's Exec
+func (t *mockDbTx) MatchExec(query string, args ...interface{}) (expected bool, err error) {
+	return t.MatchExecContext(context.Background(), query, args...)
+}
+
+// MatchExecContext is MatchExec for an exec taking a context
+func (t *mockDbTx) MatchExecContext(ctx context.Context, query string, args ...interface{}) (expected bool, err error) {
+	if t.done() {
+		return true, ErrTxDone
+	}
+	return t.db.matchExec(ctx, t.id, query, args)
+}
+
+func (t *mockDbTx) QueryValues(query *Query, result ...interface{}) error {
+	return QueryValues(t, query, result...)
+}
+
+func (t *mockDbTx) QueryJSON(query string, args ...interface{}) (string, error) {
+	return QueryJSON(t, query, args...)
+}
+
+func (t *mockDbTx) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return QueryJSONRow(t, query, args...)
+}
+
+func (t *mockDbTx) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return QueryJSONWriter(w, t, query, args...)
+}
+
+func (t *mockDbTx) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return QueryStruct(t, result, query, args...)
+}
+
+func (t *mockDbTx) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return QueryStructRow(t, result, query, args...)
+}
+
+func (t *mockDbTx) QueryWriteCSV(w io.Writer, options CSVOptions, query string, args ...interface{}) error {
+	return QueryWriteCSV(w, options, t, query, args...)
+}
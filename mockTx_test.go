@@ -0,0 +1,97 @@
+package onedb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMockTxCommit(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectBegin()
+	d.ExpectQuery("select id, name from users where id = $1").WithArgs(1).WillReturnRows([]SimpleData{{1, "alice"}})
+	d.ExpectExec("update users set name = $1 where id = $2").WithArgs("bob", 1)
+	d.ExpectCommit()
+
+	err := WithTx[MockTxer](d, func(tx MockTxer) error {
+		var user SimpleData
+		if err := tx.QueryStructRow(&user, "select id, name from users where id = $1", 1); err != nil {
+			return err
+		}
+		return tx.Execute("update users set name = $1 where id = $2", "bob", user.IntVal)
+	})
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	d.VerifyExpectations(t)
+}
+
+func TestMockTxQueryOutsideTx(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectBegin()
+	d.ExpectExec("delete from users")
+	d.ExpectCommit()
+
+	tx, _ := d.Begin()
+	if err := d.(*mockDb).Execute("delete from users"); err == nil || !strings.Contains(err.Error(), "expected to run in transaction 1") {
+		t.Error("expected error for an exec outside the transaction", err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Error("expected commit to be out of order since the exec didn't run")
+	}
+	if err := d.ExpectationsWereMet(); err == nil {
+		t.Error("expected unmet exec")
+	}
+}
+
+func TestMockTxRollback(t *testing.T) {
+	fail := errors.New("fail")
+	d := NewMock(nil, nil)
+	d.ExpectBegin()
+	d.ExpectExec("insert into users").WillReturnError(fail)
+	d.ExpectRollback()
+
+	err := WithTx[MockTxer](d, func(tx MockTxer) error {
+		return tx.Execute("insert into users")
+	})
+	if err != fail {
+		t.Error("expected exec error", err)
+	}
+	d.VerifyExpectations(t)
+
+	d = NewMock(nil, nil)
+	d.ExpectBegin()
+	d.ExpectCommit()
+	tx, _ := d.Begin()
+	if err := tx.Rollback(); err == nil || !strings.Contains(err.Error(), "next expectation is Commit") {
+		t.Error("expected error for rollback when commit was expected", err)
+	}
+}
+
+func TestMockTxLifecycle(t *testing.T) {
+	d := NewMock(nil, nil)
+	tx, _ := d.Begin()
+	if err := d.ExpectationsWereMet(); err == nil || !strings.Contains(err.Error(), "neither committed nor rolled back") {
+		t.Error("expected error for an open transaction", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Error("expected success", err)
+	}
+	if err := tx.Rollback(); err != ErrTxDone {
+		t.Error("expected ErrTxDone for a deferred rollback", err)
+	}
+	if err := d.ExpectationsWereMet(); err != nil {
+		t.Error("expected rollback after commit to be allowed", err)
+	}
+	if err := tx.Execute("delete from users"); err != ErrTxDone {
+		t.Error("expected ErrTxDone after commit", err)
+	}
+
+	if err := tx.Commit(); err != ErrTxDone {
+		t.Error("expected ErrTxDone", err)
+	}
+	if err := d.ExpectationsWereMet(); err == nil || !strings.Contains(err.Error(), "committed and then committed or rolled back 1 more time") {
+		t.Error("expected error for committing twice", err)
+	}
+}
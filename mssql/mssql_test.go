@@ -0,0 +1,246 @@
+package mssql
+
+import (
+	"context"
+	sqllib "database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+func TestNewMSSQLRealConnection(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	db, err := NewMSSQL("localhost", 1433, "gotest", "go", "GoTest")
+	if err != nil {
+		t.Fatal("expected connection success", err)
+	}
+	defer db.Close()
+	var value int
+	if err := db.QueryRow("select $1", 1).Scan(&value); err != nil || value != 1 {
+		t.Error("expected select to succeed", value, err)
+	}
+}
+
+func TestConnectionURL(t *testing.T) {
+	u, err := url.Parse(connectionURL("db.local", 1433, "sa", "p;ss@word&encrypt=disable", "app;encrypt=disable"))
+	if err != nil {
+		t.Fatal("expected a valid URL", err)
+	}
+	password, _ := u.User.Password()
+	query := u.Query()
+	if u.Host != "db.local:1433" || password != "p;ss@word&encrypt=disable" || len(query) != 1 || query.Get("database") != "app;encrypt=disable" {
+		t.Error("expected each parameter escaped", u)
+	}
+	if u := connectionURL(`db.local\SQLEXPRESS`, 1433, "sa", "", "app"); u != "sqlserver://sa:@db.local:1433/SQLEXPRESS?database=app" {
+		t.Error("expected the instance as the path", u)
+	}
+}
+
+func TestExecRowsAffected(t *testing.T) {
+	d, db := newFakeDB(t, Config{})
+	d.rowsAffected = 3
+	tag, err := db.Exec("update t set a = 1")
+	if err != nil || tag.RowsAffected() != 3 {
+		t.Error("expected rows affected", tag, err)
+	}
+}
+
+func TestExecRetriesDeadConn(t *testing.T) {
+	d, db := newFakeDB(t, Config{})
+	d.failures = 2
+	if _, err := db.Exec("update t set a = 1"); err != nil {
+		t.Error("expected retry to succeed", err)
+	}
+	if d.execs != 3 {
+		t.Error("expected 3 attempts", d.execs)
+	}
+}
+
+func TestExecRetryPolicy(t *testing.T) {
+	d, db := newFakeDB(t, Config{RetryPolicy: onedb.NoRetry})
+	d.failures = 1
+	if _, err := db.Exec("update t set a = 1"); err == nil {
+		t.Error("expected error without retries")
+	}
+	if d.execs != 1 {
+		t.Error("expected a single attempt", d.execs)
+	}
+}
+
+func TestQueryRowRetriesDeadConn(t *testing.T) {
+	d, db := newFakeDB(t, Config{})
+	d.failures = 1
+	var id int
+	if err := db.QueryRow("select id from t").Scan(&id); err != nil || id != 1 {
+		t.Error("expected retry to succeed", id, err)
+	}
+	if d.queries != 2 {
+		t.Error("expected 2 attempts", d.queries)
+	}
+}
+
+func TestDeadConnPredicates(t *testing.T) {
+	custom := errors.New("server is restarting")
+	d, db := newFakeDB(t, Config{DeadConnPredicates: []onedb.DeadConnPredicate{func(err error) bool {
+		return err != nil && strings.Contains(err.Error(), "restarting")
+	}}})
+	d.failures = 1
+	d.failErr = custom
+	if _, err := db.Query("select id from t"); err != nil {
+		t.Error("expected custom dead connection error to be retried", err)
+	}
+}
+
+func TestTxNotRetried(t *testing.T) {
+	d, db := newFakeDB(t, Config{})
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	d.failures = 1
+	if _, err := tx.Exec("update t set a = 1"); err == nil {
+		t.Error("expected error in transaction")
+	}
+	if d.execs != 1 {
+		t.Error("expected a single attempt", d.execs)
+	}
+}
+
+func TestRetryStopsOnContext(t *testing.T) {
+	r := &retrier{policy: onedb.RetryPolicyFunc(func(int, time.Duration) (time.Duration, bool) { return time.Hour, true }),
+		ping: func(context.Context) error { return nil }}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.retry(ctx, func() error { return io.EOF }); err != io.EOF {
+		t.Error("expected retry to stop when the context is done", err)
+	}
+}
+
+func TestPing(t *testing.T) {
+	_, db := newFakeDB(t, Config{})
+	if err := db.Ping(); err != nil {
+		t.Error("expected ping success", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := db.PingContext(ctx); err == nil {
+		t.Error("expected context error")
+	}
+}
+
+/***************************** MOCKS ****************************/
+func newFakeDB(t *testing.T, config Config) (*fakeDriver, MSSQLer) {
+	d := &fakeDriver{failErr: io.EOF}
+	name := "mssql-" + t.Name()
+	sqllib.Register(name, d)
+	db, err := sqllib.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return d, newBackend(db, config)
+}
+
+// fakeDriver fails the first failures statements with failErr and then returns a single row with id 1
+type fakeDriver struct {
+	failures     int
+	failErr      error
+	rowsAffected int64
+	execs        int
+	queries      int
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d}, nil
+}
+
+func (d *fakeDriver) fail() error {
+	if d.failures > 0 {
+		d.failures--
+		return d.failErr
+	}
+	return nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c.d}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	return nil
+}
+
+type fakeStmt struct {
+	d *fakeDriver
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.execs++
+	if err := s.d.fail(); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(s.d.rowsAffected), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.queries++
+	if err := s.d.fail(); err != nil {
+		return nil, err
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	done bool
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
@@ -0,0 +1,125 @@
+package mysql
+
+import (
+	sqllib "database/sql"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/EndFirstCorp/onedb/sqladapter"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// MySQLer is the interface containing the capability available for a MySQL or MariaDB database. Queries may use
+// either MySQL's ? placeholders or Postgres style $N placeholders, which are translated before being sent
+type MySQLer interface {
+	sqladapter.DBer
+}
+
+// NewMySQL returns a MySQLer from a set of parameters. Time columns are parsed into time.Time
+func NewMySQL(server string, port uint16, username string, password string, database string) (MySQLer, error) {
+	config := mysql.NewConfig()
+	config.Net = "tcp"
+	config.Addr = net.JoinHostPort(server, strconv.Itoa(int(port)))
+	config.User = username
+	config.Passwd = password
+	config.DBName = database
+	config.ParseTime = true
+	return Open(config.FormatDSN())
+}
+
+// Open returns a MySQLer from a go-sql-driver DSN such as "user:password@tcp(localhost:3306)/db?parseTime=true"
+func Open(dsn string) (MySQLer, error) {
+	db, err := sqllib.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return sqladapter.NewWithRewriter(db, translatePlaceholders), nil
+}
+
+// translatePlaceholders rewrites $N placeholders to ?. MySQL binds ? in order, so the arguments are reordered
+// (and repeated) to match the order the placeholders appear in. Queries without $N placeholders are returned
+// unchanged. Quoted strings, quoted identifiers and comments are left untouched
+func translatePlaceholders(query string, args []interface{}) (string, []interface{}, error) {
+	if !strings.Contains(query, "$") {
+		return query, args, nil
+	}
+
+	var b strings.Builder
+	var ordered []interface{}
+	found := false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(query, i, c)
+			b.WriteString(query[i:end])
+			i = end
+		case c == '#' || c == '-' && strings.HasPrefix(query[i:], "-- "):
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			end := i + 1
+			for end < len(query) && isDigit(query[end]) {
+				end++
+			}
+			n, _ := strconv.Atoi(query[i+1 : end])
+			if n < 1 || n > len(args) {
+				return "", nil, errors.Errorf("placeholder $%d has no matching argument (%d provided)", n, len(args))
+			}
+			ordered = append(ordered, args[n-1])
+			found = true
+			b.WriteByte('?')
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	if !found {
+		return query, args, nil
+	}
+	return b.String(), ordered, nil
+}
+
+// skipQuoted returns the index just past the quoted section starting at start. Both doubled quotes and
+// backslashes escape a quote
+func skipQuoted(query string, start int, quote byte) int {
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
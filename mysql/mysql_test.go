@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewMySQLRealConnection(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	db, err := NewMySQL("localhost", 3306, "gotest", "go", "GoTest")
+	if err != nil {
+		t.Fatal("expected connection success", err)
+	}
+	defer db.Close()
+	var value int
+	if err := db.QueryRow("select $1", 1).Scan(&value); err != nil || value != 1 {
+		t.Error("expected select to succeed", value, err)
+	}
+}
+
+func TestTranslatePlaceholders(t *testing.T) {
+	tests := []struct {
+		query    string
+		args     []interface{}
+		expected string
+		expArgs  []interface{}
+	}{
+		{"select * from t where a = ?", []interface{}{1}, "select * from t where a = ?", []interface{}{1}},
+		{"select * from t where a = $1 and b = $2", []interface{}{1, 2}, "select * from t where a = ? and b = ?", []interface{}{1, 2}},
+		{"select * from t where b = $2 and a = $1", []interface{}{1, 2}, "select * from t where b = ? and a = ?", []interface{}{2, 1}},
+		{"select * from t where a = $1 or b = $1", []interface{}{1}, "select * from t where a = ? or b = ?", []interface{}{1, 1}},
+		{"select '$1', \"$2\", `$3` from t where a = $1", []interface{}{1}, "select '$1', \"$2\", `$3` from t where a = ?", []interface{}{1}},
+		{"select 'it''s $1', 'a\\'$1' from t where a = $1", []interface{}{1}, "select 'it''s $1', 'a\\'$1' from t where a = ?", []interface{}{1}},
+		{"select a -- $1\nfrom t # $1\nwhere a = $1 /* $1 */", []interface{}{1}, "select a -- $1\nfrom t # $1\nwhere a = ? /* $1 */", []interface{}{1}},
+		{"select price$ from t", nil, "select price$ from t", nil},
+	}
+	for _, test := range tests {
+		query, args, err := translatePlaceholders(test.query, test.args)
+		if err != nil || query != test.expected || !reflect.DeepEqual(args, test.expArgs) {
+			t.Errorf("%q: expected %q %v, got %q %v %v", test.query, test.expected, test.expArgs, query, args, err)
+		}
+	}
+}
+
+func TestTranslatePlaceholdersMissingArg(t *testing.T) {
+	if _, _, err := translatePlaceholders("select $2", []interface{}{1}); err == nil {
+		t.Error("expected error for missing argument")
+	}
+	if _, _, err := translatePlaceholders("select $0", []interface{}{1}); err == nil {
+		t.Error("expected error for $0")
+	}
+}
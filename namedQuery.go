@@ -0,0 +1,169 @@
+package onedb
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNamedArgInvalid occurs when the value provided for named parameters is not a map or a struct.
+var ErrNamedArgInvalid = errors.New("named arguments must be a map[string]interface{} or a struct")
+
+// NewNamedQuery creates a Query from one using :name placeholders. Each placeholder is rewritten to a positional
+// $N placeholder and its value is looked up in arg, which must be a map[string]interface{}, a struct or a pointer
+// to a struct. Struct fields are matched the same way QueryStruct matches columns. A name used more than once
+// shares a single positional argument. Casts (::type), quoted strings, quoted identifiers, dollar quoted strings
+// and comments are left untouched. Queries which already have positional $N placeholders return an error since
+// their arguments would collide with the named ones.
+func NewNamedQuery(query string, arg interface{}) (*Query, error) {
+	lookup, err := getNamedLookup(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	args := []interface{}{}
+	positions := make(map[string]int)
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := skipQuoted(query, i, c)
+			b.WriteString(query[i:end])
+			i = end
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			end := i + 1
+			for end < len(query) && isDigit(query[end]) {
+				end++
+			}
+			return nil, errors.Errorf("positional placeholder %s can't be mixed with named parameters", query[i:end])
+		case c == '$':
+			end := skipDollarQuoted(query, i)
+			b.WriteString(query[i:end])
+			i = end
+		case c == ':' && strings.HasPrefix(query[i:], "::"):
+			b.WriteString("::")
+			i += 2
+		case c == ':' && i+1 < len(query) && isNameStart(query[i+1]):
+			end := i + 1
+			for end < len(query) && isNameChar(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			position, ok := positions[name]
+			if !ok {
+				value, found := lookup(name)
+				if !found {
+					return nil, errors.Errorf("no value found for named parameter :%s", name)
+				}
+				args = append(args, value)
+				position = len(args)
+				positions[name] = position
+			}
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(position))
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return NewQuery(b.String(), args...), nil
+}
+
+func getNamedLookup(arg interface{}) (func(name string) (interface{}, bool), error) {
+	if m, ok := arg.(map[string]interface{}); ok {
+		return func(name string) (interface{}, bool) {
+			value, ok := m[name]
+			return value, ok
+		}, nil
+	}
+
+	value := reflect.ValueOf(arg)
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, ErrNamedArgInvalid
+	}
+	structType := value.Type()
+	fields := make(map[string]int, structType.NumField())
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		if name := columnName(field); name != "" {
+			fields[strings.ToLower(name)] = i
+		}
+	}
+	return func(name string) (interface{}, bool) {
+		i, ok := fields[strings.ToLower(name)]
+		if !ok {
+			return nil, false
+		}
+		return value.Field(i).Interface(), true
+	}, nil
+}
+
+// skipQuoted returns the index just past the quoted section starting at start. Doubled quotes are escapes
+func skipQuoted(query string, start int, quote byte) int {
+	for i := start + 1; i < len(query); i++ {
+		if query[i] == quote {
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// skipDollarQuoted returns the index just past a dollar quoted string like $tag$...$tag$ starting at start, or
+// just past the $ when it doesn't start one (e.g. a $1 placeholder)
+func skipDollarQuoted(query string, start int) int {
+	end := start + 1
+	for end < len(query) && isNameChar(query[end]) && !(end == start+1 && isDigit(query[end])) {
+		end++
+	}
+	if end >= len(query) || query[end] != '$' {
+		return start + 1
+	}
+	tag := query[start : end+1]
+	closing := strings.Index(query[end+1:], tag)
+	if closing == -1 {
+		return len(query)
+	}
+	return end + 1 + closing + len(tag)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
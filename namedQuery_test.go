@@ -0,0 +1,54 @@
+package onedb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewNamedQueryMap(t *testing.T) {
+	q, err := NewNamedQuery("select * from users where id = :id and (name = :name or nickname = :name)", map[string]interface{}{"id": 1, "name": "bob"})
+	if err != nil || q.Query != "select * from users where id = $1 and (name = $2 or nickname = $2)" || len(q.Args) != 2 || q.Args[0] != 1 || q.Args[1] != "bob" {
+		t.Error("expected named parameters to be rewritten", q, err)
+	}
+
+	_, err = NewNamedQuery("select * from users where id = :id", map[string]interface{}{})
+	if err == nil {
+		t.Error("expected error for missing parameter")
+	}
+}
+
+func TestNewNamedQueryStruct(t *testing.T) {
+	item := TaggedItem{UserID: 12, LastName: "smith"}
+	q, err := NewNamedQuery("update users set last_name = :lastname where id = :ID", &item)
+	if err != nil || q.Query != "update users set last_name = $1 where id = $2" || len(q.Args) != 2 || q.Args[0] != "smith" || q.Args[1] != 12 {
+		t.Error("expected named parameters to be rewritten", q, err)
+	}
+
+	if _, err := NewNamedQuery("select :ignored", item); err == nil {
+		t.Error("expected error for ignored field")
+	}
+
+	if _, err := NewNamedQuery("select 1", 12); err != ErrNamedArgInvalid {
+		t.Error("expected invalid argument error", err)
+	}
+}
+
+func TestNewNamedQuerySkipsLiterals(t *testing.T) {
+	query := `select ':notparam', "col:umn", created::date, $tag$ :body $tag$, $$ :body $$ -- :comment
+	/* :comment $1 */ from t where a = :a and b = '$1'`
+	expected := `select ':notparam', "col:umn", created::date, $tag$ :body $tag$, $$ :body $$ -- :comment
+	/* :comment $1 */ from t where a = $1 and b = '$1'`
+	q, err := NewNamedQuery(query, map[string]interface{}{"a": 1})
+	if err != nil || q.Query != expected || len(q.Args) != 1 {
+		t.Error("expected literals to be skipped", q, err)
+	}
+
+	if _, err := NewNamedQuery("select * from t where a = :a and b = $1", map[string]interface{}{"a": 1}); err == nil || !strings.Contains(err.Error(), "$1") {
+		t.Error("expected error for a positional placeholder mixed with named ones", err)
+	}
+
+	q, err = NewNamedQuery("select 'it''s :a' || :a", map[string]interface{}{"a": 1})
+	if err != nil || q.Query != "select 'it''s :a' || $1" {
+		t.Error("expected escaped quotes to be skipped", q, err)
+	}
+}
@@ -0,0 +1,100 @@
+package onedb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ScanNumeric stores the value of a numeric column in dest without converting it to float64, so amounts of money
+// keep every digit. dest can be a *big.Rat, a *string, or an sql.Scanner such as a *decimal.Decimal from
+// github.com/shopspring/decimal, or point to any other type ScanValue converts numeric text to, like float64.
+// src can be numeric text or bytes, an integer, or a value like decimal.Decimal
+// which formats itself as text. Floats are converted using the fewest digits which represent them exactly
+func ScanNumeric(src interface{}, dest interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.Errorf("ScanNumeric requires a non-nil pointer, not %T", dest)
+	}
+	if src == nil {
+		if scanner, ok := dest.(sql.Scanner); ok {
+			return scanner.Scan(nil)
+		}
+		value.Elem().Set(reflect.Zero(value.Elem().Type()))
+		return nil
+	}
+	ok, err := setNumeric(value.Elem(), src)
+	if err != nil || ok {
+		return err
+	}
+	text, err := numericText(src)
+	if err != nil {
+		return err
+	}
+	return ScanValue(text, dest)
+}
+
+var ratType = reflect.TypeOf(big.Rat{})
+
+func isRatField(t reflect.Type) bool {
+	return t == ratType || t.Kind() == reflect.Ptr && t.Elem() == ratType
+}
+
+// setNumeric stores a numeric value in dest, reporting false if dest can't hold one
+func setNumeric(dest reflect.Value, src interface{}) (bool, error) {
+	text, err := numericText(src)
+	if err != nil {
+		return false, err
+	}
+	t := dest.Type()
+	switch {
+	case isRatField(t):
+		r, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return false, errors.Errorf("invalid numeric %q", text)
+		}
+		if t.Kind() == reflect.Ptr {
+			dest.Set(reflect.ValueOf(r))
+		} else {
+			dest.Set(reflect.ValueOf(r).Elem())
+		}
+		return true, nil
+	case reflect.PtrTo(t).Implements(scannerType):
+		return true, dest.Addr().Interface().(sql.Scanner).Scan(text)
+	case t.Kind() == reflect.String:
+		dest.SetString(text)
+		return true, nil
+	}
+	return false, nil
+}
+
+// numericText returns the text of a numeric value
+func numericText(src interface{}) (string, error) {
+	if valuer, ok := src.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		src = v
+	}
+	switch v := src.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	return "", errors.Errorf("cannot convert %T to a numeric", src)
+}
@@ -0,0 +1,81 @@
+package onedb
+
+import (
+	"math/big"
+	"testing"
+)
+
+type numericScanner struct {
+	text string
+}
+
+func (n *numericScanner) Scan(src interface{}) error {
+	if src == nil {
+		n.text = "NULL"
+		return nil
+	}
+	switch v := src.(type) {
+	case string:
+		n.text = v
+	case []byte:
+		n.text = string(v)
+	}
+	return nil
+}
+
+func TestScanNumeric(t *testing.T) {
+	var r big.Rat
+	if err := ScanNumeric("12345678901234567890.0000000001", &r); err != nil || r.FloatString(10) != "12345678901234567890.0000000001" {
+		t.Error("expected every digit kept", r.FloatString(10), err)
+	}
+	for _, src := range []interface{}{[]byte("0.1"), 0.1, "1/10"} {
+		if err := ScanNumeric(src, &r); err != nil || r.Cmp(big.NewRat(1, 10)) != 0 {
+			t.Errorf("expected %v scanned as 1/10: %v %v", src, r.String(), err)
+		}
+	}
+	var text string
+	if err := ScanNumeric(int64(42), &text); err != nil || text != "42" {
+		t.Error("expected numeric as text", text, err)
+	}
+	var s numericScanner
+	if err := ScanNumeric([]byte("1.50"), &s); err != nil || s.text != "1.50" {
+		t.Error("expected text passed to the scanner", s, err)
+	}
+	if err := ScanNumeric(nil, &s); err != nil || s.text != "NULL" {
+		t.Error("expected NULL passed to the scanner", s, err)
+	}
+	if err := ScanNumeric("abc", &r); err == nil {
+		t.Error("expected error for invalid text")
+	}
+	var f float64
+	if err := ScanNumeric("1.5", &f); err != nil || f != 1.5 {
+		t.Error("expected numeric converted to a float", f, err)
+	}
+	var n int
+	if err := ScanNumeric("1.5", &n); err == nil {
+		t.Error("expected error for a numeric which doesn't fit")
+	}
+}
+
+type numericRow struct {
+	Amount string
+	Rate   float64
+	Fee    []byte
+}
+
+type numericResult struct {
+	Amount big.Rat
+	Rate   *big.Rat
+	Fee    numericScanner
+}
+
+func TestGetStructNumeric(t *testing.T) {
+	result := []numericResult{}
+	rows := NewRowsScanner([]numericRow{{"99999999999999999.99", 0.25, []byte("1.10")}})
+	if err := getStruct(rows, &result, StructOptions{}); err != nil || len(result) != 1 {
+		t.Fatal("expected success", err, result)
+	}
+	if r := result[0]; r.Amount.FloatString(2) != "99999999999999999.99" || r.Rate == nil || r.Rate.Cmp(big.NewRat(1, 4)) != 0 || r.Fee.text != "1.10" {
+		t.Error("expected numerics converted exactly", r.Amount.FloatString(2), r.Rate, r.Fee)
+	}
+}
@@ -1,6 +1,7 @@
 package onedb
 
 import (
+	"context"
 	"io"
 	"net"
 	"reflect"
@@ -9,6 +10,17 @@ import (
 	"github.com/pkg/errors"
 )
 
+// Exec runs a statement which returns no rows, such as an insert or DDL, against the provided Backender.
+// Backender has no Exec, so the statement is run with Query and its rows are closed
+func Exec(backend Backender, query string, args ...interface{}) error {
+	rows, err := backend.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
 // QueryValues runs a query against the provided Backender and populates result values
 func QueryValues(backend Backender, query *Query, result ...interface{}) error {
 	if query == nil {
@@ -40,8 +52,49 @@ func QueryJSONRow(backend Backender, query string, args ...interface{}) (string,
 	return getJSONRow(rows)
 }
 
+// QueryJSONWriter runs a query against the provided Backender and streams the JSON result to w as rows are read
+func QueryJSONWriter(w io.Writer, backend Backender, query string, args ...interface{}) error {
+	rows, err := backend.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return writeJSON(rows, w)
+}
+
+// QueryMap runs a query against the provided Backender and returns the first row as a map of column name to
+// value, for queries whose columns aren't known ahead of time
+func QueryMap(backend Backender, query string, args ...interface{}) (map[string]interface{}, error) {
+	rows, err := backend.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return getMap(rows)
+}
+
+// QueryMaps runs a query against the provided Backender and returns each row as a map of column name to value,
+// for queries whose columns aren't known ahead of time
+func QueryMaps(backend Backender, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := backend.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return getMaps(rows)
+}
+
 // QueryStruct runs a query against the provided Backender and populates the provided result
 func QueryStruct(backend Backender, result interface{}, query string, args ...interface{}) error {
+	return QueryStructOptions(backend, StructOptions{}, result, query, args...)
+}
+
+// QueryStructOptions runs a query against the provided Backender and populates the provided result as set by
+// options
+func QueryStructOptions(backend Backender, options StructOptions, result interface{}, query string, args ...interface{}) error {
 	resultType := reflect.TypeOf(result)
 	if !IsPointer(resultType) || !IsSlice(resultType.Elem()) {
 		return errors.New("Invalid result argument.  Must be a pointer to a slice")
@@ -53,11 +106,17 @@ func QueryStruct(backend Backender, result interface{}, query string, args ...in
 	}
 	defer rows.Close()
 
-	return getStruct(rows, result)
+	return getStruct(rows, result, options)
 }
 
 // QueryStructRow runs a query against the provided Backender and populates the provided result
 func QueryStructRow(backend Backender, result interface{}, query string, args ...interface{}) error {
+	return QueryStructRowOptions(backend, StructOptions{}, result, query, args...)
+}
+
+// QueryStructRowOptions runs a query against the provided Backender and populates the provided result as set
+// by options
+func QueryStructRowOptions(backend Backender, options StructOptions, result interface{}, query string, args ...interface{}) error {
 	if !IsPointer(reflect.TypeOf(result)) {
 		return errors.New("Invalid result argument.  Must be a pointer to a struct")
 	}
@@ -68,7 +127,61 @@ func QueryStructRow(backend Backender, result interface{}, query string, args ..
 	}
 	defer rows.Close()
 
-	return getStructRow(rows, result)
+	return getStructRow(rows, result, options)
+}
+
+// QueryRows runs a query against the provided Backender and returns the rows as a slice of T. T must be a struct
+func QueryRows[T any](backend Backender, query string, args ...interface{}) ([]T, error) {
+	if !IsStruct(reflect.TypeOf((*T)(nil)).Elem()) {
+		return nil, ErrRowsScannerInvalidData
+	}
+	result := []T{}
+	if err := QueryStruct(backend, &result, query, args...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryRow runs a query against the provided Backender and returns the first row as a T. T must be a struct
+func QueryRow[T any](backend Backender, query string, args ...interface{}) (T, error) {
+	var result T
+	if !IsStruct(reflect.TypeOf(result)) {
+		return result, ErrRowScannerInvalidData
+	}
+	err := QueryStructRow(backend, &result, query, args...)
+	return result, err
+}
+
+// QueryColumn runs a query which returns a single column against the provided Backender and returns its values
+// as a slice of T, such as the ids or names of the rows. Values are converted to T as ScanValue does, so NULL is
+// an error unless T can be nil
+func QueryColumn[T any](backend Backender, query string, args ...interface{}) ([]T, error) {
+	rows, err := backend.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) != 1 {
+		return nil, errors.Errorf("QueryColumn requires a single column, not %d", len(columns))
+	}
+	result := []T{}
+	for rows.Next() {
+		var value interface{}
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		var item T
+		if err := ScanValue(value, &item); err != nil {
+			return nil, errors.Wrapf(err, "Unable to scan row %d of column %s", len(result)+1, columns[0])
+		}
+		result = append(result, item)
+	}
+	return result, rows.Err()
 }
 
 // IsPointer is used to determine if a reflect.Type is a pointer
@@ -97,6 +210,50 @@ func QueryWriteCSV(w io.Writer, options CSVOptions, backend Backender, query str
 	return writeCSV(rows, w, options)
 }
 
+// WithTx begins a transaction and runs fn with it. The transaction is committed if fn returns nil and rolled
+// back if fn returns an error or panics. A panic is rethrown after the rollback
+func WithTx[T Txer](db TxBeginner[T], fn func(tx T) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	finished := false
+	defer func() {
+		if !finished { // fn panicked
+			tx.Rollback()
+		}
+	}()
+
+	err = fn(tx)
+	finished = true
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// DefaultTxRetryPolicy is the RetryPolicy WithTxRetry uses when given nil. It retries twice, waiting 10 and
+// then 20 milliseconds
+var DefaultTxRetryPolicy RetryPolicy = &ExponentialBackoff{MaxAttempts: 2, InitialInterval: 10 * time.Millisecond}
+
+// WithTxRetry runs fn in a transaction like WithTx and runs the whole transaction again in a new one when it
+// fails with a serialization failure (SQLSTATE 40001) or a deadlock (40P01), waiting as policy says before each
+// retry. Waiting stops early when ctx is done. Serializable transactions need this since the server aborts any
+// which conflict. fn must be safe to run more than once. The error of the last attempt is returned
+func WithTxRetry[T Txer](ctx context.Context, db TxBeginner[T], policy RetryPolicy, fn func(tx T) error) error {
+	if policy == nil {
+		policy = DefaultTxRetryPolicy
+	}
+	return Retry(ctx, policy, isTxConflict, func() error { return WithTx(db, fn) })
+}
+
+// isTxConflict matches the errors of transactions the server aborted because of concurrent ones
+func isTxConflict(err error) bool {
+	code := PgErrorCode(err)
+	return code == CodeSerializationFailure || code == CodeDeadlockDetected
+}
+
 // Query is a generic struct that houses a query string and arguments used to construct a query
 type Query struct {
 	Query string
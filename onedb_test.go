@@ -1,8 +1,12 @@
 package onedb
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestQueryJson(t *testing.T) {
@@ -43,6 +47,61 @@ func TestQueryJsonRow(t *testing.T) {
 	}
 }
 
+func TestExecWithQuery(t *testing.T) {
+	db := NewMock(nil, nil)
+	db.ExpectQuery("insert into users (name) values ($1)").WithArgs("alice")
+	if err := Exec(db, "insert into users (name) values ($1)", "alice"); err != nil {
+		t.Error("expected success", err)
+	}
+	db.VerifyExpectations(t)
+
+	if err := Exec(&mockBackend{QueryErr: errors.New("fail")}, "insert into users default values"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestQueryJSONWriter(t *testing.T) {
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})
+	db := &mockBackend{Rows: rows}
+
+	var b bytes.Buffer
+	err := QueryJSONWriter(&b, db, "select * from TestTable")
+	if err != nil || b.String() != `[{"IntVal":1,"StringVal":"hello"},{"IntVal":2,"StringVal":"world"}]` {
+		t.Error("expected different json back.  Actual:", b.String(), err)
+	}
+
+	db = &mockBackend{QueryErr: errors.New("fail")}
+	if err := QueryJSONWriter(&b, db, "select * from TestTable"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestQueryMaps(t *testing.T) {
+	db := &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})}
+	result, err := QueryMaps(db, "query")
+	if err != nil || len(result) != 2 || result[1]["IntVal"] != 2 || result[1]["StringVal"] != "world" {
+		t.Error("expected a map per row", result, err)
+	}
+
+	db = &mockBackend{QueryErr: errors.New("fail")}
+	if _, err := QueryMaps(db, "query"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestQueryMap(t *testing.T) {
+	db := &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}})}
+	result, err := QueryMap(db, "query")
+	if err != nil || result["IntVal"] != 1 || result["StringVal"] != "hello" {
+		t.Error("expected the row as a map", result, err)
+	}
+
+	db = &mockBackend{QueryErr: errors.New("fail")}
+	if _, err := QueryMap(db, "query"); err == nil {
+		t.Error("expected error")
+	}
+}
+
 func TestQueryStruct(t *testing.T) {
 	rows := NewRowsScanner([]SimpleData{{1, "hello"}})
 	db := &mockBackend{Rows: rows}
@@ -93,6 +152,79 @@ func TestQueryStructRow(t *testing.T) {
 	}
 }
 
+type columnRow struct {
+	ID interface{}
+}
+
+func TestQueryColumn(t *testing.T) {
+	db := &mockBackend{Rows: NewRowsScanner([]columnRow{{int64(1)}, {[]byte("2")}})}
+	ids, err := QueryColumn[int](db, "query")
+	if err != nil || !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Error("expected the column's values converted", ids, err)
+	}
+
+	db = &mockBackend{Rows: NewRowsScanner([]columnRow{{"a"}, {nil}})}
+	names, err := QueryColumn[*string](db, "query")
+	if err != nil || len(names) != 2 || *names[0] != "a" || names[1] != nil {
+		t.Error("expected NULL stored as nil", names, err)
+	}
+	db = &mockBackend{Rows: NewRowsScanner([]columnRow{{"a"}, {nil}})}
+	if _, err := QueryColumn[string](db, "query"); err == nil {
+		t.Error("expected error for NULL in a string")
+	}
+
+	db = &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}})}
+	if _, err := QueryColumn[int](db, "query"); err == nil {
+		t.Error("expected error for more than one column")
+	}
+	db = &mockBackend{QueryErr: errors.New("fail")}
+	if _, err := QueryColumn[int](db, "query"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestQueryRows(t *testing.T) {
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})
+	db := &mockBackend{Rows: rows}
+
+	data, err := QueryRows[SimpleData](db, "query")
+	if err != nil || len(data) != 2 || data[0].IntVal != 1 || data[0].StringVal != "hello" || data[1].IntVal != 2 || data[1].StringVal != "world" {
+		t.Error("expected success", data, err)
+	}
+
+	// not a struct
+	if _, err := QueryRows[int](db, "query"); err != ErrRowsScannerInvalidData {
+		t.Error("expected error", err)
+	}
+
+	// query error
+	db = &mockBackend{QueryErr: errors.New("fail")}
+	if data, err := QueryRows[SimpleData](db, "query"); err == nil || data != nil {
+		t.Error("expected error", err)
+	}
+}
+
+func TestQueryRow(t *testing.T) {
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}})
+	db := &mockBackend{Rows: rows}
+
+	data, err := QueryRow[SimpleData](db, "query")
+	if err != nil || data.IntVal != 1 || data.StringVal != "hello" {
+		t.Error("expected success", data, err)
+	}
+
+	// not a struct
+	if _, err := QueryRow[*SimpleData](db, "query"); err != ErrRowScannerInvalidData {
+		t.Error("expected error", err)
+	}
+
+	// query error
+	db = &mockBackend{QueryErr: errors.New("fail")}
+	if _, err := QueryRow[SimpleData](db, "query"); err == nil {
+		t.Error("expected error", err)
+	}
+}
+
 func TestNewQuery(t *testing.T) {
 	q := NewQuery("query", "arg1", "arg2")
 	if q == nil || q.Query != "query" || len(q.Args) != 2 || q.Args[0] != "arg1" || q.Args[1] != "arg2" {
@@ -100,7 +232,97 @@ func TestNewQuery(t *testing.T) {
 	}
 }
 
+func TestWithTx(t *testing.T) {
+	db := &mockTxBeginner{}
+	if err := WithTx(db, func(tx *mockTx) error { return nil }); err != nil || db.tx.commits != 1 || db.tx.rollbacks != 0 {
+		t.Error("expected commit", err)
+	}
+
+	fail := errors.New("fail")
+	if err := WithTx(db, func(tx *mockTx) error { return fail }); err != fail || db.tx.commits != 0 || db.tx.rollbacks != 1 {
+		t.Error("expected rollback", err)
+	}
+
+	db.BeginErr = fail
+	if err := WithTx(db, func(tx *mockTx) error { t.Error("unexpected call"); return nil }); err != fail {
+		t.Error("expected begin error", err)
+	}
+}
+
+func TestWithTxPanic(t *testing.T) {
+	db := &mockTxBeginner{}
+	defer func() {
+		if r := recover(); r != "boom" || db.tx.rollbacks != 1 || db.tx.commits != 0 {
+			t.Error("expected rollback and panic to be rethrown", r)
+		}
+	}()
+	WithTx(db, func(tx *mockTx) error { panic("boom") })
+}
+
+func TestWithTxRetry(t *testing.T) {
+	db := &mockTxBeginner{}
+	policy := &ExponentialBackoff{MaxAttempts: 2, InitialInterval: time.Microsecond}
+	calls := 0
+	err := WithTxRetry(context.Background(), db, policy, func(tx *mockTx) error {
+		if calls++; calls < 3 {
+			return &PgError{Code: CodeSerializationFailure}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 || db.tx.commits != 1 {
+		t.Error("expected commit on third attempt", err, calls)
+	}
+
+	calls = 0
+	err = WithTxRetry(context.Background(), db, policy, func(tx *mockTx) error { calls++; return &PgError{Code: CodeDeadlockDetected} })
+	if PgErrorCode(err) != CodeDeadlockDetected || calls != 3 || db.tx.rollbacks != 1 {
+		t.Error("expected deadlock after max attempts", err, calls)
+	}
+
+	calls = 0
+	err = WithTxRetry(context.Background(), db, nil, func(tx *mockTx) error { calls++; return &PgError{Code: CodeUniqueViolation} })
+	if !IsUniqueViolation(err) || calls != 1 {
+		t.Error("expected no retry for other errors", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	err = WithTxRetry(ctx, db, &ExponentialBackoff{InitialInterval: time.Hour}, func(tx *mockTx) error {
+		calls++
+		return &PgError{Code: CodeSerializationFailure}
+	})
+	if PgErrorCode(err) != CodeSerializationFailure || calls != 1 {
+		t.Error("expected no wait once the context is done", err, calls)
+	}
+}
+
 /******************** MOCKS ************************/
+type mockTxBeginner struct {
+	BeginErr error
+	tx       *mockTx
+}
+
+func (b *mockTxBeginner) Begin() (*mockTx, error) {
+	b.tx = &mockTx{}
+	return b.tx, b.BeginErr
+}
+
+type mockTx struct {
+	commits   int
+	rollbacks int
+}
+
+func (t *mockTx) Commit() error {
+	t.commits++
+	return nil
+}
+
+func (t *mockTx) Rollback() error {
+	t.rollbacks++
+	return nil
+}
+
 type mockBackend struct {
 	Rows     RowsScanner
 	Row      Scanner
@@ -0,0 +1,66 @@
+// Package otel traces statements run by the onedb pgx backend with OpenTelemetry
+package otel
+
+import (
+	"context"
+
+	"github.com/EndFirstCorp/onedb/pgx"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/EndFirstCorp/onedb/otel"
+
+// NewTracer returns a pgx.Tracer which creates client spans carrying db.system, db.operation, db.statement
+// and the number of rows returned or affected. The global TracerProvider is used when tp is nil
+func NewTracer(tp trace.TracerProvider) pgx.Tracer {
+	if tp == nil {
+		tp = otelapi.GetTracerProvider()
+	}
+	return &tracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+func (t *tracer) StartSpan(ctx context.Context, operation, query string) (context.Context, pgx.Span) {
+	ctx, span := t.tracer.Start(ctx, "onedb."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", query),
+		))
+	return ctx, &otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) End(rows int64, err error) {
+	s.span.SetAttributes(attribute.Int64("db.rows_affected", rows))
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// SQLCommentTags returns the traceparent, and tracestate if any, of the span in ctx, for use as
+// onedb.SQLCommentOptions.ContextTags so statements in the database's logs can be matched to their traces. It
+// returns nil when ctx has no valid span
+func SQLCommentTags(ctx context.Context) map[string]string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	tags := map[string]string{"traceparent": "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-" + sc.TraceFlags().String()}
+	if state := sc.TraceState().String(); state != "" {
+		tags["tracestate"] = state
+	}
+	return tags
+}
@@ -0,0 +1,63 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	_, span := tracer.StartSpan(context.Background(), "query", "select * from users")
+	span.End(3, nil)
+	_, span = tracer.StartSpan(context.Background(), "exec", "delete from users")
+	span.End(0, errors.New("fail"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatal("expected 2 spans", len(spans))
+	}
+	if spans[0].Name() != "onedb.query" || spans[0].Status().Code == codes.Error {
+		t.Error("expected successful query span", spans[0].Name(), spans[0].Status())
+	}
+	attributes := attribute.NewSet(spans[0].Attributes()...)
+	if v, _ := attributes.Value("db.system"); v.AsString() != "postgresql" {
+		t.Error("expected db.system", v)
+	}
+	if v, _ := attributes.Value("db.statement"); v.AsString() != "select * from users" {
+		t.Error("expected db.statement", v)
+	}
+	if v, _ := attributes.Value("db.rows_affected"); v.AsInt64() != 3 {
+		t.Error("expected row count", v)
+	}
+	if spans[1].Status().Code != codes.Error || len(spans[1].Events()) != 1 {
+		t.Error("expected error to be recorded", spans[1].Status())
+	}
+}
+
+func TestNewTracerGlobal(t *testing.T) {
+	if NewTracer(nil) == nil {
+		t.Error("expected tracer from global provider")
+	}
+}
+
+func TestSQLCommentTags(t *testing.T) {
+	if tags := SQLCommentTags(context.Background()); tags != nil {
+		t.Error("expected no tags without a span", tags)
+	}
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+	sc := span.SpanContext()
+	expected := "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-01"
+	if tags := SQLCommentTags(ctx); tags["traceparent"] != expected || len(tags) != 1 {
+		t.Error("expected traceparent of the span", tags)
+	}
+}
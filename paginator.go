@@ -0,0 +1,156 @@
+package onedb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidPageToken occurs when a page token wasn't returned by the Paginator it is passed to
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// Paginator pages through the results of a query using keyset (seek) pagination. Each page starts after the key
+// values of the last row of the previous page, so later pages cost the same as the first unlike with OFFSET.
+// The key columns must be returned by the query, must not be NULL and together identify a row, for example
+// created_at, id
+type Paginator struct {
+	backend  Backender
+	query    *Query
+	pageSize int
+	keys     []string
+
+	// Descending pages from the highest keys to the lowest
+	Descending bool
+}
+
+// NewPaginator returns a Paginator returning pageSize rows at a time from query. query must not have its own
+// ORDER BY or LIMIT since pages are sorted by the keys. Its placeholders must be numbered like $1
+func NewPaginator(backend Backender, query *Query, pageSize int, keys ...string) *Paginator {
+	return &Paginator{backend: backend, query: query, pageSize: pageSize, keys: keys}
+}
+
+// Page returns the rows of the page following token along with the token of the next page. An empty token
+// returns the first page, and the next token is empty after the last page. Tokens are opaque and safe to pass
+// in a URL
+func (p *Paginator) Page(token string) (rows RowsScanner, next string, err error) {
+	if p.query == nil {
+		return nil, "", ErrQueryIsNil
+	}
+	if p.pageSize <= 0 || len(p.keys) == 0 {
+		return nil, "", errors.New("a Paginator needs a page size greater than zero and at least one key")
+	}
+	var after []interface{}
+	if token != "" {
+		if after, err = decodePageToken(token, len(p.keys)); err != nil {
+			return nil, "", err
+		}
+	}
+	query, args := p.pageQuery(after)
+	result, err := p.backend.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer result.Close()
+
+	page, err := p.readPage(result)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(page.rows) > p.pageSize {
+		page.rows = page.rows[:p.pageSize]
+		if next, err = p.nextToken(page); err != nil {
+			return nil, "", err
+		}
+	}
+	return page, next, nil
+}
+
+// pageQuery wraps the query so the keys can be referred to by their column names, and selects one extra row to
+// find out if there is a next page
+func (p *Paginator) pageQuery(after []interface{}) (string, []interface{}) {
+	order := " asc"
+	compare := " > "
+	if p.Descending {
+		order, compare = " desc", " < "
+	}
+	var b strings.Builder
+	b.WriteString("select * from (" + p.query.Query + ") as onedb_page")
+	args := append([]interface{}(nil), p.query.Args...)
+	if after != nil {
+		placeholders := make([]string, len(after))
+		for i := range after {
+			placeholders[i] = "$" + strconv.Itoa(len(args)+i+1)
+		}
+		b.WriteString(" where (" + strings.Join(p.keys, ", ") + ")" + compare + "(" + strings.Join(placeholders, ", ") + ")")
+		args = append(args, after...)
+	}
+	b.WriteString(" order by " + strings.Join(p.keys, order+", ") + order)
+	b.WriteString(" limit " + strconv.Itoa(p.pageSize+1))
+	return b.String(), args
+}
+
+func (p *Paginator) readPage(result RowsScanner) (*recordedRows, error) {
+	columns, vals, err := getColumnNamesAndValues(result, false)
+	if err != nil {
+		return nil, err
+	}
+	page := &recordedRows{columns: columns}
+	for result.Next() {
+		if err := result.Scan(vals...); err != nil {
+			return nil, err
+		}
+		row := make([]interface{}, len(vals))
+		for i, v := range vals {
+			row[i] = *v.(*interface{})
+		}
+		page.rows = append(page.rows, row)
+	}
+	return page, result.Err()
+}
+
+// nextToken encodes the key values of the page's last row
+func (p *Paginator) nextToken(page *recordedRows) (string, error) {
+	last := page.rows[len(page.rows)-1]
+	values := make([]interface{}, len(p.keys))
+	for i, key := range p.keys {
+		index := -1
+		for c, column := range page.columns {
+			if strings.EqualFold(column, key) {
+				index = c
+				break
+			}
+		}
+		if index == -1 {
+			return "", errors.Errorf("key %q isn't one of the query's columns", key)
+		}
+		values[i] = last[index]
+	}
+	encoded, err := encodeValues(values)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodePageToken(token string, keys int) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	var encoded []recordedValue
+	if err := json.Unmarshal(data, &encoded); err != nil || len(encoded) != keys {
+		return nil, ErrInvalidPageToken
+	}
+	values, err := decodeValues(encoded)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	return values, nil
+}
@@ -0,0 +1,68 @@
+package onedb
+
+import (
+	"testing"
+	"time"
+)
+
+type pageRow struct {
+	Created time.Time
+	ID      int
+	Name    string
+}
+
+func TestPaginator(t *testing.T) {
+	created := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	d := NewMock(nil, nil)
+	d.ExpectQuery("select * from (select created, id, name from users where active = $1) as onedb_page order by created asc, id asc limit 3").
+		WithArgs(true).WillReturnRows([]pageRow{{created, 1, "alice"}, {created, 2, "bob"}, {created, 3, "carol"}})
+	d.ExpectQuery("select * from (select created, id, name from users where active = $1) as onedb_page where (created, id) > ($2, $3) order by created asc, id asc limit 3").
+		WithArgs(true, created, int64(2)).WillReturnRows([]pageRow{{created, 3, "carol"}})
+
+	p := NewPaginator(d, NewQuery("select created, id, name from users where active = $1", true), 2, "created", "id")
+	rows, next, err := p.Page("")
+	if err != nil || next == "" {
+		t.Fatal("expected the first page and a next token", next, err)
+	}
+	var page []pageRow
+	if err := getStruct(rows, &page, StructOptions{}); err != nil || len(page) != 2 || page[1].Name != "bob" {
+		t.Error("expected page size rows", page, err)
+	}
+
+	rows, next, err = p.Page(next)
+	if err != nil || next != "" {
+		t.Fatal("expected the last page without a next token", next, err)
+	}
+	page = nil
+	if err := getStruct(rows, &page, StructOptions{}); err != nil || len(page) != 1 || page[0].ID != 3 {
+		t.Error("expected the remaining row", page, err)
+	}
+	d.VerifyExpectations(t)
+
+	if _, _, err := p.Page("not a token"); err != ErrInvalidPageToken {
+		t.Error("expected invalid token error", err)
+	}
+}
+
+func TestPaginatorDescending(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectQuery("select * from (select id, name from users) as onedb_page order by id desc limit 2").WillReturnRows([]pageRow{{ID: 3}, {ID: 2}})
+	p := NewPaginator(d, NewQuery("select id, name from users"), 1, "id")
+	p.Descending = true
+	_, next, err := p.Page("")
+	if err != nil || next == "" {
+		t.Fatal("expected a next token", err)
+	}
+	d.ExpectQuery("select * from (select id, name from users) as onedb_page where (id) < ($1) order by id desc limit 2").
+		WithArgs(int64(3)).WillReturnRows([]pageRow{{ID: 2}, {ID: 1}})
+	if _, _, err := p.Page(next); err != nil {
+		t.Error("expected the next page to seek below the last key", err)
+	}
+	d.VerifyExpectations(t)
+
+	p = NewPaginator(d, NewQuery("select id, name from users"), 1, "missing")
+	d.ExpectQuery("select * from (select id, name from users) as onedb_page order by missing asc limit 2").WillReturnRows([]SimpleData{{1, "alice"}, {2, "bob"}})
+	if _, _, err := p.Page(""); err == nil {
+		t.Error("expected error for a key which isn't a column")
+	}
+}
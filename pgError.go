@@ -0,0 +1,109 @@
+package onedb
+
+import "sync"
+
+// PgError is a PostgreSQL error in a form which doesn't depend on the driver, so applications can branch on
+// database errors portably. Code is the SQLSTATE, like 23505 for a unique violation. AsPgError converts the
+// errors of backends which have registered a converter
+type PgError struct {
+	Severity       string
+	Code           string
+	Message        string
+	Detail         string
+	Hint           string
+	SchemaName     string
+	TableName      string
+	ColumnName     string
+	ConstraintName string
+}
+
+func (e *PgError) Error() string {
+	return e.Severity + ": " + e.Message + " (SQLSTATE " + e.Code + ")"
+}
+
+// SQLSTATE codes of common errors
+const (
+	CodeNotNullViolation     = "23502"
+	CodeForeignKeyViolation  = "23503"
+	CodeUniqueViolation      = "23505"
+	CodeCheckViolation       = "23514"
+	CodeExclusionViolation   = "23P01"
+	CodeSerializationFailure = "40001"
+	CodeDeadlockDetected     = "40P01"
+)
+
+var pgErrorConverters struct {
+	sync.RWMutex
+	converters []func(err error) (*PgError, bool)
+}
+
+// RegisterPgErrorConverter adds a function which converts a driver's error into a PgError, reporting false for
+// errors it doesn't know. Backends register a converter for their driver's error type
+func RegisterPgErrorConverter(convert func(err error) (*PgError, bool)) {
+	pgErrorConverters.Lock()
+	defer pgErrorConverters.Unlock()
+	pgErrorConverters.converters = append(pgErrorConverters.converters, convert)
+}
+
+// AsPgError returns the PgError err is or wraps, following both Unwrap and github.com/pkg/errors' Cause
+func AsPgError(err error) (*PgError, bool) {
+	pgErrorConverters.RLock()
+	converters := pgErrorConverters.converters
+	pgErrorConverters.RUnlock()
+	for err != nil {
+		if pgErr, ok := err.(*PgError); ok {
+			return pgErr, true
+		}
+		for _, convert := range converters {
+			if pgErr, ok := convert(err); ok {
+				return pgErr, true
+			}
+		}
+		err = unwrapError(err)
+	}
+	return nil, false
+}
+
+// pgErrorCodeOf returns the SQLSTATE of err itself, without unwrapping it
+func pgErrorCodeOf(err error) string {
+	if pgErr, ok := err.(*PgError); ok {
+		return pgErr.Code
+	}
+	pgErrorConverters.RLock()
+	converters := pgErrorConverters.converters
+	pgErrorConverters.RUnlock()
+	for _, convert := range converters {
+		if pgErr, ok := convert(err); ok {
+			return pgErr.Code
+		}
+	}
+	return ""
+}
+
+// PgErrorCode returns the SQLSTATE of the PgError err is or wraps, or an empty string
+func PgErrorCode(err error) string {
+	if pgErr, ok := AsPgError(err); ok {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// IsUniqueViolation reports whether err is a violation of a unique constraint or index
+func IsUniqueViolation(err error) bool {
+	return PgErrorCode(err) == CodeUniqueViolation
+}
+
+// IsForeignKeyViolation reports whether err is a violation of a foreign key constraint
+func IsForeignKeyViolation(err error) bool {
+	return PgErrorCode(err) == CodeForeignKeyViolation
+}
+
+// IsNotNullViolation reports whether err is an attempt to store NULL in a NOT NULL column
+func IsNotNullViolation(err error) bool {
+	return PgErrorCode(err) == CodeNotNullViolation
+}
+
+// IsCheckViolation reports whether err is a violation of a check constraint
+func IsCheckViolation(err error) bool {
+	return PgErrorCode(err) == CodeCheckViolation
+}
@@ -0,0 +1,43 @@
+package onedb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+)
+
+type driverError struct {
+	code string
+}
+
+func (e driverError) Error() string {
+	return e.code
+}
+
+func TestAsPgError(t *testing.T) {
+	pgErr := &PgError{Code: CodeUniqueViolation, ConstraintName: "users_email_key"}
+	if e, ok := AsPgError(fmt.Errorf("insert: %w", pgErr)); !ok || e != pgErr || !IsUniqueViolation(pgErr) {
+		t.Error("expected a wrapped PgError", e)
+	}
+	if IsForeignKeyViolation(pgErr) || IsNotNullViolation(pgErr) || IsCheckViolation(pgErr) {
+		t.Error("expected other violations to be false")
+	}
+	if _, ok := AsPgError(errors.New("fail")); ok || PgErrorCode(nil) != "" {
+		t.Error("expected no PgError")
+	}
+
+	RegisterPgErrorConverter(func(err error) (*PgError, bool) {
+		if e, ok := err.(driverError); ok {
+			return &PgError{Code: e.code}, true
+		}
+		return nil, false
+	})
+	defer func() {
+		pgErrorConverters.converters = pgErrorConverters.converters[:len(pgErrorConverters.converters)-1]
+	}()
+	if !IsForeignKeyViolation(pkgerrors.Wrap(driverError{CodeForeignKeyViolation}, "insert")) {
+		t.Error("expected a driver's error converted through Cause")
+	}
+}
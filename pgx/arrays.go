@@ -0,0 +1,125 @@
+package pgx
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// isArrayType reports whether a column is an array. Postgres names array types after their element type
+// with a leading underscore
+func isArrayType(field pgx.FieldDescription) bool {
+	return strings.HasPrefix(field.DataTypeName, "_")
+}
+
+// decodeArrays parses in place the arrays pgx returns as text, like uuid[] and numeric[], into slices of their
+// elements as strings, or nil for NULL. pgx already decodes the arrays of its built in types
+func decodeArrays(fields []pgx.FieldDescription, values []interface{}) error {
+	for i := 0; i < len(fields) && i < len(values); i++ {
+		field := fields[i]
+		text, ok := values[i].(string)
+		if !ok || !isArrayType(field) {
+			continue
+		}
+		elements, err := onedb.ParseArray(text)
+		if err != nil {
+			return errors.Wrapf(err, "Unable to parse array column %s", field.Name)
+		}
+		values[i] = elements
+	}
+	return nil
+}
+
+// arrayArg formats a slice argument pgx can't encode itself, like []int or a slice of UUIDs, as an array literal.
+// Array literals are sent as text, which the server parses as whatever array type the parameter has
+func arrayArg(arg interface{}) (string, bool, error) {
+	switch arg.(type) {
+	case nil, pgx.Encoder, driver.Valuer, []byte, [][]byte, []string, []bool, []int16, []uint16, []int32, []uint32,
+		[]int64, []uint64, []float32, []float64, []time.Time, []net.IP, []net.IPNet, []pgx.AclItem:
+		return "", false, nil
+	}
+	value := reflect.ValueOf(arg)
+	if value.Kind() != reflect.Slice {
+		return "", false, nil
+	}
+	if value.IsNil() {
+		return "", false, nil // sent as NULL
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i < value.Len(); i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		text, null, err := arrayElementText(value.Index(i).Interface())
+		if err != nil {
+			return "", false, errors.Wrapf(err, "Unable to format array element %d", i+1)
+		}
+		if null {
+			b.WriteString("NULL")
+			continue
+		}
+		b.WriteByte('"')
+		for _, r := range text {
+			if r == '"' || r == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String(), true, nil
+}
+
+// arrayElementText formats an element of an array argument the way the server parses it as text
+func arrayElementText(element interface{}) (text string, null bool, err error) {
+	if t, ok := registeredByGoType(element); ok {
+		text, err = t.codec.Encode(element)
+		return text, false, err
+	}
+	switch e := element.(type) {
+	case nil:
+		return "", true, nil
+	case driver.Valuer:
+		v, err := e.Value()
+		if err != nil {
+			return "", false, err
+		}
+		return arrayElementText(v)
+	case encoding.TextMarshaler:
+		data, err := e.MarshalText()
+		return string(data), false, err
+	case string:
+		return e, false, nil
+	case []byte:
+		return `\x` + hex.EncodeToString(e), false, nil
+	case bool:
+		if e {
+			return "t", false, nil
+		}
+		return "f", false, nil
+	case fmt.Stringer:
+		return e.String(), false, nil
+	}
+	value := reflect.ValueOf(element)
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return "", true, nil
+		}
+		return arrayElementText(value.Elem().Interface())
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return "", false, errors.Errorf("cannot format %T as an array element", element)
+	}
+	return fmt.Sprint(element), false, nil
+}
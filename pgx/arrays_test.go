@@ -0,0 +1,54 @@
+package pgx
+
+import (
+	"reflect"
+	"testing"
+
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+type arrayUUID [2]byte
+
+func (u arrayUUID) MarshalText() ([]byte, error) {
+	return []byte{'0' + u[0], '0' + u[1]}, nil
+}
+
+func TestArrayArgs(t *testing.T) {
+	s := "b"
+	encoded, err := encodeArgs([]interface{}{[]int{1, 2}, []*string{&s, nil}, []arrayUUID{{1, 2}}, []interface{}{`a"\`, true}})
+	expected := []interface{}{`{"1","2"}`, `{"b",NULL}`, `{"12"}`, `{"a\"\\","t"}`}
+	if err != nil || !reflect.DeepEqual(encoded, expected) {
+		t.Error("expected slices formatted as array literals", encoded, err)
+	}
+
+	args := []interface{}{[]int64{1}, []string{"a"}, []byte("a"), []int(nil)}
+	if encoded, err := encodeArgs(args); err != nil || &encoded[0] != &args[0] {
+		t.Error("expected slices pgx encodes and nil slices left alone", encoded, err)
+	}
+	if _, err := encodeArgs([]interface{}{[][]int{{1}}}); err == nil {
+		t.Error("expected error for a multidimensional slice")
+	}
+}
+
+func TestPgxRowsArrays(t *testing.T) {
+	m := newMockPgxRows()
+	m.Fields = []pgx.FieldDescription{{Name: "ids", DataTypeName: "_citext"}, {Name: "counts", DataTypeName: "_int4"}}
+	m.ValuesData = []interface{}{`{a,"b c",NULL}`, []int32{1, 2}}
+	r := &pgxRows{rows: m}
+	values, err := r.Values()
+	if err != nil || !reflect.DeepEqual(values, []interface{}{[]interface{}{"a", "b c", nil}, []int32{1, 2}}) {
+		t.Error("expected text arrays parsed into their elements", values, err)
+	}
+
+	m.ValuesData = []interface{}{`{a,b}`, []int32{1, 2}}
+	var ids []string
+	var counts []int64
+	if err := r.Scan(&ids, &counts); err != nil || !reflect.DeepEqual(ids, []string{"a", "b"}) || !reflect.DeepEqual(counts, []int64{1, 2}) {
+		t.Error("expected arrays scanned into slices", ids, counts, err)
+	}
+
+	m.ValuesData = []interface{}{`{a`, nil}
+	if _, err := r.Values(); err == nil || len(m.MethodsCalled["Fatal"]) == 0 {
+		t.Error("expected an invalid array to fail the rows", err)
+	}
+}
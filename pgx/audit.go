@@ -0,0 +1,380 @@
+package pgx
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// AuditRecord describes a statement which may have modified data
+type AuditRecord struct {
+	Time        time.Time // when the statement started
+	Operation   string    // exec or copy
+	Fingerprint string    // the statement's onedb.QueryFingerprint, so argument values aren't recorded. Empty for copy
+	Table       string    // the table modified, schema qualified if it was in the statement. Empty if unknown
+	Rows        int64     // rows affected
+	Actor       string    // from WithAuditActor
+	Err         error
+}
+
+// AuditSink stores audit records, e.g. in a table, a file or a Kafka topic. A Kafka producer can be adapted with
+// AuditSinkFunc
+type AuditSink interface {
+	WriteAudit(ctx context.Context, record AuditRecord) error
+}
+
+// AuditSinkFunc is an adapter to allow the use of an ordinary function as an AuditSink
+type AuditSinkFunc func(ctx context.Context, record AuditRecord) error
+
+// WriteAudit calls f(ctx, record)
+func (f AuditSinkFunc) WriteAudit(ctx context.Context, record AuditRecord) error {
+	return f(ctx, record)
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a context whose statements are recorded as run by actor, e.g. the signed in user
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActor returns the actor added to ctx with WithAuditActor
+func AuditActor(ctx context.Context) string {
+	actor, _ := ctx.Value(auditActorKey{}).(string)
+	return actor
+}
+
+// Audit returns db with every Exec, CopyFrom and BulkInsert, including those of prepared statements and
+// transactions, recorded to sink once it finishes. Failed statements are recorded with their error. When the
+// statement succeeds but the sink fails, the sink's error is returned so a transaction can be rolled back rather
+// than commit changes which weren't audited. Queries are not recorded, so a statement which writes
+// and returns rows, like INSERT ... RETURNING, must be run with Exec to be audited.
+//
+// The actor is read from the statement's context, or for statements without one, such as CopyFrom, from the
+// context the transaction was begun with
+func Audit(db PGXer, sink AuditSink) PGXer {
+	return &auditBackend{PGXer: db, auditor: auditor{sink: sink}}
+}
+
+// auditor records statements to sink, using actor when their context has none
+type auditor struct {
+	sink  AuditSink
+	actor string
+}
+
+type auditBackend struct {
+	PGXer
+	auditor
+}
+
+func (b *auditBackend) Begin() (Txer, error) {
+	return b.tx(b.PGXer.Begin())
+}
+
+func (b *auditBackend) BeginContext(ctx context.Context) (Txer, error) {
+	tx, err := b.PGXer.BeginContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &auditTx{Txer: tx, auditor: auditor{sink: b.sink, actor: AuditActor(ctx)}}, nil
+}
+
+func (b *auditBackend) BeginTx(opts TxOptions) (Txer, error) {
+	return b.tx(b.PGXer.BeginTx(opts))
+}
+
+func (b *auditBackend) Exec(query string, args ...interface{}) (CommandTag, error) {
+	return b.ExecContext(context.Background(), query, args...)
+}
+
+func (b *auditBackend) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	return b.exec(ctx, query, func() (CommandTag, error) {
+		return b.PGXer.ExecContext(ctx, query, args...)
+	})
+}
+
+func (b *auditBackend) CopyFrom(tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int, error) {
+	return b.copy(tableName, func() (int, error) {
+		return b.PGXer.CopyFrom(tableName, columnNames, rowSrc)
+	})
+}
+
+// BulkInsert runs its statements on a transaction begun for it, so each statement is recorded
+func (b *auditBackend) BulkInsert(tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	tx, err := b.Begin()
+	if err != nil {
+		return 0, err
+	}
+	return bulkInsertTx(tx, tableName, columnNames, rows, suffix)
+}
+
+func (b *auditBackend) Prepare(name, sql string) (Stmt, error) {
+	return b.stmt(b.PGXer.Prepare(name, sql))
+}
+
+// auditTx records the statements of a transaction and the nested transactions it begins
+type auditTx struct {
+	Txer
+	auditor
+}
+
+func (t *auditTx) Begin() (Txer, error) {
+	return t.tx(t.Txer.Begin())
+}
+
+func (t *auditTx) Exec(query string, args ...interface{}) (CommandTag, error) {
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+func (t *auditTx) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	return t.exec(ctx, query, func() (CommandTag, error) {
+		return t.Txer.ExecContext(ctx, query, args...)
+	})
+}
+
+func (t *auditTx) CopyFrom(tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int, error) {
+	return t.copy(tableName, func() (int, error) {
+		return t.Txer.CopyFrom(tableName, columnNames, rowSrc)
+	})
+}
+
+func (t *auditTx) BulkInsert(tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error) {
+	return bulkInsert(t, tableName, columnNames, rows, suffix)
+}
+
+func (t *auditTx) Prepare(name, sql string) (Stmt, error) {
+	return t.stmt(t.Txer.Prepare(name, sql))
+}
+
+// auditStmt records the statement's Exec under its SQL rather than its name
+type auditStmt struct {
+	Stmt
+	auditor
+}
+
+func (s *auditStmt) Exec(args ...interface{}) (CommandTag, error) {
+	return s.exec(context.Background(), s.SQL(), func() (CommandTag, error) {
+		return s.Stmt.Exec(args...)
+	})
+}
+
+func (a auditor) tx(tx Txer, err error) (Txer, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &auditTx{Txer: tx, auditor: a}, nil
+}
+
+func (a auditor) stmt(stmt Stmt, err error) (Stmt, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &auditStmt{Stmt: stmt, auditor: a}, nil
+}
+
+func (a auditor) actorFrom(ctx context.Context) string {
+	if actor := AuditActor(ctx); actor != "" {
+		return actor
+	}
+	return a.actor
+}
+
+func (a auditor) exec(ctx context.Context, query string, run func() (CommandTag, error)) (CommandTag, error) {
+	record := AuditRecord{Time: time.Now(), Operation: opExec, Fingerprint: onedb.QueryFingerprint(query), Table: auditTable(query), Actor: a.actorFrom(ctx)}
+	tag, err := run()
+	record.Rows, record.Err = tag.RowsAffected(), err
+	return tag, a.write(ctx, record)
+}
+
+func (a auditor) copy(tableName Identifier, run func() (int, error)) (int, error) {
+	record := AuditRecord{Time: time.Now(), Operation: opCopy, Table: strings.Join(tableName, "."), Actor: a.actor}
+	count, err := run()
+	record.Rows, record.Err = int64(count), err
+	return count, a.write(context.Background(), record)
+}
+
+// write writes record to the sink and returns the statement's error, or the sink's when the statement succeeded
+func (a auditor) write(ctx context.Context, record AuditRecord) error {
+	err := a.sink.WriteAudit(ctx, record)
+	if record.Err != nil {
+		return record.Err
+	}
+	return errors.Wrap(err, "Unable to write audit record")
+}
+
+// auditTable returns the table modified by an insert, update, delete, merge or truncate statement, or "" for
+// anything else, including statements starting with a with clause
+func auditTable(query string) string {
+	words := sqlWords(query, 5)
+	if len(words) < 2 {
+		return ""
+	}
+	var rest []string
+	switch strings.ToLower(words[0]) {
+	case "insert", "merge":
+		if strings.ToLower(words[1]) != "into" {
+			return ""
+		}
+		rest = words[2:]
+	case "delete":
+		if strings.ToLower(words[1]) != "from" {
+			return ""
+		}
+		rest = words[2:]
+	case "update":
+		rest = words[1:]
+	case "truncate":
+		rest = words[1:]
+		if len(rest) > 0 && strings.ToLower(rest[0]) == "table" {
+			rest = rest[1:]
+		}
+	default:
+		return ""
+	}
+	if len(rest) > 0 && strings.ToLower(rest[0]) == "only" {
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return ""
+	}
+	return unquoteName(rest[0])
+}
+
+// sqlWords returns up to n of the leading words of query, skipping comments. A word is a run of characters up to
+// whitespace, a comment, an opening parenthesis, a comma or a semicolon, so a quoted or schema qualified name is one word
+func sqlWords(query string, n int) []string {
+	var words []string
+	for i := 0; i < len(query) && len(words) < n; {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ',' || c == ';':
+			i++
+		case strings.HasPrefix(query[i:], "--"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(query)
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(query)
+			}
+		default:
+			start := i
+			for quoted := false; i < len(query); i++ {
+				if query[i] == '"' {
+					quoted = !quoted
+				} else if !quoted && strings.IndexByte(" \t\n\r(,;", query[i]) >= 0 {
+					break
+				}
+			}
+			words = append(words, query[start:i])
+		}
+	}
+	return words
+}
+
+// unquoteName removes the double quotes from a possibly schema qualified name, e.g. "My Schema".users
+func unquoteName(name string) string {
+	var unquoted strings.Builder
+	quoted := false
+	for i := 0; i < len(name); i++ {
+		switch {
+		case name[i] == '"' && quoted && i+1 < len(name) && name[i+1] == '"':
+			unquoted.WriteByte('"')
+			i++
+		case name[i] == '"':
+			quoted = !quoted
+		case quoted:
+			unquoted.WriteByte(name[i])
+		default:
+			unquoted.WriteString(strings.ToLower(name[i : i+1]))
+		}
+	}
+	return unquoted.String()
+}
+
+// auditWriter writes audit records to w as JSON lines
+type auditWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewAuditWriter returns an AuditSink writing each record to w as a line of JSON, e.g. to an append-only file.
+// It is safe for concurrent use
+func NewAuditWriter(w io.Writer) AuditSink {
+	return &auditWriter{w: w}
+}
+
+type auditJSON struct {
+	Time        time.Time `json:"time"`
+	Operation   string    `json:"operation"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Table       string    `json:"table,omitempty"`
+	Rows        int64     `json:"rows"`
+	Actor       string    `json:"actor,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+func (a *auditWriter) WriteAudit(ctx context.Context, record AuditRecord) error {
+	entry := auditJSON{Time: record.Time, Operation: record.Operation, Fingerprint: record.Fingerprint, Table: record.Table, Rows: record.Rows, Actor: record.Actor}
+	if record.Err != nil {
+		entry.Error = record.Err.Error()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.w.Write(append(line, '\n'))
+	return err
+}
+
+// auditTableSink inserts audit records into a table
+type auditTableSink struct {
+	db    querier
+	query string
+}
+
+// NewAuditTableSink returns an AuditSink inserting records into table, which can be created with AuditTableSQL.
+// db must not be the audited backend, or each insert would be audited in turn. Records are inserted on their own
+// connection, so they are kept even when the audited transaction is rolled back
+func NewAuditTableSink(db PGXer, table Identifier) AuditSink {
+	return &auditTableSink{db: db, query: "insert into " + pgx.Identifier(table).Sanitize() +
+		" (at, operation, fingerprint, table_name, rows_affected, actor, error) values ($1, $2, $3, $4, $5, $6, $7)"}
+}
+
+func (s *auditTableSink) WriteAudit(ctx context.Context, record AuditRecord) error {
+	var errText *string
+	if record.Err != nil {
+		text := record.Err.Error()
+		errText = &text
+	}
+	_, err := s.db.ExecContext(ctx, s.query, record.Time, record.Operation, record.Fingerprint, record.Table, record.Rows, record.Actor, errText)
+	return err
+}
+
+// AuditTableSQL returns the create table statement for the table NewAuditTableSink inserts into
+func AuditTableSQL(table Identifier) string {
+	return "create table if not exists " + pgx.Identifier(table).Sanitize() + " (" +
+		"id bigserial primary key, " +
+		"at timestamptz not null, " +
+		"operation text not null, " +
+		"fingerprint text not null, " +
+		"table_name text not null, " +
+		"rows_affected bigint not null, " +
+		"actor text not null, " +
+		"error text)"
+}
@@ -0,0 +1,122 @@
+package pgx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.ExpectExec("update users set name = $1 where id = $2").WithArgs("alice", 1)
+	m.ExpectBegin()
+	m.ExpectExec("delete from public.sessions where user_id = 7")
+	m.ExpectCommit()
+
+	var records []AuditRecord
+	db := Audit(m, AuditSinkFunc(func(ctx context.Context, record AuditRecord) error {
+		records = append(records, record)
+		return nil
+	}))
+	ctx := WithAuditActor(context.Background(), "admin")
+	if _, err := db.ExecContext(ctx, "update users set name = $1 where id = $2", "alice", 1); err != nil {
+		t.Error("expected success", err)
+	}
+	tx, _ := db.BeginContext(WithAuditActor(context.Background(), "importer"))
+	tx.Exec("delete from public.sessions where user_id = 7")
+	tx.CopyFrom(Identifier{"public", "events"}, []string{"id"}, CopyFromRows([][]interface{}{{1}, {2}}))
+	tx.Commit()
+	db.QueryRow("select 1")
+	m.VerifyExpectations(t)
+
+	if len(records) != 3 {
+		t.Fatal("expected the execs and the copy to be recorded", records)
+	}
+	if r := records[0]; r.Operation != "exec" || r.Fingerprint != "update users set name = $1 where id = $2" || r.Table != "users" || r.Actor != "admin" || r.Err != nil {
+		t.Error("expected exec record", r)
+	}
+	if r := records[1]; r.Fingerprint != "delete from public.sessions where user_id = ?" || r.Table != "public.sessions" || r.Actor != "importer" {
+		t.Error("expected the transaction's actor and a fingerprint without literals", r)
+	}
+	if r := records[2]; r.Operation != "copy" || r.Table != "public.events" || r.Rows != 2 || r.Actor != "importer" {
+		t.Error("expected copy record", r)
+	}
+}
+
+func TestAuditErrors(t *testing.T) {
+	fail := errors.New("fail")
+	m := NewMock(nil, nil)
+	m.ExpectExec("insert into users (name) values ($1)").WillReturnError(fail)
+	m.ExpectExec("insert into users (name) values ($1)")
+
+	var records []AuditRecord
+	sinkErr := errors.New("sink unavailable")
+	db := Audit(m, AuditSinkFunc(func(ctx context.Context, record AuditRecord) error {
+		records = append(records, record)
+		return sinkErr
+	}))
+	if _, err := db.Exec("insert into users (name) values ($1)", "alice"); err != fail {
+		t.Error("expected the statement's error", err)
+	}
+	if _, err := db.Exec("insert into users (name) values ($1)", "bob"); err == nil || err.Error() != "Unable to write audit record: sink unavailable" {
+		t.Error("expected the sink's error after a successful statement", err)
+	}
+	if len(records) != 2 || records[0].Err != fail || records[1].Err != nil {
+		t.Error("expected both statements to be recorded", records)
+	}
+}
+
+func TestAuditTable(t *testing.T) {
+	tests := map[string]string{
+		"insert into users (name) values ($1)":        "users",
+		"INSERT INTO Public.Users VALUES (1)":         "public.users",
+		`update "My Schema"."Users" set a = 1`:        "My Schema.Users",
+		"delete from only accounts where id = $1":     "accounts",
+		"/* app */ truncate table logs":               "logs",
+		"-- cleanup\ntruncate sessions, tokens":       "sessions",
+		"merge into stock s using deliveries d on 1":  "stock",
+		"with moved as (delete from a) insert into b": "",
+		"create table t (id int)":                     "",
+		"delete":                                      "",
+	}
+	for query, expected := range tests {
+		if table := auditTable(query); table != expected {
+			t.Errorf("expected %q for %q, got %q", expected, query, table)
+		}
+	}
+}
+
+func TestNewAuditWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewAuditWriter(&buf)
+	w.WriteAudit(context.Background(), AuditRecord{Operation: "exec", Fingerprint: "delete from t", Table: "t", Rows: 3, Actor: "admin"})
+	w.WriteAudit(context.Background(), AuditRecord{Operation: "copy", Table: "t", Err: errors.New("fail")})
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatal("expected a line per record", buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(lines[0], &entry); err != nil || entry["fingerprint"] != "delete from t" || entry["rows"] != 3.0 || entry["actor"] != "admin" || entry["error"] != nil {
+		t.Error("expected record as JSON", string(lines[0]), err)
+	}
+	if err := json.Unmarshal(lines[1], &entry); err != nil || entry["error"] != "fail" {
+		t.Error("expected error text", string(lines[1]), err)
+	}
+}
+
+func TestAuditTableSink(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.ExpectExec(`insert into "audit"."log" (at, operation, fingerprint, table_name, rows_affected, actor, error) values ($1, $2, $3, $4, $5, $6, $7)`)
+	sink := NewAuditTableSink(m, Identifier{"audit", "log"})
+	if err := sink.WriteAudit(context.Background(), AuditRecord{Operation: "exec", Table: "users"}); err != nil {
+		t.Error("expected success", err)
+	}
+	m.VerifyExpectations(t)
+
+	if sql := AuditTableSQL(Identifier{"audit", "log"}); sql[:42] != `create table if not exists "audit"."log" (` {
+		t.Error("expected create table statement", sql)
+	}
+}
@@ -0,0 +1,102 @@
+package pgx
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// maxBulkInsertParams is the most parameters PostgreSQL allows in one statement
+const maxBulkInsertParams = 65535
+
+// bulkInsert inserts rows with multi-row VALUES statements on q, each with as many rows as fit under the
+// parameter limit, and returns the total rows affected
+func bulkInsert(q querier, tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error) {
+	if len(columnNames) == 0 {
+		return 0, errors.New("BulkInsert needs at least one column")
+	}
+	for i, row := range rows {
+		if len(row) != len(columnNames) {
+			return 0, errors.Errorf("row %d has %d values for %d columns", i, len(row), len(columnNames))
+		}
+	}
+	columns := make([]string, len(columnNames))
+	for i, name := range columnNames {
+		columns[i] = pgx.Identifier{name}.Sanitize()
+	}
+	prefix := "insert into " + pgx.Identifier(tableName).Sanitize() + " (" + strings.Join(columns, ", ") + ") values "
+	chunkSize := maxBulkInsertParams / len(columnNames)
+
+	var affected int64
+	for start := 0; start < len(rows); start += chunkSize {
+		end := start + chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		query, args := bulkInsertStatement(prefix, rows[start:end], suffix)
+		tag, err := q.Exec(query, args...)
+		if err != nil {
+			return affected, err
+		}
+		affected += tag.RowsAffected()
+	}
+	return affected, nil
+}
+
+func bulkInsertStatement(prefix string, rows [][]interface{}, suffix string) (string, []interface{}) {
+	var b strings.Builder
+	b.WriteString(prefix)
+	args := make([]interface{}, 0, len(rows)*len(rows[0]))
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j, value := range row {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			args = append(args, value)
+			b.WriteString("$" + strconv.Itoa(len(args)))
+		}
+		b.WriteByte(')')
+	}
+	if suffix != "" {
+		b.WriteString(" " + suffix)
+	}
+	return b.String(), args
+}
+
+// BulkInsert inserts rows into tableName with multi-row INSERT statements, split into as many statements as are
+// needed to stay under PostgreSQL's parameter limit. suffix is added to each statement, e.g. "on conflict (id) do
+// nothing", which CopyFrom can't do. The statements run in a new transaction, so either all rows are inserted or
+// none are. It returns the total rows affected
+func (b *pgxBackend) BulkInsert(tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	tx, err := b.Begin()
+	if err != nil {
+		return 0, err
+	}
+	return bulkInsertTx(tx, tableName, columnNames, rows, suffix)
+}
+
+// BulkInsert inserts rows into tableName with multi-row INSERT statements, split into as many statements as are
+// needed to stay under PostgreSQL's parameter limit. suffix is added to each statement, e.g. "on conflict (id) do
+// nothing". It returns the total rows affected
+func (t *pgxTx) BulkInsert(tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error) {
+	return bulkInsert(t, tableName, columnNames, rows, suffix)
+}
+
+// bulkInsertTx runs bulkInsert in a transaction begun for it, committing it if every statement succeeds
+func bulkInsertTx(tx Txer, tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error) {
+	affected, err := bulkInsert(tx, tableName, columnNames, rows, suffix)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return affected, tx.Commit()
+}
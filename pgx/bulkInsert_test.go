@@ -0,0 +1,52 @@
+package pgx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+func TestBulkInsert(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.ExpectBegin()
+	m.ExpectExec(`insert into "users" ("id", "name") values ($1, $2), ($3, $4) on conflict (id) do nothing`).WithArgs(1, "alice", 2, "bob")
+	m.ExpectCommit()
+	if _, err := m.BulkInsert(Identifier{"users"}, []string{"id", "name"}, [][]interface{}{{1, "alice"}, {2, "bob"}}, "on conflict (id) do nothing"); err != nil {
+		t.Error("expected success", err)
+	}
+	m.VerifyExpectations(t)
+
+	if _, err := m.BulkInsert(Identifier{"users"}, []string{"id", "name"}, [][]interface{}{{1}}, ""); err == nil {
+		t.Error("expected error for a row without a value for each column")
+	}
+}
+
+func TestBulkInsertChunks(t *testing.T) {
+	rows := make([][]interface{}, maxBulkInsertParams/2+1)
+	for i := range rows {
+		rows[i] = []interface{}{i, "name"}
+	}
+	m := NewMock(nil, nil)
+	m.SetQueryMatcher(onedb.QueryMatcherRegexp)
+	m.ExpectBegin()
+	m.ExpectExec(`^insert into "users" \("id", "name"\) values \(\$1, \$2\), .*\(\$65533, \$65534\)$`)
+	m.ExpectExec(`^insert into "users" \("id", "name"\) values \(\$1, \$2\)$`).WithArgs(maxBulkInsertParams/2, "name")
+	m.ExpectCommit()
+	tx, _ := m.Begin()
+	if _, err := tx.BulkInsert(Identifier{"users"}, []string{"id", "name"}, rows, ""); err != nil {
+		t.Error("expected success", err)
+	}
+	tx.Commit()
+	m.VerifyExpectations(t)
+
+	fail := errors.New("fail")
+	m = NewMock(nil, nil)
+	m.ExpectBegin()
+	m.ExpectExec(`insert into "users" ("id") values ($1)`).WillReturnError(fail)
+	m.ExpectRollback()
+	if _, err := m.BulkInsert(Identifier{"users"}, []string{"id"}, [][]interface{}{{1}}, ""); err != fail {
+		t.Error("expected the insert to be rolled back with its error", err)
+	}
+	m.VerifyExpectations(t)
+}
@@ -0,0 +1,62 @@
+package pgx
+
+import (
+	"context"
+	"strings"
+
+	"github.com/EndFirstCorp/onedb"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// Listener subscribes to LISTEN/NOTIFY channels. It is implemented by PGXer
+type Listener interface {
+	Listen(ctx context.Context, channel string) (<-chan *Notification, error)
+}
+
+// InvalidateOnNotify listens on channel and drops the results in cache of queries which mention the tables named
+// in each notification's payload, separated by commas, until ctx is done. An empty payload purges the cache.
+// CacheInvalidationTrigger creates triggers which send these notifications. Notifications sent while the
+// listening connection reconnects are lost, so keep a TTL on the cache as well
+func InvalidateOnNotify(ctx context.Context, listener Listener, channel string, cache onedb.QueryCache) error {
+	notifications, err := listener.Listen(ctx, channel)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for n := range notifications {
+			invalidate(cache, n.Payload)
+		}
+	}()
+	return nil
+}
+
+func invalidate(cache onedb.QueryCache, payload string) {
+	if strings.TrimSpace(payload) == "" {
+		cache.Purge()
+		return
+	}
+	for _, table := range strings.Split(payload, ",") {
+		if table = strings.TrimSpace(table); table != "" {
+			cache.InvalidateTable(table)
+		}
+	}
+}
+
+// cacheInvalidationFunction is the trigger function run by CacheInvalidationTrigger's triggers. It sends the
+// changed table's name on the channel passed as the trigger's argument
+const cacheInvalidationFunction = `create or replace function onedb_notify_table_change() returns trigger language plpgsql as $$
+begin
+	perform pg_notify(TG_ARGV[0], TG_TABLE_NAME);
+	return null;
+end
+$$`
+
+// CacheInvalidationTrigger returns the statements, to run with Exec, which notify channel with the table's name
+// after every statement which changes table, for InvalidateOnNotify
+func CacheInvalidationTrigger(channel string, table Identifier) string {
+	name := pgx.Identifier(table).Sanitize()
+	return cacheInvalidationFunction + ";\n" +
+		"drop trigger if exists onedb_cache_invalidation on " + name + ";\n" +
+		"create trigger onedb_cache_invalidation after insert or update or delete or truncate on " + name +
+		" for each statement execute procedure onedb_notify_table_change('" + strings.Replace(channel, "'", "''", -1) + "')"
+}
@@ -0,0 +1,65 @@
+package pgx
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+func TestInvalidateOnNotify(t *testing.T) {
+	cache := onedb.NewQueryCache(onedb.QueryCacheOptions{})
+	db := onedb.Intercept(onedb.NewMock(nil, nil, []interceptorData{{1}}, []interceptorData{{2}}, []interceptorData{{3}}), cache.Interceptor())
+	var rows []interceptorData
+	db.QueryStruct(&rows, "select * from users")
+	db.QueryStruct(&rows, "select * from roles")
+	db.QueryStruct(&rows, "select * from settings")
+
+	notifications := make(chan *Notification)
+	listener := &mockListener{notifications: notifications}
+	if err := InvalidateOnNotify(context.Background(), listener, "cache", cache); err != nil || listener.channel != "cache" {
+		t.Fatal("expected to listen on channel", listener.channel, err)
+	}
+	notifications <- &Notification{Channel: "cache", Payload: "users, roles"}
+	close(notifications)
+	for deadline := time.Now().Add(time.Second); cache.Len() != 1 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if cache.Len() != 1 {
+		t.Error("expected queries on the notified tables to be invalidated", cache.Len())
+	}
+
+	invalidate(cache, "")
+	if cache.Len() != 0 {
+		t.Error("expected empty payload to purge cache", cache.Len())
+	}
+
+	fail := errors.New("fail")
+	if err := InvalidateOnNotify(context.Background(), &mockListener{err: fail}, "cache", cache); err != fail {
+		t.Error("expected listen error", err)
+	}
+}
+
+func TestCacheInvalidationTrigger(t *testing.T) {
+	sql := CacheInvalidationTrigger("it's", Identifier{"public", "users"})
+	if !strings.Contains(sql, `on "public"."users" for each statement execute procedure onedb_notify_table_change('it''s')`) ||
+		!strings.HasPrefix(sql, "create or replace function onedb_notify_table_change()") {
+		t.Error("expected trigger statements", sql)
+	}
+}
+
+/***************************** MOCKS ****************************/
+
+type mockListener struct {
+	notifications chan *Notification
+	err           error
+	channel       string
+}
+
+func (l *mockListener) Listen(ctx context.Context, channel string) (<-chan *Notification, error) {
+	l.channel = channel
+	return l.notifications, l.err
+}
@@ -0,0 +1,29 @@
+package pgx
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCanceled is returned by statements stopped with the CancelFunc from WithCancel
+var ErrCanceled = errors.New("query canceled")
+
+// WithCancel returns a context for the Context methods, e.g. QueryContext or ExecContext, and a function which
+// cancels the statement running with it from another goroutine, such as for a "stop query" button. A cancel
+// request is sent to the server and the statement returns ErrCanceled rather than context.Canceled, to tell
+// the user's request apart from a parent context being done
+func WithCancel(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	return ctx, func() { cancel(ErrCanceled) }
+}
+
+// canceled returns the error for a done ctx: ErrCanceled if it was canceled through WithCancel, or else the
+// context's own error. It returns nil while ctx isn't done
+func canceled(ctx context.Context) error {
+	err := ctx.Err()
+	if err != nil && context.Cause(ctx) == ErrCanceled {
+		return ErrCanceled
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithCancel(t *testing.T) {
+	ctx, cancel := WithCancel(context.Background())
+	if canceled(ctx) != nil {
+		t.Error("expected no error before cancel")
+	}
+	cancel()
+	if err := canceled(ctx); err != ErrCanceled {
+		t.Error("expected ErrCanceled", err)
+	}
+	if err := contextErr(ctx, errors.New("canceling statement due to user request")); err != ErrCanceled {
+		t.Error("expected statement error to be replaced", err)
+	}
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel = WithCancel(parent)
+	defer cancel()
+	cancelParent()
+	if err := canceled(ctx); err != context.Canceled {
+		t.Error("expected parent's error", err)
+	}
+
+	b := &pgxWithReconnect{}
+	ctx, cancel = WithCancel(context.Background())
+	cancel()
+	if _, err := b.QueryContext(ctx, "query"); err != ErrCanceled {
+		t.Error("expected ErrCanceled", err)
+	}
+	if _, err := b.ExecContext(ctx, "query"); err != ErrCanceled {
+		t.Error("expected ErrCanceled", err)
+	}
+}
+
+func TestMockWithCancel(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.InjectFault("update users set active = false").WillDelayFor(time.Minute)
+	ctx, cancel := WithCancel(context.Background())
+	go func() {
+		time.Sleep(time.Millisecond)
+		cancel()
+	}()
+	if _, err := m.ExecContext(ctx, "update users set active = false"); err != ErrCanceled {
+		t.Error("expected running statement to be canceled", err)
+	}
+	if err := m.QueryRowContext(ctx, "select 1").Scan(); err != ErrCanceled {
+		t.Error("expected ErrCanceled", err)
+	}
+	if _, err := m.QueryContext(ctx, "select 1"); err != ErrCanceled {
+		t.Error("expected ErrCanceled", err)
+	}
+}
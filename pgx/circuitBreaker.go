@@ -0,0 +1,101 @@
+package pgx
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen occurs when the circuit breaker is open and statements fail without reaching the database.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerConfig configures a circuit breaker which opens after FailureThreshold consecutive connection
+// failures. While open, statements fail fast with ErrCircuitOpen. Once CoolDown has passed, the next statement
+// first pings the database and closes the breaker if the ping succeeds
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // defaults to 5
+	CoolDown         time.Duration // defaults to 10 seconds
+}
+
+type circuitBreaker struct {
+	threshold int
+	coolDown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(config *CircuitBreakerConfig) *circuitBreaker {
+	if config == nil {
+		return nil
+	}
+	c := &circuitBreaker{threshold: config.FailureThreshold, coolDown: config.CoolDown}
+	if c.threshold <= 0 {
+		c.threshold = 5
+	}
+	if c.coolDown <= 0 {
+		c.coolDown = 10 * time.Second
+	}
+	return c
+}
+
+// allow returns ErrCircuitOpen if the breaker is open. After the cool down, a single caller runs probe and the
+// breaker closes if it succeeds. A nil breaker allows everything
+func (c *circuitBreaker) allow(probe func() error) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	if !c.open {
+		c.mu.Unlock()
+		return nil
+	}
+	if c.probing || time.Since(c.openedAt) < c.coolDown {
+		c.mu.Unlock()
+		return ErrCircuitOpen
+	}
+	c.probing = true
+	c.mu.Unlock()
+
+	err := probe()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probing = false
+	if err != nil {
+		c.openedAt = time.Now()
+		return ErrCircuitOpen
+	}
+	c.open = false
+	c.failures = 0
+	return nil
+}
+
+// record counts consecutive failures and opens the breaker when the threshold is reached
+func (c *circuitBreaker) record(failed bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !failed {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= c.threshold && !c.open {
+		c.open = true
+		c.openedAt = time.Now()
+	}
+}
+
+// isConnFailure reports whether err means the database couldn't be reached, as opposed to an error returned
+// by the server for the statement itself
+func (b *pgxWithReconnect) isConnFailure(err error) bool {
+	var netErr net.Error
+	return b.isDeadConn(err) || errors.As(err, &netErr)
+}
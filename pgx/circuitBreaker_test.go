@@ -0,0 +1,70 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNewCircuitBreaker(t *testing.T) {
+	if newCircuitBreaker(nil) != nil {
+		t.Error("expected disabled breaker")
+	}
+	c := newCircuitBreaker(&CircuitBreakerConfig{})
+	if c.threshold != 5 || c.coolDown != 10*time.Second {
+		t.Error("expected defaults", c.threshold, c.coolDown)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	c := newCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 2, CoolDown: time.Hour})
+	probes := 0
+	probe := func() error { probes++; return nil }
+
+	c.record(true)
+	c.record(false) // success resets the count
+	c.record(true)
+	if err := c.allow(probe); err != nil {
+		t.Error("expected closed breaker", err)
+	}
+	c.record(true)
+	if err := c.allow(probe); err != ErrCircuitOpen || probes != 0 {
+		t.Error("expected open breaker to fail fast", err, probes)
+	}
+
+	c.openedAt = time.Now().Add(-2 * time.Hour) // cool down has passed
+	if err := c.allow(func() error { probes++; return errors.New("fail") }); err != ErrCircuitOpen || probes != 1 {
+		t.Error("expected failed probe to keep breaker open", err, probes)
+	}
+	if err := c.allow(probe); err != ErrCircuitOpen || probes != 1 {
+		t.Error("expected failed probe to restart cool down", err, probes)
+	}
+
+	c.openedAt = time.Now().Add(-2 * time.Hour)
+	if err := c.allow(probe); err != nil || probes != 2 || c.open || c.failures != 0 {
+		t.Error("expected successful probe to close breaker", err, probes)
+	}
+
+	var nilBreaker *circuitBreaker
+	nilBreaker.record(true)
+	if err := nilBreaker.allow(probe); err != nil {
+		t.Error("expected nil breaker to allow", err)
+	}
+}
+
+func TestRetryCircuitBreaker(t *testing.T) {
+	calls := 0
+	b := &pgxWithReconnect{retryPolicy: NoRetry, breaker: newCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Hour})}
+	fail := func() error { calls++; return &net.OpError{Op: "dial", Err: errors.New("connection refused")} }
+
+	b.retry(context.Background(), func() error { calls++; return errors.New("syntax error") })
+	if b.breaker.open {
+		t.Error("expected statement errors not to open the breaker")
+	}
+	b.retry(context.Background(), fail)
+	if err := b.retry(context.Background(), fail); err != ErrCircuitOpen || calls != 2 {
+		t.Error("expected breaker to open after connection failure", err, calls)
+	}
+}
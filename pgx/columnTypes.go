@@ -0,0 +1,83 @@
+package pgx
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// scanTypes are the Go types Values returns for Postgres types
+var scanTypes = map[string]reflect.Type{
+	"bool":         reflect.TypeOf(false),
+	"int2":         reflect.TypeOf(int16(0)),
+	"int4":         reflect.TypeOf(int32(0)),
+	"int8":         reflect.TypeOf(int64(0)),
+	"oid":          reflect.TypeOf(pgx.Oid(0)),
+	"float4":       reflect.TypeOf(float32(0)),
+	"float8":       reflect.TypeOf(float64(0)),
+	"bytea":        reflect.TypeOf([]byte(nil)),
+	"date":         reflect.TypeOf(time.Time{}),
+	"timestamp":    reflect.TypeOf(time.Time{}),
+	"timestamptz":  reflect.TypeOf(time.Time{}),
+	"inet":         reflect.TypeOf(net.IPNet{}),
+	"cidr":         reflect.TypeOf(net.IPNet{}),
+	"uuid":         reflect.TypeOf(uuid.UUID{}),
+	"numeric":      reflect.TypeOf(decimal.Decimal{}),
+	"hstore":       reflect.TypeOf(onedb.Hstore(nil)),
+	"_bool":        reflect.TypeOf([]bool(nil)),
+	"_int2":        reflect.TypeOf([]int16(nil)),
+	"_int4":        reflect.TypeOf([]int32(nil)),
+	"_int8":        reflect.TypeOf([]int64(nil)),
+	"_float4":      reflect.TypeOf([]float32(nil)),
+	"_float8":      reflect.TypeOf([]float64(nil)),
+	"_text":        reflect.TypeOf([]string(nil)),
+	"_varchar":     reflect.TypeOf([]string(nil)),
+	"_timestamp":   reflect.TypeOf([]time.Time(nil)),
+	"_timestamptz": reflect.TypeOf([]time.Time(nil)),
+}
+
+var (
+	stringType    = reflect.TypeOf("")
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// scanType returns the Go type Values returns for a column. Registered types are returned as their Go type,
+// other types pgx doesn't know as strings, and their arrays as slices of elements
+func scanType(field pgx.FieldDescription) reflect.Type {
+	if t := registeredByName(field.DataTypeName); t != nil && t.goType != nil {
+		return t.goType
+	}
+	if t, ok := scanTypes[field.DataTypeName]; ok {
+		return t
+	}
+	switch {
+	case field.DataTypeName == "json" || field.DataTypeName == "jsonb":
+		return interfaceType
+	case isArrayType(field):
+		return reflect.TypeOf([]interface{}(nil))
+	}
+	return stringType
+}
+
+// ColumnTypes describes the columns by their Postgres types. The server doesn't say whether a column of a
+// query's result can be NULL, so every column is nullable
+func (r *pgxRows) ColumnTypes() ([]onedb.ColumnType, error) {
+	fields := r.rows.FieldDescriptions()
+	columnTypes := make([]onedb.ColumnType, len(fields))
+	for i, field := range fields {
+		columnTypes[i] = onedb.ColumnType{
+			Name:         field.Name,
+			DatabaseType: strings.TrimSpace(field.DataTypeName),
+			OID:          uint32(field.DataType),
+			Nullable:     true,
+			ScanType:     scanType(field),
+		}
+	}
+	return columnTypes, nil
+}
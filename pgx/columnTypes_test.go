@@ -0,0 +1,33 @@
+package pgx
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/shopspring/decimal"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestPgxRowsColumnTypes(t *testing.T) {
+	registerLtree(t)
+	m := newMockPgxRows()
+	m.Fields = []pgx.FieldDescription{{Name: "id", DataType: 23, DataTypeName: "int4"}, {Name: "created", DataTypeName: "timestamptz"},
+		{Name: "amount", DataTypeName: "numeric"}, {Name: "path", DataTypeName: "ltree"}, {Name: "tags", DataTypeName: "_citext"},
+		{Name: "email", DataTypeName: "citext"}}
+	r := &pgxRows{rows: m}
+	types, err := onedb.ColumnTypes(r)
+	if err != nil || len(types) != 6 {
+		t.Fatal("expected a type for each column", types, err)
+	}
+	if types[0] != (onedb.ColumnType{Name: "id", DatabaseType: "int4", OID: 23, Nullable: true, ScanType: reflect.TypeOf(int32(0))}) {
+		t.Error("expected column described by its field", types[0])
+	}
+	expected := []interface{}{int32(0), time.Time{}, decimal.Decimal{}, ltree(nil), []interface{}(nil), ""}
+	for i, v := range expected {
+		if types[i].ScanType != reflect.TypeOf(v) {
+			t.Errorf("expected %s scanned as %T, not %v", types[i].Name, v, types[i].ScanType)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package pgx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RowsAffected returns the number of rows affected by the command, or 0 if the command doesn't report a count
+func (ct CommandTag) RowsAffected() int64 {
+	fields := strings.Fields(string(ct))
+	if len(fields) < 2 {
+		return 0
+	}
+	n, _ := strconv.ParseInt(fields[len(fields)-1], 10, 64)
+	return n
+}
+
+// Operation returns the command the tag reports on, like INSERT, UPDATE, DELETE, SELECT or CREATE TABLE
+func (ct CommandTag) Operation() string {
+	fields := strings.Fields(string(ct))
+	for len(fields) > 1 && isDigits(fields[len(fields)-1]) {
+		fields = fields[:len(fields)-1]
+	}
+	return strings.Join(fields, " ")
+}
+
+// InsertOID returns the OID of the row added by an INSERT of a single row into a table with OIDs. It is 0 for
+// other commands and tables
+func (ct CommandTag) InsertOID() Oid {
+	fields := strings.Fields(string(ct))
+	if len(fields) != 3 || fields[0] != "INSERT" {
+		return 0
+	}
+	oid, _ := strconv.ParseUint(fields[1], 10, 32)
+	return Oid(oid)
+}
+
+func (ct CommandTag) String() string {
+	return string(ct)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
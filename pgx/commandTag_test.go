@@ -0,0 +1,25 @@
+package pgx
+
+import "testing"
+
+func TestCommandTag(t *testing.T) {
+	tests := []struct {
+		tag       CommandTag
+		operation string
+		rows      int64
+		oid       Oid
+	}{
+		{"INSERT 0 5", "INSERT", 5, 0},
+		{"INSERT 16402 1", "INSERT", 1, 16402},
+		{"UPDATE 3", "UPDATE", 3, 0},
+		{"DELETE 0", "DELETE", 0, 0},
+		{"SELECT 12", "SELECT", 12, 0},
+		{"CREATE TABLE", "CREATE TABLE", 0, 0},
+		{"", "", 0, 0},
+	}
+	for _, test := range tests {
+		if op, rows, oid := test.tag.Operation(), test.tag.RowsAffected(), test.tag.InsertOID(); op != test.operation || rows != test.rows || oid != test.oid {
+			t.Errorf("expected %q to be %s of %d rows with OID %d, not %s of %d rows with OID %d", test.tag, test.operation, test.rows, test.oid, op, rows, oid)
+		}
+	}
+}
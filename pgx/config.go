@@ -0,0 +1,175 @@
+package pgx
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// ConnConfig contains all the options used to establish a connection. It is reexported from pgx
+type ConnConfig pgx.ConnConfig
+
+// ParseURI parses a database URI into a ConnConfig
+func ParseURI(uri string) (ConnConfig, error) {
+	config, err := pgx.ParseURI(uri)
+	return ConnConfig(config), err
+}
+
+// clientParams are connection string parameters used by the client rather than sent to the server
+var clientParams = []string{"connect_timeout", "sslcert", "sslkey", "sslrootcert"}
+
+// ParseConnString parses a postgres:// URL or a libpq keyword/value DSN such as "host=localhost dbname=app"
+// into a ConnConfig with pgx's parser, which reads the password from the pgpass file, PGPASSFILE or ~/.pgpass,
+// when the string has none. Besides the connection parameters, sslmode, sslrootcert, sslcert, sslkey and
+// connect_timeout (in seconds) are applied to the config. Other parameters, such as application_name, are
+// sent to the server as run-time parameters
+func ParseConnString(s string) (ConnConfig, error) {
+	parsed, err := pgx.ParseConnectionString(s)
+	if err != nil {
+		return ConnConfig{}, err
+	}
+	config := ConnConfig(parsed)
+	params := config.RuntimeParams
+	sslMode := connStringSSLMode(s)
+	if sslMode == "" {
+		sslMode = string(SSLPrefer) // libpq's default
+	}
+	err = config.ConfigureTLS(TLSOptions{
+		Mode:       SSLMode(sslMode),
+		RootCAFile: params["sslrootcert"],
+		CertFile:   params["sslcert"],
+		KeyFile:    params["sslkey"],
+	})
+	if err != nil {
+		return ConnConfig{}, err
+	}
+	if timeout := params["connect_timeout"]; timeout != "" {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil || seconds < 0 {
+			return ConnConfig{}, errors.Errorf("invalid connect_timeout %q", timeout)
+		}
+		if seconds > 0 {
+			config.Dial = (&net.Dialer{Timeout: time.Duration(seconds) * time.Second, KeepAlive: 5 * time.Minute}).Dial
+		}
+	}
+	for _, param := range clientParams {
+		delete(params, param)
+	}
+	return config, nil
+}
+
+// connStringSSLMode returns the sslmode of a connection string, which pgx's parser applies but doesn't keep
+func connStringSSLMode(s string) string {
+	if strings.HasPrefix(s, "postgres://") || strings.HasPrefix(s, "postgresql://") {
+		if u, err := url.Parse(s); err == nil {
+			return u.Query().Get("sslmode")
+		}
+		return ""
+	}
+	for _, param := range strings.Fields(s) {
+		if strings.HasPrefix(param, "sslmode=") {
+			return strings.TrimPrefix(param, "sslmode=")
+		}
+	}
+	return ""
+}
+
+// PoolConfig configures the connection pool created by NewPgxWithConfig
+type PoolConfig struct {
+	ConnConfig
+	MaxConnections  int           // defaults to 10
+	AcquireTimeout  time.Duration // max wait for a connection when all are busy, 0 means no timeout
+	MaxConnLifetime time.Duration // connections older than this are closed, 0 means no limit
+	MaxConnIdleTime time.Duration // connections idle longer than this are closed, 0 means no limit
+	RetryPolicy     RetryPolicy   // defaults to DefaultRetryPolicy
+
+	// DeadConnPredicates are checked, in addition to ErrDeadConn and "connection reset by peer", to decide
+	// whether a failed statement should be retried on a new connection
+	DeadConnPredicates []DeadConnPredicate
+
+	// CircuitBreaker enables failing fast while the database is unreachable. It is disabled when nil
+	CircuitBreaker *CircuitBreakerConfig
+
+	// Metrics receives query, reconnect and pool measurements. See the prometheus package for a ready made
+	// implementation
+	Metrics Metrics
+
+	// Tracer starts a span for each statement. See the otel package for an OpenTelemetry implementation
+	Tracer Tracer
+
+	// QueryLogger receives the details of every statement. See NewSlogLogger for a log/slog adapter
+	QueryLogger QueryLogger
+
+	// RedactArgs replaces the arguments it reports true for with "<redacted>" in the QueryLogs and QueryErrors,
+	// as is always done for Secret arguments
+	RedactArgs ArgRedacter
+
+	// QueryStats counts the calls, errors, time and rows of every statement by fingerprint. See NewQueryStats
+	QueryStats *QueryStats
+
+	// StatementCacheSize enables preparing statements run with arguments and reusing them by query text, keeping
+	// up to this many per connection. Hot queries then skip parsing and planning. It is disabled when zero
+	StatementCacheSize int
+
+	// OnConnect is run on every new connection before the pool hands it out, to set up the session, e.g. with
+	// SET search_path, SET TIME ZONE or SET application_name, or to create temporary tables. The connection is
+	// closed and the acquire fails if it returns an error
+	OnConnect func(conn *pgx.Conn) error
+
+	// StatementTimeout, LockTimeout and IdleInTxSessionTimeout set the session's statement_timeout, lock_timeout
+	// and idle_in_transaction_session_timeout on every new connection, before OnConnect runs. The server
+	// cancels statements, lock waits or idle transactions which take longer. The server default is kept when zero
+	StatementTimeout       time.Duration
+	LockTimeout            time.Duration
+	IdleInTxSessionTimeout time.Duration
+
+	// SlowQueryThreshold limits QueryLogger to statements which take at least this long. Slow query logs also
+	// include the columns returned. Every statement is logged when it is zero
+	SlowQueryThreshold time.Duration
+
+	// QueryErrors adds the query, the number of arguments and optionally their values to errors from Query,
+	// QueryRow, Exec and Scan by returning them as *onedb.QueryError. Values are sanitized as in the QueryLogs
+	// before QueryErrors.Redact is applied. It is disabled when nil
+	QueryErrors *onedb.QueryErrorOptions
+
+	// SQLComment appends sqlcommenter style comments with the configured tags, and those added to the statement's
+	// context with onedb.WithSQLCommentTags, to every query and exec. It is disabled when nil
+	SQLComment *onedb.SQLCommentOptions
+
+	// Interceptors wrap every Query, QueryRow, Exec and Begin, including the statements run in transactions, in
+	// the order given, the first being the outermost. Exec results are a CommandTag and Begin results a Txer. A
+	// Begin started with BeginTx has its TxOptions as the call's only argument
+	Interceptors []onedb.Interceptor
+
+	// ProfilerLabels adds pprof labels to the goroutine running a query, exec or copy while the statement is sent
+	// and its result awaited, so CPU and block profiles can be broken down by statement. The labels are
+	// onedb_operation and onedb_query, the statement's onedb.QueryFingerprint, added to the labels of the
+	// statement's context. The statement is run on a goroutine of its own, so the labels of the calling goroutine
+	// are left as they were
+	ProfilerLabels bool
+
+	// SimpleProtocol makes the pool work behind PgBouncer in transaction pooling mode, where each transaction
+	// may run on a different server connection and prepared statements break with "unnamed prepared statement
+	// does not exist" errors. Arguments of statements run outside a transaction are written into the SQL as
+	// literals. Execs are then sent with the simple query protocol, and queries, which pgx always prepares, run
+	// in a transaction of their own, committed when their rows are closed, so each of them costs two more round
+	// trips, for the begin and the commit. Run queries in a transaction, or use the pgxv5 package with
+	// pgx.QueryExecModeSimpleProtocol, to avoid them. The statement cache is disabled and Prepare fails with
+	// ErrSimpleProtocolPrepare. Statements in transactions are run as usual.
+	//
+	// Strings are written as standard SQL literals, so the server's standard_conforming_strings setting must be
+	// on, the default since PostgreSQL 9.1. Setting it off is not supported: backslashes in arguments would then
+	// be read as escapes
+	SimpleProtocol bool
+}
+
+// NewPgxWithConfig returns a PGX DBer instance using the provided pool configuration
+func NewPgxWithConfig(config PoolConfig) (PGXer, error) {
+	return newPgx(config)
+}
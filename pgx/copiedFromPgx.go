@@ -18,6 +18,15 @@ func CopyFromRows(rows [][]interface{}) CopyFromSource {
 // CommandTag is the result of an Exec function
 type CommandTag pgx.CommandTag
 
+// Transaction statuses returned by Txer.Status
+const (
+	TxStatusInProgress      = pgx.TxStatusInProgress
+	TxStatusCommitFailure   = pgx.TxStatusCommitFailure
+	TxStatusRollbackFailure = pgx.TxStatusRollbackFailure
+	TxStatusCommitSuccess   = pgx.TxStatusCommitSuccess
+	TxStatusRollbackSuccess = pgx.TxStatusRollbackSuccess
+)
+
 type copyFromRows struct {
 	rows [][]interface{}
 	idx  int
@@ -36,6 +45,13 @@ func (ctr *copyFromRows) Err() error {
 	return nil
 }
 
+// Notification is a message received from the PostgreSQL LISTEN/NOTIFY system
+type Notification struct {
+	Pid     int32  // backend pid that sent the notification
+	Channel string // channel from which notification was received
+	Payload string
+}
+
 type FieldDescription struct {
 	Name            string
 	Table           Oid
@@ -0,0 +1,206 @@
+package pgx
+
+import (
+	"encoding/csv"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+// CSVSource is a CopyFromSource reading records from CSV, with the column names taken from its header row
+type CSVSource struct {
+	r       *csv.Reader
+	columns []string
+	parsers []func(field string) (interface{}, error)
+	record  []string
+	err     error
+}
+
+// CopyFromCSV returns a CopyFromSource over the CSV records read from r, whose first row names the columns. Empty
+// fields are copied as NULL and all others as strings, which suits text columns. Use ParseColumn to convert the
+// fields of columns of other types
+func CopyFromCSV(r io.Reader) (*CSVSource, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read CSV header")
+	}
+	columns := append([]string(nil), header...)
+	return &CSVSource{r: reader, columns: columns, parsers: make([]func(string) (interface{}, error), len(columns))}, nil
+}
+
+// Columns returns the column names from the header row to pass to CopyFrom
+func (s *CSVSource) Columns() []string {
+	return s.columns
+}
+
+// ParseColumn sets how the fields of column are converted before they are copied. For example, an integer column
+// can be parsed with func(field string) (interface{}, error) { return strconv.Atoi(field) }
+func (s *CSVSource) ParseColumn(column string, parse func(field string) (interface{}, error)) error {
+	for i, name := range s.columns {
+		if name == column {
+			s.parsers[i] = parse
+			return nil
+		}
+	}
+	return errors.Errorf("column %q isn't in the CSV header", column)
+}
+
+func (s *CSVSource) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	s.record, s.err = s.r.Read()
+	if s.err == io.EOF {
+		s.err = nil
+		return false
+	}
+	return s.err == nil
+}
+
+func (s *CSVSource) Values() ([]interface{}, error) {
+	values := make([]interface{}, len(s.record))
+	for i, field := range s.record {
+		if field == "" {
+			continue
+		}
+		if s.parsers[i] == nil {
+			values[i] = field
+			continue
+		}
+		v, err := s.parsers[i](field)
+		if err != nil {
+			line, _ := s.r.FieldPos(i)
+			return nil, errors.Wrapf(err, "Unable to parse column %q on line %d", s.columns[i], line)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func (s *CSVSource) Err() error {
+	return s.err
+}
+
+// CopyFromStructs returns a CopyFromSource over a slice of structs, or pointers to structs, along with the column
+// names of their fields. Columns are named the same way QueryStruct matches them, by `db` tag or the NameMapper.
+// Fields tagged `db:"-"` and unexported fields are skipped
+func CopyFromStructs[T any](rows []T) ([]string, CopyFromSource) {
+	itemType := reflect.TypeOf(rows).Elem()
+	if itemType.Kind() == reflect.Ptr {
+		itemType = itemType.Elem()
+	}
+	if itemType.Kind() != reflect.Struct {
+		return nil, &structSource{err: errors.Errorf("CopyFromStructs requires a slice of structs, not %T", rows)}
+	}
+	var columns []string
+	var fields []int
+	for i := 0; i < itemType.NumField(); i++ {
+		field := itemType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if name := onedb.ColumnName(field); name != "" {
+			columns = append(columns, name)
+			fields = append(fields, i)
+		}
+	}
+	return columns, &structSource{rows: reflect.ValueOf(rows), fields: fields, idx: -1}
+}
+
+type structSource struct {
+	rows   reflect.Value
+	fields []int
+	idx    int
+	err    error
+}
+
+func (s *structSource) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	s.idx++
+	return s.idx < s.rows.Len()
+}
+
+func (s *structSource) Values() ([]interface{}, error) {
+	row := s.rows.Index(s.idx)
+	if row.Kind() == reflect.Ptr {
+		if row.IsNil() {
+			return nil, errors.Errorf("row %d is nil", s.idx)
+		}
+		row = row.Elem()
+	}
+	values := make([]interface{}, len(s.fields))
+	for i, field := range s.fields {
+		values[i] = row.Field(field).Interface()
+	}
+	return values, nil
+}
+
+func (s *structSource) Err() error {
+	return s.err
+}
+
+// ErrCopyAborted is returned by a ChannelSource's Err when it is aborted without an error
+var ErrCopyAborted = errors.New("copy aborted")
+
+// ChannelSource is a CopyFromSource receiving rows from a channel, so rows can be copied while they are still being
+// produced. The channel's capacity sets how far the producer can get ahead of the copy. CopyFrom stops reading if
+// it fails, so producers should also stop sending when the context they share with it is done
+type ChannelSource struct {
+	ch    <-chan []interface{}
+	row   []interface{}
+	abort chan struct{}
+	once  sync.Once
+	err   error
+}
+
+// CopyFromChannel returns a CopyFromSource which copies each row sent on ch until it is closed
+func CopyFromChannel(ch <-chan []interface{}) *ChannelSource {
+	return &ChannelSource{ch: ch, abort: make(chan struct{})}
+}
+
+// Abort ends the copy with err, even while it is waiting for a row. CopyFrom returns the error so the rows already
+// sent are not committed. It is safe to call from the producer's goroutine
+func (s *ChannelSource) Abort(err error) {
+	s.once.Do(func() {
+		if err == nil {
+			err = ErrCopyAborted
+		}
+		s.err = err
+		close(s.abort)
+	})
+}
+
+func (s *ChannelSource) Next() bool {
+	select {
+	case <-s.abort:
+		return false
+	default:
+	}
+	select {
+	case row, ok := <-s.ch:
+		s.row = row
+		return ok
+	case <-s.abort:
+		return false
+	}
+}
+
+func (s *ChannelSource) Values() ([]interface{}, error) {
+	return s.row, nil
+}
+
+func (s *ChannelSource) Err() error {
+	select {
+	case <-s.abort:
+		return s.err
+	default:
+		return nil
+	}
+}
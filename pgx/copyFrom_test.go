@@ -0,0 +1,94 @@
+package pgx
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCopyFromCSV(t *testing.T) {
+	src, err := CopyFromCSV(strings.NewReader("id,name,note\n1,alice,\"multi\nline\"\n2,bob,\n"))
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	if err := src.ParseColumn("id", func(field string) (interface{}, error) { return strconv.Atoi(field) }); err != nil {
+		t.Fatal("expected success", err)
+	}
+	if err := src.ParseColumn("missing", nil); err == nil {
+		t.Error("expected error for a column not in the header")
+	}
+
+	m := NewMock(nil, nil)
+	if n, err := m.CopyFrom(Identifier{"users"}, src.Columns(), src); n != 2 || err != nil {
+		t.Fatal("expected rows to be copied", n, err)
+	}
+	call := m.CopyFromCalls()[0]
+	expected := [][]interface{}{{1, "alice", "multi\nline"}, {2, "bob", nil}}
+	if !reflect.DeepEqual(call.ColumnNames, []string{"id", "name", "note"}) || !reflect.DeepEqual(call.Rows, expected) {
+		t.Error("expected header columns and parsed rows", call.ColumnNames, call.Rows)
+	}
+
+	src, _ = CopyFromCSV(strings.NewReader("id\n1\nx\n"))
+	src.ParseColumn("id", func(field string) (interface{}, error) { return strconv.Atoi(field) })
+	if _, err := m.CopyFrom(Identifier{"users"}, src.Columns(), src); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Error("expected parse error with the line number", err)
+	}
+	if _, err := CopyFromCSV(strings.NewReader("")); err == nil {
+		t.Error("expected error for a missing header")
+	}
+}
+
+type copyUser struct {
+	ID       int `db:"user_id"`
+	Name     string
+	Password string `db:"-"`
+	internal int
+}
+
+func TestCopyFromStructs(t *testing.T) {
+	columns, src := CopyFromStructs([]*copyUser{{ID: 1, Name: "alice", Password: "secret"}, {ID: 2, Name: "bob"}})
+	m := NewMock(nil, nil)
+	if n, err := m.CopyFrom(Identifier{"users"}, columns, src); n != 2 || err != nil {
+		t.Fatal("expected rows to be copied", n, err)
+	}
+	call := m.CopyFromCalls()[0]
+	if !reflect.DeepEqual(call.ColumnNames, []string{"user_id", "Name"}) || !reflect.DeepEqual(call.Rows, [][]interface{}{{1, "alice"}, {2, "bob"}}) {
+		t.Error("expected tagged columns and field values", call.ColumnNames, call.Rows)
+	}
+
+	if _, src := CopyFromStructs([]int{1}); src.Next() || src.Err() == nil {
+		t.Error("expected error for a slice of non-structs")
+	}
+}
+
+func TestCopyFromChannel(t *testing.T) {
+	ch := make(chan []interface{})
+	src := CopyFromChannel(ch)
+	go func() {
+		for i := 1; i <= 3; i++ {
+			ch <- []interface{}{i}
+		}
+		close(ch)
+	}()
+	m := NewMock(nil, nil)
+	if n, err := m.CopyFrom(Identifier{"users"}, []string{"id"}, src); n != 3 || err != nil {
+		t.Fatal("expected every row sent to be copied", n, err)
+	}
+	if rows := m.CopyFromCalls()[0].Rows; !reflect.DeepEqual(rows, [][]interface{}{{1}, {2}, {3}}) {
+		t.Error("expected rows in the order sent", rows)
+	}
+
+	fail := errors.New("fail")
+	src = CopyFromChannel(make(chan []interface{}))
+	go src.Abort(fail)
+	if _, err := m.CopyFrom(Identifier{"users"}, []string{"id"}, src); err != fail {
+		t.Error("expected abort to end the copy while it waits for a row", err)
+	}
+	src = CopyFromChannel(make(chan []interface{}))
+	src.Abort(nil)
+	if src.Next() || src.Err() != ErrCopyAborted {
+		t.Error("expected ErrCopyAborted", src.Err())
+	}
+}
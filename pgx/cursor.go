@@ -0,0 +1,149 @@
+package pgx
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+var cursorCount int64
+
+// cursorRows is a RowsScanner over a server-side cursor which fetches fetchSize rows at a time, so only one batch
+// is held in memory however many rows the query returns
+type cursorRows struct {
+	q         querier
+	name      string
+	fetchSize int
+	batch     onedb.RowsScanner
+	batchRows int
+	columns   []string
+	done      func(err error) error
+	finished  bool
+	closed    bool
+	err       error
+}
+
+// queryCursor declares a cursor for query in the transaction q and fetches the first batch. done is called when
+// the rows are closed, with the error which ended them if there was one
+func queryCursor(q querier, query string, fetchSize int, args []interface{}, done func(err error) error) (onedb.RowsScanner, error) {
+	if fetchSize <= 0 {
+		err := errors.New("fetchSize must be greater than zero")
+		done(err)
+		return nil, err
+	}
+	name := "onedb_cursor_" + strconv.FormatInt(atomic.AddInt64(&cursorCount, 1), 10)
+	if _, err := q.Exec("declare "+name+" no scroll cursor for "+query, args...); err != nil {
+		done(err)
+		return nil, err
+	}
+	r := &cursorRows{q: q, name: name, fetchSize: fetchSize, done: done}
+	if err := r.fetch(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	columns, err := r.batch.Columns()
+	if err != nil {
+		r.err = err
+		r.Close()
+		return nil, err
+	}
+	r.columns = columns
+	return r, nil
+}
+
+func (r *cursorRows) fetch() error {
+	batch, err := r.q.Query("fetch forward " + strconv.Itoa(r.fetchSize) + " from " + r.name)
+	if err != nil {
+		r.err = err
+		return err
+	}
+	r.batch, r.batchRows = batch, 0
+	return nil
+}
+
+func (r *cursorRows) Next() bool {
+	if r.closed || r.finished || r.err != nil {
+		return false
+	}
+	for {
+		if r.batch.Next() {
+			r.batchRows++
+			return true
+		}
+		r.err = r.batch.Err()
+		if err := r.batch.Close(); r.err == nil {
+			r.err = err
+		}
+		if r.err != nil || r.batchRows < r.fetchSize {
+			r.finished = true
+			return false
+		}
+		if r.fetch() != nil {
+			return false
+		}
+	}
+}
+
+func (r *cursorRows) Scan(dest ...interface{}) error {
+	return r.batch.Scan(dest...)
+}
+
+func (r *cursorRows) Columns() ([]string, error) {
+	return r.columns, nil
+}
+
+func (r *cursorRows) Err() error {
+	return r.err
+}
+
+// Close closes the cursor and ends its transaction if QueryCursor began one
+func (r *cursorRows) Close() error {
+	if r.closed {
+		return r.err
+	}
+	r.closed = true
+	if r.batch != nil && !r.finished {
+		r.batch.Close()
+	}
+	if r.err == nil {
+		_, r.err = r.q.Exec("close " + r.name)
+	}
+	if err := r.done(r.err); r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+func noCursorTx(err error) error {
+	return nil
+}
+
+// queryCursorTx runs QueryCursor in a transaction begun for it, which ends when the rows are closed
+func queryCursorTx(tx Txer, query string, fetchSize int, args []interface{}) (onedb.RowsScanner, error) {
+	return queryCursor(tx, query, fetchSize, args, func(err error) error {
+		if err != nil {
+			tx.Rollback()
+			return nil
+		}
+		return tx.Commit()
+	})
+}
+
+// QueryCursor runs query through a server-side cursor declared in a new transaction, fetching fetchSize rows at
+// a time so memory stays bounded on very large results. The transaction is committed when the rows are closed, so
+// they must always be closed
+func (b *pgxBackend) QueryCursor(query string, fetchSize int, args ...interface{}) (onedb.RowsScanner, error) {
+	tx, err := b.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return queryCursorTx(tx, query, fetchSize, args)
+}
+
+// QueryCursor runs query through a server-side cursor fetching fetchSize rows at a time. The cursor is closed
+// with the rows, or when the transaction ends
+func (t *pgxTx) QueryCursor(query string, fetchSize int, args ...interface{}) (onedb.RowsScanner, error) {
+	return queryCursor(t, query, fetchSize, args, noCursorTx)
+}
@@ -0,0 +1,63 @@
+package pgx
+
+import (
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+func TestQueryCursor(t *testing.T) {
+	type user struct {
+		ID   int
+		Name string
+	}
+	m := NewMock(nil, nil)
+	m.SetQueryMatcher(onedb.QueryMatcherRegexp)
+	m.ExpectBegin()
+	m.ExpectExec(`^declare onedb_cursor_\d+ no scroll cursor for select id, name from users where id > \$1$`).WithArgs(0)
+	m.ExpectQuery(`^fetch forward 2 from onedb_cursor_\d+$`).WillReturnRows([]user{{1, "alice"}, {2, "bob"}})
+	m.ExpectQuery(`^fetch forward 2 from onedb_cursor_\d+$`).WillReturnRows([]user{{3, "carol"}})
+	m.ExpectExec(`^close onedb_cursor_\d+$`)
+	m.ExpectCommit()
+
+	rows, err := m.QueryCursor("select id, name from users where id > $1", 2, 0)
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	var users []user
+	for rows.Next() {
+		var u user
+		if err := rows.Scan(&u.ID, &u.Name); err != nil {
+			t.Fatal("expected scan success", err)
+		}
+		users = append(users, u)
+	}
+	if columns, _ := rows.Columns(); len(columns) != 2 || len(users) != 3 || users[2].Name != "carol" {
+		t.Error("expected every batch to be read", columns, users)
+	}
+	if err := rows.Close(); err != nil {
+		t.Error("expected close to succeed", err)
+	}
+	m.VerifyExpectations(t)
+
+	if _, err := m.QueryCursor("select 1", 0); err == nil {
+		t.Error("expected error for a fetch size of zero")
+	}
+}
+
+func TestQueryCursorCloseEarly(t *testing.T) {
+	m := NewMock(nil, nil, []SimpleData{{1, "alice"}, {2, "bob"}})
+	tx, _ := m.Begin()
+	rows, err := tx.QueryCursor("select * from users", 2)
+	if err != nil || !rows.Next() {
+		t.Fatal("expected the first row", err)
+	}
+	rows.Close()
+	if rows.Next() {
+		t.Error("expected no rows after Close")
+	}
+	queries := m.QueriesRun()
+	if last := queries[len(queries)-1]; last.MethodName != "Exec" || last.Arguments[0] != "close "+rows.(*cursorRows).name {
+		t.Error("expected the cursor to be closed", last)
+	}
+}
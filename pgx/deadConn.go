@@ -0,0 +1,55 @@
+package pgx
+
+import (
+	"errors"
+
+	"github.com/EndFirstCorp/onedb"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// DeadConnPredicate reports whether err means the connection is no longer usable, so the statement should be
+// retried on a new connection. It is onedb's DeadConnPredicate
+type DeadConnPredicate = onedb.DeadConnPredicate
+
+// IsSQLState returns a DeadConnPredicate matching PostgreSQL errors with any of the given SQLSTATE codes, such
+// as 57P01 (admin_shutdown) or 57P03 (cannot_connect_now)
+func IsSQLState(codes ...string) DeadConnPredicate {
+	return func(err error) bool {
+		var pgErr pgx.PgError
+		if !errors.As(err, &pgErr) {
+			return false
+		}
+		for _, code := range codes {
+			if pgErr.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// IsEOF matches errors caused by the server closing the connection. See onedb.IsEOF
+func IsEOF(err error) bool {
+	return onedb.IsEOF(err)
+}
+
+// IsBrokenPipe matches errors caused by writing to a connection the server has closed. See onedb.IsBrokenPipe
+func IsBrokenPipe(err error) bool {
+	return onedb.IsBrokenPipe(err)
+}
+
+// isDeadConn checks for pgx's dead connection errors and then any configured predicates
+func (b *pgxWithReconnect) isDeadConn(err error) bool {
+	if err == nil {
+		return false
+	}
+	if isDeadConn(err) {
+		return true
+	}
+	for _, predicate := range b.deadConnPredicates {
+		if predicate(err) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package pgx
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestIsSQLState(t *testing.T) {
+	isShutdown := IsSQLState("57P01", "57P03")
+	if !isShutdown(pgx.PgError{Code: "57P01"}) || !isShutdown(pgx.PgError{Code: "57P03"}) {
+		t.Error("expected matching codes")
+	}
+	if isShutdown(pgx.PgError{Code: "23505"}) || isShutdown(errors.New("57P01")) || isShutdown(nil) {
+		t.Error("expected no match")
+	}
+}
+
+func TestPgxWithReconnectIsDeadConn(t *testing.T) {
+	b := &pgxWithReconnect{}
+	if !b.isDeadConn(ErrDeadConn) || !b.isDeadConn(errors.New("read: connection reset by peer")) || b.isDeadConn(io.EOF) || b.isDeadConn(nil) {
+		t.Error("expected default dead connection detection")
+	}
+	b.deadConnPredicates = []DeadConnPredicate{IsEOF}
+	if !b.isDeadConn(io.EOF) || b.isDeadConn(errors.New("fail")) {
+		t.Error("expected configured predicate to be checked")
+	}
+}
@@ -0,0 +1,27 @@
+package pgx
+
+import (
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func isHstoreType(field pgx.FieldDescription) bool {
+	return field.DataTypeName == "hstore"
+}
+
+// decodeHstores parses in place the hstore values pgx returns as text into onedb.Hstore maps
+func decodeHstores(fields []pgx.FieldDescription, values []interface{}) error {
+	for i := 0; i < len(fields) && i < len(values); i++ {
+		text, ok := values[i].(string)
+		if !ok || !isHstoreType(fields[i]) {
+			continue
+		}
+		h, err := onedb.ParseHstore(text)
+		if err != nil {
+			return errors.Wrapf(err, "Unable to parse hstore column %s", fields[i].Name)
+		}
+		values[i] = h
+	}
+	return nil
+}
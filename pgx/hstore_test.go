@@ -0,0 +1,37 @@
+package pgx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestPgxRowsHstore(t *testing.T) {
+	m := newMockPgxRows()
+	m.Fields = []pgx.FieldDescription{{Name: "attrs", DataTypeName: "hstore"}, {Name: "name", DataTypeName: "text"}}
+	m.ValuesData = []interface{}{`"a"=>"1", "b"=>NULL`, `"not"=>"hstore"`}
+	r := &pgxRows{rows: m}
+	values, err := r.Values()
+	a := "1"
+	if err != nil || !reflect.DeepEqual(values[0], onedb.Hstore{"a": &a, "b": nil}) || values[1] != `"not"=>"hstore"` {
+		t.Error("expected hstore columns parsed", values, err)
+	}
+
+	m.ValuesData = []interface{}{`"a"=>"1", "b"=>NULL`, "x"}
+	var attrs map[string]string
+	var name interface{}
+	if err := r.Scan(&attrs, &name); err != nil || !reflect.DeepEqual(attrs, map[string]string{"a": "1"}) {
+		t.Error("expected hstore scanned into a map", attrs, err)
+	}
+	var n string
+	if err := r.Scan(&attrs, &n); err != nil || n != "x" {
+		t.Error("expected a text column scanned into a string", n, err)
+	}
+
+	m.ValuesData = []interface{}{`"a"`, nil}
+	if _, err := r.Values(); err == nil {
+		t.Error("expected error for invalid hstore")
+	}
+}
@@ -0,0 +1,160 @@
+package pgx
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// Operations reported to Metrics and Tracer
+const (
+	opQuery   = "query"
+	opExec    = "exec"
+	opCopy    = "copy"
+	opBegin   = "begin"
+	opPrepare = "prepare"
+)
+
+// instrumentation holds the hooks which observe statements run through the backend and its transactions.
+// A nil *instrumentation observes nothing
+type instrumentation struct {
+	pool    *pgx.ConnPool
+	metrics Metrics
+	tracer  Tracer
+	logger  QueryLogger
+	redact  ArgRedacter
+	slow    time.Duration
+	labels  bool
+	stats   *QueryStats
+}
+
+func newInstrumentation(pool *pgx.ConnPool, config PoolConfig) *instrumentation {
+	if config.Metrics == nil && config.Tracer == nil && config.QueryLogger == nil && !config.ProfilerLabels && config.QueryStats == nil {
+		return nil
+	}
+	return &instrumentation{
+		pool:    pool,
+		metrics: config.Metrics,
+		tracer:  config.Tracer,
+		logger:  config.QueryLogger,
+		redact:  config.RedactArgs,
+		slow:    config.SlowQueryThreshold,
+		labels:  config.ProfilerLabels,
+		stats:   config.QueryStats,
+	}
+}
+
+// statement is a statement being observed. A nil *statement observes nothing
+type statement struct {
+	inst      *instrumentation
+	ctx       context.Context
+	operation string
+	query     string
+	args      []interface{}
+	fields    []pgx.FieldDescription
+	start     time.Time
+	span      Span
+	rows      int64
+	finished  int32
+}
+
+// instrument is called before a statement runs. finish must be called on the returned statement once the
+// statement is done
+func (i *instrumentation) instrument(ctx context.Context, operation, query string, args []interface{}) *statement {
+	if i == nil {
+		return nil
+	}
+	s := &statement{inst: i, ctx: ctx, operation: operation, query: query, args: args, start: time.Now()}
+	if i.tracer != nil {
+		s.ctx, s.span = i.tracer.StartSpan(ctx, operation, query) // log within the span
+	}
+	return s
+}
+
+// Profiler label keys set by ProfilerLabels
+const (
+	labelOperation = "onedb_operation"
+	labelQuery     = "onedb_query"
+)
+
+// run calls f with the statement's operation and query fingerprint added to the pprof labels of the statement's
+// context when ProfilerLabels is set. f runs on a goroutine of its own, which the caller waits for, since pprof.Do
+// would leave the calling goroutine with the context's labels, dropping those set with pprof.SetGoroutineLabels.
+// A panic in f is raised again in the caller
+func (s *statement) run(f func()) {
+	if s == nil || !s.inst.labels {
+		f()
+		return
+	}
+	labels := pprof.Labels(labelOperation, s.operation, labelQuery, onedb.QueryFingerprint(s.query))
+	done := make(chan interface{}, 1)
+	go pprof.Do(s.ctx, labels, func(context.Context) {
+		defer func() { done <- recover() }()
+		f()
+	})
+	if p := <-done; p != nil {
+		panic(p)
+	}
+}
+
+// addRows counts rows returned or affected by the statement
+func (s *statement) addRows(n int64) {
+	if s != nil {
+		atomic.AddInt64(&s.rows, n)
+	}
+}
+
+// setFields records the columns returned by a query
+func (s *statement) setFields(fields []pgx.FieldDescription) {
+	if s != nil {
+		s.fields = fields
+	}
+}
+
+// finish reports the statement to the hooks. Only the first call has an effect
+func (s *statement) finish(err error) {
+	if s == nil || !atomic.CompareAndSwapInt32(&s.finished, 0, 1) {
+		return
+	}
+	i := s.inst
+	duration := time.Since(s.start)
+	rows := atomic.LoadInt64(&s.rows)
+	if s.span != nil {
+		s.span.End(rows, err)
+	}
+	if i.logger != nil && duration >= i.slow {
+		entry := QueryLog{
+			Operation: s.operation,
+			Query:     s.query,
+			Args:      sanitizeArgs(s.query, s.args, i.redact),
+			ArgCount:  len(s.args),
+			Duration:  duration,
+			Rows:      rows,
+			Err:       err,
+		}
+		if i.slow > 0 {
+			entry.FieldDescriptions = toFieldDescriptions(s.fields)
+		}
+		i.logger.LogQuery(s.ctx, entry)
+	}
+	if i.stats != nil {
+		i.stats.observe(s.operation, s.query, duration, rows, err)
+	}
+	if i.metrics != nil {
+		i.metrics.ObserveQuery(s.operation, duration, err)
+		if i.pool != nil {
+			stat := i.pool.Stat()
+			i.metrics.ObservePool(stat.CurrentConnections, stat.AvailableConnections)
+		}
+	}
+}
+
+func (i *instrumentation) reconnect(err error) {
+	if i != nil && i.metrics != nil {
+		i.metrics.ObserveReconnect(err)
+	}
+}
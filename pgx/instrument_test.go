@@ -0,0 +1,149 @@
+package pgx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstrumentation(t *testing.T) {
+	var nilInst *instrumentation
+	st := nilInst.instrument(context.Background(), opQuery, "select 1", nil)
+	st.addRows(1)
+	st.finish(nil)
+	nilInst.reconnect(nil)
+	if newInstrumentation(nil, PoolConfig{}) != nil {
+		t.Error("expected no instrumentation without hooks")
+	}
+
+	m := &mockMetrics{}
+	i := newInstrumentation(nil, PoolConfig{Metrics: m})
+	fail := errors.New("fail")
+	st = i.instrument(context.Background(), opExec, "delete", nil)
+	st.finish(fail)
+	st.finish(nil) // only the first finish is reported
+	i.reconnect(nil)
+	if len(m.operations) != 1 || m.operations[0] != opExec || m.errs[0] != fail || m.reconnects != 1 {
+		t.Error("expected metrics to be observed", m)
+	}
+}
+
+func TestInstrumentationTracer(t *testing.T) {
+	tracer := &mockTracer{}
+	i := newInstrumentation(nil, PoolConfig{Tracer: tracer})
+	st := i.instrument(context.Background(), opQuery, "select * from users", nil)
+	st.addRows(2)
+	st.addRows(1)
+	st.finish(nil)
+	if len(tracer.spans) != 1 {
+		t.Fatal("expected span to be started")
+	}
+	span := tracer.spans[0]
+	if span.operation != opQuery || span.query != "select * from users" || !span.ended || span.rows != 3 || span.err != nil {
+		t.Error("expected span to record statement", span)
+	}
+}
+
+func TestInstrumentationProfilerLabels(t *testing.T) {
+	goroutineLabels := func() string {
+		var buf bytes.Buffer
+		pprof.Lookup("goroutine").WriteTo(&buf, 1)
+		return buf.String()
+	}
+	i := newInstrumentation(nil, PoolConfig{ProfilerLabels: true})
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("route", "/invoices"))
+	st := i.instrument(ctx, opExec, "update invoices set paid = true where id = 42", nil)
+	var during string
+	st.run(func() { during = goroutineLabels() })
+	st.finish(nil)
+	if !strings.Contains(during, `"onedb_query":"update invoices set paid = true where id = ?"`) ||
+		!strings.Contains(during, `"onedb_operation":"exec"`) || !strings.Contains(during, `"route":"/invoices"`) {
+		t.Error("expected the statement's labels along with the context's", during)
+	}
+	if after := goroutineLabels(); strings.Contains(after, "onedb_query") {
+		t.Error("expected labels to be removed after the statement", after)
+	}
+
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("caller", "batch")))
+	defer pprof.SetGoroutineLabels(context.Background())
+	i.instrument(context.Background(), opExec, "delete from sessions", nil).run(func() {})
+	if after := goroutineLabels(); !strings.Contains(after, `"caller":"batch"`) {
+		t.Error("expected the caller's labels to be kept after a statement without them in its context", after)
+	}
+	func() {
+		defer func() {
+			if p := recover(); p != "fail" {
+				t.Error("expected a panic in the statement to reach the caller", p)
+			}
+		}()
+		i.instrument(ctx, opExec, "delete", nil).run(func() { panic("fail") })
+	}()
+
+	ran := false
+	newInstrumentation(nil, PoolConfig{Metrics: &mockMetrics{}}).instrument(ctx, opExec, "delete", nil).run(func() { ran = true })
+	if !ran {
+		t.Error("expected run to call f without labels")
+	}
+}
+
+func TestPgxRowsCountsRows(t *testing.T) {
+	st := &statement{inst: &instrumentation{}}
+	r := &pgxRows{rows: &mockNextPgxRows{newMockPgxRows()}, st: st}
+	r.Next()
+	if st.rows != 1 {
+		t.Error("expected row to be counted", st.rows)
+	}
+}
+
+type mockNextPgxRows struct {
+	*mockPgxRows
+}
+
+func (r *mockNextPgxRows) Next() bool {
+	return true
+}
+
+type mockMetrics struct {
+	operations []string
+	errs       []error
+	reconnects int
+}
+
+func (m *mockMetrics) ObserveQuery(operation string, duration time.Duration, err error) {
+	m.operations = append(m.operations, operation)
+	m.errs = append(m.errs, err)
+}
+
+func (m *mockMetrics) ObserveReconnect(err error) {
+	m.reconnects++
+}
+
+func (m *mockMetrics) ObservePool(open, idle int) {}
+
+type mockTracer struct {
+	spans []*mockSpan
+}
+
+func (t *mockTracer) StartSpan(ctx context.Context, operation, query string) (context.Context, Span) {
+	span := &mockSpan{operation: operation, query: query}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+type mockSpan struct {
+	operation string
+	query     string
+	ended     bool
+	rows      int64
+	err       error
+}
+
+func (s *mockSpan) End(rows int64, err error) {
+	s.ended = true
+	s.rows = rows
+	s.err = err
+}
@@ -0,0 +1,215 @@
+package pgx
+
+import (
+	"context"
+	"io"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+// interceptedPgx runs Query, QueryRow, Exec and Begin, including those of prepared statements, through an
+// interceptor chain. Everything else goes straight to the backend
+type interceptedPgx struct {
+	pgxWrapper
+	interceptors []onedb.Interceptor
+	handler      onedb.Handler
+}
+
+func intercept(db pgxWrapper, interceptors []onedb.Interceptor) pgxWrapper {
+	if len(interceptors) == 0 {
+		return db
+	}
+	b := &interceptedPgx{pgxWrapper: db, interceptors: interceptors}
+	b.handler = onedb.ChainInterceptors(interceptors...)(b.call)
+	return b
+}
+
+// call runs call against the backend at the end of the chain. A Begin started with BeginTx has its TxOptions
+// as the call's only argument
+func (b *interceptedPgx) call(ctx context.Context, call *onedb.Call) (interface{}, error) {
+	if call.Method != onedb.MethodBegin {
+		return runCall(ctx, b.pgxWrapper, call)
+	}
+	var tx Txer
+	var err error
+	if opts, ok := txOptionsArg(call.Args); ok {
+		tx, err = b.pgxWrapper.BeginTx(opts)
+	} else {
+		tx, err = b.pgxWrapper.BeginContext(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return interceptTx(tx, b.interceptors), nil
+}
+
+// Prepare prepares the statement on the backend and returns one whose statements run through the chain
+func (b *interceptedPgx) Prepare(name, sql string) (Stmt, error) {
+	if _, err := b.pgxWrapper.Prepare(name, sql); err != nil {
+		return nil, err
+	}
+	return &pgxStmt{name: name, sql: sql, q: b}, nil
+}
+
+func (b *interceptedPgx) Begin() (Txer, error) {
+	return b.BeginContext(context.Background())
+}
+
+func (b *interceptedPgx) BeginContext(ctx context.Context) (Txer, error) {
+	return interceptedTxer(b.handler(ctx, &onedb.Call{Method: onedb.MethodBegin}))
+}
+
+func (b *interceptedPgx) BeginTx(opts TxOptions) (Txer, error) {
+	return interceptedTxer(b.handler(context.Background(), &onedb.Call{Method: onedb.MethodBegin, Args: []interface{}{opts}}))
+}
+
+func (b *interceptedPgx) Exec(query string, args ...interface{}) (CommandTag, error) {
+	return b.ExecContext(context.Background(), query, args...)
+}
+
+func (b *interceptedPgx) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	return interceptedTag(b.handler(ctx, &onedb.Call{Method: onedb.MethodExec, Query: query, Args: args}))
+}
+
+func (b *interceptedPgx) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return b.QueryContext(context.Background(), query, args...)
+}
+
+func (b *interceptedPgx) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return onedb.InterceptedRows(b.handler(ctx, &onedb.Call{Method: onedb.MethodQuery, Query: query, Args: args}))
+}
+
+func (b *interceptedPgx) QueryRow(query string, args ...interface{}) onedb.Scanner {
+	return b.QueryRowContext(context.Background(), query, args...)
+}
+
+func (b *interceptedPgx) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	return onedb.InterceptedRow(b.handler(ctx, &onedb.Call{Method: onedb.MethodQueryRow, Query: query, Args: args}))
+}
+
+// interceptedTx runs the statements of a transaction, and the nested transactions it begins, through the
+// interceptor chain
+type interceptedTx struct {
+	Txer
+	interceptors []onedb.Interceptor
+	handler      onedb.Handler
+}
+
+func interceptTx(tx Txer, interceptors []onedb.Interceptor) Txer {
+	t := &interceptedTx{Txer: tx, interceptors: interceptors}
+	t.handler = onedb.ChainInterceptors(interceptors...)(t.call)
+	return t
+}
+
+func (t *interceptedTx) call(ctx context.Context, call *onedb.Call) (interface{}, error) {
+	if call.Method != onedb.MethodBegin {
+		return runCall(ctx, t.Txer, call)
+	}
+	tx, err := t.Txer.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return interceptTx(tx, t.interceptors), nil
+}
+
+// Prepare prepares the statement in the transaction and returns one whose statements run through the chain
+func (t *interceptedTx) Prepare(name, sql string) (Stmt, error) {
+	if _, err := t.Txer.Prepare(name, sql); err != nil {
+		return nil, err
+	}
+	return &pgxStmt{name: name, sql: sql, q: t}, nil
+}
+
+func (t *interceptedTx) Begin() (Txer, error) {
+	return interceptedTxer(t.handler(context.Background(), &onedb.Call{Method: onedb.MethodBegin, InTx: true}))
+}
+
+func (t *interceptedTx) Exec(query string, args ...interface{}) (CommandTag, error) {
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+func (t *interceptedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	return interceptedTag(t.handler(ctx, &onedb.Call{Method: onedb.MethodExec, Query: query, Args: args, InTx: true}))
+}
+
+func (t *interceptedTx) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return t.QueryContext(context.Background(), query, args...)
+}
+
+func (t *interceptedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return onedb.InterceptedRows(t.handler(ctx, &onedb.Call{Method: onedb.MethodQuery, Query: query, Args: args, InTx: true}))
+}
+
+func (t *interceptedTx) QueryRow(query string, args ...interface{}) onedb.Scanner {
+	return t.QueryRowContext(context.Background(), query, args...)
+}
+
+func (t *interceptedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	return onedb.InterceptedRow(t.handler(ctx, &onedb.Call{Method: onedb.MethodQueryRow, Query: query, Args: args, InTx: true}))
+}
+
+func (t *interceptedTx) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValues(t, query, result...)
+}
+
+func (t *interceptedTx) QueryJSON(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSON(t, query, args...)
+}
+
+func (t *interceptedTx) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRow(t, query, args...)
+}
+
+func (t *interceptedTx) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, t, query, args...)
+}
+
+func (t *interceptedTx) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStruct(t, result, query, args...)
+}
+
+func (t *interceptedTx) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRow(t, result, query, args...)
+}
+
+func (t *interceptedTx) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSV(w, options, t, query, args...)
+}
+
+// runCall runs a Query, QueryRow or Exec call on q
+func runCall(ctx context.Context, q querier, call *onedb.Call) (interface{}, error) {
+	switch call.Method {
+	case onedb.MethodQuery:
+		return q.QueryContext(ctx, call.Query, call.Args...)
+	case onedb.MethodQueryRow:
+		return q.QueryRowContext(ctx, call.Query, call.Args...), nil
+	case onedb.MethodExec:
+		return q.ExecContext(ctx, call.Query, call.Args...)
+	}
+	return nil, errors.Errorf("unknown method %s", call.Method)
+}
+
+func txOptionsArg(args []interface{}) (TxOptions, bool) {
+	if len(args) != 1 {
+		return TxOptions{}, false
+	}
+	opts, ok := args[0].(TxOptions)
+	return opts, ok
+}
+
+func interceptedTag(result interface{}, err error) (CommandTag, error) {
+	tag, ok := result.(CommandTag)
+	if err == nil && !ok {
+		err = errors.Errorf("interceptor returned %T rather than CommandTag for Exec", result)
+	}
+	return tag, err
+}
+
+func interceptedTxer(result interface{}, err error) (Txer, error) {
+	tx, ok := result.(Txer)
+	if err == nil && !ok {
+		err = errors.Errorf("interceptor returned %T rather than Txer for Begin", result)
+	}
+	return tx, err
+}
@@ -0,0 +1,124 @@
+package pgx
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+type interceptorData struct {
+	ID int
+}
+
+func TestIntercept(t *testing.T) {
+	m := NewMock(nil, nil, []interceptorData{{1}}, []interceptorData{{2}})
+	if intercept(m, nil) != m {
+		t.Error("expected backend unchanged without interceptors")
+	}
+
+	var calls []string
+	var beginArgs []interface{}
+	log := func(next onedb.Handler) onedb.Handler {
+		return func(ctx context.Context, call *onedb.Call) (interface{}, error) {
+			c := strings.TrimSpace(call.Method + " " + call.Query)
+			if call.InTx {
+				c += " in tx"
+			}
+			calls = append(calls, c)
+			if call.Method == onedb.MethodBegin {
+				beginArgs = call.Args
+			}
+			return next(ctx, call)
+		}
+	}
+	b := &pgxBackend{db: intercept(m, []onedb.Interceptor{log})}
+
+	var rows []interceptorData
+	if err := b.QueryStruct(&rows, "select id from t"); err != nil || len(rows) != 1 || rows[0].ID != 1 {
+		t.Error("expected rows from backend", rows, err)
+	}
+	var id int
+	if err := b.QueryRow("select id from t limit 1").Scan(&id); err != nil || id != 2 {
+		t.Error("expected row from backend", id, err)
+	}
+	if _, err := b.Exec("update t set id = 3"); err != nil {
+		t.Error("expected exec success", err)
+	}
+
+	tx, err := b.BeginTx(TxOptions{IsoLevel: Serializable})
+	if err != nil {
+		t.Fatal("expected transaction", err)
+	}
+	if len(beginArgs) != 1 || beginArgs[0].(TxOptions).IsoLevel != Serializable {
+		t.Error("expected TxOptions as the Begin call's argument", beginArgs)
+	}
+	tx.Exec("delete from t")
+	nested, err := tx.Begin()
+	if err != nil {
+		t.Fatal("expected nested transaction", err)
+	}
+	nested.ExecContext(context.Background(), "insert into t values (4)")
+	nested.Commit()
+	tx.Commit()
+
+	expected := []string{"Query select id from t", "QueryRow select id from t limit 1", "Exec update t set id = 3", "Begin",
+		"Exec delete from t in tx", "Begin in tx", "Exec insert into t values (4) in tx"}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Error("expected every call to be intercepted", calls)
+	}
+}
+
+func TestInterceptWrongResult(t *testing.T) {
+	wrong := func(next onedb.Handler) onedb.Handler {
+		return func(ctx context.Context, call *onedb.Call) (interface{}, error) {
+			return 42, nil
+		}
+	}
+	b := &pgxBackend{db: intercept(NewMock(nil, nil), []onedb.Interceptor{wrong})}
+	if _, err := b.Exec("update t set id = 1"); err == nil {
+		t.Error("expected error for a result which isn't a CommandTag")
+	}
+	if _, err := b.Begin(); err == nil {
+		t.Error("expected error for a result which isn't a Txer")
+	}
+	if _, err := b.Query("select 1"); err == nil {
+		t.Error("expected error for a result which isn't RowsScanner")
+	}
+}
+
+func TestInterceptTxParity(t *testing.T) {
+	m := NewMock(nil, nil)
+	var calls []string
+	log := func(next onedb.Handler) onedb.Handler {
+		return func(ctx context.Context, call *onedb.Call) (interface{}, error) {
+			calls = append(calls, strings.TrimSpace(call.Method+" "+call.Query))
+			return next(ctx, call)
+		}
+	}
+	b := &pgxBackend{db: intercept(m, []onedb.Interceptor{log})}
+	s, err := b.Prepare("deleteUser", "delete from users where id = $1")
+	if err != nil {
+		t.Fatal("expected statement", err)
+	}
+	s.Exec(1)
+
+	tx, _ := b.Begin()
+	s, err = tx.Prepare("updateUser", "update users set name = $2 where id = $1")
+	if err != nil {
+		t.Fatal("expected statement in transaction", err)
+	}
+	s.Exec(1, "alice")
+	if n, err := tx.CopyFrom(Identifier{"users"}, []string{"id"}, CopyFromRows([][]interface{}{{2}, {3}})); n != 2 || err != nil {
+		t.Error("expected rows copied in transaction", n, err)
+	}
+	tx.Commit()
+
+	if strings.Join(calls, ",") != "Exec deleteUser,Begin,Exec updateUser" {
+		t.Error("expected prepared statements to be intercepted", calls)
+	}
+	if copies := m.CopyFromCalls(); len(copies) != 1 || len(copies[0].Rows) != 2 {
+		t.Error("expected CopyFrom to reach the backend", copies)
+	}
+}
@@ -0,0 +1,69 @@
+package pgx
+
+import (
+	"io"
+
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// LargeObjectMode sets whether a large object is opened for reading, writing or both
+type LargeObjectMode pgx.LargeObjectMode
+
+// Modes for opening a large object. Combine them with | to read and write
+const (
+	LargeObjectModeWrite = LargeObjectMode(pgx.LargeObjectModeWrite)
+	LargeObjectModeRead  = LargeObjectMode(pgx.LargeObjectModeRead)
+)
+
+// LargeObjects creates, opens and removes the large objects stored in pg_largeobject. It is only valid within the
+// transaction it was returned from.
+//
+// For more details see: http://www.postgresql.org/docs/current/static/largeobjects.html
+type LargeObjects interface {
+	// Create creates a new large object. If id is zero, the server assigns an unused OID
+	Create(id Oid) (Oid, error)
+	Open(oid Oid, mode LargeObjectMode) (LargeObject, error)
+	Unlink(oid Oid) error
+}
+
+// LargeObject is an open large object. It streams its contents to and from the server, so it can be used with
+// io.Copy without loading the whole object in memory. It is only valid within the transaction it was opened in
+type LargeObject interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Tell() (int64, error)
+	Truncate(size int64) error
+}
+
+type pgxLargeObjects struct {
+	lo *pgx.LargeObjects
+}
+
+// LargeObjects returns the large objects API for the transaction
+func (t *pgxTx) LargeObjects() (LargeObjects, error) {
+	if t.done() {
+		return nil, ErrTxDone
+	}
+	lo, err := t.tx.LargeObjects()
+	if err != nil {
+		return nil, err
+	}
+	return &pgxLargeObjects{lo}, nil
+}
+
+func (o *pgxLargeObjects) Create(id Oid) (Oid, error) {
+	oid, err := o.lo.Create(pgx.Oid(id))
+	return Oid(oid), err
+}
+
+func (o *pgxLargeObjects) Open(oid Oid, mode LargeObjectMode) (LargeObject, error) {
+	obj, err := o.lo.Open(pgx.Oid(oid), pgx.LargeObjectMode(mode))
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (o *pgxLargeObjects) Unlink(oid Oid) error {
+	return o.lo.Unlink(pgx.Oid(oid))
+}
@@ -0,0 +1,53 @@
+package pgx
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMockLargeObjects(t *testing.T) {
+	m := NewMock(nil, nil)
+	tx, _ := m.Begin()
+	nested, _ := tx.Begin()
+	lo, err := nested.LargeObjects()
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	oid, err := lo.Create(0)
+	if err != nil || oid == 0 {
+		t.Fatal("expected an OID to be assigned", oid, err)
+	}
+	if _, err := lo.Create(oid); err == nil {
+		t.Error("expected error creating an existing object")
+	}
+
+	obj, _ := lo.Open(oid, LargeObjectModeWrite|LargeObjectModeRead)
+	if n, err := io.Copy(obj, strings.NewReader("hello world")); n != 11 || err != nil {
+		t.Fatal("expected contents to be streamed in", n, err)
+	}
+	obj.Seek(6, io.SeekStart)
+	obj.Write([]byte("there"))
+	obj.Truncate(8)
+	if pos, _ := obj.Tell(); pos != 11 {
+		t.Error("expected position after the write", pos)
+	}
+	obj.Close()
+
+	obj, _ = lo.Open(oid, LargeObjectModeRead)
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, obj); err != nil || buf.String() != "hello th" {
+		t.Error("expected contents to be streamed out", buf.String(), err)
+	}
+	if _, err := obj.Write([]byte("x")); err == nil {
+		t.Error("expected error writing an object opened for reading")
+	}
+
+	if err := lo.Unlink(oid); err != nil {
+		t.Error("expected success", err)
+	}
+	if _, err := lo.Open(oid, LargeObjectModeRead); err == nil {
+		t.Error("expected error opening an unlinked object")
+	}
+}
@@ -0,0 +1,100 @@
+package pgx
+
+import (
+	"context"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// listenPollInterval is how long WaitForNotification blocks before the context is checked again
+var listenPollInterval = time.Second
+
+// Listen subscribes to channel on a dedicated connection and delivers each notification on the returned
+// channel until ctx is done, at which point the channel is closed. If the connection dies, a new one is
+// acquired and LISTEN is run again, retrying as PoolConfig.RetryPolicy and the circuit breaker allow. The channel
+// is also closed if reconnecting gives up. Notifications sent while reconnecting are lost.
+func (b *pgxWithReconnect) Listen(ctx context.Context, channel string) (<-chan *Notification, error) {
+	conn, err := b.listenConn(channel)
+	if err != nil {
+		return nil, err
+	}
+	notifications := make(chan *Notification)
+	go b.listen(ctx, conn, channel, notifications)
+	return notifications, nil
+}
+
+func (b *pgxWithReconnect) listenConn(channel string) (*pgx.Conn, error) {
+	conn, err := b.acquire()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Listen(channel); err != nil {
+		b.release(conn)
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (b *pgxWithReconnect) listen(ctx context.Context, conn *pgx.Conn, channel string, notifications chan<- *Notification) {
+	defer close(notifications)
+	for {
+		n, err := conn.WaitForNotification(listenPollInterval)
+		if ctx.Err() != nil {
+			b.release(conn) // Release runs UNLISTEN
+			return
+		}
+		if err == pgx.ErrNotificationTimeout {
+			continue
+		} else if err != nil {
+			conn.Close() // make sure the pool discards the connection
+			b.release(conn)
+			if conn = b.relisten(ctx, channel); conn == nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case notifications <- &Notification{Pid: n.Pid, Channel: n.Channel, Payload: n.Payload}:
+		case <-ctx.Done():
+			b.release(conn)
+			return
+		}
+	}
+}
+
+// relisten acquires a new listening connection. It returns nil if ctx is done first or reconnect gives up
+func (b *pgxWithReconnect) relisten(ctx context.Context, channel string) *pgx.Conn {
+	var conn *pgx.Conn
+	err := b.reconnect(ctx, func() error {
+		var err error
+		conn, err = b.listenConn(channel)
+		return err
+	})
+	if err != nil {
+		return nil
+	}
+	return conn
+}
+
+// reconnect runs connect until it succeeds, waiting between attempts as the retry policy allows. Only
+// connection failures and an open circuit breaker are retried. While the breaker is open, attempts fail with
+// ErrCircuitOpen without running connect
+func (b *pgxWithReconnect) reconnect(ctx context.Context, connect func() error) error {
+	policy := b.retryPolicy
+	if policy == nil {
+		policy = DefaultRetryPolicy
+	}
+	return onedb.Retry(ctx, policy, func(err error) bool {
+		return err == ErrCircuitOpen || b.isConnFailure(err)
+	}, func() error {
+		if err := b.breaker.allow(b.Ping); err != nil {
+			return err
+		}
+		err := connect()
+		b.breaker.record(b.isConnFailure(err))
+		return err
+	})
+}
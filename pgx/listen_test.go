@@ -0,0 +1,55 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReconnectRetryPolicy(t *testing.T) {
+	var attempts []int
+	policy := RetryPolicyFunc(func(attempt int, elapsed time.Duration) (time.Duration, bool) {
+		attempts = append(attempts, attempt)
+		return 0, attempt <= 3
+	})
+	b := &pgxWithReconnect{retryPolicy: policy}
+	refused := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+
+	connects := 0
+	err := b.reconnect(context.Background(), func() error {
+		if connects++; connects < 3 {
+			return refused
+		}
+		return nil
+	})
+	if err != nil || connects != 3 || len(attempts) != 2 {
+		t.Error("expected reconnect retried as the policy allows", err, connects, attempts)
+	}
+
+	connects, attempts = 0, nil
+	if err := b.reconnect(context.Background(), func() error { connects++; return refused }); err != refused || connects != 4 {
+		t.Error("expected reconnect to give up with the policy", err, connects)
+	}
+
+	connects = 0
+	if err := b.reconnect(context.Background(), func() error { connects++; return errors.New("permission denied") }); err == nil || connects != 1 {
+		t.Error("expected errors which aren't connection failures not retried", err, connects)
+	}
+}
+
+func TestReconnectCircuitBreaker(t *testing.T) {
+	b := &pgxWithReconnect{
+		retryPolicy: RetryPolicyFunc(func(attempt int, elapsed time.Duration) (time.Duration, bool) { return 0, attempt <= 3 }),
+		breaker:     newCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 1, CoolDown: time.Hour}),
+	}
+	connects := 0
+	err := b.reconnect(context.Background(), func() error {
+		connects++
+		return &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	})
+	if err != ErrCircuitOpen || connects != 1 {
+		t.Error("expected attempts to stop reaching the database once the breaker opens", err, connects)
+	}
+}
@@ -0,0 +1,19 @@
+package pgx
+
+import (
+	"time"
+)
+
+// Metrics receives measurements from the pgx backend. Implementations must be safe for concurrent use
+type Metrics interface {
+	// ObserveQuery is called once each statement finishes with its operation (query, exec, copy, begin or
+	// prepare), how long it took and the error it returned, if any. A query finishes when its rows are closed
+	ObserveQuery(operation string, duration time.Duration, err error)
+
+	// ObserveReconnect is called each time the backend pings the database before retrying a statement which
+	// failed on a dead connection. err is nil if the database was reachable
+	ObserveReconnect(err error)
+
+	// ObservePool is called after each statement with the number of open and idle connections in the pool
+	ObservePool(open, idle int)
+}
@@ -1,10 +1,15 @@
 package pgx
 
 import (
+	"context"
 	"io"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
 )
 
 type mockBackend struct {
@@ -12,37 +17,193 @@ type mockBackend struct {
 	CopyFromErr error
 	ExecErr     error
 	PGXer
+
+	copyMu sync.Mutex
+	copies []CopyFromCall
+
+	loMu         sync.Mutex
+	largeObjects map[Oid][]byte
+	nextOid      Oid
+}
+
+// CopyFromCall is a CopyFrom run on the mock along with the rows read from its CopyFromSource
+type CopyFromCall struct {
+	TableName   Identifier
+	ColumnNames []string
+	Rows        [][]interface{}
 }
 
-// Mocker is the interface for mocking and includes all of the PGXer interface plus 3 methods to make testing easier
+// Mocker is the interface for mocking and includes all of the PGXer interface plus methods to make testing easier.
+// Queries and execs can be checked as they run with the onedb.Expecter methods
 type Mocker interface {
 	PGXer
 	QueriesRun() []onedb.MethodsRun
 	SaveMethodCall(name string, arguments []interface{})
 	VerifyNextCommand(t *testing.T, name string, expected ...interface{})
+	CopyFromCalls() []CopyFromCall
+	onedb.Expecter
+	onedb.Injector
 }
 
-// NewMock returns a Mock PGX instance from a set of parameters
+// NewPgError returns a PostgreSQL error with the given SQLSTATE code, such as 40001 (serialization_failure), to
+// inject into a mock with InjectFault
+func NewPgError(code, message string) error {
+	return pgx.PgError{Severity: "ERROR", Code: code, Message: message}
+}
+
+// NewMock returns a Mock PGX instance from a set of parameters. copyFromErr is returned by CopyFrom after the rows
+// have been read
 func NewMock(copyFromErr, execErr error, data ...interface{}) Mocker {
-	return &mockBackend{db: onedb.NewMock(copyFromErr, execErr, data...)}
+	return &mockBackend{db: onedb.NewMock(copyFromErr, execErr, data...), CopyFromErr: copyFromErr}
 }
 
 func (b *mockBackend) Close() {
 	b.SaveMethodCall("Close", []interface{}{})
 }
+func (b *mockBackend) Begin() (Txer, error) {
+	return b.begin()
+}
+func (b *mockBackend) BeginContext(ctx context.Context) (Txer, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+	return b.begin()
+}
+func (b *mockBackend) BeginTx(opts TxOptions) (Txer, error) {
+	b.SaveMethodCall("BeginTx", []interface{}{opts})
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	return b.begin()
+}
+func (b *mockBackend) begin() (Txer, error) {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &mockTx{b: b, tx: tx, status: TxStatusInProgress}, nil
+}
+func (b *mockBackend) Ping() error {
+	return b.db.Ping()
+}
+func (b *mockBackend) PingContext(ctx context.Context) error {
+	return contextErr(ctx, b.db.PingContext(ctx))
+}
+func (b *mockBackend) WarmUp(n int) error {
+	b.SaveMethodCall("WarmUp", []interface{}{n})
+	return nil
+}
+func (b *mockBackend) Shutdown(timeout time.Duration) error {
+	b.SaveMethodCall("Shutdown", []interface{}{timeout})
+	return nil
+}
+func (b *mockBackend) Stats() PoolStats {
+	b.SaveMethodCall("Stats", []interface{}{})
+	return PoolStats{}
+}
 func (b *mockBackend) Exec(query string, args ...interface{}) (CommandTag, error) {
 	b.SaveMethodCall("Exec", append([]interface{}{query}, args...))
+	if expected, err := b.db.MatchExec(query, args...); expected {
+		return "", err
+	}
+	return "", b.ExecErr
+}
+func (b *mockBackend) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	b.SaveMethodCall("ExecContext", append([]interface{}{query}, args...))
+	if err := canceled(ctx); err != nil {
+		return "", err
+	}
+	if expected, err := b.db.MatchExecContext(ctx, query, args...); expected {
+		return "", contextErr(ctx, err)
+	}
 	return "", b.ExecErr
 }
+func (b *mockBackend) Listen(ctx context.Context, channel string) (<-chan *Notification, error) {
+	b.SaveMethodCall("Listen", []interface{}{channel})
+	notifications := make(chan *Notification)
+	go func() {
+		<-ctx.Done()
+		close(notifications)
+	}()
+	return notifications, nil
+}
 func (b *mockBackend) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
 	return b.db.Query(query, args...)
 }
+func (b *mockBackend) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	return rows, contextErr(ctx, err)
+}
 func (b *mockBackend) QueryRow(query string, args ...interface{}) onedb.Scanner {
 	return b.db.QueryRow(query, args...)
 }
+func (b *mockBackend) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	row := b.db.QueryRowContext(ctx, query, args...)
+	if err := canceled(ctx); err != nil {
+		return &errRow{err}
+	}
+	return row
+}
 func (b *mockBackend) CopyFrom(tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int, error) {
 	b.SaveMethodCall("CopyFrom", []interface{}{tableName, columnNames, rowSrc})
-	return 0, b.CopyFromErr
+	call := CopyFromCall{TableName: tableName, ColumnNames: columnNames}
+	err := readCopyFromSource(rowSrc, &call)
+	b.copyMu.Lock()
+	b.copies = append(b.copies, call)
+	b.copyMu.Unlock()
+	if err != nil {
+		return len(call.Rows), err
+	}
+	return len(call.Rows), b.CopyFromErr
+}
+
+// readCopyFromSource reads every row from rowSrc into call, copying the values since a source may reuse its slice
+func readCopyFromSource(rowSrc CopyFromSource, call *CopyFromCall) error {
+	if rowSrc == nil {
+		return nil
+	}
+	for rowSrc.Next() {
+		values, err := rowSrc.Values()
+		if err != nil {
+			return err
+		}
+		call.Rows = append(call.Rows, append([]interface{}(nil), values...))
+	}
+	return rowSrc.Err()
+}
+
+// QueryCursor runs the cursor's statements in a mock transaction, so they are matched against expectations and
+// each fetch returns the next data
+func (b *mockBackend) QueryCursor(query string, fetchSize int, args ...interface{}) (onedb.RowsScanner, error) {
+	tx, err := b.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return queryCursorTx(tx, query, fetchSize, args)
+}
+
+// BulkInsert runs the insert statements in a mock transaction so they are matched against expectations
+func (b *mockBackend) BulkInsert(tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	tx, err := b.Begin()
+	if err != nil {
+		return 0, err
+	}
+	return bulkInsertTx(tx, tableName, columnNames, rows, suffix)
+}
+func (b *mockBackend) CommitPrepared(gid string) error {
+	_, err := b.Exec("commit prepared " + quoteLiteral(gid))
+	return err
+}
+func (b *mockBackend) RollbackPrepared(gid string) error {
+	_, err := b.Exec("rollback prepared " + quoteLiteral(gid))
+	return err
+}
+func (b *mockBackend) Prepare(name, sql string) (Stmt, error) {
+	b.SaveMethodCall("Prepare", []interface{}{name, sql})
+	return &pgxStmt{name: name, sql: sql, q: b}, nil
 }
 func (b *mockBackend) QueryValues(query *onedb.Query, result ...interface{}) error {
 	return onedb.QueryValues(b, query, result...)
@@ -53,6 +214,9 @@ func (b *mockBackend) QueryJSON(query string, args ...interface{}) (string, erro
 func (b *mockBackend) QueryJSONRow(query string, args ...interface{}) (string, error) {
 	return onedb.QueryJSONRow(b, query, args...)
 }
+func (b *mockBackend) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, b, query, args...)
+}
 func (b *mockBackend) QueryStruct(result interface{}, query string, args ...interface{}) error {
 	return onedb.QueryStruct(b, result, query, args...)
 }
@@ -71,3 +235,330 @@ func (b *mockBackend) SaveMethodCall(name string, arguments []interface{}) {
 func (b *mockBackend) VerifyNextCommand(t *testing.T, name string, expected ...interface{}) {
 	b.db.VerifyNextCommand(t, name, expected...)
 }
+
+// CopyFromCalls returns the CopyFrom calls run on the mock and in its transactions, in order
+func (b *mockBackend) CopyFromCalls() []CopyFromCall {
+	b.copyMu.Lock()
+	defer b.copyMu.Unlock()
+	return append([]CopyFromCall(nil), b.copies...)
+}
+func (b *mockBackend) InjectFault(query string) *onedb.Fault {
+	return b.db.InjectFault(query)
+}
+func (b *mockBackend) ExpectBegin() *onedb.Expectation {
+	return b.db.ExpectBegin()
+}
+func (b *mockBackend) ExpectCommit() *onedb.Expectation {
+	return b.db.ExpectCommit()
+}
+func (b *mockBackend) ExpectRollback() *onedb.Expectation {
+	return b.db.ExpectRollback()
+}
+func (b *mockBackend) ExpectQuery(query string) *onedb.Expectation {
+	return b.db.ExpectQuery(query)
+}
+func (b *mockBackend) ExpectExec(query string) *onedb.Expectation {
+	return b.db.ExpectExec(query)
+}
+func (b *mockBackend) MatchExpectationsInOrder(inOrder bool) {
+	b.db.MatchExpectationsInOrder(inOrder)
+}
+func (b *mockBackend) SetQueryMatcher(matcher onedb.QueryMatcher) {
+	b.db.SetQueryMatcher(matcher)
+}
+func (b *mockBackend) ExpectationsWereMet() error {
+	return b.db.ExpectationsWereMet()
+}
+func (b *mockBackend) VerifyExpectations(t *testing.T) {
+	t.Helper()
+	b.db.VerifyExpectations(t)
+}
+
+// mockTx is a transaction on the mock. Its statements are checked against the expectations added between
+// ExpectBegin and ExpectCommit or ExpectRollback
+type mockTx struct {
+	b      *mockBackend
+	tx     onedb.MockTxer
+	status int8
+	hooks  txHooks
+}
+
+func (t *mockTx) Begin() (Txer, error) {
+	if t.status != TxStatusInProgress {
+		return nil, ErrTxDone
+	}
+	return beginSavepoint(t, 1)
+}
+func (t *mockTx) Commit() error {
+	inProgress := t.status == TxStatusInProgress
+	err := t.tx.Commit()
+	if err == nil {
+		t.status = TxStatusCommitSuccess
+	} else if inProgress {
+		t.status = TxStatusCommitFailure
+	}
+	if inProgress {
+		t.hooks.run(err == nil)
+	}
+	return err
+}
+func (t *mockTx) Conn() *pgx.Conn {
+	return nil
+}
+func (t *mockTx) Rollback() error {
+	inProgress := t.status == TxStatusInProgress
+	err := t.tx.Rollback()
+	if err == nil {
+		t.status = TxStatusRollbackSuccess
+	} else if inProgress {
+		t.status = TxStatusRollbackFailure
+	}
+	if inProgress {
+		t.hooks.run(false)
+	}
+	return err
+}
+func (t *mockTx) PrepareTransaction(gid string) error {
+	t.hooks = txHooks{}
+	return prepareTransaction(t, gid)
+}
+func (t *mockTx) RollbackTo(name string) error {
+	return rollbackTo(t, name)
+}
+func (t *mockTx) Savepoint(name string) error {
+	return savepoint(t, name)
+}
+func (t *mockTx) Status() int8 {
+	return t.status
+}
+
+// LargeObjects returns large objects kept in memory by the mock. Unlike on a server, changes to them aren't undone
+// when the transaction is rolled back
+func (t *mockTx) LargeObjects() (LargeObjects, error) {
+	t.b.SaveMethodCall("LargeObjects", nil)
+	if t.status != TxStatusInProgress {
+		return nil, ErrTxDone
+	}
+	return &mockLargeObjects{b: t.b}, nil
+}
+func (t *mockTx) Exec(query string, args ...interface{}) (CommandTag, error) {
+	t.b.SaveMethodCall("Exec", append([]interface{}{query}, args...))
+	return t.exec(context.Background(), query, args)
+}
+func (t *mockTx) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	if err := canceled(ctx); err != nil {
+		t.b.SaveMethodCall("ExecContext", append([]interface{}{query}, args...))
+		return "", err
+	}
+	t.b.SaveMethodCall("Exec", append([]interface{}{query}, args...))
+	return t.exec(ctx, query, args)
+}
+func (t *mockTx) exec(ctx context.Context, query string, args []interface{}) (CommandTag, error) {
+	if expected, err := t.tx.MatchExecContext(ctx, query, args...); expected {
+		return "", contextErr(ctx, err)
+	}
+	return "", t.b.ExecErr
+}
+func (t *mockTx) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return t.tx.Query(query, args...)
+}
+func (t *mockTx) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	return rows, contextErr(ctx, err)
+}
+func (t *mockTx) QueryRow(query string, args ...interface{}) onedb.Scanner {
+	return t.tx.QueryRow(query, args...)
+}
+func (t *mockTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	row := t.tx.QueryRowContext(ctx, query, args...)
+	if err := canceled(ctx); err != nil {
+		return &errRow{err}
+	}
+	return row
+}
+func (t *mockTx) CopyFrom(tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int, error) {
+	if t.status != TxStatusInProgress {
+		return 0, ErrTxDone
+	}
+	return t.b.CopyFrom(tableName, columnNames, rowSrc)
+}
+func (t *mockTx) QueryCursor(query string, fetchSize int, args ...interface{}) (onedb.RowsScanner, error) {
+	return queryCursor(t, query, fetchSize, args, noCursorTx)
+}
+func (t *mockTx) BulkInsert(tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error) {
+	return bulkInsert(t, tableName, columnNames, rows, suffix)
+}
+func (t *mockTx) Prepare(name, sql string) (Stmt, error) {
+	t.b.SaveMethodCall("Prepare", []interface{}{name, sql})
+	if t.status != TxStatusInProgress {
+		return nil, ErrTxDone
+	}
+	return &pgxStmt{name: name, sql: sql, q: t}, nil
+}
+func (t *mockTx) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValues(t, query, result...)
+}
+func (t *mockTx) QueryJSON(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSON(t, query, args...)
+}
+func (t *mockTx) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRow(t, query, args...)
+}
+func (t *mockTx) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, t, query, args...)
+}
+func (t *mockTx) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStruct(t, result, query, args...)
+}
+func (t *mockTx) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRow(t, result, query, args...)
+}
+func (t *mockTx) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSV(w, options, t, query, args...)
+}
+
+type mockLargeObjects struct {
+	b *mockBackend
+}
+
+func (o *mockLargeObjects) Create(id Oid) (Oid, error) {
+	o.b.loMu.Lock()
+	defer o.b.loMu.Unlock()
+	if o.b.largeObjects == nil {
+		o.b.largeObjects = make(map[Oid][]byte)
+		o.b.nextOid = 16384
+	}
+	if id == 0 {
+		for o.b.largeObjects[o.b.nextOid] != nil {
+			o.b.nextOid++
+		}
+		id = o.b.nextOid
+	} else if o.b.largeObjects[id] != nil {
+		return 0, errors.Errorf("large object %d already exists", id)
+	}
+	o.b.largeObjects[id] = []byte{}
+	return id, nil
+}
+
+func (o *mockLargeObjects) Open(oid Oid, mode LargeObjectMode) (LargeObject, error) {
+	o.b.loMu.Lock()
+	defer o.b.loMu.Unlock()
+	if o.b.largeObjects[oid] == nil {
+		return nil, errors.Errorf("large object %d does not exist", oid)
+	}
+	return &mockLargeObject{b: o.b, oid: oid, mode: mode}, nil
+}
+
+func (o *mockLargeObjects) Unlink(oid Oid) error {
+	o.b.loMu.Lock()
+	defer o.b.loMu.Unlock()
+	if o.b.largeObjects[oid] == nil {
+		return errors.Errorf("large object %d does not exist", oid)
+	}
+	delete(o.b.largeObjects, oid)
+	return nil
+}
+
+type mockLargeObject struct {
+	b      *mockBackend
+	oid    Oid
+	mode   LargeObjectMode
+	pos    int64
+	closed bool
+}
+
+// data returns the object's contents, checking it can still be used in mode. It must be called with loMu held
+func (o *mockLargeObject) data(mode LargeObjectMode) ([]byte, error) {
+	if o.closed {
+		return nil, errors.New("large object is closed")
+	}
+	if o.mode&mode == 0 {
+		return nil, errors.Errorf("large object %d was not opened for this operation", o.oid)
+	}
+	data := o.b.largeObjects[o.oid]
+	if data == nil {
+		return nil, errors.Errorf("large object %d does not exist", o.oid)
+	}
+	return data, nil
+}
+
+func (o *mockLargeObject) Read(p []byte) (int, error) {
+	o.b.loMu.Lock()
+	defer o.b.loMu.Unlock()
+	data, err := o.data(LargeObjectModeRead)
+	if err != nil {
+		return 0, err
+	}
+	if o.pos >= int64(len(data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, data[o.pos:])
+	o.pos += int64(n)
+	return n, nil
+}
+
+func (o *mockLargeObject) Write(p []byte) (int, error) {
+	o.b.loMu.Lock()
+	defer o.b.loMu.Unlock()
+	data, err := o.data(LargeObjectModeWrite)
+	if err != nil {
+		return 0, err
+	}
+	if end := o.pos + int64(len(p)); end > int64(len(data)) {
+		data = append(data, make([]byte, end-int64(len(data)))...)
+	}
+	copy(data[o.pos:], p)
+	o.b.largeObjects[o.oid] = data
+	o.pos += int64(len(p))
+	return len(p), nil
+}
+
+func (o *mockLargeObject) Seek(offset int64, whence int) (int64, error) {
+	o.b.loMu.Lock()
+	defer o.b.loMu.Unlock()
+	data, err := o.data(LargeObjectModeRead | LargeObjectModeWrite)
+	if err != nil {
+		return 0, err
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.pos
+	case io.SeekEnd:
+		offset += int64(len(data))
+	}
+	if offset < 0 {
+		return 0, errors.New("invalid seek offset")
+	}
+	o.pos = offset
+	return o.pos, nil
+}
+
+func (o *mockLargeObject) Tell() (int64, error) {
+	return o.Seek(0, io.SeekCurrent)
+}
+
+func (o *mockLargeObject) Truncate(size int64) error {
+	o.b.loMu.Lock()
+	defer o.b.loMu.Unlock()
+	data, err := o.data(LargeObjectModeWrite)
+	if err != nil {
+		return err
+	}
+	if size < int64(len(data)) {
+		data = data[:size]
+	} else {
+		data = append(data, make([]byte, size-int64(len(data)))...)
+	}
+	o.b.largeObjects[o.oid] = data
+	return nil
+}
+
+func (o *mockLargeObject) Close() error {
+	o.b.loMu.Lock()
+	defer o.b.loMu.Unlock()
+	if o.closed {
+		return errors.New("large object is closed")
+	}
+	o.closed = true
+	return nil
+}
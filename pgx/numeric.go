@@ -0,0 +1,42 @@
+package pgx
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// decodeNumerics parses in place the numeric values pgx returns as text into decimal.Decimals, which keep every
+// digit unlike float64, including the elements of numeric arrays. decimal.Decimal is also accepted as a query
+// argument
+func decodeNumerics(fields []pgx.FieldDescription, values []interface{}) error {
+	for i := 0; i < len(fields) && i < len(values); i++ {
+		var err error
+		switch fields[i].DataTypeName {
+		case "numeric":
+			if text, ok := values[i].(string); ok {
+				values[i], err = parseNumeric(text)
+			}
+		case "_numeric":
+			if elements, ok := values[i].([]interface{}); ok {
+				for e, element := range elements {
+					if text, ok := element.(string); ok && err == nil {
+						elements[e], err = parseNumeric(text)
+					}
+				}
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Unable to parse numeric column %s", fields[i].Name)
+		}
+	}
+	return nil
+}
+
+// parseNumeric parses numeric text. NaN, which decimal.Decimal can't hold, is left as text
+func parseNumeric(text string) (interface{}, error) {
+	if text == "NaN" {
+		return text, nil
+	}
+	return decimal.NewFromString(text)
+}
@@ -0,0 +1,41 @@
+package pgx
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestPgxRowsNumeric(t *testing.T) {
+	m := newMockPgxRows()
+	m.Fields = []pgx.FieldDescription{{Name: "amount", DataTypeName: "numeric"}, {Name: "rates", DataTypeName: "_numeric"}}
+	m.ValuesData = []interface{}{"12345678901234567890.01", "{0.1,NULL,NaN}"}
+	r := &pgxRows{rows: m}
+	values, err := r.Values()
+	if err != nil || !values[0].(decimal.Decimal).Equal(decimal.RequireFromString("12345678901234567890.01")) {
+		t.Fatal("expected numeric parsed into a decimal", values, err)
+	}
+	if rates := values[1].([]interface{}); !rates[0].(decimal.Decimal).Equal(decimal.RequireFromString("0.1")) || rates[1] != nil || rates[2] != "NaN" {
+		t.Error("expected numeric elements parsed", rates)
+	}
+
+	m.ValuesData = []interface{}{"0.30", "{0.1}"}
+	var amount decimal.Decimal
+	var rates []decimal.Decimal
+	if err := r.Scan(&amount, &rates); err != nil || amount.String() != "0.3" || len(rates) != 1 || rates[0].String() != "0.1" {
+		t.Error("expected numerics scanned into decimals", amount, rates, err)
+	}
+	var rat big.Rat
+	var text []string
+	if err := r.Scan(&rat, &text); err != nil || rat.Cmp(big.NewRat(3, 10)) != 0 || !reflect.DeepEqual(text, []string{"0.1"}) {
+		t.Error("expected numerics scanned into a big.Rat and strings", rat.String(), text, err)
+	}
+
+	m.ValuesData = []interface{}{"1.2.3", nil}
+	if _, err := r.Values(); err == nil {
+		t.Error("expected error for an invalid numeric")
+	}
+}
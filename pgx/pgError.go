@@ -0,0 +1,37 @@
+package pgx
+
+import (
+	"github.com/EndFirstCorp/onedb"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func init() {
+	onedb.RegisterPgErrorConverter(toPgError)
+	onedb.RegisterRetryableErrors(pgx.ErrDeadConn)
+	onedb.RegisterTimeoutErrors(pgx.ErrAcquireTimeout)
+	onedb.RegisterSentinelErrors(pgx.ErrNoRows, pgx.ErrTxClosed, ErrCanceled, ErrCircuitOpen, ErrShutdown)
+}
+
+// toPgError converts pgx's errors for onedb.AsPgError
+func toPgError(err error) (*onedb.PgError, bool) {
+	var pgErr pgx.PgError
+	switch e := err.(type) {
+	case pgx.PgError:
+		pgErr = e
+	case *pgx.PgError:
+		pgErr = *e
+	default:
+		return nil, false
+	}
+	return &onedb.PgError{
+		Severity:       pgErr.Severity,
+		Code:           pgErr.Code,
+		Message:        pgErr.Message,
+		Detail:         pgErr.Detail,
+		Hint:           pgErr.Hint,
+		SchemaName:     pgErr.SchemaName,
+		TableName:      pgErr.TableName,
+		ColumnName:     pgErr.ColumnName,
+		ConstraintName: pgErr.ConstraintName,
+	}, true
+}
@@ -0,0 +1,26 @@
+package pgx
+
+import (
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestPgError(t *testing.T) {
+	err := onedb.WrapQueryError(pgx.PgError{Code: "23505", ConstraintName: "users_email_key", TableName: "users", Detail: "Key exists"}, "pgx", onedb.QueryErrorOptions{}, "insert")
+	pgErr, ok := onedb.AsPgError(err)
+	if !ok || pgErr.ConstraintName != "users_email_key" || pgErr.TableName != "users" || pgErr.Detail != "Key exists" || !onedb.IsUniqueViolation(err) {
+		t.Error("expected pgx's error converted", pgErr)
+	}
+	if !onedb.IsForeignKeyViolation(&pgx.PgError{Code: "23503"}) || onedb.IsUniqueViolation(NewPgError("40001", "fail")) {
+		t.Error("expected errors classified by code")
+	}
+
+	if !onedb.IsRetryable(NewPgError("40001", "could not serialize access")) || !onedb.IsRetryable(ErrDeadConn) || !onedb.IsTimeout(ErrAcquireTimeout) {
+		t.Error("expected pgx's errors classified")
+	}
+	if !onedb.IsConstraintViolation(NewPgError("23502", "null value")) || onedb.IsRetryable(ErrNoRows) {
+		t.Error("expected pgx's errors classified")
+	}
+}
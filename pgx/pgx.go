@@ -1,7 +1,9 @@
 package pgx
 
 import (
+	"context"
 	"io"
+	"time"
 
 	"github.com/EndFirstCorp/onedb"
 	pgx "gopkg.in/jackc/pgx.v2"
@@ -14,57 +16,136 @@ type pgxBackend struct {
 
 type PGXer interface {
 	pgxWrapper
+	QueryCursor(query string, fetchSize int, args ...interface{}) (onedb.RowsScanner, error)
+	BulkInsert(tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error)
+	CommitPrepared(gid string) error
+	RollbackPrepared(gid string) error
 	onedb.DBer
 }
 
-// NewPgxFromURI returns a PGX DBer instance from a connection URI
+// NewPgxFromURI returns a PGX DBer instance from a postgres:// connection URI or libpq keyword/value DSN.
+// See ParseConnString for the supported parameters
 func NewPgxFromURI(uri string) (PGXer, error) {
-	connConfig, err := pgx.ParseURI(uri)
+	connConfig, err := ParseConnString(uri)
 	if err != nil {
 		return nil, err
 	}
-	return newPgx(&connConfig)
+	return newPgx(PoolConfig{ConnConfig: connConfig})
 }
 
 // NewPgx returns a PGX DBer instance from a set of parameters
 func NewPgx(server string, port uint16, username string, password string, database string) (PGXer, error) {
-	return newPgx(&pgx.ConnConfig{Host: server, Port: port, User: username, Password: password, Database: database, Dial: onedb.DialTCP})
+	return newPgx(PoolConfig{ConnConfig: ConnConfig{Host: server, Port: port, User: username, Password: password, Database: database, Dial: onedb.DialTCP}})
 }
 
-func newPgx(connConfig *pgx.ConnConfig) (PGXer, error) {
-	poolConfig := pgx.ConnPoolConfig{ConnConfig: *connConfig, MaxConnections: 10}
-	pgxDb, err := pgx.NewConnPool(poolConfig)
+func newPgx(config PoolConfig) (PGXer, error) {
+	connConfig := pgx.ConnConfig(config.ConnConfig)
+	maxConnections := config.MaxConnections
+	if maxConnections == 0 {
+		maxConnections = 10
+	}
+	times := newConnTimes(config)
+	stmts := newStmtCache(config.StatementCacheSize)
+	if config.SimpleProtocol {
+		stmts = nil
+	}
+	pgxDb, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig:     connConfig,
+		MaxConnections: maxConnections,
+		AcquireTimeout: config.AcquireTimeout,
+		AfterConnect:   afterConnect(times, sessionTimeouts(config), config.OnConnect),
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &pgxBackend{db: &pgxWithReconnect{db: pgxDb}}, nil
+	return &pgxBackend{db: intercept(&pgxWithReconnect{
+		db:                 pgxDb,
+		config:             &connConfig,
+		retryPolicy:        config.RetryPolicy,
+		deadConnPredicates: config.DeadConnPredicates,
+		breaker:            newCircuitBreaker(config.CircuitBreaker),
+		inst:               newInstrumentation(pgxDb, config),
+		times:              times,
+		stmts:              stmts,
+		errs:               newQueryErrors(config.QueryErrors, config.RedactArgs),
+		comments:           config.SQLComment,
+		simple:             config.SimpleProtocol,
+	}, config.Interceptors)}, nil
 }
 
 func (b *pgxBackend) Begin() (Txer, error) {
 	return b.db.Begin()
 }
 
+func (b *pgxBackend) BeginContext(ctx context.Context) (Txer, error) {
+	return b.db.BeginContext(ctx)
+}
+
+func (b *pgxBackend) BeginTx(opts TxOptions) (Txer, error) {
+	return b.db.BeginTx(opts)
+}
+
 func (b *pgxBackend) Close() {
 	b.db.Close()
 }
 
+func (b *pgxBackend) Listen(ctx context.Context, channel string) (<-chan *Notification, error) {
+	return b.db.Listen(ctx, channel)
+}
+
+func (b *pgxBackend) Ping() error {
+	return b.db.Ping()
+}
+
+func (b *pgxBackend) PingContext(ctx context.Context) error {
+	return b.db.PingContext(ctx)
+}
+
+func (b *pgxBackend) WarmUp(n int) error {
+	return b.db.WarmUp(n)
+}
+
+func (b *pgxBackend) Shutdown(timeout time.Duration) error {
+	return b.db.Shutdown(timeout)
+}
+
+func (b *pgxBackend) Stats() PoolStats {
+	return b.db.Stats()
+}
+
 func (b *pgxBackend) Exec(query string, args ...interface{}) (CommandTag, error) {
 	return b.db.Exec(query, args...)
 }
 
+func (b *pgxBackend) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	return b.db.ExecContext(ctx, query, args...)
+}
+
 func (b *pgxBackend) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
 	return b.db.Query(query, args...)
 }
 
+func (b *pgxBackend) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return b.db.QueryContext(ctx, query, args...)
+}
+
 func (b *pgxBackend) QueryRow(query string, args ...interface{}) onedb.Scanner {
 	return b.db.QueryRow(query, args...)
 }
 
+func (b *pgxBackend) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	return b.db.QueryRowContext(ctx, query, args...)
+}
+
 func (b *pgxBackend) CopyFrom(tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int, error) {
 	return b.db.CopyFrom(tableName, columnNames, rowSrc)
 }
 
+func (b *pgxBackend) Prepare(name, sql string) (Stmt, error) {
+	return b.db.Prepare(name, sql)
+}
+
 func (b *pgxBackend) QueryValues(query *onedb.Query, result ...interface{}) error {
 	return onedb.QueryValues(b, query, result...)
 }
@@ -77,6 +158,10 @@ func (b *pgxBackend) QueryJSONRow(query string, args ...interface{}) (string, er
 	return onedb.QueryJSONRow(b, query, args...)
 }
 
+func (b *pgxBackend) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, b, query, args...)
+}
+
 func (b *pgxBackend) QueryStruct(result interface{}, query string, args ...interface{}) error {
 	return onedb.QueryStruct(b, result, query, args...)
 }
@@ -90,15 +175,35 @@ func (b *pgxBackend) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query
 }
 
 type pgxTx struct {
-	tx *pgx.Tx
+	tx       *pgx.Tx
+	config   *pgx.ConnConfig
+	inst     *instrumentation
+	stmts    *stmtCache
+	errs     *queryErrors
+	comments *onedb.SQLCommentOptions
+	hooks    txHooks
 	Txer
 }
 
+// Txer is a transaction. It runs everything a PGXer does, including CopyFrom for bulk loading, Prepare and
+// QueryRow, on the transaction's connection with the same instrumentation, statement cache, comments and
+// interceptors. Unlike on the pool nothing is retried after a dead connection, since the transaction is lost with
+// it, and Prepare only prepares the statement on the transaction's connection. OnCommit and OnRollback register
+// funcs to run once the transaction ends, such as cache invalidation which must wait until the changes commit
 type Txer interface {
+	Begin() (Txer, error)
 	Commit() error
+	OnCommit(f func())
+	OnRollback(f func())
 	Conn() *pgx.Conn
 	Rollback() error
+	RollbackTo(name string) error
+	Savepoint(name string) error
 	Status() int8
+	LargeObjects() (LargeObjects, error)
+	QueryCursor(query string, fetchSize int, args ...interface{}) (onedb.RowsScanner, error)
+	BulkInsert(tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error)
+	PrepareTransaction(gid string) error
 	PGXQuerier
 }
 
@@ -107,16 +212,54 @@ type PGXQuerier interface {
 	onedb.DBer
 }
 
+// Begin starts a transaction nested inside this one using a savepoint. Committing the nested transaction
+// keeps its changes as part of this one and rolling it back undoes only its own changes
+func (t *pgxTx) Begin() (Txer, error) {
+	if t.done() {
+		return nil, ErrTxDone
+	}
+	return beginSavepoint(t, 1)
+}
+
+// Commit commits the transaction. It returns ErrTxDone if the transaction has already been committed or rolled back
 func (t *pgxTx) Commit() error {
-	return t.tx.Commit()
+	if t.done() {
+		return ErrTxDone
+	}
+	err := t.tx.Commit()
+	t.hooks.run(err == nil)
+	return err
 }
 
 func (t *pgxTx) Conn() *pgx.Conn {
 	return t.tx.Conn()
 }
 
+// Rollback rolls back the transaction. It returns ErrTxDone if the transaction has already been committed or
+// rolled back, so a deferred Rollback after Commit is harmless
 func (t *pgxTx) Rollback() error {
-	return t.tx.Rollback()
+	if t.done() {
+		return ErrTxDone
+	}
+	err := t.tx.Rollback()
+	t.hooks.run(false)
+	return err
+}
+
+// done reports whether the transaction has been committed or rolled back, including when the attempt failed,
+// since the connection no longer has a transaction either way
+func (t *pgxTx) done() bool {
+	return t.tx.Status() != pgx.TxStatusInProgress
+}
+
+// RollbackTo undoes all changes made since the named savepoint was created
+func (t *pgxTx) RollbackTo(name string) error {
+	return rollbackTo(t, name)
+}
+
+// Savepoint creates a named savepoint which can be rolled back to with RollbackTo
+func (t *pgxTx) Savepoint(name string) error {
+	return savepoint(t, name)
 }
 
 func (t *pgxTx) Status() int8 {
@@ -124,23 +267,123 @@ func (t *pgxTx) Status() int8 {
 }
 
 func (t *pgxTx) CopyFrom(tableName Identifier, columnNames []string, rows CopyFromSource) (int, error) {
-	return t.tx.CopyFrom(pgx.Identifier(tableName), columnNames, rows)
+	if t.done() {
+		return 0, ErrTxDone
+	}
+	st := t.inst.instrument(context.Background(), opCopy, pgx.Identifier(tableName).Sanitize(), nil)
+	var count int
+	var err error
+	st.run(func() {
+		count, err = t.tx.CopyFrom(pgx.Identifier(tableName), columnNames, rows)
+	})
+	st.addRows(int64(count))
+	st.finish(err)
+	return count, err
+}
+
+// Prepare creates a prepared statement on the transaction's connection
+func (t *pgxTx) Prepare(name, sql string) (Stmt, error) {
+	if t.done() {
+		return nil, ErrTxDone
+	}
+	st := t.inst.instrument(context.Background(), opPrepare, sql, nil)
+	_, err := t.tx.Prepare(name, sql)
+	st.finish(err)
+	if err != nil {
+		return nil, err
+	}
+	return &pgxStmt{name: name, sql: sql, q: t}, nil
 }
 
 func (t *pgxTx) QueryRow(query string, args ...interface{}) onedb.Scanner {
-	return t.tx.QueryRow(query, args...)
+	return t.QueryRowContext(context.Background(), query, args...)
+}
+
+func (t *pgxTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	errs := t.errs.statement(query, args)
+	rows, st, err := t.queryContext(ctx, query, args...)
+	if err != nil {
+		return &errRow{errs.wrap(err)}
+	}
+	return &pgxRow{rows: rows, st: st, errs: errs}
 }
 
 func (t *pgxTx) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
-	rows, err := t.tx.Query(query, args...)
+	return t.QueryContext(context.Background(), query, args...)
+}
+
+func (t *pgxTx) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	errs := t.errs.statement(query, args)
+	rows, st, err := t.queryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, errs.wrap(err)
+	}
+	return &pgxRows{rows: rows, st: st, errs: errs}, errs.wrap(rows.Err())
+}
+
+func (t *pgxTx) queryContext(ctx context.Context, query string, args ...interface{}) (*pgx.Rows, *statement, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, nil, err
+	}
+	if t.done() {
+		return nil, nil, ErrTxDone
+	}
+	args, err := encodeArgs(args)
+	if err != nil {
+		return nil, nil, err
 	}
-	return &pgxRows{rows: rows}, rows.Err()
+	st := t.inst.instrument(ctx, opQuery, query, args)
+	stop := watchContext(ctx, t.config, t.tx.Conn())
+	var rows *pgx.Rows
+	st.run(func() {
+		rows, err = t.tx.Query(commented(ctx, t.comments, t.stmts.statement(t.tx.Conn(), query, args), query), args...)
+	})
+	if err != nil {
+		stop()
+		t.stmts.invalidate(t.tx.Conn(), query, err)
+		err = contextErr(ctx, err)
+		st.finish(err)
+		return nil, nil, err
+	}
+	st.setFields(rows.FieldDescriptions())
+	rows.AfterClose(func(r *pgx.Rows) {
+		stop()
+		st.finish(r.Err())
+	})
+	return rows, st, nil
 }
 
 func (t *pgxTx) Exec(query string, args ...interface{}) (CommandTag, error) {
-	tag, err := t.tx.Exec(query, args...)
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+func (t *pgxTx) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	tag, err := t.execContext(ctx, query, args...)
+	return tag, t.errs.statement(query, args).wrap(err)
+}
+
+func (t *pgxTx) execContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	if err := canceled(ctx); err != nil {
+		return "", err
+	}
+	if t.done() {
+		return "", ErrTxDone
+	}
+	args, err := encodeArgs(args)
+	if err != nil {
+		return "", err
+	}
+	st := t.inst.instrument(ctx, opExec, query, args)
+	stop := watchContext(ctx, t.config, t.tx.Conn())
+	var tag pgx.CommandTag
+	st.run(func() {
+		tag, err = t.tx.Exec(commented(ctx, t.comments, t.stmts.statement(t.tx.Conn(), query, args), query), args...)
+	})
+	stop()
+	t.stmts.invalidate(t.tx.Conn(), query, err)
+	err = contextErr(ctx, err)
+	st.addRows(tag.RowsAffected())
+	st.finish(err)
 	return CommandTag(tag), err
 }
 
@@ -156,6 +399,10 @@ func (t *pgxTx) QueryJSONRow(query string, args ...interface{}) (string, error)
 	return onedb.QueryJSONRow(t, query, args...)
 }
 
+func (t *pgxTx) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, t, query, args...)
+}
+
 func (t *pgxTx) QueryStruct(result interface{}, query string, args ...interface{}) error {
 	return onedb.QueryStruct(t, result, query, args...)
 }
@@ -1,7 +1,16 @@
 package pgx
 
 import (
-	"math"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,15 +21,35 @@ import (
 
 type pgxWrapper interface {
 	Begin() (Txer, error)
+	BeginContext(ctx context.Context) (Txer, error)
+	BeginTx(opts TxOptions) (Txer, error)
 	Close()
+	Listen(ctx context.Context, channel string) (<-chan *Notification, error)
+	Stats() PoolStats
+	WarmUp(n int) error
+	Shutdown(timeout time.Duration) error
+	onedb.Pinger
 	querier
 }
 
 type querier interface {
 	Exec(query string, args ...interface{}) (CommandTag, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error)
 	Query(query string, args ...interface{}) (onedb.RowsScanner, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error)
 	QueryRow(query string, args ...interface{}) onedb.Scanner
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner
 	CopyFrom(tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int, error)
+	Prepare(name, sql string) (Stmt, error)
+}
+
+// Stmt is a statement which has been parsed by the server once and can be run repeatedly with different arguments
+type Stmt interface {
+	Name() string
+	SQL() string
+	Exec(args ...interface{}) (CommandTag, error)
+	Query(args ...interface{}) (onedb.RowsScanner, error)
+	QueryRow(args ...interface{}) onedb.Scanner
 }
 
 // Rower is the public interface for all the capability found in a *pgx.Rows. Note that the Close method
@@ -36,7 +65,8 @@ type Rower interface {
 	onedb.Scanner
 	Values() ([]interface{}, error)
 
-	Columns() ([]string, error) // added
+	Columns() ([]string, error)               // added
+	ColumnTypes() ([]onedb.ColumnType, error) // added
 }
 
 type pgxRower interface {
@@ -52,9 +82,19 @@ type pgxRower interface {
 }
 
 type pgxWithReconnect struct {
-	db         *pgx.ConnPool
-	lastRetry  time.Time
-	retryCount int
+	db                 *pgx.ConnPool
+	config             *pgx.ConnConfig
+	retryPolicy        RetryPolicy
+	deadConnPredicates []DeadConnPredicate
+	breaker            *circuitBreaker
+	inst               *instrumentation
+	counters           poolCounters
+	times              *connTimes
+	stmts              *stmtCache
+	errs               *queryErrors
+	comments           *onedb.SQLCommentOptions
+	simple             bool
+	drain              drain
 	pgxWrapper
 }
 
@@ -69,6 +109,17 @@ var ErrNotificationTimeout = pgx.ErrNotificationTimeout
 // ErrDeadConn occurs on an attempt to use a dead connection
 var ErrDeadConn = pgx.ErrDeadConn
 
+// ErrAcquireTimeout occurs when an attempt to acquire a connection times out.
+var ErrAcquireTimeout = pgx.ErrAcquireTimeout
+
+// ErrTxClosed is the error pgx returns when a transaction is used after Commit or Rollback has been called. Txer
+// checks its state first and returns ErrTxDone instead
+var ErrTxClosed = pgx.ErrTxClosed
+
+// ErrTxDone occurs when a Txer is committed, rolled back or used after it has already been committed or rolled
+// back. It is the same error the onedb mocks return
+var ErrTxDone = onedb.ErrTxDone
+
 // ErrTLSRefused occurs when the connection attempt requires TLS and the
 // PostgreSQL server refuses to use TLS
 var ErrTLSRefused = pgx.ErrTLSRefused
@@ -84,77 +135,390 @@ var ErrInvalidLogLevel = pgx.ErrInvalidLogLevel
 type ProtocolError pgx.ProtocolError
 
 func (b *pgxWithReconnect) Begin() (Txer, error) {
-	t, err := b.db.Begin()
+	return b.BeginTx(TxOptions{})
+}
+
+// BeginTx starts a transaction with the isolation level, access mode and deferrable mode in opts
+func (b *pgxWithReconnect) BeginTx(opts TxOptions) (Txer, error) {
+	return b.beginTx(context.Background(), opts)
+}
+
+func (b *pgxWithReconnect) beginTx(ctx context.Context, opts TxOptions) (Txer, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	st := b.inst.instrument(ctx, opBegin, "begin", nil)
+	var t *pgx.Tx
+	err := b.retry(context.Background(), func() (err error) {
+		conn, err := b.acquire()
+		if err != nil {
+			return err
+		}
+		if t, err = conn.BeginIso(string(opts.IsoLevel)); err != nil {
+			b.release(conn)
+			return err
+		}
+		t.AfterClose(func(*pgx.Tx) { b.release(conn) })
+		return nil
+	})
+	if err == nil {
+		if modes := opts.modes(); modes != "" {
+			if _, err = t.Exec("set transaction " + modes); err != nil {
+				t.Rollback()
+			}
+		}
+	}
+	st.finish(err)
 	if err != nil {
 		return nil, err
 	}
-	return &pgxTx{tx: t}, err
+	return &pgxTx{tx: t, config: b.config, inst: b.inst, stmts: b.stmts, errs: b.errs, comments: b.comments}, nil
+}
+
+// BeginContext starts a transaction unless ctx is already done. Statements run through the Context
+// methods of the returned Txer can be canceled individually
+func (b *pgxWithReconnect) BeginContext(ctx context.Context) (Txer, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+	tx, err := b.beginTx(ctx, TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if err := canceled(ctx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
 }
 
 func (b *pgxWithReconnect) Close() {
 	b.db.Close()
 }
 
+// CopyFrom is retried on a dead connection only if no rows have been read from rows yet, since a
+// CopyFromSource can't be rewound
 func (b *pgxWithReconnect) CopyFrom(tableName Identifier, columnNames []string, rows CopyFromSource) (int, error) {
-	return b.db.CopyFrom(pgx.Identifier(tableName), columnNames, rows)
+	st := b.inst.instrument(context.Background(), opCopy, pgx.Identifier(tableName).Sanitize(), nil)
+	src := &copyFromTracker{CopyFromSource: rows}
+	var count int
+	err := b.retry(context.Background(), func() (err error) {
+		conn, err := b.acquire()
+		if err != nil {
+			return err
+		}
+		st.run(func() {
+			count, err = conn.CopyFrom(pgx.Identifier(tableName), columnNames, src)
+		})
+		b.release(conn)
+		if err != nil && src.started {
+			return &finalError{err}
+		}
+		return err
+	})
+	st.addRows(int64(count))
+	st.finish(err)
+	return count, err
+}
+
+// Prepare creates a prepared statement on every connection in the pool
+func (b *pgxWithReconnect) Prepare(name, sql string) (Stmt, error) {
+	if b.simple {
+		return nil, ErrSimpleProtocolPrepare
+	}
+	st := b.inst.instrument(context.Background(), opPrepare, sql, nil)
+	err := b.retry(context.Background(), func() error {
+		if b.drain.stopped() {
+			return ErrShutdown
+		}
+		_, err := b.db.Prepare(name, sql)
+		return err
+	})
+	st.finish(err)
+	if err != nil {
+		return nil, err
+	}
+	return &pgxStmt{name: name, sql: sql, q: b}, nil
 }
 
 func (b *pgxWithReconnect) QueryRow(query string, args ...interface{}) onedb.Scanner {
-	return b.db.QueryRow(query, args...)
+	return b.QueryRowContext(context.Background(), query, args...)
+}
+
+func (b *pgxWithReconnect) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	errs := b.errs.statement(query, args)
+	rows, st, err := b.queryContext(ctx, query, args...)
+	if err != nil {
+		return &errRow{errs.wrap(err)}
+	}
+	return &pgxRow{rows: rows, st: st, errs: errs}
 }
 
 func (b *pgxWithReconnect) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
-	rows, err := b.db.Query(query, args...)
-	if (err == pgx.ErrDeadConn || err != nil && strings.HasSuffix(err.Error(), "connection reset by peer")) && b.reconnect() {
-		return b.Query(query)
-	} else if err != nil {
-		return nil, err
+	return b.QueryContext(context.Background(), query, args...)
+}
+
+func (b *pgxWithReconnect) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	errs := b.errs.statement(query, args)
+	rows, st, err := b.queryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errs.wrap(err)
 	}
-	return &pgxRows{rows: rows}, rows.Err()
+	return &pgxRows{rows: rows, st: st, errs: errs}, errs.wrap(rows.Err())
+}
+
+// queryContext runs a query on its own connection, which is released once the rows are closed. The returned
+// statement counts rows and is finished when the rows are closed
+func (b *pgxWithReconnect) queryContext(ctx context.Context, query string, args ...interface{}) (*pgx.Rows, *statement, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, nil, err
+	}
+	args, err := encodeArgs(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	st := b.inst.instrument(ctx, opQuery, query, args)
+	sql, sqlArgs, err := b.simpleStatement(query, args)
+	if err != nil {
+		st.finish(err)
+		return nil, nil, err
+	}
+	var rows *pgx.Rows
+	err = b.retry(ctx, func() error {
+		conn, err := b.acquire()
+		if err != nil {
+			return err
+		}
+		stop := watchContext(ctx, b.config, conn)
+		if b.simple {
+			if _, err := conn.Exec("begin"); err != nil {
+				stop()
+				b.release(conn)
+				return err
+			}
+		}
+		st.run(func() {
+			rows, err = conn.Query(commented(ctx, b.comments, b.stmts.statement(conn, sql, sqlArgs), sql), sqlArgs...)
+		})
+		if err != nil {
+			stop()
+			b.stmts.invalidate(conn, sql, err)
+			if b.simple {
+				conn.Exec("rollback")
+			}
+			b.release(conn)
+			return err
+		}
+		st.setFields(rows.FieldDescriptions())
+		rows.AfterClose(func(r *pgx.Rows) {
+			stop()
+			if b.simple {
+				endQueryTx(conn, r)
+			}
+			b.release(conn)
+			st.finish(r.Err())
+		})
+		return nil
+	})
+	if err != nil {
+		err = contextErr(ctx, err)
+		st.finish(err)
+		return nil, nil, err
+	}
+	return rows, st, nil
 }
 
 func (b *pgxWithReconnect) Exec(query string, args ...interface{}) (CommandTag, error) {
-	tag, err := b.db.Exec(query, args...)
-	if (err == pgx.ErrDeadConn || err != nil && strings.HasSuffix(err.Error(), "connection reset by peer")) && b.reconnect() {
-		return b.Exec(query, args...)
+	return b.ExecContext(context.Background(), query, args...)
+}
+
+func (b *pgxWithReconnect) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	tag, err := b.execContext(ctx, query, args...)
+	return tag, b.errs.statement(query, args).wrap(err)
+}
+
+func (b *pgxWithReconnect) execContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	if err := canceled(ctx); err != nil {
+		return "", err
 	}
+	args, err := encodeArgs(args)
+	if err != nil {
+		return "", err
+	}
+	st := b.inst.instrument(ctx, opExec, query, args)
+	sql, sqlArgs, err := b.simpleStatement(query, args)
+	if err != nil {
+		st.finish(err)
+		return "", err
+	}
+	var tag pgx.CommandTag
+	err = b.retry(ctx, func() error {
+		conn, err := b.acquire()
+		if err != nil {
+			return err
+		}
+		stop := watchContext(ctx, b.config, conn)
+		st.run(func() {
+			tag, err = conn.Exec(commented(ctx, b.comments, b.stmts.statement(conn, sql, sqlArgs), sql), sqlArgs...)
+		})
+		stop()
+		b.stmts.invalidate(conn, sql, err)
+		b.release(conn)
+		return err
+	})
+	err = contextErr(ctx, err)
+	st.addRows(tag.RowsAffected())
+	st.finish(err)
 	return CommandTag(tag), err
 }
 
-func (b *pgxWithReconnect) ping() error {
-	var val int
-	if err := b.db.QueryRow("select 1 + 1").Scan(&val); err != nil {
-		return err
+// simpleStatement returns the statement to run for query and args: query with args written into it when
+// SimpleProtocol is set, or query and args as they are
+func (b *pgxWithReconnect) simpleStatement(query string, args []interface{}) (string, []interface{}, error) {
+	if !b.simple {
+		return query, args, nil
 	}
-	if val != 2 {
-		return errors.New("Failed ping test")
+	sql, err := interpolate(query, args)
+	return sql, nil, err
+}
+
+// endQueryTx ends the transaction a query was run in with SimpleProtocol, committing it unless the query failed.
+// A failed commit becomes the rows' error
+func endQueryTx(conn *pgx.Conn, rows *pgx.Rows) {
+	if rows.Err() != nil {
+		conn.Exec("rollback")
+	} else if _, err := conn.Exec("commit"); err != nil {
+		rows.Fatal(err)
 	}
-	return nil
 }
 
-func (b *pgxWithReconnect) reconnect() bool {
-	ms := time.Millisecond * time.Duration(math.Pow10(b.retryCount)) // retry every 10^lastRetry milliseconds
-	if time.Since(b.lastRetry) > ms {
-		b.lastRetry = time.Now()
-		err := b.ping()
-		if err == nil {
-			b.retryCount = 0
-			return true
-		} else if b.retryCount < 4 { // max retry time is 10 seconds
-			b.retryCount++
+func isDeadConn(err error) bool {
+	return err == pgx.ErrDeadConn || err != nil && strings.HasSuffix(err.Error(), "connection reset by peer")
+}
+
+// commented appends the SQL comment for ctx to statement when it's the query text rather than the name of a
+// prepared statement, whose text was sent when it was prepared
+func commented(ctx context.Context, comments *onedb.SQLCommentOptions, statement, query string) string {
+	if comments == nil || statement != query {
+		return statement
+	}
+	return comments.Comment(ctx, query)
+}
+
+// contextErr returns the context's error in place of err when the statement failed because ctx was done
+func contextErr(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return canceled(ctx)
+	}
+	return err
+}
+
+// watchContext sends a cancel request for the statement running on conn if ctx is done before the
+// returned stop function is called. stop waits for any cancel request in flight so that it can't
+// affect a later statement on the same connection
+func watchContext(ctx context.Context, config *pgx.ConnConfig, conn *pgx.Conn) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-ctx.Done():
+			cancelRequest(config, conn)
+		case <-done:
 		}
+	}()
+	return func() {
+		close(done)
+		<-finished
 	}
-	return false
+}
+
+// cancelRequest asks the server to cancel the statement currently running on conn. Cancellation
+// uses a separate connection as described in the PostgreSQL frontend/backend protocol
+func cancelRequest(config *pgx.ConnConfig, conn *pgx.Conn) error {
+	if config == nil {
+		return errors.New("unable to cancel request without a connection config")
+	}
+	port := config.Port
+	if port == 0 {
+		port = 5432
+	}
+	network := "tcp"
+	address := fmt.Sprintf("%s:%d", config.Host, port)
+	if _, err := os.Stat(config.Host); err == nil {
+		network = "unix"
+		address = config.Host
+		if !strings.Contains(address, "/.s.PGSQL.") {
+			address = filepath.Join(address, ".s.PGSQL.") + strconv.FormatInt(int64(port), 10)
+		}
+	}
+	dial := config.Dial
+	if dial == nil {
+		dial = (&net.Dialer{KeepAlive: 5 * time.Minute}).Dial
+	}
+	c, err := dial(network, address)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint32(buf[0:4], 16)
+	binary.BigEndian.PutUint32(buf[4:8], 80877102) // cancel request code
+	binary.BigEndian.PutUint32(buf[8:12], uint32(conn.Pid))
+	binary.BigEndian.PutUint32(buf[12:16], uint32(conn.SecretKey))
+	if _, err := c.Write(buf); err != nil {
+		return err
+	}
+	_, err = ioutil.ReadAll(c) // the server closes the connection once the request is processed
+	return err
+}
+
+// Ping verifies that a connection can be acquired from the pool and runs a trivial query
+func (b *pgxWithReconnect) Ping() error {
+	return b.PingContext(context.Background())
+}
+
+// PingContext is Ping, sending a cancel request for the query if ctx is done first
+func (b *pgxWithReconnect) PingContext(ctx context.Context) error {
+	if err := canceled(ctx); err != nil {
+		return err
+	}
+	conn, err := b.acquire()
+	if err != nil {
+		return err
+	}
+	stop := watchContext(ctx, b.config, conn)
+	var val int
+	err = conn.QueryRow("select 1 + 1").Scan(&val)
+	stop()
+	b.release(conn)
+	if err != nil {
+		return contextErr(ctx, err)
+	}
+	if val != 2 {
+		return errors.New("Failed ping test")
+	}
+	return nil
 }
 
 type pgxRows struct {
-	rows pgxRower
+	rows       pgxRower
+	st         *statement
+	afterClose []func(Rower)
+	closed     bool
+	errs       *queryErrors
 	Rower
 }
 
-// AfterClose adds f to a LILO queue of functions that will be called when
+// AfterClose adds f to a LIFO queue of functions that will be called when
 // rows is closed.
-func (r *pgxRows) AfterClose(f func(Rower)) {}
+func (r *pgxRows) AfterClose(f func(Rower)) {
+	r.afterClose = append(r.afterClose, f)
+}
 
 func (r *pgxRows) Columns() ([]string, error) {
 	fields := r.rows.FieldDescriptions()
@@ -169,13 +533,27 @@ func (r *pgxRows) Columns() ([]string, error) {
 // row and false if no more rows are available. It automatically closes rows
 // when all rows are read.
 func (r *pgxRows) Next() bool {
-	return r.rows.Next()
+	if r.rows.Next() {
+		r.st.addRows(1)
+		return true
+	}
+	r.Close()
+	return false
 }
 
-// Close closes the rows, making the connection ready for use again. It is safe
+// Close closes the rows, making the connection ready for use again, and then
+// calls the functions added with AfterClose, the last added first. It is safe
 // to call Close after rows is already closed.
 func (r *pgxRows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
 	r.rows.Close()
+	for i := len(r.afterClose) - 1; i >= 0; i-- {
+		r.afterClose[i](r)
+	}
+	r.afterClose = nil
 	return nil
 }
 
@@ -188,10 +566,14 @@ func (r *pgxRows) Conn() *pgx.Conn {
 // closes the rows automatically.
 func (r *pgxRows) Fatal(err error) {
 	r.rows.Fatal(err)
+	r.Close()
 }
 
 func (r *pgxRows) FieldDescriptions() []FieldDescription {
-	descriptions := r.rows.FieldDescriptions()
+	return toFieldDescriptions(r.rows.FieldDescriptions())
+}
+
+func toFieldDescriptions(descriptions []pgx.FieldDescription) []FieldDescription {
 	result := make([]FieldDescription, len(descriptions))
 	for i := 0; i < len(descriptions); i++ {
 		d := descriptions[i]
@@ -211,22 +593,165 @@ func (r *pgxRows) FieldDescriptions() []FieldDescription {
 
 // Scan works the same as (*Rows Scan) with the following exceptions. If no
 // rows were found it returns ErrNoRows. If multiple rows are returned it
-// ignores all but the first.
+// ignores all but the first. Destinations can be *interface{}, sql.Scanners,
+// or pointers to any type onedb.ScanValue converts the value to, like *int,
+// *string or *time.Time. In addition json and jsonb columns can be scanned
+// into structs, maps and slices, arrays into slices of any element type
+// onedb.ScanArray converts to, hstore columns into maps of strings, uuid
+// columns into 16 byte arrays like uuid.UUID or strings, and numeric columns
+// into decimal.Decimal or big.Rat.
 func (r *pgxRows) Scan(dest ...interface{}) error {
-	vals, err := r.rows.Values()
+	return r.errs.wrap(r.scan(dest))
+}
+
+func (r *pgxRows) scan(dest []interface{}) error {
+	vals, err := r.values()
 	if err != nil {
 		return err
 	}
+	if len(dest) != len(vals) {
+		return errors.Errorf("Expected %d destination arguments in Scan, not %d", len(vals), len(dest))
+	}
+	fields := r.rows.FieldDescriptions()
 	for i, item := range dest {
-		*(item.(*interface{})) = vals[i]
+		if v, ok := item.(*interface{}); ok && v != nil {
+			*v = vals[i]
+			continue
+		}
+		field := pgx.FieldDescription{Name: strconv.Itoa(i)}
+		if i < len(fields) {
+			field = fields[i]
+		}
+		if err := scanTyped(field, vals[i], item); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// scanTyped stores a value in a destination other than *interface{}. sql.Scanners are passed the value, and
+// the types of json, jsonb, hstore, uuid, numeric and array columns are converted to suit the destination
+func scanTyped(field pgx.FieldDescription, value interface{}, dest interface{}) error {
+	var err error
+	switch _, isScanner := dest.(sql.Scanner); {
+	case isScanner && !isJSONType(field.DataType) && !isArrayType(field):
+		err = onedb.ScanValue(value, dest)
+	case isJSONType(field.DataType):
+		err = scanJSON(value, dest)
+	case isHstoreType(field):
+		err = onedb.ScanHstore(value, dest)
+	case field.DataTypeName == "uuid":
+		err = onedb.ScanUUID(value, dest)
+	case field.DataTypeName == "numeric":
+		err = onedb.ScanNumeric(value, dest)
+	case isArrayType(field):
+		err = onedb.ScanArray(value, dest)
+	default:
+		err = onedb.ScanValue(value, dest)
+	}
+	return errors.Wrapf(err, "Unable to scan %s column %s", field.DataTypeName, field.Name)
+}
+
+func isJSONType(oid pgx.Oid) bool {
+	return oid == pgx.JsonOid || oid == pgx.JsonbOid
+}
+
+// scanJSON stores a json or jsonb value, which pgx has already decoded into maps and slices, in a struct, map
+// or other destination by encoding it again and unmarshaling it into dest
+func scanJSON(value interface{}, dest interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// Values returns the values of the row, decoding those of registered types with their codecs, arrays pgx
+// returns as text into slices, hstore columns into onedb.Hstore maps, uuid columns into uuid.UUIDs and numeric
+// columns into decimal.Decimals
 func (r *pgxRows) Values() ([]interface{}, error) {
-	return r.rows.Values()
+	vals, err := r.values()
+	return vals, r.errs.wrap(err)
+}
+
+func (r *pgxRows) values() ([]interface{}, error) {
+	vals, err := r.rows.Values()
+	if err != nil {
+		return nil, err
+	}
+	fields := r.rows.FieldDescriptions()
+	for _, decode := range []func([]pgx.FieldDescription, []interface{}) error{decodeValues, decodeArrays, decodeHstores, decodeUUIDs, decodeNumerics} {
+		if err := decode(fields, vals); err != nil {
+			r.Fatal(err)
+			return nil, err
+		}
+	}
+	return vals, nil
 }
 
 func (r *pgxRows) Err() error {
+	return r.errs.wrap(r.rows.Err())
+}
+
+// pgxStmt runs a prepared statement through its querier. pgx looks up prepared statements by name
+// when the name is passed in place of the sql
+type pgxStmt struct {
+	name string
+	sql  string
+	q    querier
+}
+
+func (s *pgxStmt) Name() string {
+	return s.name
+}
+
+func (s *pgxStmt) SQL() string {
+	return s.sql
+}
+
+func (s *pgxStmt) Exec(args ...interface{}) (CommandTag, error) {
+	return s.q.Exec(s.name, args...)
+}
+
+func (s *pgxStmt) Query(args ...interface{}) (onedb.RowsScanner, error) {
+	return s.q.Query(s.name, args...)
+}
+
+func (s *pgxStmt) QueryRow(args ...interface{}) onedb.Scanner {
+	return s.q.QueryRow(s.name, args...)
+}
+
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
+
+// pgxRow is a *pgx.Row which counts the row it reads. Scan matches (*pgx.Row).Scan
+type pgxRow struct {
+	rows *pgx.Rows
+	st   *statement
+	errs *queryErrors
+}
+
+func (r *pgxRow) Scan(dest ...interface{}) error {
+	return r.errs.wrap(r.scan(dest))
+}
+
+func (r *pgxRow) scan(dest []interface{}) error {
+	if r.rows.Err() != nil {
+		return r.rows.Err()
+	}
+	if !r.rows.Next() {
+		if r.rows.Err() == nil {
+			return ErrNoRows
+		}
+		return r.rows.Err()
+	}
+	r.st.addRows(1)
+	r.rows.Scan(codecDest(r.rows.FieldDescriptions(), dest)...)
+	r.rows.Close()
 	return r.rows.Err()
 }
@@ -0,0 +1,221 @@
+package pgx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// connTimes enforces the maximum lifetime and idle time of pooled connections. pgx.v2 doesn't support
+// either, so connections which have expired are closed as they are acquired or released, which makes the
+// pool discard them. A nil *connTimes enforces nothing
+type connTimes struct {
+	maxLifetime time.Duration
+	maxIdleTime time.Duration
+
+	mu       sync.Mutex
+	created  map[*pgx.Conn]time.Time
+	lastUsed map[*pgx.Conn]time.Time
+}
+
+func newConnTimes(config PoolConfig) *connTimes {
+	if config.MaxConnLifetime <= 0 && config.MaxConnIdleTime <= 0 {
+		return nil
+	}
+	return &connTimes{
+		maxLifetime: config.MaxConnLifetime,
+		maxIdleTime: config.MaxConnIdleTime,
+		created:     make(map[*pgx.Conn]time.Time),
+		lastUsed:    make(map[*pgx.Conn]time.Time),
+	}
+}
+
+// connected records when conn was created. It is called by the pool for every new connection
+func (c *connTimes) connected(conn *pgx.Conn) error {
+	if c != nil {
+		c.mu.Lock()
+		c.created[conn] = time.Now()
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+// sessionTimeouts returns the SET statements for the server-side timeouts in config. Durations are rounded up
+// to whole milliseconds since a timeout of 0 disables it
+func sessionTimeouts(config PoolConfig) []string {
+	var statements []string
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"statement_timeout", config.StatementTimeout},
+		{"lock_timeout", config.LockTimeout},
+		{"idle_in_transaction_session_timeout", config.IdleInTxSessionTimeout},
+	} {
+		if timeout.value > 0 {
+			ms := (timeout.value + time.Millisecond - 1) / time.Millisecond
+			statements = append(statements, "SET "+timeout.name+" = "+strconv.FormatInt(int64(ms), 10))
+		}
+	}
+	return statements
+}
+
+// afterConnect records each new connection, looks up the registered types it doesn't know, runs the session
+// setup statements and then runs the user's OnConnect hook, if there is one
+func afterConnect(times *connTimes, setup []string, onConnect func(conn *pgx.Conn) error) func(conn *pgx.Conn) error {
+	return func(conn *pgx.Conn) error {
+		times.connected(conn)
+		if err := loadTypes(conn); err != nil {
+			return err
+		}
+		for _, statement := range setup {
+			if _, err := conn.Exec(statement); err != nil {
+				return errors.Wrapf(err, "Unable to %s", statement)
+			}
+		}
+		if onConnect == nil {
+			return nil
+		}
+		return onConnect(conn)
+	}
+}
+
+// expired reports whether conn has been open longer than the maximum lifetime or, when acquiring, has been
+// idle longer than the maximum idle time. Expired connections are forgotten
+func (c *connTimes) expired(conn *pgx.Conn, acquiring bool) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	created, ok := c.created[conn]
+	if !ok {
+		created = now
+		c.created[conn] = now
+	}
+	lastUsed, used := c.lastUsed[conn]
+	expired := c.maxLifetime > 0 && now.Sub(created) > c.maxLifetime ||
+		acquiring && used && c.maxIdleTime > 0 && now.Sub(lastUsed) > c.maxIdleTime
+	if expired {
+		delete(c.created, conn)
+		delete(c.lastUsed, conn)
+	} else if !acquiring {
+		c.lastUsed[conn] = now
+	}
+	return expired
+}
+
+// forget stops tracking a connection the pool is about to discard
+func (c *connTimes) forget(conn *pgx.Conn) {
+	if c != nil {
+		c.mu.Lock()
+		delete(c.created, conn)
+		delete(c.lastUsed, conn)
+		c.mu.Unlock()
+	}
+}
+
+// acquire gets a connection from the pool, counting the acquire as a wait when the pool is exhausted and
+// replacing connections which have expired
+func (b *pgxWithReconnect) acquire() (*pgx.Conn, error) {
+	for {
+		if b.drain.stopped() {
+			return nil, ErrShutdown
+		}
+		conn, err := b.acquireConn()
+		if err != nil {
+			return nil, err
+		}
+		if !b.times.expired(conn, true) {
+			if err := b.drain.acquired(conn); err != nil {
+				b.db.Release(conn)
+				return nil, err
+			}
+			return conn, nil
+		}
+		b.stmts.forget(conn)
+		conn.Close()
+		b.db.Release(conn)
+	}
+}
+
+// WarmUpError reports the connections WarmUp couldn't open
+type WarmUpError struct {
+	Requested int
+	Errors    []error // one for each connection which failed
+}
+
+func (e *WarmUpError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("unable to open %d of %d connections: %s", len(e.Errors), e.Requested, strings.Join(messages, "; "))
+}
+
+// WarmUp opens n connections at once, up to the pool's maximum, so the first burst of statements doesn't wait for
+// connecting, TLS and authentication. Connections which are already open count towards n. Every connection is
+// returned to the pool before WarmUp returns. The failures, such as a wrong password or an unreachable host,
+// are returned in a *WarmUpError so misconfiguration is caught at startup
+func (b *pgxWithReconnect) WarmUp(n int) error {
+	if n <= 0 {
+		return nil
+	}
+	if max := b.db.Stat().MaxConnections; n > max {
+		n = max
+	}
+	conns := make([]*pgx.Conn, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range conns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conns[i], errs[i] = b.acquire()
+		}(i)
+	}
+	wg.Wait()
+
+	warmUpErr := &WarmUpError{Requested: n}
+	for i, conn := range conns {
+		if errs[i] != nil {
+			warmUpErr.Errors = append(warmUpErr.Errors, errs[i])
+		} else {
+			b.release(conn)
+		}
+	}
+	if len(warmUpErr.Errors) > 0 {
+		return warmUpErr
+	}
+	return nil
+}
+
+func (b *pgxWithReconnect) acquireConn() (*pgx.Conn, error) {
+	stat := b.db.Stat()
+	if stat.AvailableConnections > 0 || stat.CurrentConnections < stat.MaxConnections {
+		return b.db.Acquire()
+	}
+	start := time.Now()
+	conn, err := b.db.Acquire()
+	b.counters.wait(time.Since(start))
+	return conn, err
+}
+
+// release returns a connection to the pool, closing it first if it has reached its maximum lifetime
+func (b *pgxWithReconnect) release(conn *pgx.Conn) {
+	if !conn.IsAlive() {
+		b.times.forget(conn)
+		b.stmts.forget(conn)
+	} else if b.times.expired(conn, false) {
+		b.stmts.forget(conn)
+		conn.Close()
+	}
+	b.db.Release(conn)
+	b.drain.released(conn)
+}
@@ -0,0 +1,108 @@
+package pgx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestNewConnTimes(t *testing.T) {
+	if newConnTimes(PoolConfig{}) != nil {
+		t.Error("expected no tracking without limits")
+	}
+	var c *connTimes
+	if c.connected(&pgx.Conn{}) != nil || c.expired(&pgx.Conn{}, true) {
+		t.Error("expected nil connTimes to enforce nothing")
+	}
+	c.forget(&pgx.Conn{})
+}
+
+func TestConnTimesLifetime(t *testing.T) {
+	c := newConnTimes(PoolConfig{MaxConnLifetime: time.Hour})
+	conn := &pgx.Conn{}
+	c.connected(conn)
+	if c.expired(conn, true) || c.expired(conn, false) {
+		t.Error("expected new connection not to be expired")
+	}
+	c.created[conn] = time.Now().Add(-2 * time.Hour)
+	if !c.expired(conn, false) {
+		t.Error("expected old connection to be expired")
+	}
+	if _, ok := c.created[conn]; ok {
+		t.Error("expected expired connection to be forgotten")
+	}
+}
+
+func TestConnTimesIdleTime(t *testing.T) {
+	c := newConnTimes(PoolConfig{MaxConnIdleTime: time.Minute})
+	conn := &pgx.Conn{}
+	c.connected(conn)
+	if c.expired(conn, true) {
+		t.Error("expected unused connection not to be idle")
+	}
+	c.expired(conn, false) // released
+	c.lastUsed[conn] = time.Now().Add(-time.Hour)
+	if c.expired(conn, false) {
+		t.Error("expected idle time to only be checked on acquire")
+	}
+	c.lastUsed[conn] = time.Now().Add(-time.Hour)
+	if !c.expired(conn, true) {
+		t.Error("expected idle connection to be expired")
+	}
+
+	c.connected(conn)
+	c.forget(conn)
+	if len(c.created) != 0 || len(c.lastUsed) != 0 {
+		t.Error("expected connection to be forgotten")
+	}
+}
+
+func TestAfterConnect(t *testing.T) {
+	c := newConnTimes(PoolConfig{MaxConnLifetime: time.Hour})
+	conn := &pgx.Conn{}
+	fail := errors.New("fail")
+	var hooked *pgx.Conn
+	err := afterConnect(c, nil, func(conn *pgx.Conn) error {
+		hooked = conn
+		return fail
+	})(conn)
+	if err != fail || hooked != conn {
+		t.Error("expected the hook to run with the new connection and its error returned", err)
+	}
+	if _, ok := c.created[conn]; !ok {
+		t.Error("expected the connection to be recorded before the hook runs")
+	}
+	if afterConnect(nil, nil, nil)(conn) != nil {
+		t.Error("expected success without a hook")
+	}
+}
+
+func TestWarmUp(t *testing.T) {
+	if err := (&pgxWithReconnect{}).WarmUp(0); err != nil {
+		t.Error("expected nothing to do", err)
+	}
+	c := newMockPgx(nil, nil)
+	(&pgxBackend{db: c}).WarmUp(5)
+	if calls := c.MethodsCalled["WarmUp"]; len(calls) != 1 || calls[0][0] != 5 {
+		t.Error("expected WarmUp method to be called on backend", calls)
+	}
+
+	err := &WarmUpError{Requested: 3, Errors: []error{errors.New("password authentication failed"), errors.New("timeout")}}
+	if err.Error() != "unable to open 2 of 3 connections: password authentication failed; timeout" {
+		t.Error("expected each failure in message", err)
+	}
+}
+
+func TestSessionTimeouts(t *testing.T) {
+	if statements := sessionTimeouts(PoolConfig{}); len(statements) != 0 {
+		t.Error("expected no statements by default", statements)
+	}
+	statements := sessionTimeouts(PoolConfig{StatementTimeout: 5 * time.Second, LockTimeout: 1500 * time.Microsecond, IdleInTxSessionTimeout: time.Minute})
+	expected := []string{"SET statement_timeout = 5000", "SET lock_timeout = 2", "SET idle_in_transaction_session_timeout = 60000"}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Error("expected timeouts in milliseconds", statements)
+	}
+}
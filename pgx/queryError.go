@@ -0,0 +1,44 @@
+package pgx
+
+import "github.com/EndFirstCorp/onedb"
+
+// queryErrors wraps errors in an onedb.QueryError describing the statement they occurred in when
+// PoolConfig.QueryErrors is set. A nil *queryErrors leaves errors unchanged
+type queryErrors struct {
+	options onedb.QueryErrorOptions
+	query   string
+	args    []interface{}
+}
+
+// newQueryErrors returns the queryErrors for options. Included arguments are sanitized as in the QueryLogs, with
+// redact, before options.Redact is applied
+func newQueryErrors(options *onedb.QueryErrorOptions, redact ArgRedacter) *queryErrors {
+	if options == nil {
+		return nil
+	}
+	q := &queryErrors{options: *options}
+	then := options.Redact
+	q.options.Redact = func(query string, args []interface{}) []interface{} {
+		sanitized := sanitizeArgs(query, args, redact)
+		if then != nil {
+			return then(query, sanitized)
+		}
+		return sanitized
+	}
+	return q
+}
+
+// statement returns the queryErrors for a statement run with query and args
+func (q *queryErrors) statement(query string, args []interface{}) *queryErrors {
+	if q == nil {
+		return nil
+	}
+	return &queryErrors{options: q.options, query: query, args: args}
+}
+
+func (q *queryErrors) wrap(err error) error {
+	if q == nil {
+		return err
+	}
+	return onedb.WrapQueryError(err, "pgx", q.options, q.query, q.args...)
+}
@@ -0,0 +1,72 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+func TestQueryErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := &pgxWithReconnect{}
+	if _, err := b.ExecContext(ctx, "delete from users"); err != context.Canceled {
+		t.Error("expected errors unchanged by default", err)
+	}
+
+	b.errs = newQueryErrors(&onedb.QueryErrorOptions{}, nil)
+	_, err := b.ExecContext(ctx, "delete from users where id = $1", 1)
+	var queryErr *onedb.QueryError
+	if !errors.As(err, &queryErr) || queryErr.Query != "delete from users where id = $1" || queryErr.ArgCount != 1 || queryErr.Args != nil || !errors.Is(err, context.Canceled) {
+		t.Error("expected Exec error described by its statement", err)
+	}
+	if _, err := b.QueryContext(ctx, "select 1"); !errors.As(err, &queryErr) || queryErr.Backend != "pgx" {
+		t.Error("expected Query error described by its statement", err)
+	}
+	if err := b.QueryRowContext(ctx, "select 1").Scan(); !errors.As(err, &queryErr) {
+		t.Error("expected QueryRow error described by its statement", err)
+	}
+	tx := &pgxTx{errs: b.errs}
+	if _, err := tx.ExecContext(ctx, "delete from users"); !errors.As(err, &queryErr) {
+		t.Error("expected transaction errors described by their statement", err)
+	}
+
+	m := newMockPgxRows()
+	m.ValuesErr = errors.New("fail")
+	r := &pgxRows{rows: m, errs: b.errs.statement("select name from users", nil)}
+	if err := r.Scan(); !errors.As(err, &queryErr) || queryErr.Query != "select name from users" || !errors.Is(err, m.ValuesErr) {
+		t.Error("expected Scan error described by its statement", err)
+	}
+
+	m.ValuesErr = ErrNoRows
+	if err := r.Scan(); err != ErrNoRows {
+		t.Error("expected ErrNoRows unwrapped so it can be compared", err)
+	}
+	if err := b.errs.statement("select 1", nil).wrap(ErrTxDone); err != ErrTxDone {
+		t.Error("expected ErrTxDone unwrapped", err)
+	}
+}
+
+func TestQueryErrorsRedactArgs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	redactEmail := func(query string, index int, arg interface{}) bool { return index == 1 }
+	b := &pgxWithReconnect{errs: newQueryErrors(&onedb.QueryErrorOptions{IncludeArgs: true}, redactEmail)}
+	_, err := b.ExecContext(ctx, "insert into users values ($1, $2, $3)", "bob", "bob@example.com", Secret("hunter2"))
+	var queryErr *onedb.QueryError
+	if !errors.As(err, &queryErr) || !reflect.DeepEqual(queryErr.Args, []interface{}{"bob", "<redacted>", "<redacted>"}) || strings.Contains(err.Error(), "hunter2") {
+		t.Error("expected Secret and RedactArgs arguments redacted", err)
+	}
+
+	b.errs = newQueryErrors(&onedb.QueryErrorOptions{IncludeArgs: true, Redact: func(query string, args []interface{}) []interface{} {
+		return append(args, "checked")
+	}}, nil)
+	_, err = b.ExecContext(ctx, "insert into users values ($1)", Secret("hunter2"))
+	if !errors.As(err, &queryErr) || !reflect.DeepEqual(queryErr.Args, []interface{}{"<redacted>", "checked"}) {
+		t.Error("expected Redact applied to sanitized arguments", err)
+	}
+}
@@ -0,0 +1,126 @@
+package pgx
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+)
+
+// QueryLog describes a finished statement
+type QueryLog struct {
+	Operation string        // query, exec, copy, begin or prepare
+	Query     string        // the SQL, or the table name for copy
+	Args      []interface{} // sanitized with SanitizeArgs and PoolConfig.RedactArgs
+	ArgCount  int
+	Duration  time.Duration
+	Rows      int64 // rows returned or affected
+	Err       error
+
+	// FieldDescriptions are the columns returned by a query. They are only included when logging slow queries
+	FieldDescriptions []FieldDescription
+}
+
+// QueryLogger receives a QueryLog for every statement run by the backend and its transactions. ctx is the
+// context passed to the statement, or context.Background() for methods without one
+type QueryLogger interface {
+	LogQuery(ctx context.Context, entry QueryLog)
+}
+
+// QueryLoggerFunc is an adapter to allow the use of an ordinary function as a QueryLogger
+type QueryLoggerFunc func(ctx context.Context, entry QueryLog)
+
+// LogQuery calls f(ctx, entry)
+func (f QueryLoggerFunc) LogQuery(ctx context.Context, entry QueryLog) {
+	f(ctx, entry)
+}
+
+// maxLoggedArgLength is the longest string argument logged before it is truncated
+const maxLoggedArgLength = 64
+
+// redactedArg is logged in place of a redacted argument
+const redactedArg = "<redacted>"
+
+// Secret is a string argument, such as a password or an API token, which SanitizeArgs always redacts. It is sent
+// to the server as the string
+type Secret string
+
+// Value returns the string sent to the server
+func (s Secret) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// ArgRedacter reports whether the argument at index, starting at 0, of query must be redacted from logs, for
+// secrets which aren't passed as a Secret. It may decide by the statement and position, such as the second
+// argument of an insert into users, or by the argument's type
+type ArgRedacter func(query string, index int, arg interface{}) bool
+
+// SanitizeArgs returns a copy of args which is safe to log. Secrets are replaced by "<redacted>", byte slices by
+// their length and long strings are truncated
+func SanitizeArgs(args []interface{}) []interface{} {
+	return sanitizeArgs("", args, nil)
+}
+
+// sanitizeArgs is SanitizeArgs which also redacts the arguments of query redact reports true for
+func sanitizeArgs(query string, args []interface{}, redact ArgRedacter) []interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+	sanitized := make([]interface{}, len(args))
+	for i, arg := range args {
+		if redact != nil && redact(query, i, arg) {
+			sanitized[i] = redactedArg
+			continue
+		}
+		switch v := arg.(type) {
+		case Secret:
+			sanitized[i] = redactedArg
+		case []byte:
+			sanitized[i] = fmt.Sprintf("<%d bytes>", len(v))
+		case string:
+			if max := maxLoggedArgLength; len(v) > max {
+				for max > 0 && !utf8.RuneStart(v[max]) {
+					max--
+				}
+				v = v[:max] + "..."
+			}
+			sanitized[i] = v
+		default:
+			sanitized[i] = arg
+		}
+	}
+	return sanitized
+}
+
+// NewSlogLogger returns a QueryLogger which writes each statement to logger at level, or at slog.LevelError
+// if the statement failed
+func NewSlogLogger(logger *slog.Logger, level slog.Level) QueryLogger {
+	return QueryLoggerFunc(func(ctx context.Context, entry QueryLog) {
+		attrs := []slog.Attr{
+			slog.String("operation", entry.Operation),
+			slog.String("sql", entry.Query),
+			slog.Any("args", entry.Args),
+			slog.Duration("duration", entry.Duration),
+			slog.Int64("rows", entry.Rows),
+		}
+		if entry.FieldDescriptions != nil {
+			attrs = append(attrs, slog.Int("arg_count", entry.ArgCount), slog.Any("fields", fieldNames(entry.FieldDescriptions)))
+		}
+		if entry.Err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "query failed", append(attrs, slog.Any("error", entry.Err))...)
+			return
+		}
+		logger.LogAttrs(ctx, level, "query", attrs...)
+	})
+}
+
+// fieldNames describes each column as name:type for logging
+func fieldNames(fields []FieldDescription) []string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name + ":" + field.DataTypeName
+	}
+	return names
+}
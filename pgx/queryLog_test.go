@@ -0,0 +1,112 @@
+package pgx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestSanitizeArgs(t *testing.T) {
+	if SanitizeArgs(nil) != nil {
+		t.Error("expected nil args")
+	}
+	long := strings.Repeat("a", 100)
+	args := []interface{}{1, "short", long, []byte("secret")}
+	sanitized := SanitizeArgs(args)
+	if sanitized[0] != 1 || sanitized[1] != "short" || sanitized[2] != long[:64]+"..." || sanitized[3] != "<6 bytes>" {
+		t.Error("expected sanitized args", sanitized)
+	}
+	if args[2] != long {
+		t.Error("expected original args to be unchanged")
+	}
+	if sanitized := SanitizeArgs([]interface{}{"bob", Secret("hunter2")}); sanitized[0] != "bob" || sanitized[1] != "<redacted>" {
+		t.Error("expected secrets to be redacted", sanitized)
+	}
+	accented := "a" + strings.Repeat("é", 40)
+	if sanitized := SanitizeArgs([]interface{}{accented}); sanitized[0] != accented[:63]+"..." || !utf8.ValidString(sanitized[0].(string)) {
+		t.Error("expected strings truncated on a rune boundary", sanitized)
+	}
+	if value, err := Secret("hunter2").Value(); value != "hunter2" || err != nil {
+		t.Error("expected the secret to be sent as is", value, err)
+	}
+}
+
+func TestInstrumentationQueryLogger(t *testing.T) {
+	var entries []QueryLog
+	i := newInstrumentation(nil, PoolConfig{QueryLogger: QueryLoggerFunc(func(ctx context.Context, entry QueryLog) {
+		entries = append(entries, entry)
+	})})
+	st := i.instrument(context.Background(), opExec, "update users set name = $1", []interface{}{[]byte("bob")})
+	st.addRows(2)
+	st.finish(nil)
+	if len(entries) != 1 {
+		t.Fatal("expected statement to be logged")
+	}
+	entry := entries[0]
+	if entry.Operation != opExec || entry.Query != "update users set name = $1" || entry.Args[0] != "<3 bytes>" || entry.Rows != 2 || entry.Err != nil {
+		t.Error("expected log entry", entry)
+	}
+
+	entries = nil
+	i = newInstrumentation(nil, PoolConfig{
+		QueryLogger: QueryLoggerFunc(func(ctx context.Context, entry QueryLog) { entries = append(entries, entry) }),
+		RedactArgs: func(query string, index int, arg interface{}) bool {
+			return strings.HasPrefix(query, "insert into users") && index == 1
+		},
+	})
+	i.instrument(context.Background(), opExec, "insert into users (name, password) values ($1, $2)", []interface{}{"bob", "hunter2"}).finish(nil)
+	i.instrument(context.Background(), opExec, "insert into groups (name, owner) values ($1, $2)", []interface{}{"admins", "bob"}).finish(nil)
+	if len(entries) != 2 || entries[0].Args[0] != "bob" || entries[0].Args[1] != "<redacted>" || entries[1].Args[1] != "bob" {
+		t.Error("expected arguments redacted by RedactArgs", entries)
+	}
+}
+
+func TestNewSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), slog.LevelDebug)
+	logger.LogQuery(context.Background(), QueryLog{Operation: opQuery, Query: "select 1", Duration: time.Millisecond, Rows: 1})
+	logger.LogQuery(context.Background(), QueryLog{Operation: opExec, Query: "delete", Err: errors.New("fail")})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal("expected 2 log lines", buf.String())
+	}
+	if !strings.Contains(lines[0], "level=DEBUG") || !strings.Contains(lines[0], `sql="select 1"`) || !strings.Contains(lines[0], "rows=1") {
+		t.Error("expected query log", lines[0])
+	}
+	if !strings.Contains(lines[1], "level=ERROR") || !strings.Contains(lines[1], "error=fail") {
+		t.Error("expected error log", lines[1])
+	}
+}
+
+func TestInstrumentationSlowQueries(t *testing.T) {
+	var entries []QueryLog
+	i := newInstrumentation(nil, PoolConfig{SlowQueryThreshold: time.Hour, QueryLogger: QueryLoggerFunc(func(ctx context.Context, entry QueryLog) {
+		entries = append(entries, entry)
+	})})
+	i.instrument(context.Background(), opQuery, "select 1", nil).finish(nil)
+	if len(entries) != 0 {
+		t.Error("expected fast query not to be logged", entries)
+	}
+
+	st := i.instrument(context.Background(), opQuery, "select id, name from users where id = $1", []interface{}{1})
+	st.setFields([]pgx.FieldDescription{{Name: "id", DataTypeName: "int4"}, {Name: "name", DataTypeName: "text"}})
+	st.start = st.start.Add(-2 * time.Hour)
+	st.finish(nil)
+	if len(entries) != 1 {
+		t.Fatal("expected slow query to be logged")
+	}
+	entry := entries[0]
+	if entry.ArgCount != 1 || len(entry.FieldDescriptions) != 2 || entry.FieldDescriptions[1].Name != "name" || entry.Duration < time.Hour {
+		t.Error("expected slow query details", entry)
+	}
+	if names := fieldNames(entry.FieldDescriptions); names[0] != "id:int4" || names[1] != "name:text" {
+		t.Error("expected field names", names)
+	}
+}
@@ -0,0 +1,135 @@
+package pgx
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+// QueryStat is the totals for the statements with the same operation and fingerprint
+type QueryStat struct {
+	Operation   string // query, exec, copy, begin or prepare
+	Fingerprint string // the statement's onedb.QueryFingerprint, or "other" once the limit is reached
+	Calls       int64
+	Errors      int64
+	TotalTime   time.Duration
+	Rows        int64 // rows returned or affected
+}
+
+// QueryStats counts calls, errors, time and rows per statement fingerprint, for a quick look at which statements
+// load the database without a metrics stack. Set it as PoolConfig.QueryStats, then publish it with
+// expvar.Publish, since String returns the stats as JSON, or serve it on a debug endpoint as an http.Handler.
+// It is safe for concurrent use
+type QueryStats struct {
+	mu    sync.Mutex
+	max   int
+	stats map[queryStatKey]*QueryStat
+}
+
+type queryStatKey struct {
+	operation, fingerprint string
+}
+
+// otherFingerprint collects the statements whose fingerprints are past the limit
+const otherFingerprint = "other"
+
+// NewQueryStats returns QueryStats keeping up to max fingerprints, 1000 when max is 0. Statements with new
+// fingerprints after that are counted together as "other" for their operation, so statements with literals the
+// fingerprint doesn't remove, like IN lists of varying length, can't grow it without limit
+func NewQueryStats(max int) *QueryStats {
+	if max <= 0 {
+		max = 1000
+	}
+	return &QueryStats{max: max, stats: make(map[queryStatKey]*QueryStat)}
+}
+
+// observe adds a finished statement to the totals
+func (s *QueryStats) observe(operation, query string, duration time.Duration, rows int64, err error) {
+	key := queryStatKey{operation, onedb.QueryFingerprint(query)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stat, ok := s.stats[key]
+	if !ok {
+		if len(s.stats) >= s.max {
+			key.fingerprint = otherFingerprint
+		}
+		if stat, ok = s.stats[key]; !ok {
+			stat = &QueryStat{Operation: key.operation, Fingerprint: key.fingerprint}
+			s.stats[key] = stat
+		}
+	}
+	stat.Calls++
+	if err != nil {
+		stat.Errors++
+	}
+	stat.TotalTime += duration
+	stat.Rows += rows
+}
+
+// Snapshot returns a copy of the totals, the most total time first
+func (s *QueryStats) Snapshot() []QueryStat {
+	s.mu.Lock()
+	stats := make([]QueryStat, 0, len(s.stats))
+	for _, stat := range s.stats {
+		stats = append(stats, *stat)
+	}
+	s.mu.Unlock()
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalTime != stats[j].TotalTime {
+			return stats[i].TotalTime > stats[j].TotalTime
+		}
+		return stats[i].Fingerprint < stats[j].Fingerprint
+	})
+	return stats
+}
+
+// Reset clears the totals
+func (s *QueryStats) Reset() {
+	s.mu.Lock()
+	s.stats = make(map[queryStatKey]*QueryStat)
+	s.mu.Unlock()
+}
+
+type queryStatJSON struct {
+	Operation   string  `json:"operation"`
+	Fingerprint string  `json:"fingerprint"`
+	Calls       int64   `json:"calls"`
+	Errors      int64   `json:"errors"`
+	TotalMs     float64 `json:"total_ms"`
+	MeanMs      float64 `json:"mean_ms"`
+	Rows        int64   `json:"rows"`
+}
+
+// String returns the snapshot as a JSON array, making QueryStats an expvar.Var
+func (s *QueryStats) String() string {
+	b, _ := json.Marshal(s.snapshotJSON())
+	return string(b)
+}
+
+// ServeHTTP writes the snapshot as a JSON array
+func (s *QueryStats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.snapshotJSON())
+}
+
+func (s *QueryStats) snapshotJSON() []queryStatJSON {
+	stats := s.Snapshot()
+	entries := make([]queryStatJSON, len(stats))
+	for i, stat := range stats {
+		total := float64(stat.TotalTime) / float64(time.Millisecond)
+		entries[i] = queryStatJSON{
+			Operation:   stat.Operation,
+			Fingerprint: stat.Fingerprint,
+			Calls:       stat.Calls,
+			Errors:      stat.Errors,
+			TotalMs:     total,
+			MeanMs:      total / float64(stat.Calls),
+			Rows:        stat.Rows,
+		}
+	}
+	return entries
+}
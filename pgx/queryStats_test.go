@@ -0,0 +1,63 @@
+package pgx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQueryStats(t *testing.T) {
+	stats := NewQueryStats(2)
+	i := newInstrumentation(nil, PoolConfig{QueryStats: stats})
+	for _, id := range []string{"1", "2"} {
+		st := i.instrument(context.Background(), opQuery, "select * from users where id = "+id, nil)
+		st.addRows(1)
+		st.finish(nil)
+	}
+	i.instrument(context.Background(), opExec, "delete from users", nil).finish(errors.New("fail"))
+	i.instrument(context.Background(), opExec, "vacuum", nil).finish(nil)
+	stats.observe(opQuery, "select 1", time.Second, 1, nil)
+
+	snapshot := stats.Snapshot()
+	if len(snapshot) != 4 || snapshot[0].Fingerprint != "other" || snapshot[0].Operation != opQuery || snapshot[0].TotalTime != time.Second {
+		t.Fatal("expected fingerprints past the limit counted as other, the most time first", snapshot)
+	}
+	for _, stat := range snapshot[1:] {
+		switch stat.Fingerprint {
+		case "other":
+			if stat.Operation != opExec || stat.Calls != 1 {
+				t.Error("expected vacuum counted as other", stat)
+			}
+		case "select * from users where id = ?":
+			if stat.Operation != opQuery || stat.Calls != 2 || stat.Rows != 2 || stat.Errors != 0 {
+				t.Error("expected both selects counted together", stat)
+			}
+		case "delete from users":
+			if stat.Operation != opExec || stat.Calls != 1 || stat.Errors != 1 {
+				t.Error("expected the error counted", stat)
+			}
+		default:
+			t.Error("unexpected fingerprint", stat)
+		}
+	}
+
+	var _ expvar.Var = stats
+	var entries []map[string]interface{}
+	if err := json.Unmarshal([]byte(stats.String()), &entries); err != nil || len(entries) != 4 || entries[0]["mean_ms"] != 1000.0 {
+		t.Error("expected JSON for expvar", stats.String(), err)
+	}
+	rec := httptest.NewRecorder()
+	stats.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/onedb", nil))
+	if rec.Header().Get("Content-Type") != "application/json" || json.Unmarshal(rec.Body.Bytes(), &entries) != nil || len(entries) != 4 {
+		t.Error("expected JSON response", rec.Body.String())
+	}
+
+	stats.Reset()
+	if len(stats.Snapshot()) != 0 {
+		t.Error("expected reset to clear the totals")
+	}
+}
@@ -0,0 +1,85 @@
+package pgx
+
+import (
+	"context"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+// RetryPolicy decides how statements which fail on a dead connection are retried. It is onedb's RetryPolicy
+type RetryPolicy = onedb.RetryPolicy
+
+// RetryPolicyFunc is an adapter to allow the use of an ordinary function as a RetryPolicy
+type RetryPolicyFunc = onedb.RetryPolicyFunc
+
+// ExponentialBackoff is a RetryPolicy which waits longer before each retry. See onedb.ExponentialBackoff
+type ExponentialBackoff = onedb.ExponentialBackoff
+
+// DefaultRetryPolicy retries up to 4 times, waiting 1 millisecond before the first retry and 10 times longer
+// before each one after that
+var DefaultRetryPolicy = onedb.DefaultRetryPolicy
+
+// NoRetry is a RetryPolicy which never retries
+var NoRetry = onedb.NoRetry
+
+// retry runs fn and, while it fails on a dead connection, runs it again as allowed by the retry policy. A
+// retry is only attempted once Ping succeeds. Waiting stops early if ctx is done. When a circuit breaker is
+// configured and open, fn isn't run at all
+func (b *pgxWithReconnect) retry(ctx context.Context, fn func() error) error {
+	if err := b.breaker.allow(b.Ping); err != nil {
+		return err
+	}
+	err := b.retryDeadConn(ctx, fn)
+	b.breaker.record(b.isConnFailure(err))
+	return err
+}
+
+func (b *pgxWithReconnect) retryDeadConn(ctx context.Context, fn func() error) error {
+	policy := b.retryPolicy
+	if policy == nil {
+		policy = DefaultRetryPolicy
+	}
+	retried := false
+	var lastErr error
+	err := onedb.Retry(ctx, policy, func(err error) bool {
+		_, final := err.(*finalError)
+		return !final && b.isDeadConn(err)
+	}, func() error {
+		if retried {
+			pingErr := b.PingContext(ctx)
+			b.counters.reconnect(pingErr)
+			b.inst.reconnect(pingErr)
+			if pingErr != nil {
+				return lastErr // still dead, so the attempt is used up without running fn
+			}
+		}
+		retried = true
+		lastErr = fn()
+		return lastErr
+	})
+	if final, ok := err.(*finalError); ok {
+		return final.err
+	}
+	return err
+}
+
+// finalError is returned by a function passed to retry when err must not be retried even if it was caused
+// by a dead connection
+type finalError struct {
+	err error
+}
+
+func (e *finalError) Error() string {
+	return e.err.Error()
+}
+
+// copyFromTracker records whether any rows have been read from a CopyFromSource
+type copyFromTracker struct {
+	started bool
+	CopyFromSource
+}
+
+func (c *copyFromTracker) Next() bool {
+	c.started = true
+	return c.CopyFromSource.Next()
+}
@@ -0,0 +1,49 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	b := &pgxWithReconnect{retryPolicy: NoRetry}
+	if err := b.retry(context.Background(), func() error { calls++; return ErrDeadConn }); err != ErrDeadConn || calls != 1 {
+		t.Error("expected no retry", err, calls)
+	}
+
+	calls = 0
+	fail := errors.New("fail")
+	b = &pgxWithReconnect{}
+	if err := b.retry(context.Background(), func() error { calls++; return fail }); err != fail || calls != 1 {
+		t.Error("expected only dead connections to be retried", err, calls)
+	}
+
+	calls = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b = &pgxWithReconnect{retryPolicy: RetryPolicyFunc(func(int, time.Duration) (time.Duration, bool) { return time.Hour, true })}
+	if err := b.retry(ctx, func() error { calls++; return ErrDeadConn }); err != ErrDeadConn || calls != 1 {
+		t.Error("expected waiting to stop when context is done", err, calls)
+	}
+}
+
+func TestRetryFinalError(t *testing.T) {
+	calls := 0
+	b := &pgxWithReconnect{}
+	if err := b.retry(context.Background(), func() error { calls++; return &finalError{ErrDeadConn} }); err != ErrDeadConn || calls != 1 {
+		t.Error("expected final error not to be retried", err, calls)
+	}
+}
+
+func TestCopyFromTracker(t *testing.T) {
+	src := &copyFromTracker{CopyFromSource: CopyFromRows([][]interface{}{{1}})}
+	if src.started {
+		t.Error("expected not started")
+	}
+	if !src.Next() || !src.started {
+		t.Error("expected started after Next")
+	}
+}
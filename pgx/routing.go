@@ -0,0 +1,202 @@
+package pgx
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+// RoutingPolicy chooses which replica a read is sent to
+type RoutingPolicy int
+
+const (
+	// RoundRobin sends reads to each healthy replica in turn
+	RoundRobin RoutingPolicy = iota
+	// LeastLoaded sends reads to the healthy replica with the fewest connections in use
+	LeastLoaded
+)
+
+// RoutingConfig configures a RoutingBackender
+type RoutingConfig struct {
+	Policy              RoutingPolicy
+	HealthCheckInterval time.Duration // defaults to 10 seconds
+	HealthCheckTimeout  time.Duration // defaults to HealthCheckInterval
+
+	// HealthCheck reports whether a replica can serve reads, e.g. checking its replication lag. The default runs
+	// select 1
+	HealthCheck func(ctx context.Context, replica PGXer) error
+}
+
+// RoutingBackender sends reads to replicas and everything else to the primary. Reads are the Query, QueryRow and
+// onedb.DBer methods; Exec, CopyFrom, BulkInsert, QueryCursor, batches, prepared statements and transactions run
+// on the primary. A query which writes and returns rows, like INSERT ... RETURNING, must be run on Primary()
+type RoutingBackender interface {
+	PGXer
+	Primary() PGXer
+	HealthyReplicas() int
+}
+
+type routingReplica struct {
+	db      PGXer
+	healthy int32
+}
+
+type routingBackend struct {
+	PGXer    // primary
+	replicas []*routingReplica
+	config   RoutingConfig
+	next     uint32
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRoutingBackender returns a RoutingBackender over a primary and its replicas. Replicas are health checked in
+// the background; one which fails is skipped until it passes again, and reads go to the primary while no replica
+// is healthy. Close closes the primary and every replica
+func NewRoutingBackender(primary PGXer, replicas []PGXer, config RoutingConfig) RoutingBackender {
+	if config.HealthCheckInterval <= 0 {
+		config.HealthCheckInterval = 10 * time.Second
+	}
+	if config.HealthCheckTimeout <= 0 {
+		config.HealthCheckTimeout = config.HealthCheckInterval
+	}
+	if config.HealthCheck == nil {
+		config.HealthCheck = pingReplica
+	}
+	b := &routingBackend{PGXer: primary, config: config, stop: make(chan struct{})}
+	for _, replica := range replicas {
+		b.replicas = append(b.replicas, &routingReplica{db: replica, healthy: 1})
+	}
+	if len(b.replicas) > 0 {
+		go b.checkHealth()
+	}
+	return b
+}
+
+func pingReplica(ctx context.Context, replica PGXer) error {
+	var n int
+	return replica.QueryRowContext(ctx, "select 1").Scan(&n)
+}
+
+func (b *routingBackend) checkHealth() {
+	ticker := time.NewTicker(b.config.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.checkReplicas()
+		}
+	}
+}
+
+// checkReplicas marks each replica healthy or not by running the health check, including on replicas which have
+// failed so they are used again once they recover
+func (b *routingBackend) checkReplicas() {
+	var wg sync.WaitGroup
+	for _, r := range b.replicas {
+		wg.Add(1)
+		go func(r *routingReplica) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), b.config.HealthCheckTimeout)
+			defer cancel()
+			healthy := int32(1)
+			if b.config.HealthCheck(ctx, r.db) != nil {
+				healthy = 0
+			}
+			atomic.StoreInt32(&r.healthy, healthy)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// reader returns the replica a read should be sent to, or the primary when no replica is healthy
+func (b *routingBackend) reader() PGXer {
+	switch b.config.Policy {
+	case LeastLoaded:
+		var best PGXer
+		least := 0
+		for _, r := range b.replicas {
+			if atomic.LoadInt32(&r.healthy) == 0 {
+				continue
+			}
+			if acquired := r.db.Stats().AcquiredConnections; best == nil || acquired < least {
+				best, least = r.db, acquired
+			}
+		}
+		if best != nil {
+			return best
+		}
+	default:
+		n := len(b.replicas)
+		start := int(atomic.AddUint32(&b.next, 1))
+		for i := 0; i < n; i++ {
+			r := b.replicas[(start+i)%n]
+			if atomic.LoadInt32(&r.healthy) == 1 {
+				return r.db
+			}
+		}
+	}
+	return b.PGXer
+}
+
+func (b *routingBackend) Primary() PGXer {
+	return b.PGXer
+}
+
+// HealthyReplicas returns the number of replicas which passed their last health check
+func (b *routingBackend) HealthyReplicas() int {
+	healthy := 0
+	for _, r := range b.replicas {
+		healthy += int(atomic.LoadInt32(&r.healthy))
+	}
+	return healthy
+}
+
+// Close stops the health checks and closes the primary and every replica
+func (b *routingBackend) Close() {
+	b.stopOnce.Do(func() { close(b.stop) })
+	b.PGXer.Close()
+	for _, r := range b.replicas {
+		r.db.Close()
+	}
+}
+
+func (b *routingBackend) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return b.reader().Query(query, args...)
+}
+func (b *routingBackend) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return b.reader().QueryContext(ctx, query, args...)
+}
+func (b *routingBackend) QueryRow(query string, args ...interface{}) onedb.Scanner {
+	return b.reader().QueryRow(query, args...)
+}
+func (b *routingBackend) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	return b.reader().QueryRowContext(ctx, query, args...)
+}
+func (b *routingBackend) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return b.reader().QueryValues(query, result...)
+}
+func (b *routingBackend) QueryJSON(query string, args ...interface{}) (string, error) {
+	return b.reader().QueryJSON(query, args...)
+}
+func (b *routingBackend) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return b.reader().QueryJSONRow(query, args...)
+}
+func (b *routingBackend) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return b.reader().QueryJSONWriter(w, query, args...)
+}
+func (b *routingBackend) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return b.reader().QueryStruct(result, query, args...)
+}
+func (b *routingBackend) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return b.reader().QueryStructRow(result, query, args...)
+}
+func (b *routingBackend) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return b.reader().QueryWriteCSV(w, options, query, args...)
+}
@@ -0,0 +1,75 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRoutingBackender(t *testing.T) {
+	primary, r1, r2 := NewMock(nil, nil), NewMock(nil, nil), NewMock(nil, nil)
+	r1.ExpectQuery("select 1 from users")
+	r2.ExpectQuery("select 2 from users")
+	primary.ExpectExec("delete from users")
+	down := map[PGXer]bool{}
+	b := NewRoutingBackender(primary, []PGXer{r1, r2}, RoutingConfig{HealthCheckInterval: time.Hour, HealthCheck: func(ctx context.Context, replica PGXer) error {
+		if down[replica] {
+			return errors.New("down")
+		}
+		return nil
+	}})
+	defer b.Close()
+
+	var n int
+	b.QueryRow("select 2 from users").Scan(&n) // the first read goes to the second replica
+	b.QueryRow("select 1 from users").Scan(&n)
+	b.Exec("delete from users")
+	r1.VerifyExpectations(t)
+	r2.VerifyExpectations(t)
+	primary.VerifyExpectations(t)
+
+	down[r2] = true
+	b.(*routingBackend).checkReplicas()
+	if b.HealthyReplicas() != 1 {
+		t.Error("expected the failed replica to be skipped", b.HealthyReplicas())
+	}
+	r1.ExpectQuery("select 1 from users").AnyTimes()
+	b.QueryStruct(&[]SimpleData{}, "select 1 from users")
+	b.QueryStruct(&[]SimpleData{}, "select 1 from users")
+	r1.VerifyExpectations(t)
+
+	down[r1] = true
+	b.(*routingBackend).checkReplicas()
+	primary.ExpectQuery("select 3 from users")
+	b.Query("select 3 from users")
+	primary.VerifyExpectations(t)
+
+	down[r1], down[r2] = false, false
+	b.(*routingBackend).checkReplicas()
+	if b.HealthyReplicas() != 2 || b.Primary() != primary {
+		t.Error("expected replicas to be used again once they recover", b.HealthyReplicas())
+	}
+}
+
+type loadedMock struct {
+	Mocker
+	acquired int
+}
+
+func (m *loadedMock) Stats() PoolStats {
+	return PoolStats{AcquiredConnections: m.acquired}
+}
+
+func TestRoutingLeastLoaded(t *testing.T) {
+	busy, idle := &loadedMock{NewMock(nil, nil), 5}, &loadedMock{NewMock(nil, nil), 1}
+	idle.ExpectQuery("select 1").AnyTimes()
+	b := NewRoutingBackender(NewMock(nil, nil), []PGXer{busy, idle}, RoutingConfig{Policy: LeastLoaded})
+	defer b.Close()
+	for i := 0; i < 3; i++ {
+		b.Query("select 1")
+	}
+	if len(busy.QueriesRun()) != 0 || len(idle.QueriesRun()) != 3 {
+		t.Error("expected reads to go to the least loaded replica", busy.QueriesRun())
+	}
+}
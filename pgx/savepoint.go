@@ -0,0 +1,219 @@
+package pgx
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/EndFirstCorp/onedb"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func savepointName(depth int) string {
+	return "onedb_sp_" + strconv.Itoa(depth)
+}
+
+func savepoint(q querier, name string) error {
+	_, err := q.Exec("savepoint " + pgx.Identifier{name}.Sanitize())
+	return err
+}
+
+func rollbackTo(q querier, name string) error {
+	_, err := q.Exec("rollback to savepoint " + pgx.Identifier{name}.Sanitize())
+	return err
+}
+
+func releaseSavepoint(q querier, name string) error {
+	_, err := q.Exec("release savepoint " + pgx.Identifier{name}.Sanitize())
+	return err
+}
+
+// savepointTx is a nested transaction implemented with a savepoint on its parent. Commit releases the
+// savepoint and Rollback undoes everything run since it was created. Statements run on the parent's connection
+// and return ErrTxDone once the savepoint is committed or rolled back
+type savepointTx struct {
+	name   string
+	depth  int
+	status int8
+	hooks  txHooks
+	Txer   // parent transaction
+}
+
+func beginSavepoint(parent Txer, depth int) (Txer, error) {
+	name := savepointName(depth)
+	if err := savepoint(parent, name); err != nil {
+		return nil, err
+	}
+	return &savepointTx{name: name, depth: depth, Txer: parent}, nil
+}
+
+// Begin starts a transaction nested inside this one
+func (t *savepointTx) Begin() (Txer, error) {
+	if t.status != TxStatusInProgress {
+		return nil, ErrTxDone
+	}
+	return beginSavepoint(t, t.depth+1)
+}
+
+// Commit releases the savepoint, keeping its changes as part of the parent transaction
+func (t *savepointTx) Commit() error {
+	if t.status != TxStatusInProgress {
+		return ErrTxDone
+	}
+	if err := releaseSavepoint(t.Txer, t.name); err != nil {
+		t.status = TxStatusCommitFailure
+		t.hooks.run(false)
+		return err
+	}
+	t.status = TxStatusCommitSuccess
+	t.hooks.moveTo(t.Txer)
+	return nil
+}
+
+// Rollback undoes all changes made since the savepoint was created. The parent transaction remains usable
+func (t *savepointTx) Rollback() error {
+	if t.status != TxStatusInProgress {
+		return ErrTxDone
+	}
+	err := rollbackTo(t.Txer, t.name)
+	if err == nil {
+		err = releaseSavepoint(t.Txer, t.name)
+	}
+	t.hooks.run(false)
+	if err != nil {
+		t.status = TxStatusRollbackFailure
+		return err
+	}
+	t.status = TxStatusRollbackSuccess
+	return nil
+}
+
+func (t *savepointTx) Status() int8 {
+	return t.status
+}
+
+// done reports whether the savepoint has been released or rolled back, including when the attempt failed
+func (t *savepointTx) done() bool {
+	return t.status != TxStatusInProgress
+}
+
+// RollbackTo undoes all changes made since the named savepoint was created
+func (t *savepointTx) RollbackTo(name string) error {
+	if t.done() {
+		return ErrTxDone
+	}
+	return t.Txer.RollbackTo(name)
+}
+
+// Savepoint creates a named savepoint which can be rolled back to with RollbackTo
+func (t *savepointTx) Savepoint(name string) error {
+	if t.done() {
+		return ErrTxDone
+	}
+	return t.Txer.Savepoint(name)
+}
+
+func (t *savepointTx) LargeObjects() (LargeObjects, error) {
+	if t.done() {
+		return nil, ErrTxDone
+	}
+	return t.Txer.LargeObjects()
+}
+
+func (t *savepointTx) QueryCursor(query string, fetchSize int, args ...interface{}) (onedb.RowsScanner, error) {
+	if t.done() {
+		return nil, ErrTxDone
+	}
+	return t.Txer.QueryCursor(query, fetchSize, args...)
+}
+
+func (t *savepointTx) BulkInsert(tableName Identifier, columnNames []string, rows [][]interface{}, suffix string) (int64, error) {
+	if t.done() {
+		return 0, ErrTxDone
+	}
+	return t.Txer.BulkInsert(tableName, columnNames, rows, suffix)
+}
+
+func (t *savepointTx) CopyFrom(tableName Identifier, columnNames []string, rows CopyFromSource) (int, error) {
+	if t.done() {
+		return 0, ErrTxDone
+	}
+	return t.Txer.CopyFrom(tableName, columnNames, rows)
+}
+
+func (t *savepointTx) Prepare(name, sql string) (Stmt, error) {
+	if t.done() {
+		return nil, ErrTxDone
+	}
+	return t.Txer.Prepare(name, sql)
+}
+
+func (t *savepointTx) QueryRow(query string, args ...interface{}) onedb.Scanner {
+	if t.done() {
+		return &errRow{ErrTxDone}
+	}
+	return t.Txer.QueryRow(query, args...)
+}
+
+func (t *savepointTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	if t.done() {
+		return &errRow{ErrTxDone}
+	}
+	return t.Txer.QueryRowContext(ctx, query, args...)
+}
+
+func (t *savepointTx) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
+	if t.done() {
+		return nil, ErrTxDone
+	}
+	return t.Txer.Query(query, args...)
+}
+
+func (t *savepointTx) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	if t.done() {
+		return nil, ErrTxDone
+	}
+	return t.Txer.QueryContext(ctx, query, args...)
+}
+
+func (t *savepointTx) Exec(query string, args ...interface{}) (CommandTag, error) {
+	if t.done() {
+		return "", ErrTxDone
+	}
+	return t.Txer.Exec(query, args...)
+}
+
+func (t *savepointTx) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	if t.done() {
+		return "", ErrTxDone
+	}
+	return t.Txer.ExecContext(ctx, query, args...)
+}
+
+func (t *savepointTx) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValues(t, query, result...)
+}
+
+func (t *savepointTx) QueryJSON(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSON(t, query, args...)
+}
+
+func (t *savepointTx) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRow(t, query, args...)
+}
+
+func (t *savepointTx) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, t, query, args...)
+}
+
+func (t *savepointTx) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStruct(t, result, query, args...)
+}
+
+func (t *savepointTx) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRow(t, result, query, args...)
+}
+
+func (t *savepointTx) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSV(w, options, t, query, args...)
+}
@@ -0,0 +1,172 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+func TestSavepointTxCommit(t *testing.T) {
+	parent := &mockExecTx{}
+	tx, err := beginSavepoint(parent, 1)
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	nested, err := tx.Begin()
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	if err := nested.Commit(); err != nil || nested.Status() != TxStatusCommitSuccess {
+		t.Error("expected commit", err)
+	}
+	if err := nested.Commit(); err != ErrTxDone {
+		t.Error("expected ErrTxDone", err)
+	}
+	if err := tx.Rollback(); err != nil || tx.Status() != TxStatusRollbackSuccess {
+		t.Error("expected rollback", err)
+	}
+	expected := []string{
+		`savepoint "onedb_sp_1"`,
+		`savepoint "onedb_sp_2"`,
+		`release savepoint "onedb_sp_2"`,
+		`rollback to savepoint "onedb_sp_1"`,
+		`release savepoint "onedb_sp_1"`,
+	}
+	verifyExecs(t, parent.execs, expected)
+	if parent.commits != 0 || parent.rollbacks != 0 {
+		t.Error("expected parent to remain open", parent)
+	}
+}
+
+func TestSavepointTxError(t *testing.T) {
+	parent := &mockExecTx{err: errors.New("fail")}
+	if _, err := beginSavepoint(parent, 1); err == nil {
+		t.Error("expected error")
+	}
+
+	tx := &savepointTx{name: "sp", Txer: parent}
+	if err := tx.Commit(); err == nil || tx.Status() != TxStatusCommitFailure {
+		t.Error("expected commit failure", err)
+	}
+	if _, err := tx.Begin(); err != ErrTxDone {
+		t.Error("expected ErrTxDone", err)
+	}
+}
+
+func TestSavepointSanitize(t *testing.T) {
+	parent := &mockExecTx{}
+	savepoint(parent, `my "point"`)
+	rollbackTo(parent, "mine")
+	verifyExecs(t, parent.execs, []string{`savepoint "my ""point"""`, `rollback to savepoint "mine"`})
+}
+
+func TestSavepointWithTx(t *testing.T) {
+	parent := &mockExecTx{}
+	tx := &savepointTx{name: "onedb_sp_1", depth: 1, Txer: parent}
+	err := onedb.WithTx(tx, func(nested Txer) error {
+		_, err := nested.Exec("insert")
+		return err
+	})
+	if err != nil {
+		t.Error("expected success", err)
+	}
+	verifyExecs(t, parent.execs, []string{`savepoint "onedb_sp_2"`, "insert", `release savepoint "onedb_sp_2"`})
+}
+
+func TestSavepointTxDone(t *testing.T) {
+	for _, end := range []func(Txer) error{Txer.Commit, Txer.Rollback} {
+		parent := &mockExecTx{}
+		tx, _ := beginSavepoint(parent, 1)
+		if err := end(tx); err != nil {
+			t.Fatal("expected success", err)
+		}
+		parent.execs = nil
+		ctx := context.Background()
+		if _, err := tx.Exec("insert"); err != ErrTxDone {
+			t.Error("expected ErrTxDone from Exec", err)
+		}
+		if _, err := tx.ExecContext(ctx, "insert"); err != ErrTxDone {
+			t.Error("expected ErrTxDone from ExecContext", err)
+		}
+		if _, err := tx.Query("select"); err != ErrTxDone {
+			t.Error("expected ErrTxDone from Query", err)
+		}
+		if _, err := tx.QueryContext(ctx, "select"); err != ErrTxDone {
+			t.Error("expected ErrTxDone from QueryContext", err)
+		}
+		if err := tx.QueryRow("select").Scan(); err != ErrTxDone {
+			t.Error("expected ErrTxDone from QueryRow", err)
+		}
+		if err := tx.QueryRowContext(ctx, "select").Scan(); err != ErrTxDone {
+			t.Error("expected ErrTxDone from QueryRowContext", err)
+		}
+		if _, err := tx.CopyFrom(Identifier{"users"}, nil, nil); err != ErrTxDone {
+			t.Error("expected ErrTxDone from CopyFrom", err)
+		}
+		if _, err := tx.Prepare("name", "select"); err != ErrTxDone {
+			t.Error("expected ErrTxDone from Prepare", err)
+		}
+		if _, err := tx.LargeObjects(); err != ErrTxDone {
+			t.Error("expected ErrTxDone from LargeObjects", err)
+		}
+		if _, err := tx.QueryCursor("select", 10); err != ErrTxDone {
+			t.Error("expected ErrTxDone from QueryCursor", err)
+		}
+		if _, err := tx.BulkInsert(Identifier{"users"}, []string{"id"}, [][]interface{}{{1}}, ""); err != ErrTxDone {
+			t.Error("expected ErrTxDone from BulkInsert", err)
+		}
+		if err := tx.Savepoint("mine"); err != ErrTxDone {
+			t.Error("expected ErrTxDone from Savepoint", err)
+		}
+		if err := tx.RollbackTo("mine"); err != ErrTxDone {
+			t.Error("expected ErrTxDone from RollbackTo", err)
+		}
+		var result []struct{ ID int }
+		if err := tx.QueryStruct(&result, "select"); err != ErrTxDone {
+			t.Error("expected ErrTxDone from QueryStruct", err)
+		}
+		if len(parent.execs) != 0 {
+			t.Error("expected nothing run on the parent", parent.execs)
+		}
+	}
+}
+
+func verifyExecs(t *testing.T, actual, expected []string) {
+	if len(actual) != len(expected) {
+		t.Fatal("expected statements", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Error("expected statement", expected[i], actual[i])
+		}
+	}
+}
+
+type mockExecTx struct {
+	execs []string
+	err   error
+	mockCommitTx
+}
+
+func (t *mockExecTx) Exec(query string, args ...interface{}) (CommandTag, error) {
+	t.execs = append(t.execs, query)
+	return "", t.err
+}
+
+type mockCommitTx struct {
+	commits   int
+	rollbacks int
+	Txer
+}
+
+func (t *mockCommitTx) Commit() error {
+	t.commits++
+	return nil
+}
+
+func (t *mockCommitTx) Rollback() error {
+	t.rollbacks++
+	return nil
+}
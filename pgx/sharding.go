@@ -0,0 +1,194 @@
+package pgx
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+// ShardFunc maps a shard key to the index of one of shards shards
+type ShardFunc func(key interface{}, shards int) int
+
+// HashShard is a ShardFunc which spreads keys evenly by hashing their fmt.Sprint representation with FNV-1a
+func HashShard(key interface{}, shards int) int {
+	h := fnv.New32a()
+	fmt.Fprint(h, key)
+	return int(h.Sum32() % uint32(shards))
+}
+
+// ShardedBackender spreads data across several databases, each with its own pool. A shard's reconnects, retries
+// and circuit breaker are its own, so one shard being down doesn't affect statements on the others
+type ShardedBackender interface {
+	// Shard returns the backend holding the data for key, for statements the other methods don't cover such as
+	// transactions. It returns an error if the ShardFunc returns an index out of range
+	Shard(key interface{}) (PGXer, error)
+	Shards() []PGXer
+	ExecOnShard(key interface{}, query string, args ...interface{}) (CommandTag, error)
+	QueryOnShard(key interface{}, query string, args ...interface{}) (onedb.RowsScanner, error)
+	// QueryAllShards runs query on every shard at once and returns their rows one shard after another. Each
+	// shard must return the same columns. Order is only kept within a shard, so a sorted result must be sorted
+	// again after it is read
+	QueryAllShards(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error)
+	Close()
+}
+
+type shardedBackend struct {
+	shards    []PGXer
+	shardFunc ShardFunc
+}
+
+// NewShardedBackender returns a ShardedBackender over shards, using shardFunc, or HashShard when it is nil, to
+// pick the shard for a key. The order of shards must not change once data has been written
+func NewShardedBackender(shards []PGXer, shardFunc ShardFunc) (ShardedBackender, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("a ShardedBackender needs at least one shard")
+	}
+	if shardFunc == nil {
+		shardFunc = HashShard
+	}
+	return &shardedBackend{shards: shards, shardFunc: shardFunc}, nil
+}
+
+// NewShardedPgx opens a pool for each config and returns a ShardedBackender over them
+func NewShardedPgx(configs []PoolConfig, shardFunc ShardFunc) (ShardedBackender, error) {
+	shards := make([]PGXer, 0, len(configs))
+	for i, config := range configs {
+		db, err := newPgx(config)
+		if err != nil {
+			for _, opened := range shards {
+				opened.Close()
+			}
+			return nil, errors.Wrapf(err, "Unable to connect to shard %d", i)
+		}
+		shards = append(shards, db)
+	}
+	return NewShardedBackender(shards, shardFunc)
+}
+
+func (b *shardedBackend) Shard(key interface{}) (PGXer, error) {
+	i := b.shardFunc(key, len(b.shards))
+	if i < 0 || i >= len(b.shards) {
+		return nil, errors.Errorf("shard function returned %d for %d shards", i, len(b.shards))
+	}
+	return b.shards[i], nil
+}
+
+func (b *shardedBackend) Shards() []PGXer {
+	return b.shards
+}
+
+func (b *shardedBackend) ExecOnShard(key interface{}, query string, args ...interface{}) (CommandTag, error) {
+	shard, err := b.Shard(key)
+	if err != nil {
+		return "", err
+	}
+	return shard.Exec(query, args...)
+}
+
+func (b *shardedBackend) QueryOnShard(key interface{}, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	shard, err := b.Shard(key)
+	if err != nil {
+		return nil, err
+	}
+	return shard.Query(query, args...)
+}
+
+func (b *shardedBackend) QueryAllShards(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	results := make([]onedb.RowsScanner, len(b.shards))
+	errs := make([]error, len(b.shards))
+	var wg sync.WaitGroup
+	for i, shard := range b.shards {
+		wg.Add(1)
+		go func(i int, shard PGXer) {
+			defer wg.Done()
+			results[i], errs[i] = shard.QueryContext(ctx, query, args...)
+		}(i, shard)
+	}
+	wg.Wait()
+
+	rows := &shardRows{shards: results}
+	for i, err := range errs {
+		if err != nil {
+			rows.Close()
+			return nil, errors.Wrapf(err, "Unable to query shard %d", i)
+		}
+	}
+	columns, err := results[0].Columns()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+	for i, result := range results[1:] {
+		other, err := result.Columns()
+		if err == nil && len(other) != len(columns) {
+			err = errors.Errorf("shard %d returned %d columns but shard 0 returned %d", i+1, len(other), len(columns))
+		}
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+	}
+	rows.columns = columns
+	return rows, nil
+}
+
+// Close closes every shard
+func (b *shardedBackend) Close() {
+	for _, shard := range b.shards {
+		shard.Close()
+	}
+}
+
+// shardRows reads the rows of each shard in turn, closing each shard's rows once they are read
+type shardRows struct {
+	shards  []onedb.RowsScanner
+	current int
+	columns []string
+	err     error
+}
+
+func (r *shardRows) Next() bool {
+	for r.err == nil && r.current < len(r.shards) {
+		shard := r.shards[r.current]
+		if shard.Next() {
+			return true
+		}
+		r.err = shard.Err()
+		if err := shard.Close(); r.err == nil {
+			r.err = err
+		}
+		if r.err != nil {
+			r.err = errors.Wrapf(r.err, "Unable to read shard %d", r.current)
+		}
+		r.current++
+	}
+	return false
+}
+
+func (r *shardRows) Scan(dest ...interface{}) error {
+	if r.current >= len(r.shards) {
+		return errors.New("Scan called after the rows of every shard were read")
+	}
+	return r.shards[r.current].Scan(dest...)
+}
+
+func (r *shardRows) Columns() ([]string, error) {
+	return r.columns, nil
+}
+
+func (r *shardRows) Err() error {
+	return r.err
+}
+
+func (r *shardRows) Close() error {
+	for ; r.current < len(r.shards); r.current++ {
+		if shard := r.shards[r.current]; shard != nil {
+			shard.Close()
+		}
+	}
+	return r.err
+}
@@ -0,0 +1,65 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestShardedBackender(t *testing.T) {
+	s0, s1 := NewMock(nil, nil), NewMock(nil, nil)
+	b, err := NewShardedBackender([]PGXer{s0, s1}, func(key interface{}, shards int) int { return key.(int) % shards })
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	s1.ExpectExec("insert into users (id) values ($1)").WithArgs(3)
+	s0.ExpectQuery("select name from users where id = $1").WithArgs(4)
+	b.ExecOnShard(3, "insert into users (id) values ($1)", 3)
+	b.QueryOnShard(4, "select name from users where id = $1", 4)
+	s0.VerifyExpectations(t)
+	s1.VerifyExpectations(t)
+
+	if _, err := b.ExecOnShard(-1, "delete from users"); err == nil {
+		t.Error("expected an error for a shard index out of range")
+	}
+	if shard, err := b.Shard(2); err != nil || shard != s0 {
+		t.Error("expected shard", err)
+	}
+
+	if _, err := NewShardedBackender(nil, nil); err == nil {
+		t.Error("expected error without shards")
+	}
+	if i := HashShard("user-1", 4); i < 0 || i >= 4 || HashShard("user-1", 4) != i {
+		t.Error("expected a stable shard in range", i)
+	}
+}
+
+func TestQueryAllShards(t *testing.T) {
+	s0 := NewMock(nil, nil, []SimpleData{{1, "alice"}, {3, "carol"}})
+	s1 := NewMock(nil, nil, []SimpleData{{2, "bob"}})
+	s2 := NewMock(nil, nil, []SimpleData{})
+	b, _ := NewShardedBackender([]PGXer{s0, s1, s2}, nil)
+	rows, err := b.QueryAllShards(context.Background(), "select * from users")
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	var names []string
+	for rows.Next() {
+		var d SimpleData
+		rows.Scan(&d.IntVal, &d.StringVal)
+		names = append(names, d.StringVal)
+	}
+	if rows.Err() != nil || len(names) != 3 || names[2] != "bob" {
+		t.Error("expected the rows of every shard", names, rows.Err())
+	}
+	if err := rows.Scan(new(int), new(string)); err == nil {
+		t.Error("expected an error scanning past the last row")
+	}
+	rows.Close()
+
+	fail := errors.New("fail")
+	s1.ExpectQuery("select * from users").WillReturnError(fail)
+	if _, err := b.QueryAllShards(context.Background(), "select * from users"); err == nil {
+		t.Error("expected error when a shard fails")
+	}
+}
@@ -0,0 +1,105 @@
+package pgx
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// ErrShutdown occurs when a statement or transaction is started after Shutdown was called
+var ErrShutdown = errors.New("backend is shutting down")
+
+// ErrShutdownTimeout occurs when connections are still in use once Shutdown's timeout has passed
+var ErrShutdownTimeout = errors.New("shutdown timed out")
+
+// drain tracks the connections acquired from the pool so Shutdown can wait for them to be released. A zero drain
+// is ready to use
+type drain struct {
+	mu      sync.Mutex
+	closing bool
+	inUse   map[*pgx.Conn]bool
+	idle    chan struct{} // closed once closing and every connection has been released
+}
+
+func (d *drain) stopped() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.closing
+}
+
+// acquired records conn as in use, or returns ErrShutdown if Shutdown has been called
+func (d *drain) acquired(conn *pgx.Conn) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closing {
+		return ErrShutdown
+	}
+	if d.inUse == nil {
+		d.inUse = make(map[*pgx.Conn]bool)
+	}
+	d.inUse[conn] = true
+	return nil
+}
+
+func (d *drain) released(conn *pgx.Conn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.inUse[conn] {
+		return
+	}
+	delete(d.inUse, conn)
+	if d.closing && len(d.inUse) == 0 {
+		close(d.idle)
+	}
+}
+
+// start stops further connections from being acquired and returns a channel which is closed once the
+// connections in use have all been released
+func (d *drain) start() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closing {
+		d.closing = true
+		d.idle = make(chan struct{})
+		if len(d.inUse) == 0 {
+			close(d.idle)
+		}
+	}
+	return d.idle
+}
+
+// busy returns the connections still in use
+func (d *drain) busy() []*pgx.Conn {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	conns := make([]*pgx.Conn, 0, len(d.inUse))
+	for conn := range d.inUse {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// Shutdown stops new statements and transactions, which fail with ErrShutdown, and waits up to timeout for the
+// ones in progress to finish, including open rows and transactions, before closing the pool. Connections held by
+// Listen are only released once its context is done. If the timeout passes first, a cancel request is sent for
+// each connection still in use, the pool is closed as they are released and an error wrapping
+// ErrShutdownTimeout is returned
+func (b *pgxWithReconnect) Shutdown(timeout time.Duration) error {
+	idle := b.drain.start()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		b.db.Close()
+		return nil
+	case <-timer.C:
+	}
+	busy := b.drain.busy()
+	for _, conn := range busy {
+		cancelRequest(b.config, conn)
+	}
+	go b.db.Close() // waits for the remaining connections to be released
+	return errors.Wrapf(ErrShutdownTimeout, "%d connections still in use after %s", len(busy), timeout)
+}
@@ -0,0 +1,67 @@
+package pgx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestDrain(t *testing.T) {
+	var d drain
+	conn1, conn2 := &pgx.Conn{}, &pgx.Conn{}
+	if d.acquired(conn1) != nil || d.acquired(conn2) != nil {
+		t.Fatal("expected connections to be tracked")
+	}
+	idle := d.start()
+	if !d.stopped() || d.acquired(&pgx.Conn{}) != ErrShutdown {
+		t.Error("expected new connections to be refused")
+	}
+	if busy := d.busy(); len(busy) != 2 {
+		t.Error("expected both connections in use", busy)
+	}
+	d.released(conn1)
+	d.released(conn1)
+	select {
+	case <-idle:
+		t.Fatal("expected to wait for the second connection")
+	default:
+	}
+	d.released(conn2)
+	select {
+	case <-idle:
+	case <-time.After(time.Second):
+		t.Fatal("expected idle once every connection is released")
+	}
+	if d.start() != idle {
+		t.Error("expected a second Shutdown to wait on the same drain")
+	}
+
+	var empty drain
+	select {
+	case <-empty.start():
+	default:
+		t.Error("expected idle right away without connections in use")
+	}
+}
+
+func TestShutdownRefusesStatements(t *testing.T) {
+	b := &pgxWithReconnect{}
+	b.drain.start()
+	if _, err := b.acquire(); err != ErrShutdown {
+		t.Error("expected ErrShutdown", err)
+	}
+	if _, err := b.Begin(); errors.Cause(err) != ErrShutdown {
+		t.Error("expected ErrShutdown", err)
+	}
+	if _, err := b.Exec("select 1"); errors.Cause(err) != ErrShutdown {
+		t.Error("expected ErrShutdown", err)
+	}
+
+	c := newMockPgx(nil, nil)
+	(&pgxBackend{db: c}).Shutdown(time.Second)
+	if calls := c.MethodsCalled["Shutdown"]; len(calls) != 1 || calls[0][0] != time.Second {
+		t.Error("expected Shutdown method to be called on backend", calls)
+	}
+}
@@ -0,0 +1,188 @@
+package pgx
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// ErrSimpleProtocolPrepare is returned by Prepare when PoolConfig.SimpleProtocol is set, since a statement
+// prepared on a connection behind PgBouncer may be run on another server connection
+var ErrSimpleProtocolPrepare = errors.New("prepared statements are disabled by SimpleProtocol")
+
+// interpolate replaces the $1, $2 ... placeholders of query with args as SQL literals, so the statement can be
+// sent with the simple protocol. Placeholders inside strings, quoted identifiers, dollar-quoted bodies and
+// comments are left alone. Backslashes only escape in E'...' strings, as when standard_conforming_strings is on
+func interpolate(query string, args []interface{}) (string, error) {
+	if len(args) == 0 {
+		return query, nil
+	}
+	var sql strings.Builder
+	last := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'' || c == '"':
+			backslashes := c == '\'' && i > 0 && (query[i-1] == 'E' || query[i-1] == 'e') && (i < 2 || !isIdentChar(query[i-2]))
+			for i++; i < len(query) && query[i] != c; i++ {
+				if backslashes && query[i] == '\\' {
+					i++
+				}
+			}
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(query)
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(query)
+			}
+		case c == '$' && (i == 0 || !isIdentChar(query[i-1])):
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			if j > i+1 {
+				n, _ := strconv.Atoi(query[i+1 : j])
+				if n < 1 || n > len(args) {
+					return "", errors.Errorf("placeholder $%d has no argument, there are %d", n, len(args))
+				}
+				literal, err := sqlLiteral(args[n-1])
+				if err != nil {
+					return "", errors.Wrapf(err, "Unable to interpolate argument %d", n)
+				}
+				sql.WriteString(query[last:i])
+				sql.WriteString(literal)
+				last, i = j, j-1
+				continue
+			}
+			for ; j < len(query) && isIdentChar(query[j]); j++ {
+			}
+			if j < len(query) && query[j] == '$' { // a dollar-quoted body like $$ ... $$ or $fn$ ... $fn$
+				tag := query[i : j+1]
+				if end := strings.Index(query[j+1:], tag); end >= 0 {
+					i = j + end + len(tag)
+				} else {
+					i = len(query)
+				}
+			}
+		}
+	}
+	sql.WriteString(query[last:])
+	return sql.String(), nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// sqlLiteral returns v as a SQL literal. Negative numbers are parenthesized so a preceding minus doesn't turn
+// them into a comment
+func sqlLiteral(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case int:
+		return intLiteral(int64(t)), nil
+	case int8:
+		return intLiteral(int64(t)), nil
+	case int16:
+		return intLiteral(int64(t)), nil
+	case int32:
+		return intLiteral(int64(t)), nil
+	case int64:
+		return intLiteral(t), nil
+	case uint:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint64:
+		return strconv.FormatUint(t, 10), nil
+	case float32:
+		return floatLiteral(float64(t), 32), nil
+	case float64:
+		return floatLiteral(t, 64), nil
+	case string:
+		return stringLiteral(t)
+	case []byte:
+		return `'\x` + hex.EncodeToString(t) + "'::bytea", nil
+	case time.Time:
+		return "'" + t.Format("2006-01-02 15:04:05.999999999Z07:00") + "'::timestamptz", nil
+	case pgx.NullString:
+		return nullLiteral(t.Valid, t.String)
+	case pgx.NullInt16:
+		return nullLiteral(t.Valid, t.Int16)
+	case pgx.NullInt32:
+		return nullLiteral(t.Valid, t.Int32)
+	case pgx.NullInt64:
+		return nullLiteral(t.Valid, t.Int64)
+	case pgx.NullFloat32:
+		return nullLiteral(t.Valid, t.Float32)
+	case pgx.NullFloat64:
+		return nullLiteral(t.Valid, t.Float64)
+	case pgx.NullBool:
+		return nullLiteral(t.Valid, t.Bool)
+	case pgx.NullTime:
+		return nullLiteral(t.Valid, t.Time)
+	case driver.Valuer:
+		value, err := t.Value()
+		if err != nil {
+			return "", err
+		}
+		if _, ok := value.(driver.Valuer); ok {
+			return "", errors.Errorf("%T's value is a driver.Valuer", v)
+		}
+		return sqlLiteral(value)
+	}
+	return "", errors.Errorf("unable to write %T as a SQL literal", v)
+}
+
+func nullLiteral(valid bool, v interface{}) (string, error) {
+	if !valid {
+		return "NULL", nil
+	}
+	return sqlLiteral(v)
+}
+
+func intLiteral(i int64) string {
+	if i < 0 {
+		return "(" + strconv.FormatInt(i, 10) + ")"
+	}
+	return strconv.FormatInt(i, 10)
+}
+
+func floatLiteral(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "'NaN'::float8"
+	case math.IsInf(f, 1):
+		return "'Infinity'::float8"
+	case math.IsInf(f, -1):
+		return "'-Infinity'::float8"
+	case f < 0:
+		return "(" + strconv.FormatFloat(f, 'g', -1, bits) + ")"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+func stringLiteral(s string) (string, error) {
+	if strings.IndexByte(s, 0) >= 0 {
+		return "", errors.New("strings can't contain a NUL byte")
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'", nil
+}
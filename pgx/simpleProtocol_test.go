@@ -0,0 +1,59 @@
+package pgx
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestInterpolate(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+	tests := []struct {
+		query    string
+		args     []interface{}
+		expected string
+	}{
+		{"select 1", nil, "select 1"},
+		{"select * from users where id = $1 and name = $2", []interface{}{1, "it's"}, "select * from users where id = 1 and name = 'it''s'"},
+		{"select 1-$1, $2, $3, $4", []interface{}{-5, -1.5, math.NaN(), nil}, "select 1-(-5), (-1.5), 'NaN'::float8, NULL"},
+		{"select $1, $2, $10", []interface{}{[]byte{1, 255}, created, 0, 0, 0, 0, 0, 0, 0, true}, `select '\x01ff'::bytea, '2024-01-02 03:04:05.0000006Z'::timestamptz, true`},
+		{"select '$1', \"$1\", E'\\'$1', $1 -- $1\n/* $1 */", []interface{}{uint8(7)}, "select '$1', \"$1\", E'\\'$1', 7 -- $1\n/* $1 */"},
+		{"do $$ begin perform $1; end $$; select a$1, $1", []interface{}{pgx.NullString{String: "x", Valid: true}}, "do $$ begin perform $1; end $$; select a$1, 'x'"},
+		{"select $body$ $1 $body$, $1::int", []interface{}{pgx.NullInt64{}}, "select $body$ $1 $body$, NULL::int"},
+		{"select E'\\'', $1", []interface{}{1}, "select E'\\'', 1"},
+		{"select some'\\', $1", []interface{}{1}, "select some'\\', 1"},
+		{"do $a$ $b$ $1 $b$ $1 $a$; select $1", []interface{}{1}, "do $a$ $b$ $1 $b$ $1 $a$; select 1"},
+		{"select $1::int, $2::text[], x::int", []interface{}{-1, "a"}, "select (-1)::int, 'a'::text[], x::int"},
+	}
+	for _, test := range tests {
+		if actual, err := interpolate(test.query, test.args); err != nil || actual != test.expected {
+			t.Errorf("expected %q for %q, got %q %v", test.expected, test.query, actual, err)
+		}
+	}
+
+	if _, err := interpolate("select $2", []interface{}{1}); err == nil {
+		t.Error("expected error for a placeholder without an argument")
+	}
+	if _, err := interpolate("select $1", []interface{}{"a\x00"}); err == nil {
+		t.Error("expected error for a string with a NUL byte")
+	}
+	if _, err := interpolate("select $1", []interface{}{struct{}{}}); err == nil {
+		t.Error("expected error for an argument which can't be a literal")
+	}
+}
+
+func TestSimpleProtocol(t *testing.T) {
+	b := &pgxWithReconnect{simple: true}
+	if _, err := b.Prepare("getUser", "select * from users where id = $1"); err != ErrSimpleProtocolPrepare {
+		t.Error("expected prepared statements to be disabled", err)
+	}
+	if sql, args, err := b.simpleStatement("select $1", []interface{}{1}); sql != "select 1" || args != nil || err != nil {
+		t.Error("expected arguments to be interpolated", sql, args, err)
+	}
+	b.simple = false
+	if sql, args, err := b.simpleStatement("select $1", []interface{}{1}); sql != "select $1" || len(args) != 1 || err != nil {
+		t.Error("expected statement to be unchanged", sql, args, err)
+	}
+}
@@ -0,0 +1,53 @@
+package pgx
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// PoolStats describes the state of the connection pool and the reconnects made by the backend
+type PoolStats struct {
+	MaxConnections      int
+	OpenConnections     int
+	AcquiredConnections int
+	IdleConnections     int
+	WaitCount           int64         // acquires which had to wait for a connection to be released
+	WaitDuration        time.Duration // total time spent waiting for connections
+	ReconnectCount      int64         // pings made to check the database after a dead connection
+	ReconnectFailures   int64         // of ReconnectCount, the pings which failed
+}
+
+// poolCounters are the counters behind PoolStats which pgx doesn't track
+type poolCounters struct {
+	waitCount         int64
+	waitDuration      int64
+	reconnectCount    int64
+	reconnectFailures int64
+}
+
+func (c *poolCounters) wait(duration time.Duration) {
+	atomic.AddInt64(&c.waitCount, 1)
+	atomic.AddInt64(&c.waitDuration, int64(duration))
+}
+
+func (c *poolCounters) reconnect(err error) {
+	atomic.AddInt64(&c.reconnectCount, 1)
+	if err != nil {
+		atomic.AddInt64(&c.reconnectFailures, 1)
+	}
+}
+
+// Stats returns the current state of the connection pool
+func (b *pgxWithReconnect) Stats() PoolStats {
+	stat := b.db.Stat()
+	return PoolStats{
+		MaxConnections:      stat.MaxConnections,
+		OpenConnections:     stat.CurrentConnections,
+		AcquiredConnections: stat.CurrentConnections - stat.AvailableConnections,
+		IdleConnections:     stat.AvailableConnections,
+		WaitCount:           atomic.LoadInt64(&b.counters.waitCount),
+		WaitDuration:        time.Duration(atomic.LoadInt64(&b.counters.waitDuration)),
+		ReconnectCount:      atomic.LoadInt64(&b.counters.reconnectCount),
+		ReconnectFailures:   atomic.LoadInt64(&b.counters.reconnectFailures),
+	}
+}
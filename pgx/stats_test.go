@@ -0,0 +1,26 @@
+package pgx
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPgxStats(t *testing.T) {
+	c := newMockPgx(nil, nil)
+	d := &pgxBackend{db: c}
+	if stats := d.Stats(); stats.MaxConnections != 10 || len(c.MethodsCalled["Stats"]) != 1 {
+		t.Error("expected Stats method to be called on backend", stats)
+	}
+}
+
+func TestPoolCounters(t *testing.T) {
+	c := &poolCounters{}
+	c.wait(time.Second)
+	c.wait(2 * time.Second)
+	c.reconnect(nil)
+	c.reconnect(errors.New("fail"))
+	if c.waitCount != 2 || time.Duration(c.waitDuration) != 3*time.Second || c.reconnectCount != 2 || c.reconnectFailures != 1 {
+		t.Error("expected counters", c)
+	}
+}
@@ -0,0 +1,124 @@
+package pgx
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// stmtCache prepares the statements run with arguments on each connection, keyed by their query text, so hot
+// queries are only parsed and planned once per connection instead of on every run. Once a connection has size
+// statements, the least recently used is deallocated. A nil *stmtCache prepares nothing
+type stmtCache struct {
+	size  int
+	count uint64
+
+	mu    sync.Mutex
+	conns map[*pgx.Conn]*connStmts
+
+	prepare    func(conn *pgx.Conn, name, query string) error
+	deallocate func(conn *pgx.Conn, name string)
+}
+
+// connStmts are the statements prepared on one connection, most recently used first
+type connStmts struct {
+	lru     *list.List
+	byQuery map[string]*list.Element
+}
+
+type cachedStmt struct {
+	query string
+	name  string
+}
+
+func newStmtCache(size int) *stmtCache {
+	if size <= 0 {
+		return nil
+	}
+	return &stmtCache{size: size, conns: make(map[*pgx.Conn]*connStmts), prepare: prepareStmt, deallocate: deallocateStmt}
+}
+
+func prepareStmt(conn *pgx.Conn, name, query string) error {
+	_, err := conn.Prepare(name, query)
+	return err
+}
+
+func deallocateStmt(conn *pgx.Conn, name string) {
+	if conn.IsAlive() {
+		conn.Deallocate(name)
+	}
+}
+
+// statement returns what to run on conn in place of query: the name of its prepared statement, or query itself
+// when it has no arguments, since those are sent with the simple protocol and aren't parsed twice anyway, or
+// when it couldn't be prepared. conn must be held by the caller so nothing else uses it meanwhile
+func (c *stmtCache) statement(conn *pgx.Conn, query string, args []interface{}) string {
+	if c == nil || len(args) == 0 {
+		return query
+	}
+	c.mu.Lock()
+	stmts, ok := c.conns[conn]
+	if !ok {
+		stmts = &connStmts{lru: list.New(), byQuery: make(map[string]*list.Element)}
+		c.conns[conn] = stmts
+	}
+	if e, ok := stmts.byQuery[query]; ok {
+		stmts.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*cachedStmt).name
+	}
+	c.mu.Unlock()
+
+	name := "onedb_stmt_" + strconv.FormatUint(atomic.AddUint64(&c.count, 1), 10)
+	if err := c.prepare(conn, name, query); err != nil {
+		return query // the error is returned when the query is run
+	}
+	c.mu.Lock()
+	stmts.byQuery[query] = stmts.lru.PushFront(&cachedStmt{query: query, name: name})
+	var evicted *cachedStmt
+	if stmts.lru.Len() > c.size {
+		evicted = stmts.lru.Remove(stmts.lru.Back()).(*cachedStmt)
+		delete(stmts.byQuery, evicted.query)
+	}
+	c.mu.Unlock()
+	if evicted != nil {
+		c.deallocate(conn, evicted.name)
+	}
+	return name
+}
+
+// invalidate deallocates query's statement on conn when err shows its plan is out of date, e.g. because a
+// table it uses was altered, so it is prepared again the next time it is run
+func (c *stmtCache) invalidate(conn *pgx.Conn, query string, err error) {
+	if c == nil || err == nil {
+		return
+	}
+	if pgErr, ok := err.(pgx.PgError); !ok || pgErr.Code != "0A000" && pgErr.Code != "26000" {
+		return
+	}
+	c.mu.Lock()
+	stmts, ok := c.conns[conn]
+	var name string
+	if ok {
+		if e, found := stmts.byQuery[query]; found {
+			name = stmts.lru.Remove(e).(*cachedStmt).name
+			delete(stmts.byQuery, query)
+		}
+	}
+	c.mu.Unlock()
+	if name != "" {
+		c.deallocate(conn, name)
+	}
+}
+
+// forget drops the statements of a connection which is being closed. The server deallocates them itself
+func (c *stmtCache) forget(conn *pgx.Conn) {
+	if c != nil {
+		c.mu.Lock()
+		delete(c.conns, conn)
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,58 @@
+package pgx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestStmtCache(t *testing.T) {
+	if c := newStmtCache(0); c != nil || c.statement(&pgx.Conn{}, "select $1", []interface{}{1}) != "select $1" {
+		t.Fatal("expected a disabled cache to run queries as they are")
+	}
+
+	c := newStmtCache(2)
+	var prepared, deallocated []string
+	c.prepare = func(conn *pgx.Conn, name, query string) error {
+		if query == "bad" {
+			return errors.New("fail")
+		}
+		prepared = append(prepared, query)
+		return nil
+	}
+	c.deallocate = func(conn *pgx.Conn, name string) {
+		deallocated = append(deallocated, name)
+	}
+	conn, other := &pgx.Conn{}, &pgx.Conn{}
+	args := []interface{}{1}
+
+	if c.statement(conn, "select 1", nil) != "select 1" || c.statement(conn, "bad", args) != "bad" {
+		t.Error("expected queries without args or which fail to prepare to run as they are")
+	}
+	a := c.statement(conn, "a", args)
+	b := c.statement(conn, "b", args)
+	if a == "a" || a == b || c.statement(conn, "a", args) != a {
+		t.Error("expected each query to be prepared once under its own name", a, b)
+	}
+	c.statement(conn, "c", args)
+	if !reflect.DeepEqual(deallocated, []string{b}) {
+		t.Error("expected the least recently used statement to be deallocated", deallocated)
+	}
+	if c.statement(other, "a", args) == a {
+		t.Error("expected statements to be prepared per connection")
+	}
+
+	c.invalidate(conn, "a", errors.New("fail"))
+	c.invalidate(conn, "a", pgx.PgError{Code: "0A000"})
+	if !reflect.DeepEqual(deallocated, []string{b, a}) || c.statement(conn, "a", args) == a {
+		t.Error("expected an out of date statement to be prepared again", deallocated)
+	}
+
+	c.forget(conn)
+	c.statement(conn, "c", args)
+	if !reflect.DeepEqual(prepared, []string{"a", "b", "c", "a", "a", "c"}) {
+		t.Error("expected a closed connection's statements to be forgotten", prepared)
+	}
+}
@@ -0,0 +1,195 @@
+package pgx
+
+import (
+	"context"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// ErrInvalidTenant occurs when a tenant ID isn't made of lowercase letters, digits and underscores starting with
+// a letter or underscore, or its schema name would be longer than PostgreSQL's 63 byte limit
+var ErrInvalidTenant = errors.New("invalid tenant")
+
+// ErrNoTenant occurs when a TenantBackender's statement is run with a context without a tenant
+var ErrNoTenant = errors.New("no tenant in context")
+
+// maxSchemaLength is the longest identifier PostgreSQL keeps without truncating it
+const maxSchemaLength = 63
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose statements a TenantBackender runs in tenant's schema, e.g. set by the
+// middleware which authenticates a request
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant added to ctx with WithTenant
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// TenantConfig configures a TenantBackender
+type TenantConfig struct {
+	SchemaPrefix  string   // added to the tenant ID to get its schema, e.g. "tenant_"
+	SharedSchemas []string // searched after the tenant's schema, e.g. public for extensions and shared tables
+}
+
+// TenantBackender runs statements for schema-per-tenant databases, with the search_path set to the tenant's
+// schema followed by the shared schemas. The search_path is set with SET LOCAL in a transaction, since pooled
+// connections are used by every tenant, so statements run outside one are each given a transaction of their own.
+// The tenant comes from the context given with WithTenant, or is passed to BeginTenant
+type TenantBackender interface {
+	BeginContext(ctx context.Context) (Txer, error)
+	BeginTenant(tenant string) (Txer, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error)
+	// QueryContext runs query in a transaction which is committed when the rows are closed
+	QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner
+	// Schema returns the schema of tenant, or ErrInvalidTenant
+	Schema(tenant string) (string, error)
+	// DB returns the backend the tenants share, e.g. for migrations which create the schemas
+	DB() PGXer
+}
+
+type tenantBackend struct {
+	db     PGXer
+	config TenantConfig
+}
+
+// NewTenantBackender returns a TenantBackender running statements on db
+func NewTenantBackender(db PGXer, config TenantConfig) TenantBackender {
+	return &tenantBackend{db: db, config: config}
+}
+
+func (b *tenantBackend) DB() PGXer {
+	return b.db
+}
+
+func (b *tenantBackend) Schema(tenant string) (string, error) {
+	if tenant == "" || len(b.config.SchemaPrefix)+len(tenant) > maxSchemaLength {
+		return "", ErrInvalidTenant
+	}
+	for i := 0; i < len(tenant); i++ {
+		switch c := tenant[i]; {
+		case c >= 'a' && c <= 'z' || c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return "", ErrInvalidTenant
+		}
+	}
+	return b.config.SchemaPrefix + tenant, nil
+}
+
+func (b *tenantBackend) BeginContext(ctx context.Context) (Txer, error) {
+	tenant := TenantFromContext(ctx)
+	if tenant == "" {
+		return nil, ErrNoTenant
+	}
+	return b.begin(ctx, tenant)
+}
+
+func (b *tenantBackend) BeginTenant(tenant string) (Txer, error) {
+	return b.begin(context.Background(), tenant)
+}
+
+// begin starts a transaction and sets its search_path to tenant's schema and the shared schemas
+func (b *tenantBackend) begin(ctx context.Context, tenant string) (Txer, error) {
+	schema, err := b.Schema(tenant)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to use tenant %q", tenant)
+	}
+	searchPath := pgx.Identifier{schema}.Sanitize()
+	for _, shared := range b.config.SharedSchemas {
+		searchPath += ", " + pgx.Identifier{shared}.Sanitize()
+	}
+	tx, err := b.db.BeginContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := onedb.SetLocal(tx, "search_path", searchPath); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (b *tenantBackend) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	tx, err := b.BeginContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	tag, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return tag, err
+	}
+	return tag, tx.Commit()
+}
+
+func (b *tenantBackend) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	tx, err := b.BeginContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &tenantRows{RowsScanner: rows, tx: tx}, nil
+}
+
+func (b *tenantBackend) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	rows, err := b.QueryContext(ctx, query, args...)
+	if err != nil {
+		return &errRow{err}
+	}
+	return &tenantRow{rows}
+}
+
+// tenantRows ends the transaction the rows were queried in when they are closed, committing it unless reading
+// them failed
+type tenantRows struct {
+	onedb.RowsScanner
+	tx     Txer
+	closed bool
+}
+
+func (r *tenantRows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	err := r.RowsScanner.Close()
+	if err == nil {
+		err = r.RowsScanner.Err()
+	}
+	if err != nil {
+		r.tx.Rollback()
+		return err
+	}
+	return r.tx.Commit()
+}
+
+// tenantRow scans the first row of rows and closes them
+type tenantRow struct {
+	rows onedb.RowsScanner
+}
+
+func (r *tenantRow) Scan(dest ...interface{}) error {
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	return r.rows.Close()
+}
@@ -0,0 +1,69 @@
+package pgx
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestTenantBackender(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.ExpectBegin()
+	m.ExpectQuery("select set_config($1, $2, true)").WithArgs("search_path", `"tenant_acme", "public"`)
+	m.ExpectExec("update invoices set paid = true")
+	m.ExpectCommit()
+	m.ExpectBegin()
+	m.ExpectQuery("select set_config($1, $2, true)").WithArgs("search_path", `"tenant_acme", "public"`)
+	m.ExpectQuery("select id from invoices").WillReturnRows([]interceptorData{{ID: 7}})
+	m.ExpectCommit()
+	m.ExpectBegin()
+	m.ExpectQuery("select set_config($1, $2, true)").WithArgs("search_path", `"tenant_globex", "public"`)
+	m.ExpectRollback()
+
+	db := NewTenantBackender(m, TenantConfig{SchemaPrefix: "tenant_", SharedSchemas: []string{"public"}})
+	ctx := WithTenant(context.Background(), "acme")
+	if _, err := db.ExecContext(ctx, "update invoices set paid = true"); err != nil {
+		t.Error("expected exec in the tenant's schema", err)
+	}
+	var id int
+	if err := db.QueryRowContext(ctx, "select id from invoices").Scan(&id); err != nil || id != 7 {
+		t.Error("expected row from the tenant's schema", id, err)
+	}
+	tx, err := db.BeginTenant("globex")
+	if err != nil {
+		t.Fatal("expected transaction", err)
+	}
+	tx.Rollback()
+	m.VerifyExpectations(t)
+
+	if _, err := db.ExecContext(context.Background(), "select 1"); err != ErrNoTenant {
+		t.Error("expected ErrNoTenant", err)
+	}
+	if _, err := db.BeginTenant(`acme"; drop schema public; --`); errors.Cause(err) != ErrInvalidTenant {
+		t.Error("expected ErrInvalidTenant", err)
+	}
+}
+
+func TestTenantSchema(t *testing.T) {
+	db := NewTenantBackender(nil, TenantConfig{SchemaPrefix: "t_"})
+	tests := map[string]bool{
+		"acme":                  true,
+		"_internal":             true,
+		"shop_42":               true,
+		"":                      false,
+		"42shop":                false,
+		"Acme":                  false,
+		"ac-me":                 false,
+		"acme.public":           false,
+		strings.Repeat("a", 61): true,
+		strings.Repeat("a", 62): false,
+	}
+	for tenant, valid := range tests {
+		schema, err := db.Schema(tenant)
+		if valid && (err != nil || schema != "t_"+tenant) || !valid && err != ErrInvalidTenant {
+			t.Errorf("expected %q to be valid: %t, got %q %v", tenant, valid, schema, err)
+		}
+	}
+}
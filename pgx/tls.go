@@ -0,0 +1,125 @@
+package pgx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// SSLMode matches the libpq sslmode connection parameter
+type SSLMode string
+
+// SSL modes, from least to most secure
+const (
+	SSLDisable    SSLMode = "disable"     // never use TLS
+	SSLAllow      SSLMode = "allow"       // try without TLS, then with TLS if the server requires it
+	SSLPrefer     SSLMode = "prefer"      // try TLS without verifying the server, then without TLS
+	SSLRequire    SSLMode = "require"     // use TLS without verifying the server
+	SSLVerifyCA   SSLMode = "verify-ca"   // use TLS and verify the server certificate is signed by a trusted CA
+	SSLVerifyFull SSLMode = "verify-full" // use TLS and verify the server certificate and host name
+)
+
+// ErrInvalidSSLMode occurs when an unknown SSL mode is provided.
+var ErrInvalidSSLMode = errors.New("invalid sslmode")
+
+// TLSOptions describe how to secure the connection to the server
+type TLSOptions struct {
+	Mode       SSLMode
+	ServerName string         // host name to verify with verify-full, defaults to the connection host
+	RootCAs    *x509.CertPool // CAs trusted with verify-ca and verify-full, defaults to the system pool
+	RootCAFile string         // PEM file of CAs added to RootCAs
+	CertFile   string         // PEM client certificate, requires KeyFile
+	KeyFile    string         // PEM client key
+}
+
+// ConfigureTLS sets the TLS fields of the connection config from opts
+func (c *ConnConfig) ConfigureTLS(opts TLSOptions) error {
+	c.TLSConfig = nil
+	c.UseFallbackTLS = false
+	c.FallbackTLSConfig = nil
+	if opts.Mode == SSLDisable || opts.Mode == "" && opts.CertFile == "" && opts.RootCAFile == "" && opts.RootCAs == nil {
+		return nil
+	}
+
+	tlsConfig, err := newTLSConfig(c.Host, opts)
+	if err != nil {
+		return err
+	}
+	switch opts.Mode {
+	case SSLAllow:
+		c.UseFallbackTLS = true
+		c.FallbackTLSConfig = tlsConfig
+	case SSLPrefer:
+		c.TLSConfig = tlsConfig
+		c.UseFallbackTLS = true
+	default:
+		c.TLSConfig = tlsConfig
+	}
+	return nil
+}
+
+func newTLSConfig(host string, opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{RootCAs: opts.RootCAs}
+	if opts.RootCAFile != "" {
+		pem, err := ioutil.ReadFile(opts.RootCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read root CA file")
+		}
+		if config.RootCAs == nil {
+			config.RootCAs = x509.NewCertPool()
+		}
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("unable to add root CA file to pool")
+		}
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	switch opts.Mode {
+	case "", SSLVerifyFull:
+		config.ServerName = opts.ServerName
+		if config.ServerName == "" {
+			config.ServerName = host
+		}
+	case SSLVerifyCA:
+		// verify the chain but not the host name, which crypto/tls can only do by skipping its own checks
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = verifyChain(config.RootCAs)
+	case SSLAllow, SSLPrefer, SSLRequire:
+		config.InsecureSkipVerify = true
+	default:
+		return nil, ErrInvalidSSLMode
+	}
+	return config, nil
+}
+
+// verifyChain returns a tls.Config.VerifyPeerCertificate which checks that the server certificate is signed
+// by one of roots, or by the system roots when roots is nil
+func verifyChain(roots *x509.CertPool) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server presented no certificates")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		return err
+	}
+}
@@ -0,0 +1,104 @@
+package pgx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigureTLSModes(t *testing.T) {
+	c := &ConnConfig{Host: "db.example.com"}
+	if err := c.ConfigureTLS(TLSOptions{Mode: SSLDisable}); err != nil || c.TLSConfig != nil || c.UseFallbackTLS {
+		t.Error("expected no TLS", err)
+	}
+
+	if err := c.ConfigureTLS(TLSOptions{Mode: SSLAllow}); err != nil || c.TLSConfig != nil || !c.UseFallbackTLS || c.FallbackTLSConfig == nil {
+		t.Error("expected fallback to TLS", err)
+	}
+
+	if err := c.ConfigureTLS(TLSOptions{Mode: SSLPrefer}); err != nil || c.TLSConfig == nil || !c.UseFallbackTLS || c.FallbackTLSConfig != nil {
+		t.Error("expected TLS with fallback to plain", err)
+	}
+
+	if err := c.ConfigureTLS(TLSOptions{Mode: SSLRequire}); err != nil || c.TLSConfig == nil || !c.TLSConfig.InsecureSkipVerify || c.UseFallbackTLS {
+		t.Error("expected unverified TLS", err)
+	}
+
+	if err := c.ConfigureTLS(TLSOptions{Mode: SSLVerifyCA}); err != nil || !c.TLSConfig.InsecureSkipVerify || c.TLSConfig.VerifyPeerCertificate == nil {
+		t.Error("expected CA verification", err)
+	}
+
+	if err := c.ConfigureTLS(TLSOptions{Mode: SSLVerifyFull}); err != nil || c.TLSConfig.InsecureSkipVerify || c.TLSConfig.ServerName != "db.example.com" {
+		t.Error("expected full verification", err)
+	}
+
+	if err := c.ConfigureTLS(TLSOptions{Mode: "bogus"}); err != ErrInvalidSSLMode {
+		t.Error("expected invalid mode", err)
+	}
+}
+
+func TestConfigureTLSFiles(t *testing.T) {
+	dir := t.TempDir()
+	certPEM, keyPEM, cert := newTestCert(t)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ioutil.WriteFile(certFile, certPEM, 0600)
+	ioutil.WriteFile(keyFile, keyPEM, 0600)
+
+	c := &ConnConfig{Host: "localhost"}
+	err := c.ConfigureTLS(TLSOptions{Mode: SSLVerifyCA, RootCAFile: certFile, CertFile: certFile, KeyFile: keyFile})
+	if err != nil || len(c.TLSConfig.Certificates) != 1 || c.TLSConfig.RootCAs == nil {
+		t.Fatal("expected client certificate and CA", err)
+	}
+	if err := c.TLSConfig.VerifyPeerCertificate([][]byte{cert.Raw}, nil); err != nil {
+		t.Error("expected certificate signed by CA to verify", err)
+	}
+
+	_, _, other := newTestCert(t)
+	if err := c.TLSConfig.VerifyPeerCertificate([][]byte{other.Raw}, nil); err == nil {
+		t.Error("expected untrusted certificate to fail")
+	}
+	if err := c.TLSConfig.VerifyPeerCertificate(nil, nil); err == nil {
+		t.Error("expected missing certificate to fail")
+	}
+
+	if err := c.ConfigureTLS(TLSOptions{Mode: SSLVerifyFull, RootCAFile: filepath.Join(dir, "missing.pem")}); err == nil {
+		t.Error("expected missing CA file error")
+	}
+	if err := c.ConfigureTLS(TLSOptions{Mode: SSLVerifyFull, CertFile: certFile}); err == nil {
+		t.Error("expected missing key error")
+	}
+}
+
+func newTestCert(t *testing.T) ([]byte, []byte, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), cert
+}
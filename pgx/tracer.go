@@ -0,0 +1,18 @@
+package pgx
+
+import (
+	"context"
+)
+
+// Tracer starts a span for each statement run by the backend and its transactions. The span starts as a
+// child of any span in the context passed to the Context methods. See the otel package for an OpenTelemetry
+// implementation
+type Tracer interface {
+	StartSpan(ctx context.Context, operation, query string) (context.Context, Span)
+}
+
+// Span is ended once its statement finishes with the number of rows returned or affected and the error
+// returned, if any. A query finishes when its rows are closed
+type Span interface {
+	End(rows int64, err error)
+}
@@ -0,0 +1,52 @@
+package pgx
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNestedPrepare occurs when PrepareTransaction is called on a transaction begun inside another one
+var ErrNestedPrepare = errors.New("a nested transaction can't be prepared")
+
+// quoteLiteral quotes s as a SQL string literal. PREPARE TRANSACTION and its siblings don't accept parameters
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// PrepareTransaction prepares the transaction for two-phase commit under the global identifier gid. Its changes
+// are kept by the server, even across restarts, until CommitPrepared or RollbackPrepared is called with gid from
+// any connection. The Txer is finished once prepared, and its Status reports it as rolled back since the
+// connection no longer has a transaction. The server's max_prepared_transactions must be greater than zero.
+// Funcs registered with OnCommit and OnRollback are dropped, since the transaction ends with CommitPrepared or
+// RollbackPrepared, possibly in another process
+func (t *pgxTx) PrepareTransaction(gid string) error {
+	t.hooks = txHooks{}
+	return prepareTransaction(t, gid)
+}
+
+func prepareTransaction(tx Txer, gid string) error {
+	_, err := tx.Exec("prepare transaction " + quoteLiteral(gid))
+	// whether or not it was prepared, the session's transaction has ended, so this only returns the connection
+	tx.Rollback()
+	return err
+}
+
+// PrepareTransaction returns ErrNestedPrepare. Only the outermost transaction can be prepared
+func (t *savepointTx) PrepareTransaction(gid string) error {
+	return ErrNestedPrepare
+}
+
+// CommitPrepared commits the transaction prepared with PrepareTransaction under gid. It must not be run in a
+// transaction
+func (b *pgxBackend) CommitPrepared(gid string) error {
+	_, err := b.Exec("commit prepared " + quoteLiteral(gid))
+	return err
+}
+
+// RollbackPrepared rolls back the transaction prepared with PrepareTransaction under gid. It must not be run in a
+// transaction
+func (b *pgxBackend) RollbackPrepared(gid string) error {
+	_, err := b.Exec("rollback prepared " + quoteLiteral(gid))
+	return err
+}
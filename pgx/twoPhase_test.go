@@ -0,0 +1,32 @@
+package pgx
+
+import (
+	"testing"
+)
+
+func TestPrepareTransaction(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.ExpectBegin()
+	m.ExpectExec("insert into ledger (amount) values ($1)").WithArgs(10)
+	m.ExpectExec("prepare transaction 'transfer-o''brien'")
+	m.ExpectRollback() // the connection's session has no transaction left, so this only releases it
+	m.ExpectExec("commit prepared 'transfer-o''brien'")
+	m.ExpectExec("rollback prepared 'other'")
+
+	tx, _ := m.Begin()
+	tx.Exec("insert into ledger (amount) values ($1)", 10)
+	if err := tx.PrepareTransaction("transfer-o'brien"); err != nil || tx.Status() == TxStatusInProgress {
+		t.Error("expected the transaction to be prepared and finished", err, tx.Status())
+	}
+	if err := m.CommitPrepared("transfer-o'brien"); err != nil {
+		t.Error("expected success", err)
+	}
+	m.RollbackPrepared("other")
+	m.VerifyExpectations(t)
+
+	tx, _ = NewMock(nil, nil).Begin()
+	nested, _ := tx.Begin()
+	if err := nested.PrepareTransaction("gid"); err != ErrNestedPrepare {
+		t.Error("expected ErrNestedPrepare", err)
+	}
+}
@@ -0,0 +1,72 @@
+package pgx
+
+// txHooks are the funcs registered with a transaction's OnCommit and OnRollback
+type txHooks struct {
+	commit   []func()
+	rollback []func()
+}
+
+func (h *txHooks) onCommit(f func()) {
+	h.commit = append(h.commit, f)
+}
+
+func (h *txHooks) onRollback(f func()) {
+	h.rollback = append(h.rollback, f)
+}
+
+// run runs the commit or the rollback funcs in the order they were registered and clears both, so each runs at
+// most once
+func (h *txHooks) run(committed bool) {
+	funcs := h.rollback
+	if committed {
+		funcs = h.commit
+	}
+	h.commit, h.rollback = nil, nil
+	for _, f := range funcs {
+		f()
+	}
+}
+
+// moveTo registers the funcs with the parent transaction, for when a savepoint is released and its changes
+// become part of the parent's
+func (h *txHooks) moveTo(parent Txer) {
+	for _, f := range h.commit {
+		parent.OnCommit(f)
+	}
+	for _, f := range h.rollback {
+		parent.OnRollback(f)
+	}
+	h.commit, h.rollback = nil, nil
+}
+
+// OnCommit registers f to run after the transaction commits, e.g. to invalidate a cache or dispatch outbox
+// messages only once the changes are visible. Funcs run in the order they were registered, on the goroutine
+// calling Commit. They don't run if the commit fails, since the transaction is rolled back
+func (t *pgxTx) OnCommit(f func()) {
+	t.hooks.onCommit(f)
+}
+
+// OnRollback registers f to run after the transaction is rolled back, or after a failed commit
+func (t *pgxTx) OnRollback(f func()) {
+	t.hooks.onRollback(f)
+}
+
+// OnCommit registers f to run when the outermost transaction commits. Releasing the savepoint hands f to the
+// parent transaction, and rolling it back drops f
+func (t *savepointTx) OnCommit(f func()) {
+	t.hooks.onCommit(f)
+}
+
+// OnRollback registers f to run when the savepoint is rolled back, or when the parent transaction is rolled back
+// after the savepoint was released
+func (t *savepointTx) OnRollback(f func()) {
+	t.hooks.onRollback(f)
+}
+
+func (t *mockTx) OnCommit(f func()) {
+	t.hooks.onCommit(f)
+}
+
+func (t *mockTx) OnRollback(f func()) {
+	t.hooks.onRollback(f)
+}
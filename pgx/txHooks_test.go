@@ -0,0 +1,99 @@
+package pgx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTxHooks(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.ExpectBegin()
+	m.ExpectCommit()
+	m.ExpectBegin()
+	m.ExpectRollback()
+	m.ExpectBegin()
+	m.ExpectCommit().WillReturnError(errors.New("fail"))
+
+	var ran []string
+	tx, _ := m.Begin()
+	tx.OnCommit(func() { ran = append(ran, "commit 1") })
+	tx.OnCommit(func() { ran = append(ran, "commit 2") })
+	tx.OnRollback(func() { ran = append(ran, "rollback") })
+	tx.Commit()
+	tx.Rollback()
+	if !reflect.DeepEqual(ran, []string{"commit 1", "commit 2"}) {
+		t.Error("expected the commit funcs to run once in order", ran)
+	}
+
+	ran = nil
+	tx, _ = m.Begin()
+	tx.OnCommit(func() { ran = append(ran, "commit") })
+	tx.OnRollback(func() { ran = append(ran, "rollback") })
+	tx.Rollback()
+	if !reflect.DeepEqual(ran, []string{"rollback"}) {
+		t.Error("expected the rollback func to run", ran)
+	}
+
+	ran = nil
+	tx, _ = m.Begin()
+	tx.OnCommit(func() { ran = append(ran, "commit") })
+	tx.OnRollback(func() { ran = append(ran, "rollback") })
+	if err := tx.Commit(); err == nil {
+		t.Error("expected commit error")
+	}
+	if !reflect.DeepEqual(ran, []string{"rollback"}) {
+		t.Error("expected the rollback func to run after a failed commit", ran)
+	}
+	m.VerifyExpectations(t)
+}
+
+func TestSavepointTxHooks(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.ExpectBegin()
+	m.ExpectExec(`savepoint "onedb_sp_1"`)
+	m.ExpectExec(`release savepoint "onedb_sp_1"`)
+	m.ExpectExec(`savepoint "onedb_sp_1"`)
+	m.ExpectExec(`rollback to savepoint "onedb_sp_1"`)
+	m.ExpectExec(`release savepoint "onedb_sp_1"`)
+	m.ExpectCommit()
+
+	var ran []string
+	tx, _ := m.Begin()
+	released, _ := tx.Begin()
+	released.OnCommit(func() { ran = append(ran, "released commit") })
+	released.OnRollback(func() { ran = append(ran, "released rollback") })
+	released.Commit()
+	if len(ran) != 0 {
+		t.Error("expected nothing to run until the outer transaction ends", ran)
+	}
+
+	rolledBack, _ := tx.Begin()
+	rolledBack.OnCommit(func() { ran = append(ran, "rolled back commit") })
+	rolledBack.OnRollback(func() { ran = append(ran, "rolled back rollback") })
+	rolledBack.Rollback()
+	if !reflect.DeepEqual(ran, []string{"rolled back rollback"}) {
+		t.Error("expected the rollback func to run with the savepoint's rollback", ran)
+	}
+
+	tx.Commit()
+	if !reflect.DeepEqual(ran, []string{"rolled back rollback", "released commit"}) {
+		t.Error("expected the released savepoint's commit func to run with the outer commit", ran)
+	}
+	m.VerifyExpectations(t)
+}
+
+func TestPrepareTransactionDropsHooks(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.ExpectBegin()
+	m.ExpectExec("prepare transaction 'gid'")
+	m.ExpectRollback()
+
+	tx, _ := m.Begin()
+	tx.OnCommit(func() { t.Error("expected commit func to be dropped") })
+	tx.OnRollback(func() { t.Error("expected rollback func to be dropped") })
+	if err := tx.PrepareTransaction("gid"); err != nil {
+		t.Error("expected success", err)
+	}
+	m.VerifyExpectations(t)
+}
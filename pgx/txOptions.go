@@ -0,0 +1,78 @@
+package pgx
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// TxIsoLevel is the transaction isolation level
+type TxIsoLevel string
+
+// Transaction isolation levels
+const (
+	Serializable    TxIsoLevel = pgx.Serializable
+	RepeatableRead  TxIsoLevel = pgx.RepeatableRead
+	ReadCommitted   TxIsoLevel = pgx.ReadCommitted
+	ReadUncommitted TxIsoLevel = pgx.ReadUncommitted
+)
+
+// TxAccessMode is the transaction access mode (read write or read only)
+type TxAccessMode string
+
+// Transaction access modes
+const (
+	ReadWrite TxAccessMode = "read write"
+	ReadOnly  TxAccessMode = "read only"
+)
+
+// TxDeferrableMode is the transaction deferrable mode. It only has an effect on serializable read only transactions
+type TxDeferrableMode string
+
+// Transaction deferrable modes
+const (
+	Deferrable    TxDeferrableMode = "deferrable"
+	NotDeferrable TxDeferrableMode = "not deferrable"
+)
+
+// TxOptions are the options used to start a transaction with BeginTx. Empty values use the server defaults
+type TxOptions struct {
+	IsoLevel       TxIsoLevel
+	AccessMode     TxAccessMode
+	DeferrableMode TxDeferrableMode
+}
+
+// ErrInvalidTxOptions occurs when an unknown isolation level, access mode or deferrable mode is provided.
+var ErrInvalidTxOptions = errors.New("invalid transaction options")
+
+func (o TxOptions) validate() error {
+	switch o.IsoLevel {
+	case "", Serializable, RepeatableRead, ReadCommitted, ReadUncommitted:
+	default:
+		return ErrInvalidTxOptions
+	}
+	switch o.AccessMode {
+	case "", ReadWrite, ReadOnly:
+	default:
+		return ErrInvalidTxOptions
+	}
+	switch o.DeferrableMode {
+	case "", Deferrable, NotDeferrable:
+	default:
+		return ErrInvalidTxOptions
+	}
+	return nil
+}
+
+// modes returns the access and deferrable modes for use in SET TRANSACTION
+func (o TxOptions) modes() string {
+	modes := []string{}
+	if o.AccessMode != "" {
+		modes = append(modes, string(o.AccessMode))
+	}
+	if o.DeferrableMode != "" {
+		modes = append(modes, string(o.DeferrableMode))
+	}
+	return strings.Join(modes, " ")
+}
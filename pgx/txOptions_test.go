@@ -0,0 +1,55 @@
+package pgx
+
+import (
+	"testing"
+)
+
+func TestTxOptionsValidate(t *testing.T) {
+	valid := []TxOptions{
+		{},
+		{IsoLevel: Serializable, AccessMode: ReadOnly, DeferrableMode: Deferrable},
+		{IsoLevel: ReadCommitted, AccessMode: ReadWrite, DeferrableMode: NotDeferrable},
+	}
+	for _, opts := range valid {
+		if err := opts.validate(); err != nil {
+			t.Error("expected valid options", opts, err)
+		}
+	}
+
+	invalid := []TxOptions{
+		{IsoLevel: "serializable; drop table users"},
+		{AccessMode: "write only"},
+		{DeferrableMode: "later"},
+	}
+	for _, opts := range invalid {
+		if err := opts.validate(); err != ErrInvalidTxOptions {
+			t.Error("expected invalid options", opts, err)
+		}
+	}
+}
+
+func TestTxOptionsModes(t *testing.T) {
+	if modes := (TxOptions{IsoLevel: Serializable}).modes(); modes != "" {
+		t.Error("expected no modes", modes)
+	}
+	if modes := (TxOptions{AccessMode: ReadOnly, DeferrableMode: Deferrable}).modes(); modes != "read only deferrable" {
+		t.Error("expected read only deferrable", modes)
+	}
+}
+
+func TestPgxBeginTx(t *testing.T) {
+	c := newMockPgx(nil, nil)
+	d := &pgxBackend{db: c}
+
+	opts := TxOptions{IsoLevel: RepeatableRead, AccessMode: ReadOnly}
+	d.BeginTx(opts)
+	queries := c.MethodsCalled["BeginTx"]
+	if len(c.MethodsCalled) != 1 || len(queries) != 1 {
+		t.Fatal("expected BeginTx method to be called on backend")
+	}
+	verifyArgs(t, queries[0], opts)
+
+	if _, err := (&pgxWithReconnect{}).BeginTx(TxOptions{IsoLevel: "bogus"}); err != ErrInvalidTxOptions {
+		t.Error("expected invalid options error", err)
+	}
+}
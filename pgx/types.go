@@ -0,0 +1,207 @@
+package pgx
+
+import (
+	"database/sql"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// TypeCodec converts the values of a custom Postgres type, such as a composite, an enum or an extension type like
+// citext or ltree, to and from the text format the server sends them in
+type TypeCodec struct {
+	// Decode parses a value received from the server. It should return a value of the Go type the codec is
+	// registered with so Scan can store it
+	Decode func(text string) (interface{}, error)
+
+	// Encode formats a query argument of the registered Go type. It can be nil when values are only read
+	Encode func(value interface{}) (string, error)
+}
+
+type registeredType struct {
+	name   string
+	goType reflect.Type
+	codec  TypeCodec
+}
+
+var types = struct {
+	sync.RWMutex
+	byName   map[string]*registeredType
+	byGoType map[reflect.Type]*registeredType
+}{byName: make(map[string]*registeredType), byGoType: make(map[reflect.Type]*registeredType)}
+
+// RegisterType registers codec for the Postgres type named typeName, so its values are returned by Values and
+// Scan as the Go type of goValue instead of as strings, and arguments of that Go type are sent as the Postgres
+// type. Types should be registered before connecting, since each connection looks up the types it doesn't know
+// when it is opened. The server describes columns of a domain by its base type, so they are decoded by the base
+// type's codec
+func RegisterType(typeName string, goValue interface{}, codec TypeCodec) {
+	t := &registeredType{name: typeName, goType: reflect.TypeOf(goValue), codec: codec}
+	types.Lock()
+	defer types.Unlock()
+	types.byName[typeName] = t
+	if t.goType != nil && codec.Encode != nil {
+		types.byGoType[t.goType] = t
+	}
+}
+
+func registeredByName(name string) *registeredType {
+	if name == "" {
+		return nil
+	}
+	types.RLock()
+	defer types.RUnlock()
+	return types.byName[name]
+}
+
+// loadTypes adds the registered types conn doesn't know, like composites and enums, to its types so the columns
+// of those types are described by name
+func loadTypes(conn *pgx.Conn) error {
+	known := make(map[string]bool, len(conn.PgTypes))
+	for _, t := range conn.PgTypes {
+		known[t.Name] = true
+	}
+	var missing []string
+	types.RLock()
+	for name := range types.byName {
+		if !known[name] {
+			missing = append(missing, name)
+		}
+	}
+	types.RUnlock()
+	if len(missing) == 0 {
+		return nil
+	}
+	rows, err := conn.Query("select oid, typname::text from pg_type where typname::text = any($1::text[])", missing)
+	if err != nil {
+		return errors.Wrap(err, "Unable to look up registered types")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var oid pgx.Oid
+		var name string
+		if err := rows.Scan(&oid, &name); err != nil {
+			return err
+		}
+		conn.PgTypes[oid] = pgx.PgType{Name: name, DefaultFormat: pgx.TextFormatCode}
+	}
+	return rows.Err()
+}
+
+func registeredByGoType(value interface{}) (*registeredType, bool) {
+	types.RLock()
+	defer types.RUnlock()
+	t, ok := types.byGoType[reflect.TypeOf(value)]
+	return t, ok
+}
+
+// encodeArgs formats the arguments whose Go type has a registered codec, and the slices pgx can't encode as
+// array literals, leaving args untouched if there are none
+func encodeArgs(args []interface{}) ([]interface{}, error) {
+	var encoded []interface{}
+	for i, arg := range args {
+		var text string
+		if t, ok := registeredByGoType(arg); ok {
+			var err error
+			if text, err = t.codec.Encode(arg); err != nil {
+				return nil, errors.Wrapf(err, "Unable to encode argument %d as %s", i+1, t.name)
+			}
+		} else if array, ok, err := arrayArg(arg); err != nil {
+			return nil, errors.Wrapf(err, "Unable to encode argument %d as an array", i+1)
+		} else if ok {
+			text = array
+		} else {
+			continue
+		}
+		if encoded == nil {
+			encoded = append([]interface{}(nil), args...)
+		}
+		encoded[i] = text
+	}
+	if encoded == nil {
+		return args, nil
+	}
+	return encoded, nil
+}
+
+// decodeValues decodes in place the values returned by (*pgx.Rows).Values whose type has a registered codec.
+// pgx returns the values of types it doesn't know as strings
+func decodeValues(fields []pgx.FieldDescription, values []interface{}) error {
+	for i := 0; i < len(fields) && i < len(values); i++ {
+		field := fields[i]
+		t := registeredByName(field.DataTypeName)
+		if t == nil {
+			continue
+		}
+		text, ok := values[i].(string)
+		if !ok {
+			continue
+		}
+		v, err := t.codec.Decode(text)
+		if err != nil {
+			return errors.Wrapf(err, "Unable to decode column %s as %s", field.Name, t.name)
+		}
+		values[i] = v
+	}
+	return nil
+}
+
+// codecDest wraps the Scan destinations of columns whose type has a registered codec, unless they scan
+// themselves
+func codecDest(fields []pgx.FieldDescription, dest []interface{}) []interface{} {
+	if len(fields) != len(dest) {
+		return dest // Scan reports the mismatch
+	}
+	var wrapped []interface{}
+	for i, field := range fields {
+		t := registeredByName(field.DataTypeName)
+		if t == nil {
+			continue
+		}
+		switch dest[i].(type) {
+		case nil, pgx.Scanner, pgx.PgxScanner, sql.Scanner, *[]byte:
+			continue
+		}
+		if wrapped == nil {
+			wrapped = append([]interface{}(nil), dest...)
+		}
+		wrapped[i] = &codecScanner{t: t, dest: dest[i]}
+	}
+	if wrapped == nil {
+		return dest
+	}
+	return wrapped
+}
+
+// codecScanner decodes a value with a codec and stores it in dest, which must point to the decoded value's type
+// or to an interface it implements. NULL stores the zero value
+type codecScanner struct {
+	t    *registeredType
+	dest interface{}
+}
+
+func (s *codecScanner) Scan(vr *pgx.ValueReader) error {
+	dest := reflect.ValueOf(s.dest)
+	if dest.Kind() != reflect.Ptr || dest.IsNil() {
+		return errors.Errorf("Scan destination for %s must be a non-nil pointer, not %T", s.t.name, s.dest)
+	}
+	dest = dest.Elem()
+	if vr.Len() == -1 {
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+	v, err := s.t.codec.Decode(vr.ReadString(vr.Len()))
+	if err != nil {
+		return err
+	}
+	value := reflect.ValueOf(v)
+	if v == nil {
+		value = reflect.Zero(dest.Type())
+	} else if !value.Type().AssignableTo(dest.Type()) {
+		return errors.Errorf("Unable to store %s value of type %T in %s", s.t.name, v, dest.Type())
+	}
+	dest.Set(value)
+	return nil
+}
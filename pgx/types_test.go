@@ -0,0 +1,80 @@
+package pgx
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+type ltree []string
+
+var ltreeCodec = TypeCodec{
+	Decode: func(text string) (interface{}, error) {
+		if text == "" {
+			return nil, errors.New("empty path")
+		}
+		return ltree(strings.Split(text, ".")), nil
+	},
+	Encode: func(value interface{}) (string, error) {
+		return strings.Join(value.(ltree), "."), nil
+	},
+}
+
+func registerLtree(t *testing.T) {
+	RegisterType("ltree", ltree(nil), ltreeCodec)
+	t.Cleanup(func() {
+		types.Lock()
+		delete(types.byName, "ltree")
+		delete(types.byGoType, reflect.TypeOf(ltree(nil)))
+		types.Unlock()
+	})
+}
+
+func TestRegisterType(t *testing.T) {
+	args := []interface{}{"a.b", 1}
+	if encoded, err := encodeArgs(args); err != nil || &encoded[0] != &args[0] {
+		t.Error("expected args untouched without registered types", encoded, err)
+	}
+	registerLtree(t)
+	args = []interface{}{ltree{"a", "b"}, 1}
+
+	encoded, err := encodeArgs(args)
+	if err != nil || !reflect.DeepEqual(encoded, []interface{}{"a.b", 1}) || !reflect.DeepEqual(args[0], ltree{"a", "b"}) {
+		t.Error("expected registered arguments encoded into a copy", encoded, err)
+	}
+
+	fields := []pgx.FieldDescription{{Name: "path", DataTypeName: "ltree"}, {Name: "name", DataTypeName: "text"}}
+	values := []interface{}{"top.science", "astronomy"}
+	if err := decodeValues(fields, values); err != nil || !reflect.DeepEqual(values, []interface{}{ltree{"top", "science"}, "astronomy"}) {
+		t.Error("expected registered columns decoded", values, err)
+	}
+	values = []interface{}{nil, nil}
+	if err := decodeValues(fields, values); err != nil || values[0] != nil {
+		t.Error("expected NULL left as nil", values, err)
+	}
+	if err := decodeValues(fields, []interface{}{"", "x"}); err == nil || !strings.Contains(err.Error(), "column path") {
+		t.Error("expected decode error naming the column", err)
+	}
+
+	var path ltree
+	var name string
+	var raw []byte
+	dest := codecDest(fields, []interface{}{&path, &name})
+	if s, ok := dest[0].(*codecScanner); !ok || s.dest != &path || dest[1] != &name {
+		t.Error("expected only the registered column's destination wrapped", dest)
+	}
+	if dest := codecDest(fields, []interface{}{&raw, &name}); dest[0] != &raw {
+		t.Error("expected raw bytes to be left to pgx", dest)
+	}
+}
+
+func TestLoadTypesNoneMissing(t *testing.T) {
+	registerLtree(t)
+	conn := &pgx.Conn{PgTypes: map[pgx.Oid]pgx.PgType{16400: {Name: "ltree"}}}
+	if err := loadTypes(conn); err != nil {
+		t.Error("expected no lookup when every registered type is known", err)
+	}
+}
@@ -0,0 +1,33 @@
+package pgx
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// decodeUUIDs parses in place the uuid values pgx returns as text into uuid.UUIDs, including the elements of
+// uuid arrays. uuid.UUID is also accepted as a query argument
+func decodeUUIDs(fields []pgx.FieldDescription, values []interface{}) error {
+	for i := 0; i < len(fields) && i < len(values); i++ {
+		var err error
+		switch fields[i].DataTypeName {
+		case "uuid":
+			if text, ok := values[i].(string); ok {
+				values[i], err = uuid.Parse(text)
+			}
+		case "_uuid":
+			if elements, ok := values[i].([]interface{}); ok {
+				for e, element := range elements {
+					if text, ok := element.(string); ok && err == nil {
+						elements[e], err = uuid.Parse(text)
+					}
+				}
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Unable to parse uuid column %s", fields[i].Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package pgx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestPgxRowsUUID(t *testing.T) {
+	id := uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	m := newMockPgxRows()
+	m.Fields = []pgx.FieldDescription{{Name: "id", DataTypeName: "uuid"}, {Name: "others", DataTypeName: "_uuid"}}
+	m.ValuesData = []interface{}{id.String(), "{" + id.String() + ",NULL}"}
+	r := &pgxRows{rows: m}
+	values, err := r.Values()
+	if err != nil || values[0] != id || !reflect.DeepEqual(values[1], []interface{}{id, nil}) {
+		t.Error("expected uuid columns and elements parsed", values, err)
+	}
+
+	m.ValuesData = []interface{}{id.String(), "{" + id.String() + "}"}
+	var scanned uuid.UUID
+	var others []uuid.UUID
+	if err := r.Scan(&scanned, &others); err != nil || scanned != id || !reflect.DeepEqual(others, []uuid.UUID{id}) {
+		t.Error("expected uuid columns scanned into uuid.UUIDs", scanned, others, err)
+	}
+	var text string
+	if err := r.Scan(&text, &others); err != nil || text != id.String() {
+		t.Error("expected uuid scanned into a string", text, err)
+	}
+
+	m.ValuesData = []interface{}{"nope", nil}
+	if _, err := r.Values(); err == nil {
+		t.Error("expected error for an invalid uuid")
+	}
+
+	if encoded, err := encodeArgs([]interface{}{[]uuid.UUID{id}}); err != nil || encoded[0] != `{"`+id.String()+`"}` {
+		t.Error("expected uuid slices sent as array literals", encoded, err)
+	}
+}
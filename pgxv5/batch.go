@@ -0,0 +1,77 @@
+package pgxv5
+
+import (
+	"context"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Batch queues statements to be sent to the server together by SendBatch. It is reexported from pgx
+type Batch = pgx.Batch
+
+// BatchResults reads the results of a Batch in the order the statements were queued. Each result must be read
+// with the method matching its statement, and Close must be called before the connection is used again
+type BatchResults interface {
+	Exec() (CommandTag, error)
+	Query() (onedb.RowsScanner, error)
+	QueryRow() onedb.Scanner
+	Close() error
+}
+
+// SendBatch sends every statement queued in batch in a single round trip, pipelining them on one connection.
+// Outside a transaction the statements run in an implicit transaction, so they all succeed or none do
+func (q querier) SendBatch(batch *Batch) BatchResults {
+	return q.SendBatchContext(context.Background(), batch)
+}
+
+func (q querier) SendBatchContext(ctx context.Context, batch *Batch) BatchResults {
+	return batchResults{q.db.SendBatch(ctx, batch)}
+}
+
+// batchResults adapts pgx.BatchResults to BatchResults
+type batchResults struct {
+	results pgx.BatchResults
+}
+
+func (r batchResults) Exec() (CommandTag, error) {
+	return r.results.Exec()
+}
+
+func (r batchResults) Query() (onedb.RowsScanner, error) {
+	rows, err := r.results.Query()
+	if err != nil {
+		return nil, err
+	}
+	return &pgxRows{rows}, nil
+}
+
+func (r batchResults) QueryRow() onedb.Scanner {
+	return r.results.QueryRow()
+}
+
+func (r batchResults) Close() error {
+	return r.results.Close()
+}
+
+// errBatchResults is a pgx.BatchResults which returns err for every result
+type errBatchResults struct {
+	err error
+}
+
+func (r errBatchResults) Exec() (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, r.err
+}
+
+func (r errBatchResults) Query() (pgx.Rows, error) {
+	return nil, r.err
+}
+
+func (r errBatchResults) QueryRow() pgx.Row {
+	return errRow{r.err}
+}
+
+func (r errBatchResults) Close() error {
+	return r.err
+}
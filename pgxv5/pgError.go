@@ -0,0 +1,29 @@
+package pgxv5
+
+import (
+	"github.com/EndFirstCorp/onedb"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func init() {
+	onedb.RegisterPgErrorConverter(toPgError)
+}
+
+// toPgError converts pgconn's errors for onedb.AsPgError
+func toPgError(err error) (*onedb.PgError, bool) {
+	pgErr, ok := err.(*pgconn.PgError)
+	if !ok {
+		return nil, false
+	}
+	return &onedb.PgError{
+		Severity:       pgErr.Severity,
+		Code:           pgErr.Code,
+		Message:        pgErr.Message,
+		Detail:         pgErr.Detail,
+		Hint:           pgErr.Hint,
+		SchemaName:     pgErr.SchemaName,
+		TableName:      pgErr.TableName,
+		ColumnName:     pgErr.ColumnName,
+		ConstraintName: pgErr.ConstraintName,
+	}, true
+}
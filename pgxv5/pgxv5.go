@@ -0,0 +1,383 @@
+package pgxv5
+
+import (
+	"context"
+	"io"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// CommandTag is the status text returned by PostgreSQL for a query. It is reexported from pgx
+type CommandTag = pgconn.CommandTag
+
+// ErrNoRows occurs when rows are expected but none are returned.
+var ErrNoRows = pgx.ErrNoRows
+
+// ErrTxClosed is the error pgx returns when Commit or Rollback is called on a transaction which has already been
+// closed. Txer checks its state first and returns ErrTxDone instead
+var ErrTxClosed = pgx.ErrTxClosed
+
+// ErrTxDone occurs when a Txer is committed, rolled back or used after it has already been committed or rolled
+// back. It is the same error the onedb mocks return
+var ErrTxDone = onedb.ErrTxDone
+
+// Querier is the set of statements which can be run against either the pool or a transaction
+type Querier interface {
+	Exec(query string, args ...interface{}) (CommandTag, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error)
+	Query(query string, args ...interface{}) (onedb.RowsScanner, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error)
+	QueryRow(query string, args ...interface{}) onedb.Scanner
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner
+	CopyTo(w io.Writer, query string, format CopyFormat) (int64, error)
+	CopyToContext(ctx context.Context, w io.Writer, query string, format CopyFormat) (int64, error)
+	SendBatch(batch *Batch) BatchResults
+	SendBatchContext(ctx context.Context, batch *Batch) BatchResults
+	onedb.DBer
+}
+
+// CopyFormat is the format CopyTo writes rows in
+type CopyFormat int
+
+const (
+	// CopyCSV writes CSV with a header row of the column names
+	CopyCSV CopyFormat = iota
+	// CopyBinary writes PostgreSQL's binary COPY format, which is the fastest to produce and to load with COPY FROM
+	CopyBinary
+	// CopyText writes PostgreSQL's tab delimited text format
+	CopyText
+)
+
+// PGXer is the interface containing the capability available for a pgx v5 database
+type PGXer interface {
+	Begin() (Txer, error)
+	BeginContext(ctx context.Context) (Txer, error)
+	Close()
+	Pool() *pgxpool.Pool
+	onedb.Pinger
+	Querier
+}
+
+// Txer is a transaction. Begin starts a nested transaction using a savepoint
+type Txer interface {
+	Begin() (Txer, error)
+	Commit() error
+	Rollback() error
+	Querier
+}
+
+// dbQuerier is the part of pgxpool.Pool and pgx.Tx used to run statements
+type dbQuerier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+type pgxBackend struct {
+	pool *pgxpool.Pool
+	querier
+}
+
+// NewPgxFromURI returns a PGXer from a postgres:// connection URI or libpq keyword/value DSN. Pool settings
+// such as pool_max_conns are read from the connection string
+func NewPgxFromURI(uri string) (PGXer, error) {
+	config, err := pgxpool.ParseConfig(uri)
+	if err != nil {
+		return nil, err
+	}
+	return NewPgxWithConfig(config)
+}
+
+// NewPgx returns a PGXer from a set of parameters
+func NewPgx(server string, port uint16, username string, password string, database string) (PGXer, error) {
+	config, err := pgxpool.ParseConfig("")
+	if err != nil {
+		return nil, err
+	}
+	config.ConnConfig.Host = server
+	config.ConnConfig.Port = port
+	config.ConnConfig.User = username
+	config.ConnConfig.Password = password
+	config.ConnConfig.Database = database
+	return NewPgxWithConfig(config)
+}
+
+// NewPgxWithConfig returns a PGXer using a pgxpool configuration. The pool is pinged to verify the connection
+func NewPgxWithConfig(config *pgxpool.Config) (PGXer, error) {
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(context.Background()); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return newBackend(pool), nil
+}
+
+func newBackend(pool *pgxpool.Pool) *pgxBackend {
+	return &pgxBackend{pool: pool, querier: querier{pool}}
+}
+
+func (b *pgxBackend) Begin() (Txer, error) {
+	return b.BeginContext(context.Background())
+}
+
+func (b *pgxBackend) BeginContext(ctx context.Context) (Txer, error) {
+	return b.querier.begin(ctx)
+}
+
+func (b *pgxBackend) Close() {
+	b.pool.Close()
+}
+
+// Ping verifies that a connection can be acquired from the pool and is alive
+func (b *pgxBackend) Ping() error {
+	return b.PingContext(context.Background())
+}
+
+func (b *pgxBackend) PingContext(ctx context.Context) error {
+	return b.pool.Ping(ctx)
+}
+
+// Pool returns the underlying pgxpool.Pool for features onedb doesn't wrap
+func (b *pgxBackend) Pool() *pgxpool.Pool {
+	return b.pool
+}
+
+type pgxTx struct {
+	tx   pgx.Tx
+	done bool
+	querier
+}
+
+func newTx(tx pgx.Tx) *pgxTx {
+	t := &pgxTx{tx: tx}
+	t.querier = querier{txQuerier{t}}
+	return t
+}
+
+// Begin starts a transaction nested inside this one using a savepoint. Committing the nested transaction
+// keeps its changes as part of this one and rolling it back undoes only its own changes
+func (t *pgxTx) Begin() (Txer, error) {
+	return t.querier.begin(context.Background())
+}
+
+// Commit commits the transaction. It returns ErrTxDone if the transaction has already been committed or rolled back
+func (t *pgxTx) Commit() error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true // pgx closes the transaction even when the commit fails
+	return t.tx.Commit(context.Background())
+}
+
+// Rollback rolls back the transaction. It returns ErrTxDone if the transaction has already been committed or
+// rolled back, so a deferred Rollback after Commit is harmless
+func (t *pgxTx) Rollback() error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+	return t.tx.Rollback(context.Background())
+}
+
+// txQuerier runs statements on a transaction, returning ErrTxDone once it has been committed or rolled back
+type txQuerier struct {
+	t *pgxTx
+}
+
+func (q txQuerier) Begin(ctx context.Context) (pgx.Tx, error) {
+	if q.t.done {
+		return nil, ErrTxDone
+	}
+	return q.t.tx.Begin(ctx)
+}
+
+func (q txQuerier) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if q.t.done {
+		return pgconn.CommandTag{}, ErrTxDone
+	}
+	return q.t.tx.Exec(ctx, sql, args...)
+}
+
+func (q txQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	if q.t.done {
+		return nil, ErrTxDone
+	}
+	return q.t.tx.Query(ctx, sql, args...)
+}
+
+func (q txQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if q.t.done {
+		return errRow{ErrTxDone}
+	}
+	return q.t.tx.QueryRow(ctx, sql, args...)
+}
+
+func (q txQuerier) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	if q.t.done {
+		return errBatchResults{ErrTxDone}
+	}
+	return q.t.tx.SendBatch(ctx, b)
+}
+
+// CopyTo runs COPY on the transaction's connection
+func (q txQuerier) CopyTo(ctx context.Context, w io.Writer, sql string) (pgconn.CommandTag, error) {
+	if q.t.done {
+		return pgconn.CommandTag{}, ErrTxDone
+	}
+	return q.t.tx.Conn().PgConn().CopyTo(ctx, w, sql)
+}
+
+// errRow is a pgx.Row which returns err from Scan
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
+
+// querier runs statements for both the pool and transactions
+type querier struct {
+	db dbQuerier
+}
+
+func (q querier) begin(ctx context.Context) (Txer, error) {
+	tx, err := q.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newTx(tx), nil
+}
+
+func (q querier) Exec(query string, args ...interface{}) (CommandTag, error) {
+	return q.ExecContext(context.Background(), query, args...)
+}
+
+func (q querier) ExecContext(ctx context.Context, query string, args ...interface{}) (CommandTag, error) {
+	return q.db.Exec(ctx, query, args...)
+}
+
+func (q querier) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return q.QueryContext(context.Background(), query, args...)
+}
+
+func (q querier) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &pgxRows{rows}, nil
+}
+
+func (q querier) QueryRow(query string, args ...interface{}) onedb.Scanner {
+	return q.QueryRowContext(context.Background(), query, args...)
+}
+
+func (q querier) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	return q.db.QueryRow(ctx, query, args...)
+}
+
+func (q querier) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValues(q, query, result...)
+}
+
+func (q querier) QueryJSON(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSON(q, query, args...)
+}
+
+func (q querier) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRow(q, query, args...)
+}
+
+func (q querier) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, q, query, args...)
+}
+
+func (q querier) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStruct(q, result, query, args...)
+}
+
+func (q querier) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRow(q, result, query, args...)
+}
+
+func (q querier) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSV(w, options, q, query, args...)
+}
+
+// CopyTo exports the results of query to w with COPY TO STDOUT, which is much faster than scanning rows. COPY
+// doesn't take parameters, so query must not be built from untrusted input. It returns the number of rows written
+func (q querier) CopyTo(w io.Writer, query string, format CopyFormat) (int64, error) {
+	return q.CopyToContext(context.Background(), w, query, format)
+}
+
+func (q querier) CopyToContext(ctx context.Context, w io.Writer, query string, format CopyFormat) (int64, error) {
+	var options string
+	switch format {
+	case CopyCSV:
+		options = "FORMAT csv, HEADER true"
+	case CopyBinary:
+		options = "FORMAT binary"
+	case CopyText:
+		options = "FORMAT text"
+	default:
+		return 0, errors.Errorf("unknown copy format %d", format)
+	}
+	conn, release, err := q.copyConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	tag, err := conn.CopyTo(ctx, w, "COPY ("+query+") TO STDOUT WITH ("+options+")")
+	return tag.RowsAffected(), err
+}
+
+// copyToer is the part of pgconn.PgConn used by CopyTo
+type copyToer interface {
+	CopyTo(ctx context.Context, w io.Writer, sql string) (pgconn.CommandTag, error)
+}
+
+// copyConn returns the connection to run COPY on, acquiring one from the pool if needed, and a func to release it
+func (q querier) copyConn(ctx context.Context) (copyToer, func(), error) {
+	switch db := q.db.(type) {
+	case copyToer:
+		return db, func() {}, nil
+	case *pgxpool.Pool:
+		conn, err := db.Acquire(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return conn.Conn().PgConn(), conn.Release, nil
+	case interface{ Conn() *pgx.Conn }:
+		return db.Conn().PgConn(), func() {}, nil
+	}
+	return nil, nil, errors.New("COPY isn't supported by this connection")
+}
+
+// pgxRows adapts pgx.Rows to onedb.RowsScanner
+type pgxRows struct {
+	pgx.Rows
+}
+
+func (r *pgxRows) Close() error {
+	r.Rows.Close()
+	return r.Rows.Err()
+}
+
+func (r *pgxRows) Columns() ([]string, error) {
+	fields := r.Rows.FieldDescriptions()
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.Name
+	}
+	return columns, nil
+}
@@ -0,0 +1,369 @@
+package pgxv5
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestNewPgxRealConnection(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	db, err := NewPgx("localhost", 5432, "postgres", "", "postgres")
+	if err != nil {
+		t.Fatal("expected connection success", err)
+	}
+	defer db.Close()
+	var value int
+	if err := db.QueryRow("select 1").Scan(&value); err != nil || value != 1 {
+		t.Error("expected select to succeed", value, err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Error("expected ping to succeed", err)
+	}
+}
+
+func TestPingUnreachable(t *testing.T) {
+	config, err := pgxpool.ParseConfig("host=127.0.0.1 port=1 connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := newBackend(pool)
+	defer b.Close()
+	if b.Ping() == nil {
+		t.Error("expected ping to fail without a server")
+	}
+}
+
+func TestNewPgxFromURIInvalid(t *testing.T) {
+	if _, err := NewPgxFromURI("postgres://localhost:notaport/db"); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestQuerierExec(t *testing.T) {
+	m := &mockDb{tag: pgconn.NewCommandTag("UPDATE 2")}
+	q := querier{m}
+	tag, err := q.Exec("update t set a = $1", 1)
+	if err != nil || tag.RowsAffected() != 2 {
+		t.Error("expected exec to return the command tag", tag, err)
+	}
+	if m.lastQuery != "update t set a = $1" || len(m.lastArgs) != 1 {
+		t.Error("expected query and args to be passed through", m.lastQuery, m.lastArgs)
+	}
+}
+
+func TestQuerierQueryStruct(t *testing.T) {
+	type user struct {
+		ID   int
+		Name string
+	}
+	m := &mockDb{rows: &mockRows{columns: []string{"id", "name"}, values: [][]interface{}{{1, "alice"}, {2, "bob"}}}}
+	q := querier{m}
+
+	var users []user
+	if err := q.QueryStruct(&users, "select id, name from users"); err != nil {
+		t.Fatal("expected success", err)
+	}
+	if len(users) != 2 || users[0].ID != 1 || users[1].Name != "bob" {
+		t.Error("expected rows scanned into structs", users)
+	}
+	if !m.rows.closed {
+		t.Error("expected rows to be closed")
+	}
+}
+
+func TestQuerierQueryError(t *testing.T) {
+	m := &mockDb{err: errors.New("fail")}
+	q := querier{m}
+	if _, err := q.Query("select 1"); err == nil {
+		t.Error("expected error")
+	}
+	if _, err := q.begin(context.Background()); err == nil {
+		t.Error("expected begin error")
+	}
+}
+
+func TestTxWithTx(t *testing.T) {
+	tx := &mockTx{mockDb: &mockDb{}}
+	m := &mockDb{tx: tx}
+	q := querier{m}
+
+	err := onedb.WithTx[Txer](txBeginner{q}, func(tx Txer) error {
+		_, err := tx.Exec("insert into t values (1)")
+		return err
+	})
+	if err != nil || !tx.committed || tx.rolledBack {
+		t.Error("expected commit", err, tx.committed, tx.rolledBack)
+	}
+
+	tx = &mockTx{mockDb: &mockDb{}}
+	m.tx = tx
+	err = onedb.WithTx[Txer](txBeginner{q}, func(tx Txer) error {
+		return errors.New("fail")
+	})
+	if err == nil || tx.committed || !tx.rolledBack {
+		t.Error("expected rollback", err, tx.committed, tx.rolledBack)
+	}
+}
+
+func TestTxDone(t *testing.T) {
+	tx := &mockTx{mockDb: &mockDb{}}
+	q := querier{&mockDb{tx: tx}}
+	txer, _ := q.begin(context.Background())
+	if err := txer.Commit(); err != nil || !tx.committed {
+		t.Error("expected commit", err)
+	}
+	if err := txer.Commit(); err != ErrTxDone {
+		t.Error("expected ErrTxDone from a second commit", err)
+	}
+	if err := txer.Rollback(); err != ErrTxDone || tx.rolledBack {
+		t.Error("expected ErrTxDone without rolling back", err)
+	}
+	if _, err := txer.Exec("insert into t values (1)"); err != ErrTxDone || tx.lastQuery != "" {
+		t.Error("expected ErrTxDone without running the statement", err, tx.lastQuery)
+	}
+	if _, err := txer.Query("select 1"); err != ErrTxDone {
+		t.Error("expected ErrTxDone", err)
+	}
+	var value int
+	if err := txer.QueryRow("select 1").Scan(&value); err != ErrTxDone {
+		t.Error("expected ErrTxDone", err)
+	}
+	if _, err := txer.CopyTo(&bytes.Buffer{}, "select 1", CopyCSV); err != ErrTxDone {
+		t.Error("expected ErrTxDone", err)
+	}
+	if _, err := txer.Begin(); err != ErrTxDone {
+		t.Error("expected ErrTxDone", err)
+	}
+	if _, err := txer.SendBatch(&Batch{}).Exec(); err != ErrTxDone || tx.batch != nil {
+		t.Error("expected ErrTxDone without sending the batch", err)
+	}
+}
+
+func TestQuerierSendBatch(t *testing.T) {
+	m := &mockDb{tag: pgconn.NewCommandTag("INSERT 0 1"), rows: &mockRows{columns: []string{"id"}, values: [][]interface{}{{1}}}}
+	q := querier{m}
+	b := &Batch{}
+	b.Queue("insert into t values ($1)", 1)
+	b.Queue("select id from t")
+	r := q.SendBatch(b)
+	if m.batch != b {
+		t.Error("expected the batch sent to pgx")
+	}
+	if tag, err := r.Exec(); err != nil || tag.RowsAffected() != 1 {
+		t.Error("expected exec result", tag, err)
+	}
+	var id int
+	if rows, err := r.Query(); err != nil || !rows.Next() || rows.Scan(&id) != nil || id != 1 {
+		t.Error("expected rows", id, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Error("expected close", err)
+	}
+}
+
+func TestQuerierCopyTo(t *testing.T) {
+	m := &mockDb{tag: pgconn.NewCommandTag("COPY 2"), copyData: "id,name\n1,alice\n2,bob\n"}
+	q := querier{m}
+	var buf bytes.Buffer
+	n, err := q.CopyTo(&buf, "select id, name from users", CopyCSV)
+	if err != nil || n != 2 || buf.String() != m.copyData {
+		t.Error("expected rows to be copied to the writer", n, buf.String(), err)
+	}
+	if m.lastQuery != "COPY (select id, name from users) TO STDOUT WITH (FORMAT csv, HEADER true)" {
+		t.Error("expected COPY TO STDOUT in CSV format", m.lastQuery)
+	}
+	q.CopyTo(&buf, "select 1", CopyBinary)
+	if m.lastQuery != "COPY (select 1) TO STDOUT WITH (FORMAT binary)" {
+		t.Error("expected binary format", m.lastQuery)
+	}
+	if _, err := q.CopyTo(&buf, "select 1", CopyFormat(9)); err == nil {
+		t.Error("expected error for an unknown format")
+	}
+}
+
+/***************************** MOCKS ****************************/
+type txBeginner struct {
+	q querier
+}
+
+func (b txBeginner) Begin() (Txer, error) {
+	return b.q.begin(context.Background())
+}
+
+type mockDb struct {
+	tag       pgconn.CommandTag
+	rows      *mockRows
+	tx        *mockTx
+	err       error
+	lastQuery string
+	lastArgs  []interface{}
+	copyData  string
+	batch     *pgx.Batch
+}
+
+func (m *mockDb) Begin(ctx context.Context) (pgx.Tx, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.tx, nil
+}
+
+func (m *mockDb) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	m.lastQuery, m.lastArgs = sql, args
+	return m.tag, m.err
+}
+
+func (m *mockDb) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	m.lastQuery, m.lastArgs = sql, args
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.rows, nil
+}
+
+func (m *mockDb) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	m.lastQuery, m.lastArgs = sql, args
+	return m.rows
+}
+
+func (m *mockDb) CopyTo(ctx context.Context, w io.Writer, sql string) (pgconn.CommandTag, error) {
+	m.lastQuery = sql
+	if m.err != nil {
+		return pgconn.CommandTag{}, m.err
+	}
+	_, err := io.WriteString(w, m.copyData)
+	return m.tag, err
+}
+
+func (m *mockDb) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	m.batch = b
+	return &mockBatchResults{m}
+}
+
+type mockBatchResults struct {
+	m *mockDb
+}
+
+func (r *mockBatchResults) Exec() (pgconn.CommandTag, error) {
+	return r.m.tag, r.m.err
+}
+
+func (r *mockBatchResults) Query() (pgx.Rows, error) {
+	return r.m.rows, r.m.err
+}
+
+func (r *mockBatchResults) QueryRow() pgx.Row {
+	return r.m.rows
+}
+
+func (r *mockBatchResults) Close() error {
+	return r.m.err
+}
+
+type mockTx struct {
+	*mockDb
+	committed  bool
+	rolledBack bool
+	pgx.Tx
+}
+
+func (t *mockTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return t.mockDb.Begin(ctx)
+}
+
+func (t *mockTx) Commit(ctx context.Context) error {
+	t.committed = true
+	return nil
+}
+
+func (t *mockTx) Rollback(ctx context.Context) error {
+	if t.committed {
+		return pgx.ErrTxClosed
+	}
+	t.rolledBack = true
+	return nil
+}
+
+func (t *mockTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return t.mockDb.Exec(ctx, sql, args...)
+}
+
+func (t *mockTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return t.mockDb.Query(ctx, sql, args...)
+}
+
+func (t *mockTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return t.mockDb.QueryRow(ctx, sql, args...)
+}
+
+func (t *mockTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return t.mockDb.SendBatch(ctx, b)
+}
+
+type mockRows struct {
+	columns []string
+	values  [][]interface{}
+	current []interface{}
+	closed  bool
+	pgx.Rows
+}
+
+func (r *mockRows) Close() {
+	r.closed = true
+}
+
+func (r *mockRows) Err() error {
+	return nil
+}
+
+func (r *mockRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(r.columns))
+	for i, column := range r.columns {
+		fields[i].Name = column
+	}
+	return fields
+}
+
+func (r *mockRows) Next() bool {
+	if len(r.values) == 0 {
+		return false
+	}
+	r.current, r.values = r.values[0], r.values[1:]
+	return true
+}
+
+func (r *mockRows) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		switch d := d.(type) {
+		case *interface{}:
+			*d = r.current[i]
+		case *int:
+			*d = r.current[i].(int)
+		case *string:
+			*d = r.current[i].(string)
+		}
+	}
+	return nil
+}
+
+func TestPgError(t *testing.T) {
+	err := &pgconn.PgError{Code: "23503", ConstraintName: "orders_user_id_fkey", TableName: "orders"}
+	pgErr, ok := onedb.AsPgError(err)
+	if !ok || pgErr.ConstraintName != "orders_user_id_fkey" || pgErr.TableName != "orders" || !onedb.IsForeignKeyViolation(err) {
+		t.Error("expected pgconn's error converted", pgErr)
+	}
+}
@@ -0,0 +1,94 @@
+// Package prometheus exposes measurements from the onedb pgx backend as Prometheus metrics
+package prometheus
+
+import (
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a pgx.Metrics which records query counts, errors, latency, reconnects and pool connections.
+// Register it with a Prometheus registry and pass it as the Metrics field of a pgx.PoolConfig
+type Collector struct {
+	queries    *prom.CounterVec
+	errors     *prom.CounterVec
+	duration   *prom.HistogramVec
+	reconnects *prom.CounterVec
+	open       prom.Gauge
+	idle       prom.Gauge
+}
+
+// NewCollector returns a Collector with metric names prefixed by namespace, e.g. myapp_onedb_queries_total
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		queries: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace, Subsystem: "onedb", Name: "queries_total",
+			Help: "Number of statements run, by operation.",
+		}, []string{"operation"}),
+		errors: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace, Subsystem: "onedb", Name: "query_errors_total",
+			Help: "Number of statements which returned an error, by operation.",
+		}, []string{"operation"}),
+		duration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace, Subsystem: "onedb", Name: "query_duration_seconds",
+			Help:    "Time taken to run statements, by operation.",
+			Buckets: prom.DefBuckets,
+		}, []string{"operation"}),
+		reconnects: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace, Subsystem: "onedb", Name: "reconnects_total",
+			Help: "Number of attempts to reach the database after a dead connection, by result.",
+		}, []string{"result"}),
+		open: prom.NewGauge(prom.GaugeOpts{
+			Namespace: namespace, Subsystem: "onedb", Name: "open_connections",
+			Help: "Number of open connections in the pool.",
+		}),
+		idle: prom.NewGauge(prom.GaugeOpts{
+			Namespace: namespace, Subsystem: "onedb", Name: "idle_connections",
+			Help: "Number of idle connections in the pool.",
+		}),
+	}
+}
+
+// ObserveQuery records a finished statement
+func (c *Collector) ObserveQuery(operation string, duration time.Duration, err error) {
+	c.queries.WithLabelValues(operation).Inc()
+	if err != nil {
+		c.errors.WithLabelValues(operation).Inc()
+	}
+	c.duration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// ObserveReconnect records an attempt to reach the database after a dead connection
+func (c *Collector) ObserveReconnect(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	c.reconnects.WithLabelValues(result).Inc()
+}
+
+// ObservePool records the number of open and idle connections
+func (c *Collector) ObservePool(open, idle int) {
+	c.open.Set(float64(open))
+	c.idle.Set(float64(idle))
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	c.queries.Describe(ch)
+	c.errors.Describe(ch)
+	c.duration.Describe(ch)
+	c.reconnects.Describe(ch)
+	c.open.Describe(ch)
+	c.idle.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	c.queries.Collect(ch)
+	c.errors.Collect(ch)
+	c.duration.Collect(ch)
+	c.reconnects.Collect(ch)
+	c.open.Collect(ch)
+	c.idle.Collect(ch)
+}
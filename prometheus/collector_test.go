@@ -0,0 +1,44 @@
+package prometheus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EndFirstCorp/onedb/pgx"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ pgx.Metrics = &Collector{}
+
+func TestCollector(t *testing.T) {
+	c := NewCollector("test")
+	registry := prom.NewRegistry()
+	if err := registry.Register(c); err != nil {
+		t.Fatal("expected collector to register", err)
+	}
+
+	c.ObserveQuery("query", time.Millisecond, nil)
+	c.ObserveQuery("query", time.Millisecond, errors.New("fail"))
+	c.ObserveQuery("exec", time.Millisecond, nil)
+	c.ObserveReconnect(nil)
+	c.ObserveReconnect(errors.New("fail"))
+	c.ObservePool(4, 3)
+
+	if count := testutil.ToFloat64(c.queries.WithLabelValues("query")); count != 2 {
+		t.Error("expected 2 queries", count)
+	}
+	if count := testutil.ToFloat64(c.errors.WithLabelValues("query")); count != 1 {
+		t.Error("expected 1 error", count)
+	}
+	if count := testutil.ToFloat64(c.reconnects.WithLabelValues("failure")); count != 1 {
+		t.Error("expected 1 failed reconnect", count)
+	}
+	if open, idle := testutil.ToFloat64(c.open), testutil.ToFloat64(c.idle); open != 4 || idle != 3 {
+		t.Error("expected pool gauges", open, idle)
+	}
+	if count, err := testutil.GatherAndCount(registry, "test_onedb_query_duration_seconds"); err != nil || count != 2 {
+		t.Error("expected duration histograms by operation", count, err)
+	}
+}
@@ -0,0 +1,146 @@
+package qb
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Condition is a boolean SQL expression for a WHERE or HAVING clause
+type Condition interface {
+	writeSQL(w *sqlWriter) error
+}
+
+type expr struct {
+	sql  string
+	args []interface{}
+}
+
+// Expr is a raw SQL expression using ? for each of args, whatever placeholder format the statement is built
+// with. Write ?? for a literal question mark, e.g. for a jsonb operator. An arg which is itself a builder, like a
+// SelectBuilder, is written in place as a subquery
+func Expr(sql string, args ...interface{}) Condition {
+	return expr{sql, args}
+}
+
+func (e expr) writeSQL(w *sqlWriter) error {
+	return w.expr(e.sql, e.args)
+}
+
+// Eq is a condition comparing each column to its value, joined with AND. A nil value is compared with IS NULL
+// and a slice with IN. Columns are written in sorted order so the same Eq always builds the same SQL
+type Eq map[string]interface{}
+
+func (eq Eq) writeSQL(w *sqlWriter) error {
+	return writeComparison(w, eq, "=", "is null", "in")
+}
+
+// NotEq is the negation of Eq, using <>, IS NOT NULL and NOT IN
+type NotEq map[string]interface{}
+
+func (eq NotEq) writeSQL(w *sqlWriter) error {
+	return writeComparison(w, eq, "<>", "is not null", "not in")
+}
+
+func writeComparison(w *sqlWriter, values map[string]interface{}, op, null, in string) error {
+	if len(values) == 0 {
+		return errors.New("a comparison needs at least one column")
+	}
+	for i, column := range sortedKeys(values) {
+		if i > 0 {
+			w.WriteString(" and ")
+		}
+		w.WriteString(column)
+		value := values[column]
+		list := reflect.ValueOf(value)
+		switch {
+		case value == nil:
+			w.WriteString(" " + null)
+		case list.Kind() == reflect.Slice && list.Type().Elem().Kind() != reflect.Uint8: // not []byte
+			if list.Len() == 0 {
+				return errors.Errorf("%s %s needs at least one value", column, in)
+			}
+			w.WriteString(" " + in + " (")
+			for j := 0; j < list.Len(); j++ {
+				if j > 0 {
+					w.WriteString(", ")
+				}
+				w.arg(list.Index(j).Interface())
+			}
+			w.WriteByte(')')
+		default:
+			w.WriteString(" " + op + " ")
+			w.arg(value)
+		}
+	}
+	return nil
+}
+
+type junction struct {
+	op         string
+	conditions []Condition
+}
+
+// And is a condition which is true when all of conditions are
+func And(conditions ...Condition) Condition {
+	return junction{" and ", conditions}
+}
+
+// Or is a condition which is true when any of conditions is
+func Or(conditions ...Condition) Condition {
+	return junction{" or ", conditions}
+}
+
+func (j junction) writeSQL(w *sqlWriter) error {
+	if len(j.conditions) == 0 {
+		return errors.Errorf("%s needs at least one condition", strings.TrimSpace(j.op))
+	}
+	return writeJoined(w, j.op, j.conditions)
+}
+
+// Not is a condition which is true when condition is false
+func Not(condition Condition) Condition {
+	return not{condition}
+}
+
+type not struct {
+	condition Condition
+}
+
+func (n not) writeSQL(w *sqlWriter) error {
+	w.WriteString("not (")
+	if err := n.condition.writeSQL(w); err != nil {
+		return err
+	}
+	w.WriteByte(')')
+	return nil
+}
+
+// writeJoined writes conditions joined by op, each in parentheses when there is more than one so raw expressions
+// keep their meaning
+func writeJoined(w *sqlWriter, op string, conditions []Condition) error {
+	if len(conditions) == 1 {
+		return conditions[0].writeSQL(w)
+	}
+	for i, c := range conditions {
+		if i > 0 {
+			w.WriteString(op)
+		}
+		w.WriteByte('(')
+		if err := c.writeSQL(w); err != nil {
+			return err
+		}
+		w.WriteByte(')')
+	}
+	return nil
+}
+
+// writeConditions writes a WHERE or HAVING clause requiring all of conditions
+func writeConditions(w *sqlWriter, keyword string, conditions []Condition) error {
+	if len(conditions) == 0 {
+		return nil
+	}
+	w.WriteString(" " + keyword + " ")
+	return writeJoined(w, " and ", conditions)
+}
@@ -0,0 +1,542 @@
+// Package qb builds parameterized SQL statements, so queries with dynamic filters, columns or values don't have
+// to be put together by string concatenation. Values are always passed as arguments, never written into the SQL
+package qb
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+// PlaceholderFormat sets how argument placeholders are written
+type PlaceholderFormat int
+
+const (
+	// Dollar numbers placeholders like $1, $2 as PostgreSQL (pgx) expects
+	Dollar PlaceholderFormat = iota
+	// Question writes each placeholder as ? as MySQL, SQLite and SQL Server's database/sql drivers expect
+	Question
+)
+
+// Builder is a statement which can be turned into SQL and its arguments
+type Builder interface {
+	ToSQL() (string, []interface{}, error)
+	Query() (*onedb.Query, error)
+}
+
+// sqlWriter accumulates a statement and its arguments, writing a placeholder for each argument
+type sqlWriter struct {
+	strings.Builder
+	format PlaceholderFormat
+	args   []interface{}
+}
+
+// subquery is implemented by builders which can be written into another statement
+type subquery interface {
+	writeSQL(w *sqlWriter) error
+}
+
+func (w *sqlWriter) arg(value interface{}) {
+	w.args = append(w.args, value)
+	if w.format == Question {
+		w.WriteByte('?')
+		return
+	}
+	w.WriteByte('$')
+	w.WriteString(strconv.Itoa(len(w.args)))
+}
+
+// expr writes sql, replacing each ? with the placeholder of the next of args. A builder arg is written in
+// parentheses as a subquery
+func (w *sqlWriter) expr(sql string, args []interface{}) error {
+	used := 0
+	for i := 0; i < len(sql); i++ {
+		if sql[i] != '?' {
+			w.WriteByte(sql[i])
+			continue
+		}
+		if i+1 < len(sql) && sql[i+1] == '?' {
+			w.WriteByte('?')
+			i++
+			continue
+		}
+		if used == len(args) {
+			return errors.Errorf("%q has more placeholders than its %d arguments", sql, len(args))
+		}
+		if sub, ok := args[used].(subquery); ok {
+			w.WriteByte('(')
+			if err := sub.writeSQL(w); err != nil {
+				return err
+			}
+			w.WriteByte(')')
+		} else {
+			w.arg(args[used])
+		}
+		used++
+	}
+	if used != len(args) {
+		return errors.Errorf("%q has %d placeholders but %d arguments", sql, used, len(args))
+	}
+	return nil
+}
+
+func build(format PlaceholderFormat, stmt subquery) (string, []interface{}, error) {
+	w := &sqlWriter{format: format}
+	if err := stmt.writeSQL(w); err != nil {
+		return "", nil, err
+	}
+	return w.String(), w.args, nil
+}
+
+func toQuery(b Builder) (*onedb.Query, error) {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	return onedb.NewQuery(query, args...), nil
+}
+
+/***************************** SELECT ****************************/
+
+// SelectBuilder builds a SELECT statement
+type SelectBuilder struct {
+	format   PlaceholderFormat
+	distinct bool
+	columns  []string
+	from     string
+	joins    []expr
+	where    []Condition
+	groupBy  []string
+	having   []Condition
+	orderBy  []string
+	limit    *uint64
+	offset   *uint64
+	suffix   []expr
+}
+
+// Select starts a SELECT statement returning columns
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns}
+}
+
+// PlaceholderFormat sets the placeholders the statement is built with. The default is Dollar
+func (b *SelectBuilder) PlaceholderFormat(format PlaceholderFormat) *SelectBuilder {
+	b.format = format
+	return b
+}
+
+// Distinct selects only distinct rows
+func (b *SelectBuilder) Distinct() *SelectBuilder {
+	b.distinct = true
+	return b
+}
+
+// Columns adds columns to those selected
+func (b *SelectBuilder) Columns(columns ...string) *SelectBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// From sets the table, or other from item such as "users u", to select from
+func (b *SelectBuilder) From(from string) *SelectBuilder {
+	b.from = from
+	return b
+}
+
+// Join adds a join clause such as "left join orders o on o.user_id = u.id". Like Expr, it may use ? for args
+func (b *SelectBuilder) Join(join string, args ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, expr{join, args})
+	return b
+}
+
+// Where adds conditions which rows must meet. Calling it more than once requires all of the conditions
+func (b *SelectBuilder) Where(conditions ...Condition) *SelectBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// GroupBy adds GROUP BY expressions
+func (b *SelectBuilder) GroupBy(groupBy ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, groupBy...)
+	return b
+}
+
+// Having adds conditions which groups must meet
+func (b *SelectBuilder) Having(conditions ...Condition) *SelectBuilder {
+	b.having = append(b.having, conditions...)
+	return b
+}
+
+// OrderBy adds ORDER BY expressions such as "created_at desc"
+func (b *SelectBuilder) OrderBy(orderBy ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, orderBy...)
+	return b
+}
+
+// Limit sets the maximum number of rows returned
+func (b *SelectBuilder) Limit(limit uint64) *SelectBuilder {
+	b.limit = &limit
+	return b
+}
+
+// Offset sets the number of rows skipped
+func (b *SelectBuilder) Offset(offset uint64) *SelectBuilder {
+	b.offset = &offset
+	return b
+}
+
+// Suffix adds SQL to the end of the statement such as "for update". Like Expr, it may use ? for args
+func (b *SelectBuilder) Suffix(sql string, args ...interface{}) *SelectBuilder {
+	b.suffix = append(b.suffix, expr{sql, args})
+	return b
+}
+
+// ToSQL returns the statement and its arguments
+func (b *SelectBuilder) ToSQL() (string, []interface{}, error) {
+	return build(b.format, b)
+}
+
+// Query returns the statement as a Query to pass to a Backender
+func (b *SelectBuilder) Query() (*onedb.Query, error) {
+	return toQuery(b)
+}
+
+func (b *SelectBuilder) writeSQL(w *sqlWriter) error {
+	if len(b.columns) == 0 {
+		return errors.New("select needs at least one column")
+	}
+	w.WriteString("select ")
+	if b.distinct {
+		w.WriteString("distinct ")
+	}
+	w.WriteString(strings.Join(b.columns, ", "))
+	if b.from != "" {
+		w.WriteString(" from " + b.from)
+	}
+	for _, join := range b.joins {
+		w.WriteByte(' ')
+		if err := join.writeSQL(w); err != nil {
+			return err
+		}
+	}
+	if err := writeConditions(w, "where", b.where); err != nil {
+		return err
+	}
+	if len(b.groupBy) > 0 {
+		w.WriteString(" group by " + strings.Join(b.groupBy, ", "))
+	}
+	if err := writeConditions(w, "having", b.having); err != nil {
+		return err
+	}
+	if len(b.orderBy) > 0 {
+		w.WriteString(" order by " + strings.Join(b.orderBy, ", "))
+	}
+	if b.limit != nil {
+		w.WriteString(" limit " + strconv.FormatUint(*b.limit, 10))
+	}
+	if b.offset != nil {
+		w.WriteString(" offset " + strconv.FormatUint(*b.offset, 10))
+	}
+	return writeSuffix(w, b.suffix)
+}
+
+/***************************** INSERT ****************************/
+
+// InsertBuilder builds an INSERT statement
+type InsertBuilder struct {
+	format    PlaceholderFormat
+	table     string
+	columns   []string
+	rows      [][]interface{}
+	selected  *SelectBuilder
+	suffix    []expr
+	returning []string
+}
+
+// Insert starts an INSERT statement into table
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// PlaceholderFormat sets the placeholders the statement is built with. The default is Dollar
+func (b *InsertBuilder) PlaceholderFormat(format PlaceholderFormat) *InsertBuilder {
+	b.format = format
+	return b
+}
+
+// Columns sets the columns values are inserted into
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// Values adds a row to insert, with a value for each column
+func (b *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// SetMap sets the columns and a single row of values from a map, with the columns in sorted order
+func (b *InsertBuilder) SetMap(values map[string]interface{}) *InsertBuilder {
+	b.columns = sortedKeys(values)
+	row := make([]interface{}, len(b.columns))
+	for i, column := range b.columns {
+		row[i] = values[column]
+	}
+	b.rows = [][]interface{}{row}
+	return b
+}
+
+// Select inserts the rows returned by a query instead of Values
+func (b *InsertBuilder) Select(query *SelectBuilder) *InsertBuilder {
+	b.selected = query
+	return b
+}
+
+// Suffix adds SQL after the values such as "on conflict (id) do nothing". Like Expr, it may use ? for args
+func (b *InsertBuilder) Suffix(sql string, args ...interface{}) *InsertBuilder {
+	b.suffix = append(b.suffix, expr{sql, args})
+	return b
+}
+
+// Returning adds a RETURNING clause with columns
+func (b *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+// ToSQL returns the statement and its arguments
+func (b *InsertBuilder) ToSQL() (string, []interface{}, error) {
+	return build(b.format, b)
+}
+
+// Query returns the statement as a Query to pass to a Backender
+func (b *InsertBuilder) Query() (*onedb.Query, error) {
+	return toQuery(b)
+}
+
+func (b *InsertBuilder) writeSQL(w *sqlWriter) error {
+	if b.table == "" {
+		return errors.New("insert needs a table")
+	}
+	w.WriteString("insert into " + b.table)
+	if len(b.columns) > 0 {
+		w.WriteString(" (" + strings.Join(b.columns, ", ") + ")")
+	}
+	switch {
+	case b.selected != nil:
+		w.WriteByte(' ')
+		if err := b.selected.writeSQL(w); err != nil {
+			return err
+		}
+	case len(b.rows) == 0:
+		return errors.New("insert needs at least one row of values")
+	default:
+		w.WriteString(" values ")
+		for i, row := range b.rows {
+			if len(b.columns) > 0 && len(row) != len(b.columns) {
+				return errors.Errorf("row %d has %d values for %d columns", i+1, len(row), len(b.columns))
+			}
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			w.WriteByte('(')
+			for j, value := range row {
+				if j > 0 {
+					w.WriteString(", ")
+				}
+				w.arg(value)
+			}
+			w.WriteByte(')')
+		}
+	}
+	if err := writeSuffix(w, b.suffix); err != nil {
+		return err
+	}
+	writeReturning(w, b.returning)
+	return nil
+}
+
+/***************************** UPDATE ****************************/
+
+type assignment struct {
+	column string
+	value  interface{}
+}
+
+// UpdateBuilder builds an UPDATE statement
+type UpdateBuilder struct {
+	format    PlaceholderFormat
+	table     string
+	set       []assignment
+	from      string
+	where     []Condition
+	returning []string
+}
+
+// Update starts an UPDATE statement on table
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// PlaceholderFormat sets the placeholders the statement is built with. The default is Dollar
+func (b *UpdateBuilder) PlaceholderFormat(format PlaceholderFormat) *UpdateBuilder {
+	b.format = format
+	return b
+}
+
+// Set sets column to value. value may be an Expr, e.g. Expr("count + ?", 1), to set it from an expression
+func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	b.set = append(b.set, assignment{column, value})
+	return b
+}
+
+// SetMap sets each column in values, in sorted order
+func (b *UpdateBuilder) SetMap(values map[string]interface{}) *UpdateBuilder {
+	for _, column := range sortedKeys(values) {
+		b.Set(column, values[column])
+	}
+	return b
+}
+
+// From adds a FROM clause listing other tables the conditions can refer to
+func (b *UpdateBuilder) From(from string) *UpdateBuilder {
+	b.from = from
+	return b
+}
+
+// Where adds conditions which updated rows must meet
+func (b *UpdateBuilder) Where(conditions ...Condition) *UpdateBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// Returning adds a RETURNING clause with columns
+func (b *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+// ToSQL returns the statement and its arguments
+func (b *UpdateBuilder) ToSQL() (string, []interface{}, error) {
+	return build(b.format, b)
+}
+
+// Query returns the statement as a Query to pass to a Backender
+func (b *UpdateBuilder) Query() (*onedb.Query, error) {
+	return toQuery(b)
+}
+
+func (b *UpdateBuilder) writeSQL(w *sqlWriter) error {
+	if b.table == "" {
+		return errors.New("update needs a table")
+	}
+	if len(b.set) == 0 {
+		return errors.New("update needs at least one column to set")
+	}
+	w.WriteString("update " + b.table + " set ")
+	for i, a := range b.set {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.WriteString(a.column + " = ")
+		if e, ok := a.value.(expr); ok {
+			if err := e.writeSQL(w); err != nil {
+				return err
+			}
+		} else {
+			w.arg(a.value)
+		}
+	}
+	if b.from != "" {
+		w.WriteString(" from " + b.from)
+	}
+	if err := writeConditions(w, "where", b.where); err != nil {
+		return err
+	}
+	writeReturning(w, b.returning)
+	return nil
+}
+
+/***************************** DELETE ****************************/
+
+// DeleteBuilder builds a DELETE statement
+type DeleteBuilder struct {
+	format    PlaceholderFormat
+	table     string
+	where     []Condition
+	returning []string
+}
+
+// Delete starts a DELETE statement from table
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// PlaceholderFormat sets the placeholders the statement is built with. The default is Dollar
+func (b *DeleteBuilder) PlaceholderFormat(format PlaceholderFormat) *DeleteBuilder {
+	b.format = format
+	return b
+}
+
+// Where adds conditions which deleted rows must meet
+func (b *DeleteBuilder) Where(conditions ...Condition) *DeleteBuilder {
+	b.where = append(b.where, conditions...)
+	return b
+}
+
+// Returning adds a RETURNING clause with columns
+func (b *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+// ToSQL returns the statement and its arguments
+func (b *DeleteBuilder) ToSQL() (string, []interface{}, error) {
+	return build(b.format, b)
+}
+
+// Query returns the statement as a Query to pass to a Backender
+func (b *DeleteBuilder) Query() (*onedb.Query, error) {
+	return toQuery(b)
+}
+
+func (b *DeleteBuilder) writeSQL(w *sqlWriter) error {
+	if b.table == "" {
+		return errors.New("delete needs a table")
+	}
+	w.WriteString("delete from " + b.table)
+	if err := writeConditions(w, "where", b.where); err != nil {
+		return err
+	}
+	writeReturning(w, b.returning)
+	return nil
+}
+
+func writeSuffix(w *sqlWriter, suffix []expr) error {
+	for _, e := range suffix {
+		w.WriteByte(' ')
+		if err := e.writeSQL(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeReturning(w *sqlWriter, returning []string) {
+	if len(returning) > 0 {
+		w.WriteString(" returning " + strings.Join(returning, ", "))
+	}
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,80 @@
+package qb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	active := Select("id").From("users").Where(Eq{"active": true})
+	tests := []struct {
+		builder Builder
+		sql     string
+		args    []interface{}
+	}{
+		{Select("id", "name").From("users"), "select id, name from users", nil},
+		{Select("u.id").Distinct().From("users u").Join("join orders o on o.user_id = u.id and o.total > ?", 10).
+			Where(Eq{"u.name": "alice", "u.deleted_at": nil}, Expr("u.created_at > ?", "2024-01-01")).
+			GroupBy("u.id").Having(Expr("count(*) > ?", 2)).OrderBy("u.id desc").Limit(10).Offset(20),
+			"select distinct u.id from users u join orders o on o.user_id = u.id and o.total > $1 where (u.deleted_at is null and u.name = $2) and (u.created_at > $3) group by u.id having count(*) > $4 order by u.id desc limit 10 offset 20",
+			[]interface{}{10, "alice", "2024-01-01", 2}},
+		{Select("*").From("users").Where(Or(Eq{"id": []int{1, 2}}, And(NotEq{"name": "bob"}, Not(Expr("data ?? 'key'"))))),
+			"select * from users where (id in ($1, $2)) or ((name <> $3) and (not (data ? 'key')))", []interface{}{1, 2, "bob"}},
+		{Select("*").From("orders").Where(Expr("user_id in ?", active), Eq{"paid": false}),
+			"select * from orders where (user_id in (select id from users where active = $1)) and (paid = $2)", []interface{}{true, false}},
+		{Select("*").From("users").Where(Eq{"id": 1, "name": "alice"}).PlaceholderFormat(Question).Suffix("for update"),
+			"select * from users where id = ? and name = ? for update", []interface{}{1, "alice"}},
+	}
+	for i, test := range tests {
+		sql, args, err := test.builder.ToSQL()
+		if err != nil || sql != test.sql || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("%d: expected %q %v, got %q %v %v", i, test.sql, test.args, sql, args, err)
+		}
+	}
+}
+
+func TestInsert(t *testing.T) {
+	sql, args, err := Insert("users").Columns("id", "name").Values(1, "alice").Values(2, "bob").
+		Suffix("on conflict (id) do update set name = excluded.name").Returning("id").ToSQL()
+	if err != nil || sql != "insert into users (id, name) values ($1, $2), ($3, $4) on conflict (id) do update set name = excluded.name returning id" ||
+		!reflect.DeepEqual(args, []interface{}{1, "alice", 2, "bob"}) {
+		t.Error("expected multi-row insert", sql, args, err)
+	}
+	q, err := Insert("users").SetMap(map[string]interface{}{"name": "alice", "id": 1}).PlaceholderFormat(Question).Query()
+	if err != nil || q.Query != "insert into users (id, name) values (?, ?)" || !reflect.DeepEqual(q.Args, []interface{}{1, "alice"}) {
+		t.Error("expected insert from map", q, err)
+	}
+	sql, args, _ = Insert("archive").Columns("id").Select(Select("id").From("users").Where(Eq{"active": false})).ToSQL()
+	if sql != "insert into archive (id) select id from users where active = $1" || !reflect.DeepEqual(args, []interface{}{false}) {
+		t.Error("expected insert from select", sql, args)
+	}
+}
+
+func TestUpdateDelete(t *testing.T) {
+	sql, args, err := Update("users").Set("name", "alice").Set("logins", Expr("logins + ?", 1)).Where(Eq{"id": 3}).Returning("logins").ToSQL()
+	if err != nil || sql != "update users set name = $1, logins = logins + $2 where id = $3 returning logins" || !reflect.DeepEqual(args, []interface{}{"alice", 1, 3}) {
+		t.Error("expected update", sql, args, err)
+	}
+	sql, args, err = Delete("users").Where(Eq{"id": []string{"a", "b"}}).PlaceholderFormat(Question).ToSQL()
+	if err != nil || sql != "delete from users where id in (?, ?)" || !reflect.DeepEqual(args, []interface{}{"a", "b"}) {
+		t.Error("expected delete", sql, args, err)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	for i, b := range []Builder{
+		Select(),
+		Select("*").From("users").Where(Expr("id = ? and name = ?", 1)),
+		Select("*").From("users").Where(Expr("id = ?", 1, 2)),
+		Select("*").From("users").Where(Eq{"id": []int{}}),
+		Select("*").From("users").Where(Or()),
+		Insert("users").Columns("id"),
+		Insert("users").Columns("id", "name").Values(1),
+		Update("users"),
+		Delete(""),
+	} {
+		if _, _, err := b.ToSQL(); err == nil {
+			t.Errorf("%d: expected error", i)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package onedb
+
+import (
+	"container/list"
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryCacheOptions sets how long QueryCache keeps results and how many
+type QueryCacheOptions struct {
+	TTL        time.Duration // how long a result is served from the cache, 0 means until it's evicted or invalidated
+	MaxEntries int           // the least recently used results are evicted beyond this, defaults to 1000
+}
+
+// QueryCache keeps the rows of queries for read heavy reference data which rarely changes, keyed on the query
+// and its arguments. Add it to a backend with Interceptor, e.g. with Intercept or pgx's PoolConfig.Interceptors.
+// Only Query calls, and the DBer methods built on them, are cached; QueryRow and Exec pass through. Queries run
+// in a transaction pass through too, since they may read the transaction's uncommitted writes, as do queries
+// with arguments which can't be keyed, such as structs. Results are read in full before being cached and failed
+// queries aren't cached
+type QueryCache interface {
+	Interceptor() Interceptor
+	// Invalidate drops the cached results of query for all arguments
+	Invalidate(query string)
+	// InvalidateTable drops the cached results of every query which mentions table
+	InvalidateTable(table string)
+	// Purge drops every cached result
+	Purge()
+	Len() int
+}
+
+type queryCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cachedResult struct {
+	key     string
+	query   string
+	result  *ResultSet
+	expires time.Time
+}
+
+// NewQueryCache returns an empty QueryCache
+func NewQueryCache(options QueryCacheOptions) QueryCache {
+	maxEntries := options.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &queryCache{ttl: options.TTL, maxEntries: maxEntries, now: time.Now, lru: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *queryCache) Interceptor() Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (interface{}, error) {
+			if call.Method != MethodQuery || call.InTx {
+				return next(ctx, call)
+			}
+			key, ok := cacheKey(call.Query, call.Args)
+			if !ok {
+				return next(ctx, call)
+			}
+			if result := c.get(key); result != nil {
+				return result.Rows(), nil
+			}
+			rows, err := InterceptedRows(next(ctx, call))
+			if err != nil {
+				return rows, err
+			}
+			result, err := ReadResultSet(rows)
+			if err != nil {
+				return nil, err
+			}
+			c.add(&cachedResult{key: key, query: call.Query, result: result})
+			return result.Rows(), nil
+		}
+	}
+}
+
+// cacheKey combines query with the type and value of each argument, length prefixing each part so different
+// calls never share a key. Pointers are followed, so a reused pointer is keyed on the value it points to now. It
+// returns false when an argument isn't a string, []byte, bool, number, time.Time, nil or driver.Valuer of one
+func cacheKey(query string, args []interface{}) (string, bool) {
+	var key strings.Builder
+	writeCacheKeyPart(&key, query)
+	for _, arg := range args {
+		typ, value, ok := cacheKeyValue(arg)
+		if !ok {
+			return "", false
+		}
+		writeCacheKeyPart(&key, typ)
+		writeCacheKeyPart(&key, value)
+	}
+	return key.String(), true
+}
+
+func writeCacheKeyPart(key *strings.Builder, part string) {
+	key.WriteString(strconv.Itoa(len(part)))
+	key.WriteByte(':')
+	key.WriteString(part)
+}
+
+// cacheKeyValue returns the type and an encoding of the value arg stands for
+func cacheKeyValue(arg interface{}) (string, string, bool) {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Kind() == reflect.Ptr {
+		return "nil", "", true
+	}
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "", "", false
+		}
+		if v = reflect.ValueOf(value); !v.IsValid() {
+			return "nil", "", true
+		}
+	}
+	typ := v.Type().String()
+	switch v.Kind() {
+	case reflect.String:
+		return typ, v.String(), true
+	case reflect.Bool:
+		return typ, strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return typ, strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return typ, strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return typ, strconv.FormatFloat(v.Float(), 'g', -1, 64), true
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return typ, string(v.Bytes()), true
+		}
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			return typ, t.Format(time.RFC3339Nano), true
+		}
+	}
+	return "", "", false
+}
+
+func (c *queryCache) get(key string) *ResultSet {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	result := e.Value.(*cachedResult)
+	if c.ttl > 0 && !c.now().Before(result.expires) {
+		c.remove(e)
+		return nil
+	}
+	c.lru.MoveToFront(e)
+	return result.result
+}
+
+func (c *queryCache) add(result *cachedResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result.expires = c.now().Add(c.ttl)
+	if e, ok := c.entries[result.key]; ok {
+		c.remove(e)
+	}
+	c.entries[result.key] = c.lru.PushFront(result)
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops e. The caller must hold mu
+func (c *queryCache) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*cachedResult).key)
+}
+
+func (c *queryCache) Invalidate(query string) {
+	c.removeIf(func(result *cachedResult) bool { return result.query == query })
+}
+
+func (c *queryCache) InvalidateTable(table string) {
+	mentions := regexp.MustCompile(`(?i)(^|[^\w$])"?` + regexp.QuoteMeta(table) + `"?($|[^\w$])`)
+	c.removeIf(func(result *cachedResult) bool { return mentions.MatchString(result.query) })
+}
+
+func (c *queryCache) Purge() {
+	c.removeIf(func(*cachedResult) bool { return true })
+}
+
+func (c *queryCache) removeIf(match func(result *cachedResult) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		if match(e.Value.(*cachedResult)) {
+			c.remove(e)
+		}
+		e = next
+	}
+}
+
+func (c *queryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,129 @@
+package onedb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryCache(t *testing.T) {
+	cache := NewQueryCache(QueryCacheOptions{TTL: time.Minute})
+	now := time.Now()
+	cache.(*queryCache).now = func() time.Time { return now }
+	m := NewMock(nil, nil, []SimpleData{{1, "hello"}}, []SimpleData{{2, "world"}}, []SimpleData{{3, "again"}})
+	b := Intercept(m, cache.Interceptor())
+
+	for i := 0; i < 2; i++ {
+		var rows []SimpleData
+		if err := b.QueryStruct(&rows, "select * from users where id = $1", 1); err != nil || len(rows) != 1 || rows[0].IntVal != 1 || rows[0].StringVal != "hello" {
+			t.Error("expected first result", i, rows, err)
+		}
+	}
+	var rows []SimpleData
+	if err := b.QueryStruct(&rows, "select * from users where id = $1", "1"); err != nil || rows[0].IntVal != 2 {
+		t.Error("expected arguments of another type to miss", rows, err)
+	}
+	if len(m.QueriesRun()) != 2 || cache.Len() != 2 {
+		t.Error("expected the repeated query to be served from the cache", m.QueriesRun(), cache.Len())
+	}
+
+	now = now.Add(time.Minute)
+	rows = nil
+	if err := b.QueryStruct(&rows, "select * from users where id = $1", 1); err != nil || rows[0].IntVal != 3 {
+		t.Error("expected expired result to be queried again", rows, err)
+	}
+}
+
+func TestQueryCacheEviction(t *testing.T) {
+	cache := NewQueryCache(QueryCacheOptions{MaxEntries: 2})
+	b := Intercept(NewMock(nil, nil, []SimpleData{{1, "a"}}, []SimpleData{{2, "b"}}, []SimpleData{{3, "c"}}), cache.Interceptor())
+	var rows []SimpleData
+	b.QueryStruct(&rows, "select * from users")
+	b.QueryStruct(&rows, "select * from users_archive")
+	b.QueryStruct(&rows, "select * from users") // most recently used
+	b.QueryStruct(&rows, "select * from \"Roles\"")
+	entries := cache.(*queryCache).entries
+	key := func(query string) string {
+		key, _ := cacheKey(query, nil)
+		return key
+	}
+	if cache.Len() != 2 || entries[key("select * from users")] == nil || entries[key("select * from users_archive")] != nil {
+		t.Error("expected least recently used query to be evicted", cache.Len())
+	}
+
+	cache.InvalidateTable("roles")
+	if cache.Len() != 1 || entries[key("select * from users")] == nil {
+		t.Error("expected the query mentioning the table to be dropped", cache.Len())
+	}
+	cache.InvalidateTable("user")
+	if cache.Len() != 1 {
+		t.Error("expected table names to match whole words only", cache.Len())
+	}
+	cache.Invalidate("select * from users")
+	if cache.Len() != 0 {
+		t.Error("expected query to be dropped", cache.Len())
+	}
+}
+
+func TestQueryCacheSkipsTransactions(t *testing.T) {
+	cache := NewQueryCache(QueryCacheOptions{})
+	m := NewMock(nil, nil, []SimpleData{{1, "a"}}, []SimpleData{{2, "b"}})
+	b := Intercept(m, cache.Interceptor())
+	var rows []SimpleData
+	b.QueryStruct(&rows, "select * from users where data = $1", struct{ ID int }{1})
+	if cache.Len() != 0 {
+		t.Error("expected a query with an argument which can't be keyed to pass through", cache.Len())
+	}
+
+	tx := Intercept(&txBackend{m}, cache.Interceptor())
+	tx.QueryStruct(&rows, "select * from users")
+	if cache.Len() != 0 || len(m.QueriesRun()) != 2 {
+		t.Error("expected a query in a transaction to pass through", cache.Len())
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	one, two := 1, 2
+	p := &one
+	keyOne, _ := cacheKey("q", []interface{}{p})
+	*p = 2
+	keyTwo, _ := cacheKey("q", []interface{}{p})
+	if keyOne == keyTwo {
+		t.Error("expected a reused pointer keyed on its value")
+	}
+	if key, _ := cacheKey("q", []interface{}{&two}); key != keyTwo {
+		t.Error("expected pointers to equal values to share a key")
+	}
+	distinct := [][]interface{}{
+		{"a\x00string:b"},
+		{"a", "b"},
+		{[]byte("a")},
+		{"a"},
+		{nil},
+		{""},
+		{int64(1)},
+		{1},
+	}
+	keys := map[string]bool{}
+	for _, args := range distinct {
+		key, ok := cacheKey("q", args)
+		if !ok || keys[key] {
+			t.Error("expected a distinct key", args, ok)
+		}
+		keys[key] = true
+	}
+	if _, ok := cacheKey("q", []interface{}{[]int{1}}); ok {
+		t.Error("expected no key for a slice")
+	}
+}
+
+type txBackend struct {
+	Backender
+}
+
+func (b *txBackend) Commit() error {
+	return nil
+}
+
+func (b *txBackend) Rollback() error {
+	return nil
+}
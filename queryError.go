@@ -0,0 +1,121 @@
+package onedb
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// QueryError describes the statement an error occurred in, so logged errors can be diagnosed. Backends return it
+// from Query, Exec and Scan when enabled in their configuration. Cause and Unwrap return the original error
+type QueryError struct {
+	Backend  string
+	Query    string // truncated to QueryErrorOptions.MaxQueryLength
+	ArgCount int
+	Args     []interface{} // nil unless QueryErrorOptions.IncludeArgs is set, redacted by QueryErrorOptions.Redact
+	Err      error
+}
+
+func (e *QueryError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s query %q with %d args", e.Backend, e.Query, e.ArgCount)
+	if e.Args != nil {
+		fmt.Fprintf(&b, " %v", e.Args)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+// Cause returns the original error for github.com/pkg/errors
+func (e *QueryError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the original error for errors.Is and errors.As
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// QueryErrorOptions sets what a QueryError includes
+type QueryErrorOptions struct {
+	// MaxQueryLength truncates longer queries. Defaults to 200
+	MaxQueryLength int
+
+	// IncludeArgs adds the argument values as returned by Redact. Values may hold passwords or personal data,
+	// which Redact should replace
+	IncludeArgs bool
+
+	// Redact returns the values of args to include, such as with secrets replaced by "<redacted>". By default byte
+	// slices are replaced by their length and strings are truncated to 64 bytes, but no value is redacted
+	Redact func(query string, args []interface{}) []interface{}
+}
+
+const (
+	defaultMaxQueryLength = 200
+	maxArgLength          = 64
+)
+
+// WrapQueryError returns err as a QueryError describing the statement it occurred in. nil, errors which are
+// already QueryErrors and sentinel errors callers compare with ==, like ErrTxDone, sql.ErrNoRows and those
+// registered with RegisterSentinelErrors, are returned unchanged
+func WrapQueryError(err error, backend string, options QueryErrorOptions, query string, args ...interface{}) error {
+	if err == nil || isSentinelError(err) {
+		return err
+	}
+	if _, ok := err.(*QueryError); ok {
+		return err
+	}
+	max := options.MaxQueryLength
+	if max <= 0 {
+		max = defaultMaxQueryLength
+	}
+	e := &QueryError{Backend: backend, Query: truncate(query, max), ArgCount: len(args), Err: err}
+	if options.IncludeArgs {
+		redact := options.Redact
+		if redact == nil {
+			redact = shortenArgs
+		}
+		e.Args = redact(query, args)
+		if e.Args == nil {
+			e.Args = []interface{}{}
+		}
+	}
+	return e
+}
+
+// shortenArgs replaces byte slices by their length and truncates long strings
+func shortenArgs(query string, args []interface{}) []interface{} {
+	shortened := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case []byte:
+			shortened[i] = fmt.Sprintf("<%d bytes>", len(v))
+		case string:
+			shortened[i] = truncate(v, maxArgLength)
+		default:
+			shortened[i] = arg
+		}
+	}
+	return shortened
+}
+
+// truncate shortens s to at most max bytes followed by "...", without splitting a rune
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max] + "..."
+}
+
+// isSentinelError reports whether err is ErrTxDone, sql.ErrNoRows or a registered sentinel error
+func isSentinelError(err error) bool {
+	registeredErrors.RLock()
+	registered := registeredErrors.sentinel
+	registeredErrors.RUnlock()
+	return err == ErrTxDone || err == sql.ErrNoRows || isRegistered(err, registered)
+}
@@ -0,0 +1,50 @@
+package onedb
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWrapQueryError(t *testing.T) {
+	if WrapQueryError(nil, "pgx", QueryErrorOptions{}, "select 1") != nil {
+		t.Error("expected nil for no error")
+	}
+	cause := errors.New("syntax error")
+	err := WrapQueryError(cause, "pgx", QueryErrorOptions{}, "select * from users where email = $1", "bob@example.com")
+	if err.Error() != `pgx query "select * from users where email = $1" with 1 args: syntax error` || !errors.Is(err, cause) || err.(*QueryError).Cause() != cause {
+		t.Error("expected query and argument count without values", err)
+	}
+	if WrapQueryError(err, "pgx", QueryErrorOptions{}, "other") != err {
+		t.Error("expected a QueryError to be returned unchanged")
+	}
+
+	err = WrapQueryError(cause, "mssql", QueryErrorOptions{MaxQueryLength: 6, IncludeArgs: true}, "select 1", "a", []byte("abc"))
+	if !strings.HasPrefix(err.Error(), `mssql query "select..." with 2 args [a <3 bytes>]: `) {
+		t.Error("expected truncated query with argument values", err)
+	}
+	long := strings.Repeat("é", 40)
+	err = WrapQueryError(cause, "pgx", QueryErrorOptions{IncludeArgs: true}, "select $1", long)
+	if arg := err.(*QueryError).Args[0].(string); arg != long[:64]+"..." || !utf8.ValidString(arg) {
+		t.Error("expected long strings truncated by default", arg)
+	}
+	redact := func(query string, args []interface{}) []interface{} { return []interface{}{query, "<redacted>"} }
+	err = WrapQueryError(cause, "pgx", QueryErrorOptions{MaxQueryLength: 6, IncludeArgs: true, Redact: redact}, "select $1", "secret")
+	if args := err.(*QueryError).Args; len(args) != 2 || args[0] != "select $1" || args[1] != "<redacted>" {
+		t.Error("expected arguments returned by Redact", args)
+	}
+	err = WrapQueryError(cause, "pgx", QueryErrorOptions{MaxQueryLength: 9}, "select 'é'")
+	if query := err.(*QueryError).Query; query != "select '..." || !utf8.ValidString(query) {
+		t.Error("expected the query truncated on a rune boundary", query)
+	}
+
+	sentinel := errors.New("sentinel")
+	RegisterSentinelErrors(sentinel)
+	for _, err := range []error{ErrTxDone, sql.ErrNoRows, sentinel} {
+		if WrapQueryError(err, "pgx", QueryErrorOptions{}, "select 1") != err {
+			t.Error("expected sentinel errors unwrapped", err)
+		}
+	}
+}
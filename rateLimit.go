@@ -0,0 +1,183 @@
+package onedb
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrRateLimited is returned for a call which would have to wait longer than RateLimitOptions.MaxWait, or than
+// its context's deadline allows, for its turn
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitOptions sets the token buckets used by RateLimit. A zero rate leaves that bucket unlimited
+type RateLimitOptions struct {
+	Rate       float64       // calls per second across the backend
+	Burst      int           // calls allowed at once before Rate applies, defaults to 1
+	QueryRate  float64       // calls per second for each query fingerprint, see QueryFingerprint
+	QueryBurst int           // calls of one fingerprint allowed at once before QueryRate applies, defaults to 1
+	MaxQueries int           // fingerprints limited separately, defaults to 1000, see RateLimit
+	MaxWait    time.Duration // calls which would wait longer fail with ErrRateLimited, 0 waits as long as ctx allows
+}
+
+type rateLimiter struct {
+	options RateLimitOptions
+	now     func() time.Time
+
+	mu      sync.Mutex
+	backend *tokenBucket
+	queries map[string]*tokenBucket
+	other   *tokenBucket // shared by the fingerprints past MaxQueries
+}
+
+// tokenBucket holds up to burst tokens, refilled at rate per second. tokens goes negative while calls wait
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// RateLimit returns an interceptor which makes every call wait for a token, so a misbehaving batch job can't
+// starve interactive traffic sharing the same pool. Add QueryRate to also limit each query fingerprint by itself.
+// Once MaxQueries fingerprints have buckets, the buckets which have refilled are dropped, since a new one is the
+// same, and calls of new fingerprints share one bucket while none have refilled
+func RateLimit(options RateLimitOptions) Interceptor {
+	l := newRateLimiter(options)
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (interface{}, error) {
+			if err := l.wait(ctx, call.Query); err != nil {
+				return nil, err
+			}
+			return next(ctx, call)
+		}
+	}
+}
+
+func newRateLimiter(options RateLimitOptions) *rateLimiter {
+	l := &rateLimiter{options: options, now: time.Now, queries: make(map[string]*tokenBucket)}
+	if options.Rate > 0 {
+		l.backend = newTokenBucket(options.Rate, options.Burst)
+	}
+	return l
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// wait takes a token from the backend's and the query's buckets, waiting until both are available
+func (l *rateLimiter) wait(ctx context.Context, query string) error {
+	l.mu.Lock()
+	now := l.now()
+	var buckets []*tokenBucket
+	if l.backend != nil {
+		buckets = append(buckets, l.backend)
+	}
+	if l.options.QueryRate > 0 {
+		buckets = append(buckets, l.queryBucket(QueryFingerprint(query), now))
+	}
+	var delay time.Duration
+	for _, bucket := range buckets {
+		if d := bucket.take(now); d > delay {
+			delay = d
+		}
+	}
+	deadline, hasDeadline := ctx.Deadline()
+	if (l.options.MaxWait > 0 && delay > l.options.MaxWait) || (hasDeadline && now.Add(delay).After(deadline)) {
+		for _, bucket := range buckets {
+			bucket.tokens++
+		}
+		l.mu.Unlock()
+		return errors.Wrapf(ErrRateLimited, "waiting %v", delay)
+	}
+	l.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		for _, bucket := range buckets {
+			bucket.tokens++
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// queryBucket returns the bucket for fingerprint, making room for a new one by dropping the refilled buckets once
+// there are MaxQueries of them, or returning the shared bucket when none have refilled
+func (l *rateLimiter) queryBucket(fingerprint string, now time.Time) *tokenBucket {
+	if bucket, ok := l.queries[fingerprint]; ok {
+		return bucket
+	}
+	max := l.options.MaxQueries
+	if max <= 0 {
+		max = 1000
+	}
+	if len(l.queries) >= max {
+		for key, bucket := range l.queries {
+			if bucket.full(now) {
+				delete(l.queries, key)
+			}
+		}
+		if len(l.queries) >= max {
+			if l.other == nil {
+				l.other = newTokenBucket(l.options.QueryRate, l.options.QueryBurst)
+			}
+			return l.other
+		}
+	}
+	bucket := newTokenBucket(l.options.QueryRate, l.options.QueryBurst)
+	l.queries[fingerprint] = bucket
+	return bucket
+}
+
+// full reports whether the bucket will have refilled to burst at now
+func (b *tokenBucket) full(now time.Time) bool {
+	return b.last.IsZero() || b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst
+}
+
+// take refills the bucket up to now and takes a token, returning how long to wait until the token is available
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+var (
+	fingerprintStrings    = regexp.MustCompile(`'(?:[^']|'')*'`)
+	fingerprintNumbers    = regexp.MustCompile(`([^\w$.]|^)-?\d+(?:\.\d+)?`)
+	fingerprintWhitespace = regexp.MustCompile(`\s+`)
+)
+
+// QueryFingerprint reduces query to its shape by replacing string and number literals with ? and collapsing
+// whitespace, so the same statement with different literals has the same fingerprint
+func QueryFingerprint(query string) string {
+	query = fingerprintStrings.ReplaceAllString(query, "?")
+	query = fingerprintNumbers.ReplaceAllString(query, "${1}?")
+	query = fingerprintWhitespace.ReplaceAllString(query, " ")
+	return strings.ToLower(strings.TrimSpace(query))
+}
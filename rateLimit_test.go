@@ -0,0 +1,89 @@
+package onedb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(RateLimitOptions{Rate: 10, Burst: 2, MaxWait: 50 * time.Millisecond})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+	if l.wait(ctx, "select 1") != nil || l.wait(ctx, "select 2") != nil {
+		t.Error("expected burst to pass")
+	}
+	if err := l.wait(ctx, "select 3"); errors.Cause(err) != ErrRateLimited {
+		t.Error("expected call past MaxWait to be limited", err)
+	}
+	now = now.Add(95 * time.Millisecond)
+	start := time.Now()
+	if err := l.wait(ctx, "select 4"); err != nil || time.Since(start) < 5*time.Millisecond {
+		t.Error("expected call to wait for its token", err, time.Since(start))
+	}
+
+	now = now.Add(60 * time.Millisecond)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.wait(canceled, "select 5"); err != context.Canceled || l.backend.tokens < 0.5 {
+		t.Error("expected token to be returned when the context is done", err, l.backend.tokens)
+	}
+	deadline, cancel := context.WithDeadline(ctx, now)
+	defer cancel()
+	if err := l.wait(deadline, "select 6"); errors.Cause(err) != ErrRateLimited {
+		t.Error("expected call past the deadline to be limited", err)
+	}
+}
+
+func TestRateLimitPerQuery(t *testing.T) {
+	l := newRateLimiter(RateLimitOptions{QueryRate: 1, MaxWait: time.Millisecond})
+	ctx := context.Background()
+	if l.wait(ctx, "select * from users where id = 1") != nil || l.wait(ctx, "select * from roles") != nil {
+		t.Error("expected each fingerprint to have its own bucket")
+	}
+	if err := l.wait(ctx, "select *  from users where id = 2"); errors.Cause(err) != ErrRateLimited {
+		t.Error("expected same fingerprint to be limited", err)
+	}
+
+	b := Intercept(NewMock(nil, nil), RateLimit(RateLimitOptions{Rate: 1, MaxWait: time.Millisecond}))
+	b.Query("select 1")
+	if _, err := b.Query("select 1"); errors.Cause(err) != ErrRateLimited {
+		t.Error("expected interceptor to limit calls", err)
+	}
+}
+
+func TestRateLimitMaxQueries(t *testing.T) {
+	l := newRateLimiter(RateLimitOptions{QueryRate: 1, MaxQueries: 2, MaxWait: time.Millisecond})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+	l.wait(ctx, "select * from users")
+	l.wait(ctx, "select * from roles")
+	if l.wait(ctx, "select * from groups") != nil || len(l.queries) != 2 || l.other == nil {
+		t.Error("expected fingerprints past MaxQueries to share a bucket", len(l.queries))
+	}
+	if err := l.wait(ctx, "select * from tenants"); errors.Cause(err) != ErrRateLimited {
+		t.Error("expected the shared bucket to be limited", err)
+	}
+
+	now = now.Add(time.Second)
+	if l.wait(ctx, "select * from tenants") != nil || len(l.queries) != 1 || l.queries["select * from tenants"] == nil {
+		t.Error("expected refilled buckets to be dropped", l.queries)
+	}
+}
+
+func TestQueryFingerprint(t *testing.T) {
+	tests := map[string]string{
+		"select * from users where id = 1":                       "select * from users where id = ?",
+		"SELECT *\n\tFROM users WHERE name = 'it''s' and x>-2.5": "select * from users where name = ? and x>?",
+		"select col1 from t1 where id = $1":                      "select col1 from t1 where id = $1",
+	}
+	for query, expected := range tests {
+		if actual := QueryFingerprint(query); actual != expected {
+			t.Errorf("expected %q for %q, got %q", expected, query, actual)
+		}
+	}
+}
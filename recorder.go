@@ -0,0 +1,564 @@
+package onedb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RecordMode selects whether a Recorder runs calls against a database or replays a recording of them
+type RecordMode int
+
+const (
+	// Replay returns the results saved in the recording file without needing a database
+	Replay RecordMode = iota
+	// Record runs calls against the backend and saves them and their results to the recording file
+	Record
+)
+
+// Recorder captures the queries run against a real database along with their results so integration tests can
+// later run offline against the recording. In Replay mode calls must be made in the order they were recorded
+type Recorder interface {
+	Backender
+	ContextBackender
+	DBer
+	Execute(query string, args ...interface{}) error
+
+	// Finish writes the recording file in Record mode. In Replay mode it returns an error if a call didn't match
+	// the recording or recorded calls were never replayed
+	Finish() error
+}
+
+type executer interface {
+	Execute(query string, args ...interface{}) error
+}
+
+type recorder struct {
+	mode        RecordMode
+	path        string
+	backend     Backender
+	knownErrors []error
+
+	mu    sync.Mutex
+	calls []*recordedCall
+	next  int
+	err   error // the first call which didn't match the recording
+}
+
+type recordedCall struct {
+	Method  string            `json:"method"`
+	Query   string            `json:"query"`
+	Args    []recordedValue   `json:"args,omitempty"`
+	Columns []string          `json:"columns,omitempty"`
+	Rows    [][]recordedValue `json:"rows,omitempty"`
+	Values  []recordedValue   `json:"values,omitempty"` // scanned by QueryRow
+	Err     string            `json:"err,omitempty"`
+}
+
+// recordedValue keeps a value's type along with it so it comes back from JSON as the type the driver returned
+type recordedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// NewRecorder returns a Recorder saving to or replaying from the file at path. backend is only used in Record mode.
+// Errors are saved as their message; replayed errors with the message of one of knownErrors, such as ErrNoRows, are
+// returned as that error so they can still be compared
+func NewRecorder(mode RecordMode, path string, backend Backender, knownErrors ...error) (Recorder, error) {
+	r := &recorder{mode: mode, path: path, backend: backend, knownErrors: knownErrors}
+	if mode == Record {
+		if backend == nil {
+			return nil, errors.New("a backend is required to record")
+		}
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read recording")
+	}
+	if err := json.Unmarshal(data, &r.calls); err != nil {
+		return nil, errors.Wrap(err, "Unable to parse recording")
+	}
+	return r, nil
+}
+
+func (r *recorder) Query(query string, args ...interface{}) (RowsScanner, error) {
+	return r.query(context.Background(), query, args)
+}
+
+func (r *recorder) QueryContext(ctx context.Context, query string, args ...interface{}) (RowsScanner, error) {
+	return r.query(ctx, query, args)
+}
+
+func (r *recorder) QueryRow(query string, args ...interface{}) Scanner {
+	return &recorderRow{r: r, ctx: context.Background(), query: query, args: args}
+}
+
+func (r *recorder) QueryRowContext(ctx context.Context, query string, args ...interface{}) Scanner {
+	return &recorderRow{r: r, ctx: ctx, query: query, args: args}
+}
+
+func (r *recorder) Execute(query string, args ...interface{}) error {
+	call, err := r.replay("Execute", query, args)
+	if err != nil {
+		return err
+	}
+	if call == nil {
+		e, ok := r.backend.(executer)
+		if !ok {
+			return errors.New("backend doesn't support Execute")
+		}
+		call = r.newCall("Execute", query, args)
+		call.setErr(e.Execute(query, args...))
+		r.save(call)
+	}
+	return r.callErr(call.Err)
+}
+
+func (r *recorder) query(ctx context.Context, query string, args []interface{}) (RowsScanner, error) {
+	call, err := r.replay("Query", query, args)
+	if err != nil {
+		return &recordedRows{err: err}, err
+	}
+	if call == nil {
+		call = r.newCall("Query", query, args)
+		call.setErr(r.readRows(ctx, call, query, args))
+		r.save(call)
+	}
+	rows := &recordedRows{columns: call.Columns, err: r.callErr(call.Err)}
+	if rows.err != nil && call.Columns == nil {
+		return rows, rows.err
+	}
+	for _, recorded := range call.Rows {
+		row, err := decodeValues(recorded)
+		if err != nil {
+			return &recordedRows{err: err}, err
+		}
+		rows.rows = append(rows.rows, row)
+	}
+	return rows, nil
+}
+
+// readRows runs the query against the backend and saves all of its rows to call. Columns is left nil when the
+// query itself fails
+func (r *recorder) readRows(ctx context.Context, call *recordedCall, query string, args []interface{}) error {
+	var rows RowsScanner
+	var err error
+	if b, ok := r.backend.(ContextBackender); ok {
+		rows, err = b.QueryContext(ctx, query, args...)
+	} else {
+		rows, err = r.backend.Query(query, args...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	call.Columns = columns
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		row, err := encodeValues(values)
+		if err != nil {
+			return err
+		}
+		call.Rows = append(call.Rows, row)
+	}
+	return rows.Err()
+}
+
+func (r *recorder) newCall(method, query string, args []interface{}) *recordedCall {
+	call := &recordedCall{Method: method, Query: query}
+	var err error
+	if call.Args, err = encodeValues(args); err != nil {
+		call.setErr(err)
+	}
+	return call
+}
+
+func (c *recordedCall) setErr(err error) {
+	if err != nil && c.Err == "" {
+		c.Err = err.Error()
+	}
+}
+
+func (r *recorder) save(call *recordedCall) {
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+}
+
+// replay returns the next recorded call, or nil in Record mode. It fails when the call made doesn't match it
+func (r *recorder) replay(method, query string, args []interface{}) (*recordedCall, error) {
+	if r.mode == Record {
+		return nil, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.match(method, query, args)
+	if err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+		return nil, err
+	}
+	call := r.calls[r.next]
+	r.next++
+	return call, nil
+}
+
+func (r *recorder) match(method, query string, args []interface{}) error {
+	if r.next >= len(r.calls) {
+		return errors.Errorf("%s %q with args %v was not recorded", method, query, args)
+	}
+	call := r.calls[r.next]
+	encoded, err := encodeValues(args)
+	if err != nil {
+		return err
+	}
+	// compare as JSON since the file is indented, which reformats the json values
+	want, _ := json.Marshal(call.Args)
+	got, _ := json.Marshal(encoded)
+	if call.Method != method || call.Query != query || !bytes.Equal(want, got) {
+		return errors.Errorf("%s %q with args %v doesn't match recorded call %d, %s %q", method, query, args, r.next+1, call.Method, call.Query)
+	}
+	return nil
+}
+
+// callErr rebuilds a recorded error, returning the matching known error if there is one
+func (r *recorder) callErr(msg string) error {
+	if msg == "" {
+		return nil
+	}
+	for _, err := range r.knownErrors {
+		if err.Error() == msg {
+			return err
+		}
+	}
+	return errors.New(msg)
+}
+
+func (r *recorder) Finish() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mode == Replay {
+		if r.err != nil {
+			return r.err
+		}
+		if r.next < len(r.calls) {
+			return errors.Errorf("%d recorded call(s) were not replayed, starting with %s %q", len(r.calls)-r.next, r.calls[r.next].Method, r.calls[r.next].Query)
+		}
+		return nil
+	}
+	calls := r.calls
+	if calls == nil {
+		calls = []*recordedCall{}
+	}
+	data, err := json.MarshalIndent(calls, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0644)
+}
+
+func (r *recorder) QueryValues(query *Query, result ...interface{}) error {
+	return QueryValues(r, query, result...)
+}
+
+func (r *recorder) QueryJSON(query string, args ...interface{}) (string, error) {
+	return QueryJSON(r, query, args...)
+}
+
+func (r *recorder) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return QueryJSONRow(r, query, args...)
+}
+
+func (r *recorder) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return QueryJSONWriter(w, r, query, args...)
+}
+
+func (r *recorder) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return QueryStruct(r, result, query, args...)
+}
+
+func (r *recorder) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return QueryStructRow(r, result, query, args...)
+}
+
+func (r *recorder) QueryWriteCSV(w io.Writer, options CSVOptions, query string, args ...interface{}) error {
+	return QueryWriteCSV(w, options, r, query, args...)
+}
+
+// recorderRow runs or replays QueryRow when it is scanned, recording the values scanned into dest
+type recorderRow struct {
+	r     *recorder
+	ctx   context.Context
+	query string
+	args  []interface{}
+}
+
+func (s *recorderRow) Scan(dest ...interface{}) error {
+	call, err := s.r.replay("QueryRow", s.query, s.args)
+	if err != nil {
+		return err
+	}
+	if call == nil {
+		call = s.r.newCall("QueryRow", s.query, s.args)
+		var row Scanner
+		if b, ok := s.r.backend.(ContextBackender); ok {
+			row = b.QueryRowContext(s.ctx, s.query, s.args...)
+		} else {
+			row = s.r.backend.QueryRow(s.query, s.args...)
+		}
+		if err := row.Scan(dest...); err != nil {
+			call.setErr(err)
+		} else {
+			values := make([]interface{}, len(dest))
+			for i := range dest {
+				values[i] = reflect.ValueOf(dest[i]).Elem().Interface()
+			}
+			call.Values, err = encodeValues(values)
+			call.setErr(err)
+		}
+		s.r.save(call)
+	}
+	if err := s.r.callErr(call.Err); err != nil {
+		return err
+	}
+	values, err := decodeValues(call.Values)
+	if err != nil {
+		return err
+	}
+	return assignValues(dest, values)
+}
+
+type recordedRows struct {
+	columns []string
+	rows    [][]interface{}
+	current []interface{}
+	err     error
+}
+
+func (r *recordedRows) Close() error {
+	r.rows = nil
+	return nil
+}
+
+func (r *recordedRows) Columns() ([]string, error) {
+	return r.columns, nil
+}
+
+func (r *recordedRows) Next() bool {
+	if len(r.rows) == 0 {
+		r.current = nil
+		return false
+	}
+	r.current, r.rows = r.rows[0], r.rows[1:]
+	return true
+}
+
+func (r *recordedRows) Err() error {
+	return r.err
+}
+
+func (r *recordedRows) Scan(dest ...interface{}) error {
+	if r.current == nil {
+		return errors.New("invalid current row")
+	}
+	return assignValues(dest, r.current)
+}
+
+func encodeValues(values []interface{}) ([]recordedValue, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	encoded := make([]recordedValue, len(values))
+	for i, v := range values {
+		var err error
+		if encoded[i], err = encodeValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return encoded, nil
+}
+
+func encodeValue(v interface{}) (recordedValue, error) {
+	var typ string
+	switch value := v.(type) {
+	case nil:
+		return recordedValue{Type: "null"}, nil
+	case bool:
+		typ = "bool"
+	case int, int8, int16, int32, int64:
+		typ, v = "int", reflect.ValueOf(v).Int()
+	case uint, uint8, uint16, uint32, uint64:
+		typ, v = "uint", reflect.ValueOf(v).Uint()
+	case float32, float64:
+		typ, v = "float", reflect.ValueOf(v).Float()
+	case string:
+		typ = "string"
+	case []byte:
+		typ = "bytes"
+	case time.Time:
+		typ = "time"
+	case driver.Valuer:
+		dv, err := value.Value()
+		if err != nil {
+			return recordedValue{}, err
+		}
+		if dv == nil || reflect.TypeOf(dv) != reflect.TypeOf(v) {
+			return encodeValue(dv)
+		}
+		typ = "json"
+	default:
+		typ = "json"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return recordedValue{}, errors.Wrapf(err, "Unable to record value of type %T", v)
+	}
+	return recordedValue{Type: typ, Value: data}, nil
+}
+
+// jsonValue is a recorded value of a type other than the basic driver types. It is unmarshaled into the destination
+// it is scanned into
+type jsonValue json.RawMessage
+
+func decodeValues(values []recordedValue) ([]interface{}, error) {
+	decoded := make([]interface{}, len(values))
+	for i, v := range values {
+		var err error
+		if decoded[i], err = decodeValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return decoded, nil
+}
+
+func decodeValue(v recordedValue) (interface{}, error) {
+	var err error
+	switch v.Type {
+	case "null":
+		return nil, nil
+	case "bool":
+		var b bool
+		err = json.Unmarshal(v.Value, &b)
+		return b, err
+	case "int":
+		var i int64
+		err = json.Unmarshal(v.Value, &i)
+		return i, err
+	case "uint":
+		var u uint64
+		err = json.Unmarshal(v.Value, &u)
+		return u, err
+	case "float":
+		var f float64
+		err = json.Unmarshal(v.Value, &f)
+		return f, err
+	case "string":
+		var s string
+		err = json.Unmarshal(v.Value, &s)
+		return s, err
+	case "bytes":
+		var b []byte
+		err = json.Unmarshal(v.Value, &b)
+		return b, err
+	case "time":
+		var t time.Time
+		err = json.Unmarshal(v.Value, &t)
+		return t, err
+	case "json":
+		return jsonValue(v.Value), nil
+	}
+	return nil, errors.Errorf("unknown recorded type %q", v.Type)
+}
+
+func assignValues(dest, values []interface{}) error {
+	if len(dest) != len(values) {
+		return fmt.Errorf("expected %d destination arguments in Scan, not %d", len(values), len(dest))
+	}
+	for i := range dest {
+		if err := assignValue(dest[i], values[i]); err != nil {
+			return errors.Wrapf(err, "Unable to scan column %d", i)
+		}
+	}
+	return nil
+}
+
+// assignValue sets dest to a replayed value, converting between numeric types and between strings and []byte
+func assignValue(dest, value interface{}) error {
+	if raw, ok := value.(jsonValue); ok {
+		if p, ok := dest.(*interface{}); ok {
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.UseNumber()
+			return dec.Decode(p)
+		}
+		return json.Unmarshal(raw, dest)
+	}
+	if p, ok := dest.(*interface{}); ok {
+		*p = value
+		return nil
+	}
+	if s, ok := dest.(sql.Scanner); ok {
+		return s.Scan(value)
+	}
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() {
+		return errors.New("destination must be a non-nil pointer")
+	}
+	e := d.Elem()
+	if value == nil {
+		switch e.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			e.Set(reflect.Zero(e.Type()))
+			return nil
+		}
+		return errors.Errorf("can't scan NULL into %s", e.Type())
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(e.Type()):
+		e.Set(v)
+		return nil
+	case isNumber(v.Kind()) && isNumber(e.Kind()), isText(v.Type()) && isText(e.Type()):
+		e.Set(v.Convert(e.Type()))
+		return nil
+	case e.Kind() == reflect.Ptr:
+		p := reflect.New(e.Type().Elem())
+		if err := assignValue(p.Interface(), value); err != nil {
+			return err
+		}
+		e.Set(p)
+		return nil
+	}
+	return errors.Errorf("can't scan %T into %s", value, e.Type())
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+func isText(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
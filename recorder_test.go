@@ -0,0 +1,111 @@
+package onedb
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordedUser struct {
+	ID      int
+	Name    string
+	Created time.Time
+	Manager sql.NullString
+}
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	noRows := errors.New("no rows in result set")
+	m := NewMock(nil, nil, []recordedUser{{1, "alice", created, sql.NullString{String: "bob", Valid: true}}, {2, "carol", created, sql.NullString{}}})
+
+	rec, err := NewRecorder(Record, path, m, noRows)
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	recorded := runRecorderQueries(t, rec)
+	if err := rec.Finish(); err != nil {
+		t.Fatal("expected recording to be saved", err)
+	}
+	m.VerifyNextCommand(t, "QueryContext", "select * from users where id > $1", 0)
+	m.VerifyNextCommand(t, "Execute", "delete from users where id = $1", int64(2), map[string]int{"by": 1})
+
+	replay, err := NewRecorder(Replay, path, nil, noRows)
+	if err != nil {
+		t.Fatal("expected recording to load", err)
+	}
+	replayed := runRecorderQueries(t, replay)
+	if len(replayed) != 2 || replayed[0] != recorded[0] || replayed[1] != recorded[1] {
+		t.Error("expected replayed results to match the recording", replayed, recorded)
+	}
+	if err := replay.Finish(); err != nil {
+		t.Error("expected every call to be replayed", err)
+	}
+}
+
+func runRecorderQueries(t *testing.T, r Recorder) []recordedUser {
+	var users []recordedUser
+	if err := r.QueryStruct(&users, "select * from users where id > $1", 0); err != nil {
+		t.Error("expected success", err)
+	}
+	if err := r.Execute("delete from users where id = $1", int64(2), map[string]int{"by": 1}); err != nil {
+		t.Error("expected success", err)
+	}
+	return users
+}
+
+func TestRecorderQueryRow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "row.json")
+	rec, _ := NewRecorder(Record, path, &mockRowBackend{values: []interface{}{int32(7), []byte("alice")}})
+	var id int64
+	var name string
+	if err := rec.QueryRow("select id, name from users").Scan(&id, &name); err != nil || id != 7 || name != "alice" {
+		t.Fatal("expected row to be scanned", id, name, err)
+	}
+	rec.Finish()
+
+	replay, _ := NewRecorder(Replay, path, nil)
+	var replayedID int
+	var replayedName []byte
+	if err := replay.QueryRow("select id, name from users").Scan(&replayedID, &replayedName); err != nil || replayedID != 7 || string(replayedName) != "alice" {
+		t.Error("expected recorded values converted to the destinations", replayedID, replayedName, err)
+	}
+}
+
+func TestRecorderReplayMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mismatch.json")
+	rec, _ := NewRecorder(Record, path, NewMock(nil, nil, []SimpleData{{1, "alice"}}))
+	rec.Query("select * from users")
+	rec.Finish()
+
+	replay, _ := NewRecorder(Replay, path, nil)
+	if _, err := replay.Query("select * from accounts"); err == nil || !strings.Contains(err.Error(), "doesn't match recorded call 1") {
+		t.Error("expected mismatch error", err)
+	}
+	if err := replay.Finish(); err == nil {
+		t.Error("expected Finish to report the mismatch")
+	}
+
+	if _, err := NewRecorder(Replay, filepath.Join(t.TempDir(), "missing.json"), nil); err == nil {
+		t.Error("expected error for a missing recording")
+	}
+	if _, err := NewRecorder(Record, path, nil); err == nil {
+		t.Error("expected error recording without a backend")
+	}
+}
+
+/***************************** MOCKS ****************************/
+type mockRowBackend struct {
+	values []interface{}
+}
+
+func (b *mockRowBackend) Query(query string, args ...interface{}) (RowsScanner, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (b *mockRowBackend) QueryRow(query string, args ...interface{}) Scanner {
+	return &recordedRows{current: b.values}
+}
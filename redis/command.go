@@ -0,0 +1,235 @@
+package redis
+
+import (
+	"io"
+	"strings"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/garyburd/redigo/redis"
+)
+
+// ErrNil occurs when QueryRow's command returns no value. It is reexported from redigo
+var ErrNil = redis.ErrNil
+
+// RedisCommand is a single Redis command with its arguments, used to queue commands in a Pipeline
+type RedisCommand struct {
+	Command string
+	Args    []interface{}
+}
+
+// NewRedisCommand returns a RedisCommand
+func NewRedisCommand(command string, args ...interface{}) *RedisCommand {
+	return &RedisCommand{Command: command, Args: args}
+}
+
+// fieldValueCommands reply with alternating field names and values, which are returned as a single row with a
+// column per field so they can be scanned into a struct
+var fieldValueCommands = map[string]bool{"HGETALL": true, "CONFIG GET": true}
+
+// Query runs command and returns its reply as rows. A nil reply has no rows, an array reply has a row for each
+// element and any other reply is a single row. Rows have one column named "value", except for replies to
+// HGETALL and CONFIG GET which are a single row with a column for each field. Bulk strings are returned as strings
+// and integer replies as int64, so struct fields should use those types
+func (r *redisBackend) Query(command string, args ...interface{}) (onedb.RowsScanner, error) {
+	reply, err := r.Do(command, args...)
+	if err != nil {
+		return nil, err
+	}
+	return newReplyRows(command, args, reply)
+}
+
+// QueryRow runs command and returns the first row of its reply, or ErrNil from Scan when there isn't one
+func (r *redisBackend) QueryRow(command string, args ...interface{}) onedb.Scanner {
+	rows, err := r.Query(command, args...)
+	if err != nil {
+		return &errRow{err}
+	}
+	return &replyRow{rows.(*replyRows)}
+}
+
+// Exec runs command, discarding its reply
+func (r *redisBackend) Exec(command string, args ...interface{}) error {
+	_, err := r.Do(command, args...)
+	return err
+}
+
+// Pipeline sends all of the commands to Redis in one round trip and returns their replies in the same order.
+// An error reply for one command doesn't stop the others from running; the first one is returned along with
+// all of the replies
+func (r *redisBackend) Pipeline(commands ...*RedisCommand) ([]interface{}, error) {
+	for _, command := range commands {
+		if command == nil {
+			return nil, errInvalidRedisExecType
+		}
+	}
+
+	c := r.pool.Get()
+	defer c.Close()
+	for _, command := range commands {
+		if err := c.Send(command.Command, command.Args...); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(commands))
+	var firstErr error
+	for i := range commands {
+		reply, err := c.Receive()
+		if _, ok := err.(redis.Error); !ok && err != nil {
+			return nil, err // connection error, the remaining replies can't be read
+		}
+		if err != nil {
+			reply = err
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		replies[i] = reply
+	}
+	return replies, firstErr
+}
+
+func (r *redisBackend) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValues(r, query, result...)
+}
+
+func (r *redisBackend) QueryJSON(command string, args ...interface{}) (string, error) {
+	return onedb.QueryJSON(r, command, args...)
+}
+
+func (r *redisBackend) QueryJSONRow(command string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRow(r, command, args...)
+}
+
+func (r *redisBackend) QueryJSONWriter(w io.Writer, command string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, r, command, args...)
+}
+
+func (r *redisBackend) QueryStruct(result interface{}, command string, args ...interface{}) error {
+	return onedb.QueryStruct(r, result, command, args...)
+}
+
+func (r *redisBackend) QueryStructRow(result interface{}, command string, args ...interface{}) error {
+	return onedb.QueryStructRow(r, result, command, args...)
+}
+
+func (r *redisBackend) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, command string, args ...interface{}) error {
+	return onedb.QueryWriteCSV(w, options, r, command, args...)
+}
+
+// replyRows exposes a Redis reply as rows
+type replyRows struct {
+	columns []string
+	rows    [][]interface{}
+	current []interface{}
+}
+
+func newReplyRows(command string, args []interface{}, reply interface{}) (*replyRows, error) {
+	name := strings.ToUpper(command)
+	if len(args) > 0 {
+		if sub, ok := args[0].(string); ok {
+			if full := name + " " + strings.ToUpper(sub); fieldValueCommands[full] {
+				name = full
+			}
+		}
+	}
+
+	values, isArray := reply.([]interface{})
+	switch {
+	case reply == nil:
+		return &replyRows{columns: []string{"value"}}, nil
+	case fieldValueCommands[name] && isArray:
+		if len(values) == 0 {
+			return &replyRows{}, nil
+		}
+		row := &replyRows{}
+		var fields []interface{}
+		for i := 0; i+1 < len(values); i += 2 {
+			field, err := redis.String(values[i], nil)
+			if err != nil {
+				return nil, err
+			}
+			row.columns = append(row.columns, field)
+			fields = append(fields, values[i+1])
+		}
+		row.rows = [][]interface{}{fields}
+		return row, nil
+	case isArray:
+		rows := &replyRows{columns: []string{"value"}}
+		for _, value := range values {
+			if err, ok := value.(redis.Error); ok {
+				return nil, err
+			}
+			rows.rows = append(rows.rows, []interface{}{value})
+		}
+		return rows, nil
+	default:
+		return &replyRows{columns: []string{"value"}, rows: [][]interface{}{{reply}}}, nil
+	}
+}
+
+func (r *replyRows) Close() error {
+	r.rows = nil
+	return nil
+}
+
+func (r *replyRows) Columns() ([]string, error) {
+	return r.columns, nil
+}
+
+func (r *replyRows) Err() error {
+	return nil
+}
+
+func (r *replyRows) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	r.current, r.rows = r.rows[0], r.rows[1:]
+	return true
+}
+
+// Scan converts the current row's values the same way redigo's Scan does. Bulk strings scanned into an
+// *interface{} become strings rather than []byte so they read naturally in JSON and CSV output
+func (r *replyRows) Scan(dest ...interface{}) error {
+	src := make([]interface{}, len(r.current))
+	for i, value := range r.current {
+		if s, ok := value.(string); ok { // status replies
+			value = []byte(s)
+		}
+		src[i] = value
+	}
+	if _, err := redis.Scan(src, dest...); err != nil {
+		return err
+	}
+	for _, d := range dest {
+		if p, ok := d.(*interface{}); ok {
+			if b, ok := (*p).([]byte); ok {
+				*p = string(b)
+			}
+		}
+	}
+	return nil
+}
+
+type replyRow struct {
+	rows *replyRows
+}
+
+func (r *replyRow) Scan(dest ...interface{}) error {
+	if !r.rows.Next() {
+		return ErrNil
+	}
+	return r.rows.Scan(dest...)
+}
+
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
@@ -0,0 +1,148 @@
+package redis
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+func TestQueryStruct(t *testing.T) {
+	c := &mockConn{replies: []interface{}{[]interface{}{[]byte("a"), []byte("b")}}}
+	r := &redisBackend{&mockPool{c}}
+
+	type item struct {
+		Value string
+	}
+	var items []item
+	if err := r.QueryStruct(&items, "LRANGE", "list", 0, -1); err != nil {
+		t.Fatal("expected success", err)
+	}
+	if !reflect.DeepEqual(items, []item{{"a"}, {"b"}}) {
+		t.Error("expected a row per element", items)
+	}
+	if !reflect.DeepEqual(c.commands[0], []interface{}{"LRANGE", "list", 0, -1}) {
+		t.Error("expected command to be run", c.commands)
+	}
+}
+
+func TestQueryStructRowHash(t *testing.T) {
+	c := &mockConn{replies: []interface{}{[]interface{}{[]byte("name"), []byte("alice"), []byte("age"), []byte("30")}}}
+	r := &redisBackend{&mockPool{c}}
+
+	type user struct {
+		Name string
+		Age  string
+	}
+	var u user
+	if err := r.QueryStructRow(&u, "HGETALL", "user:1"); err != nil {
+		t.Fatal("expected success", err)
+	}
+	if u.Name != "alice" || u.Age != "30" {
+		t.Error("expected hash fields scanned into struct", u)
+	}
+}
+
+func TestQueryJSON(t *testing.T) {
+	r := &redisBackend{&mockPool{&mockConn{replies: []interface{}{[]byte("hello")}}}}
+	json, err := r.QueryJSON("GET", "key")
+	if err != nil || json != `[{"value":"hello"}]` {
+		t.Error("expected bulk string as a string", json, err)
+	}
+}
+
+func TestQueryRow(t *testing.T) {
+	r := &redisBackend{&mockPool{&mockConn{replies: []interface{}{int64(3)}}}}
+	var count int
+	if err := r.QueryRow("INCR", "counter").Scan(&count); err != nil || count != 3 {
+		t.Error("expected integer reply", count, err)
+	}
+
+	r = &redisBackend{&mockPool{&mockConn{replies: []interface{}{nil}}}}
+	var value string
+	if err := r.QueryRow("GET", "missing").Scan(&value); err != ErrNil {
+		t.Error("expected ErrNil", err)
+	}
+
+	r = &redisBackend{&mockPool{&mockConn{err: errors.New("fail")}}}
+	if err := r.QueryRow("GET", "key").Scan(&value); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	c := &mockConn{replies: []interface{}{"OK", redis.Error("WRONGTYPE"), int64(1)}}
+	r := &redisBackend{&mockPool{c}}
+	replies, err := r.Pipeline(NewRedisCommand("SET", "a", 1), NewRedisCommand("INCR", "list"), NewRedisCommand("DEL", "a"))
+	if err != redis.Error("WRONGTYPE") {
+		t.Error("expected the error reply to be returned", err)
+	}
+	if len(replies) != 3 || replies[0] != "OK" || replies[2] != int64(1) {
+		t.Error("expected all replies", replies)
+	}
+	if len(c.commands) != 3 || !c.flushed || !c.closed {
+		t.Error("expected commands sent in one flush", c.commands, c.flushed, c.closed)
+	}
+
+	if _, err := r.Pipeline(nil); err != errInvalidRedisExecType {
+		t.Error("expected invalid command error", err)
+	}
+}
+
+/***************************** MOCKS ****************************/
+type mockPool struct {
+	conn *mockConn
+}
+
+func (p *mockPool) Close() error {
+	return nil
+}
+
+func (p *mockPool) Get() redis.Conn {
+	return p.conn
+}
+
+type mockConn struct {
+	replies  []interface{}
+	err      error
+	commands [][]interface{}
+	flushed  bool
+	closed   bool
+}
+
+func (c *mockConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (c *mockConn) Err() error {
+	return nil
+}
+
+func (c *mockConn) Do(command string, args ...interface{}) (interface{}, error) {
+	c.Send(command, args...)
+	return c.Receive()
+}
+
+func (c *mockConn) Send(command string, args ...interface{}) error {
+	c.commands = append(c.commands, append([]interface{}{command}, args...))
+	return nil
+}
+
+func (c *mockConn) Flush() error {
+	c.flushed = true
+	return nil
+}
+
+func (c *mockConn) Receive() (interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+	if err, ok := reply.(redis.Error); ok {
+		return nil, err
+	}
+	return reply, nil
+}
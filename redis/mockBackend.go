@@ -1,7 +1,9 @@
 package redis
 
 import (
+	"context"
 	"errors"
+	"io"
 	"reflect"
 	"testing"
 
@@ -25,9 +27,10 @@ type Mocker interface {
 	VerifyNextCommand(t *testing.T, name string, expected ...interface{})
 }
 
-// NewMock is the constructor for a fake Redis connection
+// NewMock is the constructor for a fake Redis connection. Del returns delErr and SetWithExpire saveErr. Do and
+// each command of a Pipeline return doResult, and Do, Exec, Pipeline and Ping return doErr
 func NewMock(delErr, saveErr error, doResult interface{}, doErr error) Mocker {
-	return &redisMock{db: onedb.NewMock(nil, nil, doResult)}
+	return &redisMock{db: onedb.NewMock(nil, nil, doResult), DoResult: doResult, DoErr: doErr, DelErr: delErr, SetErr: saveErr}
 }
 
 func (r *redisMock) Close() error {
@@ -62,11 +65,73 @@ func (r *redisMock) Del(key string) error {
 	return r.DelErr
 }
 
+func (r *redisMock) Ping() error {
+	r.db.SaveMethodCall("Ping", nil)
+	return r.DoErr
+}
+
+func (r *redisMock) PingContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.Ping()
+}
+
 func (r *redisMock) Do(command string, args ...interface{}) (interface{}, error) {
 	r.db.SaveMethodCall("Do", append([]interface{}{command}, args...))
 	return r.DoResult, r.DoErr
 }
 
+func (r *redisMock) Exec(command string, args ...interface{}) error {
+	r.db.SaveMethodCall("Exec", append([]interface{}{command}, args...))
+	return r.DoErr
+}
+
+func (r *redisMock) Pipeline(commands ...*RedisCommand) ([]interface{}, error) {
+	r.db.SaveMethodCall("Pipeline", []interface{}{commands})
+	replies := make([]interface{}, len(commands))
+	for i := range replies {
+		replies[i] = r.DoResult
+	}
+	return replies, r.DoErr
+}
+
+func (r *redisMock) Query(command string, args ...interface{}) (onedb.RowsScanner, error) {
+	return r.db.Query(command, args...)
+}
+
+func (r *redisMock) QueryRow(command string, args ...interface{}) onedb.Scanner {
+	return r.db.QueryRow(command, args...)
+}
+
+func (r *redisMock) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return r.db.QueryValues(query, result...)
+}
+
+func (r *redisMock) QueryJSON(command string, args ...interface{}) (string, error) {
+	return r.db.QueryJSON(command, args...)
+}
+
+func (r *redisMock) QueryJSONRow(command string, args ...interface{}) (string, error) {
+	return r.db.QueryJSONRow(command, args...)
+}
+
+func (r *redisMock) QueryJSONWriter(w io.Writer, command string, args ...interface{}) error {
+	return r.db.QueryJSONWriter(w, command, args...)
+}
+
+func (r *redisMock) QueryStruct(result interface{}, command string, args ...interface{}) error {
+	return r.db.QueryStruct(result, command, args...)
+}
+
+func (r *redisMock) QueryStructRow(result interface{}, command string, args ...interface{}) error {
+	return r.db.QueryStructRow(result, command, args...)
+}
+
+func (r *redisMock) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, command string, args ...interface{}) error {
+	return r.db.QueryWriteCSV(w, options, command, args...)
+}
+
 func (r *redisMock) QueriesRun() []onedb.MethodsRun {
 	return r.db.QueriesRun()
 }
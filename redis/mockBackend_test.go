@@ -0,0 +1,34 @@
+package redis
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewMock(t *testing.T) {
+	delErr, saveErr, doErr := errors.New("del"), errors.New("save"), errors.New("do")
+	m := NewMock(delErr, saveErr, "OK", doErr)
+	if result, err := m.Do("GET", "key"); result != "OK" || err != doErr {
+		t.Error("expected Do to return the result and error", result, err)
+	}
+	if err := m.Exec("SET", "key", "value"); err != doErr {
+		t.Error("expected Exec to return the error", err)
+	}
+	if replies, err := m.Pipeline(NewRedisCommand("GET", "a"), NewRedisCommand("GET", "b")); len(replies) != 2 || replies[1] != "OK" || err != doErr {
+		t.Error("expected Pipeline to return a result per command", replies, err)
+	}
+	if err := m.Ping(); err != doErr {
+		t.Error("expected Ping to return the error", err)
+	}
+	if err := m.Del("key"); err != delErr {
+		t.Error("expected Del to return its error", err)
+	}
+	if err := m.SetWithExpire("key", "value", 10); err != saveErr {
+		t.Error("expected SetWithExpire to return its error", err)
+	}
+	m.VerifyNextCommand(t, "Do", "GET", "key")
+
+	if err := NewMock(nil, nil, nil, nil).Ping(); err != nil {
+		t.Error("expected no error by default", err)
+	}
+}
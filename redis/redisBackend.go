@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -51,9 +52,15 @@ type Rediser interface {
 	Close() error
 	Del(key string) error
 	Do(command string, args ...interface{}) (interface{}, error)
+	Exec(command string, args ...interface{}) error
 	Get(key string) (string, error)
 	GetStruct(key string, result interface{}) error
+	Pipeline(commands ...*RedisCommand) ([]interface{}, error)
+	Query(command string, args ...interface{}) (onedb.RowsScanner, error)
+	QueryRow(command string, args ...interface{}) onedb.Scanner
 	SetWithExpire(key string, value interface{}, expireSeconds int) error
+	onedb.Pinger
+	onedb.DBer
 }
 
 type pooler interface {
@@ -74,6 +81,29 @@ func (r *redisBackend) Close() error {
 	return r.pool.Close()
 }
 
+// Ping sends PING on a pooled connection
+func (r *redisBackend) Ping() error {
+	_, err := r.Do("PING")
+	return err
+}
+
+// PingContext is Ping, returning the context's error if ctx is done before the reply arrives
+func (r *redisBackend) PingContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Ping()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (r *redisBackend) Get(key string) (string, error) {
 	return redis.String(r.Do("GET", key))
 }
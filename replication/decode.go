@@ -0,0 +1,245 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// Decoder decodes the WAL data sent by a logical decoding output plugin into events
+type Decoder interface {
+	// Plugin is the output plugin the replication slot is created with
+	Plugin() string
+	// PluginArgs are the options passed to the plugin when replication starts
+	PluginArgs() []string
+	Decode(data []byte) ([]Event, error)
+}
+
+// Wal2JSON decodes the output of the wal2json plugin in its default format, which sends each transaction as a
+// single JSON document. Values have their JSON types, with integers as int64
+func Wal2JSON() Decoder {
+	return wal2json{}
+}
+
+type wal2json struct{}
+
+type wal2jsonChange struct {
+	Kind         string        `json:"kind"`
+	Schema       string        `json:"schema"`
+	Table        string        `json:"table"`
+	ColumnNames  []string      `json:"columnnames"`
+	ColumnValues []interface{} `json:"columnvalues"`
+	OldKeys      struct {
+		KeyNames  []string      `json:"keynames"`
+		KeyValues []interface{} `json:"keyvalues"`
+	} `json:"oldkeys"`
+}
+
+func (wal2json) Plugin() string {
+	return "wal2json"
+}
+
+func (wal2json) PluginArgs() []string {
+	return nil
+}
+
+func (wal2json) Decode(data []byte) ([]Event, error) {
+	var message struct {
+		Change []wal2jsonChange `json:"change"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&message); err != nil {
+		return nil, errors.Wrap(err, "Unable to decode wal2json message")
+	}
+	var events []Event
+	for _, change := range message.Change {
+		var op Operation
+		switch change.Kind {
+		case "insert":
+			op = Insert
+		case "update":
+			op = Update
+		case "delete":
+			op = Delete
+		default: // e.g. message
+			continue
+		}
+		events = append(events, Event{
+			Operation: op,
+			Schema:    change.Schema,
+			Table:     change.Table,
+			Values:    wal2jsonValues(change.ColumnNames, change.ColumnValues),
+			OldKeys:   wal2jsonValues(change.OldKeys.KeyNames, change.OldKeys.KeyValues),
+		})
+	}
+	return events, nil
+}
+
+func wal2jsonValues(names []string, values []interface{}) map[string]interface{} {
+	if len(names) == 0 {
+		return nil
+	}
+	row := make(map[string]interface{}, len(names))
+	for i, name := range names {
+		if i >= len(values) {
+			break
+		}
+		value := values[i]
+		if n, ok := value.(json.Number); ok {
+			if integer, err := n.Int64(); err == nil {
+				value = integer
+			} else {
+				value, _ = n.Float64()
+			}
+		}
+		row[name] = value
+	}
+	return row
+}
+
+// PgOutput decodes the output of pgoutput, the plugin built into PostgreSQL 10 and later, for changes to the
+// tables in publications. Values are the text representation PostgreSQL would return for them, such as "42"
+// or "t", and a column whose TOASTed value didn't change in an update is left out
+func PgOutput(publications ...string) Decoder {
+	return &pgOutput{publications: publications, relations: make(map[uint32]relation)}
+}
+
+type relation struct {
+	schema  string
+	table   string
+	columns []string
+}
+
+type pgOutput struct {
+	publications []string
+	relations    map[uint32]relation
+}
+
+func (d *pgOutput) Plugin() string {
+	return "pgoutput"
+}
+
+// PluginArgs quotes each publication name as an identifier, so names with commas, quotes or capitals are passed
+// as they are, and then the list as a string literal
+func (d *pgOutput) PluginArgs() []string {
+	names := make([]string, len(d.publications))
+	for i, publication := range d.publications {
+		names[i] = pgx.Identifier{publication}.Sanitize()
+	}
+	list := strings.Replace(strings.Join(names, ","), "'", "''", -1)
+	return []string{`("proto_version" '1', "publication_names" '` + list + `')`}
+}
+
+// pgOutputReader reads the fields of a pgoutput message
+type pgOutputReader struct {
+	data []byte
+	err  error
+}
+
+func (r *pgOutputReader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if len(r.data) < n {
+		r.err = errors.New("pgoutput message is too short")
+		return make([]byte, n)
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *pgOutputReader) byte() byte {
+	return r.next(1)[0]
+}
+
+func (r *pgOutputReader) uint16() uint16 {
+	return binary.BigEndian.Uint16(r.next(2))
+}
+
+func (r *pgOutputReader) uint32() uint32 {
+	return binary.BigEndian.Uint32(r.next(4))
+}
+
+func (r *pgOutputReader) string() string {
+	end := bytes.IndexByte(r.data, 0)
+	if end == -1 {
+		r.next(len(r.data) + 1) // fail
+		return ""
+	}
+	s := string(r.data[:end])
+	r.data = r.data[end+1:]
+	return s
+}
+
+// tuple reads TupleData into a row keyed by the relation's column names
+func (r *pgOutputReader) tuple(rel relation) map[string]interface{} {
+	n := int(r.uint16())
+	row := make(map[string]interface{}, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		name := ""
+		if i < len(rel.columns) {
+			name = rel.columns[i]
+		}
+		switch kind := r.byte(); kind {
+		case 'n':
+			row[name] = nil
+		case 'u': // unchanged TOAST value, which isn't sent
+		case 't':
+			row[name] = string(r.next(int(r.uint32())))
+		default:
+			r.err = errors.Errorf("unknown pgoutput tuple data type %q", kind)
+		}
+	}
+	return row
+}
+
+func (d *pgOutput) Decode(data []byte) ([]Event, error) {
+	r := &pgOutputReader{data: data}
+	var event Event
+	switch kind := r.byte(); kind {
+	case 'R':
+		id := r.uint32()
+		rel := relation{schema: r.string(), table: r.string()}
+		r.byte() // replica identity
+		n := int(r.uint16())
+		for i := 0; i < n && r.err == nil; i++ {
+			r.byte() // flags
+			rel.columns = append(rel.columns, r.string())
+			r.next(8) // type oid and modifier
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		d.relations[id] = rel
+		return nil, nil
+	case 'I', 'U', 'D':
+		id := r.uint32()
+		rel, ok := d.relations[id]
+		if !ok {
+			return nil, errors.Errorf("pgoutput change for unknown relation %d", id)
+		}
+		event = Event{Operation: map[byte]Operation{'I': Insert, 'U': Update, 'D': Delete}[kind], Schema: rel.schema, Table: rel.table}
+		tupleType := r.byte()
+		if tupleType == 'K' || tupleType == 'O' { // the key or the whole old row
+			event.OldKeys = r.tuple(rel)
+			if kind == 'U' {
+				tupleType = r.byte()
+			}
+		}
+		if tupleType == 'N' {
+			event.Values = r.tuple(rel)
+		}
+	default: // begin, commit, origin, type and truncate messages
+		return nil, nil
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []Event{event}, nil
+}
@@ -0,0 +1,201 @@
+// Package replication streams changes from PostgreSQL using logical replication, so services can react to
+// inserts, updates and deletes without polling. Changes are decoded by an output plugin on the server, either
+// pgoutput (built in) or wal2json, and delivered as Events on a channel
+package replication
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	onedbpgx "github.com/EndFirstCorp/onedb/pgx"
+	"github.com/pkg/errors"
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+// pollInterval is how long WaitForReplicationMessage blocks before the context is checked again
+var pollInterval = time.Second
+
+// Operation is the kind of change an Event describes
+type Operation string
+
+// Operations
+const (
+	Insert Operation = "insert"
+	Update Operation = "update"
+	Delete Operation = "delete"
+)
+
+// Event is a change to a row
+type Event struct {
+	Operation Operation
+	Schema    string
+	Table     string
+	Values    map[string]interface{} // the new row, for inserts and updates
+	OldKeys   map[string]interface{} // the replica identity of the old row, for updates which change it and deletes
+	LSN       uint64                 // pass to Ack once the event is handled
+}
+
+// Config configures a replication Subscription
+type Config struct {
+	onedbpgx.ConnConfig
+	Slot       string // the replication slot, which keeps the server's position in the WAL between connections
+	CreateSlot bool   // create Slot if it doesn't exist
+	Decoder    Decoder
+	StartLSN   uint64 // 0 starts from the slot's confirmed position
+
+	// StatusInterval is how often the acknowledged position is reported to the server. Defaults to 10 seconds
+	StatusInterval time.Duration
+}
+
+// replicationConn is the part of *pgx.ReplicationConn a Subscription uses
+type replicationConn interface {
+	WaitForReplicationMessage(timeout time.Duration) (*pgx.ReplicationMessage, error)
+	SendStandbyStatus(status *pgx.StandbyStatus) error
+	Close() error
+}
+
+// Subscription delivers the changes streamed from a replication slot
+type Subscription struct {
+	conn     replicationConn
+	decoder  Decoder
+	interval time.Duration
+	events   chan Event
+	acked    uint64
+	done     chan struct{}
+	mu       sync.Mutex
+	err      error
+}
+
+// Listen connects with the replication protocol and streams changes from config.Slot until ctx is done. The server
+// keeps the WAL for changes which haven't been acknowledged with Ack, so unacknowledged changes are sent again
+// after a reconnect and each event must be handled idempotently
+func Listen(ctx context.Context, config Config) (*Subscription, error) {
+	if config.Decoder == nil || config.Slot == "" {
+		return nil, errors.New("replication needs a Slot and a Decoder")
+	}
+	conn, err := pgx.ReplicationConnect(pgx.ConnConfig(config.ConnConfig))
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to open replication connection")
+	}
+	if config.CreateSlot {
+		if err := conn.CreateReplicationSlot(config.Slot, config.Decoder.Plugin()); err != nil && !isDuplicateObject(err) {
+			conn.Close()
+			return nil, errors.Wrap(err, "Unable to create replication slot")
+		}
+	}
+	if err := conn.StartReplication(config.Slot, config.StartLSN, -1, config.Decoder.PluginArgs()...); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "Unable to start replication")
+	}
+	return newSubscription(ctx, conn, config), nil
+}
+
+func isDuplicateObject(err error) bool {
+	pgErr, ok := err.(pgx.PgError)
+	return ok && pgErr.Code == "42710"
+}
+
+func newSubscription(ctx context.Context, conn replicationConn, config Config) *Subscription {
+	interval := config.StatusInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	s := &Subscription{conn: conn, decoder: config.Decoder, interval: interval, acked: config.StartLSN,
+		events: make(chan Event), done: make(chan struct{})}
+	go s.run(ctx)
+	return s
+}
+
+// Events returns the channel changes are delivered on. It is closed when the context is done or the stream fails
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Ack acknowledges every change up to and including lsn, so the server can discard their WAL
+func (s *Subscription) Ack(lsn uint64) {
+	for {
+		acked := atomic.LoadUint64(&s.acked)
+		if lsn <= acked || atomic.CompareAndSwapUint64(&s.acked, acked, lsn) {
+			return
+		}
+	}
+}
+
+// Err returns the error which ended the stream, once Events is closed
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Done is closed once the stream has ended and the connection is closed
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscription) run(ctx context.Context) {
+	err := s.stream(ctx)
+	s.sendStatus() // report the final position before closing
+	s.conn.Close()
+	if err != nil && ctx.Err() == nil {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+	}
+	close(s.events)
+	close(s.done)
+}
+
+func (s *Subscription) stream(ctx context.Context) error {
+	lastStatus := time.Now()
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(lastStatus) >= s.interval {
+			if err := s.sendStatus(); err != nil {
+				return err
+			}
+			lastStatus = time.Now()
+		}
+		message, err := s.conn.WaitForReplicationMessage(pollInterval)
+		if err == pgx.ErrNotificationTimeout {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if heartbeat := message.ServerHeartbeat; heartbeat != nil && heartbeat.ReplyRequested == 1 {
+			if err := s.sendStatus(); err != nil {
+				return err
+			}
+			lastStatus = time.Now()
+		}
+		wal := message.WalMessage
+		if wal == nil {
+			continue
+		}
+		events, err := s.decoder.Decode(wal.WalData)
+		if err != nil {
+			return errors.Wrapf(err, "Unable to decode WAL at %s", pgx.FormatLSN(wal.WalStart))
+		}
+		for _, event := range events {
+			event.LSN = wal.WalStart
+			select {
+			case s.events <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func (s *Subscription) sendStatus() error {
+	status, err := pgx.NewStandbyStatus(atomic.LoadUint64(&s.acked))
+	if err != nil {
+		return err
+	}
+	return s.conn.SendStandbyStatus(status)
+}
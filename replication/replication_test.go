@@ -0,0 +1,158 @@
+package replication
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	pgx "gopkg.in/jackc/pgx.v2"
+)
+
+func TestWal2JSON(t *testing.T) {
+	events, err := Wal2JSON().Decode([]byte(`{"xid": 1, "change": [
+		{"kind": "insert", "schema": "public", "table": "users", "columnnames": ["id", "name", "score"], "columnvalues": [1, "alice", 1.5]},
+		{"kind": "delete", "schema": "public", "table": "users", "oldkeys": {"keynames": ["id"], "keyvalues": [2]}},
+		{"kind": "message", "prefix": "note"}
+	]}`))
+	if err != nil || len(events) != 2 {
+		t.Fatal("expected insert and delete events", events, err)
+	}
+	if !reflect.DeepEqual(events[0], Event{Operation: Insert, Schema: "public", Table: "users", Values: map[string]interface{}{"id": int64(1), "name": "alice", "score": 1.5}}) {
+		t.Error("expected typed insert values", events[0])
+	}
+	if events[1].Operation != Delete || events[1].OldKeys["id"] != int64(2) || events[1].Values != nil {
+		t.Error("expected delete keys", events[1])
+	}
+	if _, err := Wal2JSON().Decode([]byte("{")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+// pgOutputMessage builds a pgoutput message from bytes, strings (null terminated), uint16s and uint32s
+func pgOutputMessage(fields ...interface{}) []byte {
+	var b []byte
+	for _, f := range fields {
+		switch v := f.(type) {
+		case byte:
+			b = append(b, v)
+		case string:
+			b = append(append(b, v...), 0)
+		case uint16:
+			b = binary.BigEndian.AppendUint16(b, v)
+		case uint32:
+			b = binary.BigEndian.AppendUint32(b, v)
+		case []byte:
+			b = append(b, v...)
+		}
+	}
+	return b
+}
+
+func TestPgOutput(t *testing.T) {
+	d := PgOutput("pub")
+	if args := d.PluginArgs(); len(args) != 1 || args[0] != `("proto_version" '1', "publication_names" '"pub"')` {
+		t.Error("expected plugin arguments", args)
+	}
+	if args := PgOutput("Orders", `a"b`, "it's,x").PluginArgs(); args[0] != `("proto_version" '1', "publication_names" '"Orders","a""b","it''s,x"')` {
+		t.Error("expected quoted publication names", args)
+	}
+	relation := pgOutputMessage(byte('R'), uint32(16385), "public", "users", byte('d'), uint16(2),
+		byte(1), "id", uint32(23), uint32(0xffffffff), byte(0), "name", uint32(25), uint32(0xffffffff))
+	if events, err := d.Decode(relation); err != nil || events != nil {
+		t.Fatal("expected relation to be cached without events", events, err)
+	}
+	if events, err := d.Decode(pgOutputMessage(byte('B'), make([]byte, 20))); err != nil || events != nil {
+		t.Error("expected begin to be skipped", events, err)
+	}
+
+	insert := pgOutputMessage(byte('I'), uint32(16385), byte('N'), uint16(2), byte('t'), uint32(1), []byte("1"), byte('n'))
+	events, err := d.Decode(insert)
+	if err != nil || len(events) != 1 || !reflect.DeepEqual(events[0].Values, map[string]interface{}{"id": "1", "name": nil}) {
+		t.Error("expected insert with text values", events, err)
+	}
+
+	update := pgOutputMessage(byte('U'), uint32(16385), byte('K'), uint16(2), byte('t'), uint32(1), []byte("1"), byte('n'),
+		byte('N'), uint16(2), byte('t'), uint32(1), []byte("2"), byte('u'))
+	events, err = d.Decode(update)
+	if err != nil || events[0].Operation != Update || events[0].OldKeys["id"] != "1" || !reflect.DeepEqual(events[0].Values, map[string]interface{}{"id": "2"}) {
+		t.Error("expected update with old keys and without unchanged TOAST columns", events, err)
+	}
+
+	if _, err := d.Decode(pgOutputMessage(byte('D'), uint32(1), byte('K'))); err == nil {
+		t.Error("expected error for an unknown relation")
+	}
+	if _, err := d.Decode(insert[:12]); err == nil {
+		t.Error("expected error for a truncated message")
+	}
+}
+
+/***************************** MOCKS ****************************/
+
+type fakeConn struct {
+	mu       sync.Mutex
+	messages []*pgx.ReplicationMessage
+	statuses []uint64
+	err      error
+	closed   bool
+}
+
+func (c *fakeConn) WaitForReplicationMessage(timeout time.Duration) (*pgx.ReplicationMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.messages) == 0 {
+		if c.err != nil {
+			return nil, c.err
+		}
+		time.Sleep(time.Millisecond)
+		return nil, pgx.ErrNotificationTimeout
+	}
+	m := c.messages[0]
+	c.messages = c.messages[1:]
+	return m, nil
+}
+
+func (c *fakeConn) SendStandbyStatus(status *pgx.StandbyStatus) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statuses = append(c.statuses, status.WalFlushPosition)
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func TestSubscription(t *testing.T) {
+	conn := &fakeConn{messages: []*pgx.ReplicationMessage{
+		{ServerHeartbeat: &pgx.ServerHeartbeat{ReplyRequested: 1}},
+		{WalMessage: &pgx.WalMessage{WalStart: 100, WalData: []byte(`{"change": [{"kind": "insert", "table": "users", "columnnames": ["id"], "columnvalues": [1]}]}`)}},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newSubscription(ctx, conn, Config{Decoder: Wal2JSON(), StatusInterval: time.Hour})
+	event := <-s.Events()
+	if event.Table != "users" || event.LSN != 100 {
+		t.Error("expected event with the WAL position", event)
+	}
+	s.Ack(event.LSN)
+	s.Ack(50) // an earlier position doesn't move the acknowledgement back
+	cancel()
+	<-s.Done()
+	if s.Err() != nil || !conn.closed || !reflect.DeepEqual(conn.statuses, []uint64{0, 100}) {
+		t.Error("expected replies to heartbeats and the acknowledged position to be reported on close", s.Err(), conn.statuses)
+	}
+
+	fail := errors.New("fail")
+	s = newSubscription(context.Background(), &fakeConn{err: fail}, Config{Decoder: Wal2JSON()})
+	for range s.Events() {
+	}
+	if s.Err() != fail {
+		t.Error("expected the stream error", s.Err())
+	}
+}
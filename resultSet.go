@@ -0,0 +1,95 @@
+package onedb
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ResultSet is the columns and values of a query's rows read into memory, so they can be replayed any number of
+// times, cached or serialized
+type ResultSet struct {
+	Columns []string
+	Values  [][]interface{}
+}
+
+// ReadResultSet reads and closes rows. Bytes are copied since drivers may reuse them for the next row
+func ReadResultSet(rows RowsScanner) (*ResultSet, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &ResultSet{Columns: columns}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = append([]byte(nil), b...)
+			}
+		}
+		result.Values = append(result.Values, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Rows returns a RowsScanner over the result. Each call returns a new RowsScanner starting at the first row and
+// the result isn't changed by scanning it, so it can be shared
+func (r *ResultSet) Rows() RowsScanner {
+	return &resultSetRows{result: r}
+}
+
+type resultSetRows struct {
+	result *ResultSet
+	pos    int
+	closed bool
+}
+
+func (r *resultSetRows) Columns() ([]string, error) {
+	return r.result.Columns, nil
+}
+
+func (r *resultSetRows) Next() bool {
+	if r.closed || r.pos >= len(r.result.Values) {
+		r.closed = true
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *resultSetRows) Scan(dest ...interface{}) error {
+	if r.closed || r.pos == 0 {
+		return errors.New("Scan called without calling Next")
+	}
+	values := r.result.Values[r.pos-1]
+	if len(dest) != len(values) {
+		return errors.Errorf("expected %d destination arguments in Scan, not %d", len(values), len(dest))
+	}
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			v = append([]byte(nil), b...)
+		}
+		if err := ScanValue(v, dest[i]); err != nil {
+			return errors.Wrapf(err, "Unable to scan column %s", r.result.Columns[i])
+		}
+	}
+	return nil
+}
+
+func (r *resultSetRows) Err() error {
+	return nil
+}
+
+func (r *resultSetRows) Close() error {
+	r.closed = true
+	return nil
+}
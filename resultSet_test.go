@@ -0,0 +1,26 @@
+package onedb
+
+import "testing"
+
+func TestResultSetRows(t *testing.T) {
+	result := &ResultSet{Columns: []string{"id", "data"}, Values: [][]interface{}{{int64(1), []byte("abc")}}}
+	rows := result.Rows()
+	var id int
+	var data []byte
+	if rows.Scan(&id, &data) == nil {
+		t.Error("expected error before Next")
+	}
+	if !rows.Next() || rows.Scan(&id) == nil {
+		t.Error("expected error for the wrong number of destinations")
+	}
+	if err := rows.Scan(&id, &data); err != nil || id != 1 || string(data) != "abc" {
+		t.Error("expected converted values", id, data, err)
+	}
+	data[0] = 'x'
+	if string(result.Values[0][1].([]byte)) != "abc" {
+		t.Error("expected scanned bytes to be a copy")
+	}
+	if rows.Next() || rows.Err() != nil || rows.Close() != nil {
+		t.Error("expected end of rows")
+	}
+}
@@ -0,0 +1,120 @@
+package onedb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy decides how operations which fail with a retryable error, such as a dead connection, are retried
+type RetryPolicy interface {
+	// Backoff returns how long to wait before retry attempt (starting at 1) given the time elapsed since the
+	// operation first failed. It returns false when no more attempts should be made
+	Backoff(attempt int, elapsed time.Duration) (time.Duration, bool)
+}
+
+// RetryPolicyFunc is an adapter to allow the use of an ordinary function as a RetryPolicy
+type RetryPolicyFunc func(attempt int, elapsed time.Duration) (time.Duration, bool)
+
+// Backoff calls f(attempt, elapsed)
+func (f RetryPolicyFunc) Backoff(attempt int, elapsed time.Duration) (time.Duration, bool) {
+	return f(attempt, elapsed)
+}
+
+// ExponentialBackoff is a RetryPolicy which waits InitialInterval before the first retry and multiplies the
+// wait by Multiplier for each retry after that. A zero MaxInterval or MaxElapsedTime means no limit, and so does
+// a zero MaxAttempts when MaxElapsedTime is set. The zero value retries 4 times, waiting 100, 200, 400 and 800
+// milliseconds
+type ExponentialBackoff struct {
+	MaxAttempts     int           // defaults to 4 unless MaxElapsedTime is set
+	InitialInterval time.Duration // defaults to 100 milliseconds
+	Multiplier      float64       // defaults to 2
+	MaxInterval     time.Duration
+	Jitter          float64 // randomize each wait by up to this fraction of it, between 0 and 1
+	MaxElapsedTime  time.Duration
+}
+
+const (
+	defaultBackoffAttempts = 4
+	defaultBackoffInterval = 100 * time.Millisecond
+)
+
+// DefaultRetryPolicy retries up to 4 times, waiting 1 millisecond before the first retry and 10 times longer
+// before each one after that
+var DefaultRetryPolicy RetryPolicy = &ExponentialBackoff{MaxAttempts: 4, InitialInterval: time.Millisecond, Multiplier: 10}
+
+// NoRetry is a RetryPolicy which never retries
+var NoRetry RetryPolicy = RetryPolicyFunc(func(int, time.Duration) (time.Duration, bool) { return 0, false })
+
+// Backoff returns the wait before the given attempt
+func (e *ExponentialBackoff) Backoff(attempt int, elapsed time.Duration) (time.Duration, bool) {
+	maxAttempts := e.MaxAttempts
+	if maxAttempts <= 0 && e.MaxElapsedTime <= 0 {
+		maxAttempts = defaultBackoffAttempts
+	}
+	if maxAttempts > 0 && attempt > maxAttempts {
+		return 0, false
+	}
+	initial := e.InitialInterval
+	if initial <= 0 {
+		initial = defaultBackoffInterval
+	}
+	multiplier := e.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+	wait := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if e.MaxInterval > 0 && wait > float64(e.MaxInterval) {
+		wait = float64(e.MaxInterval)
+	}
+	if e.Jitter > 0 {
+		wait += wait * e.Jitter * (2*rand.Float64() - 1)
+	}
+	delay := time.Duration(wait)
+	if e.MaxElapsedTime > 0 && elapsed+delay > e.MaxElapsedTime {
+		return 0, false
+	}
+	return delay, true
+}
+
+// Retry runs fn and, while it fails with an error retryable reports true for, runs it again as allowed by
+// policy, waiting the policy's backoff before each attempt. Waiting stops early when ctx is done. The error of
+// the last attempt is returned
+func Retry(ctx context.Context, policy RetryPolicy, retryable func(err error) bool, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	for attempt := 1; err != nil && retryable(err); attempt++ {
+		delay, ok := policy.Backoff(attempt, time.Since(start))
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		err = fn()
+	}
+	return err
+}
+
+// DeadConnPredicate reports whether err means the connection is no longer usable, so the operation should be
+// retried on a new connection
+type DeadConnPredicate func(err error) bool
+
+// IsEOF matches errors caused by the server closing the connection
+func IsEOF(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsBrokenPipe matches errors caused by writing to a connection the server has closed
+func IsBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || err != nil && strings.HasSuffix(err.Error(), "broken pipe")
+}
@@ -0,0 +1,90 @@
+package onedb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	e := &ExponentialBackoff{MaxAttempts: 3, InitialInterval: time.Millisecond, Multiplier: 10, MaxInterval: 50 * time.Millisecond}
+	expected := []time.Duration{time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond}
+	for i, wait := range expected {
+		if delay, ok := e.Backoff(i+1, 0); !ok || delay != wait {
+			t.Error("expected backoff", i+1, wait, delay, ok)
+		}
+	}
+	if _, ok := e.Backoff(4, 0); ok {
+		t.Error("expected max attempts to stop retries")
+	}
+
+	e = &ExponentialBackoff{InitialInterval: time.Second, MaxElapsedTime: 2 * time.Second}
+	if delay, ok := e.Backoff(1, 0); !ok || delay != time.Second {
+		t.Error("expected default multiplier", delay, ok)
+	}
+	if _, ok := e.Backoff(2, time.Second); ok {
+		t.Error("expected max elapsed time to stop retries")
+	}
+
+	e = &ExponentialBackoff{}
+	for i, wait := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond} {
+		if delay, ok := e.Backoff(i+1, 0); !ok || delay != wait {
+			t.Error("expected default backoff", i+1, wait, delay, ok)
+		}
+	}
+	if _, ok := e.Backoff(5, 0); ok {
+		t.Error("expected the zero value to stop after 4 retries")
+	}
+	e = &ExponentialBackoff{MaxElapsedTime: time.Minute}
+	if _, ok := e.Backoff(5, 0); !ok {
+		t.Error("expected MaxElapsedTime alone to limit retries")
+	}
+
+	e = &ExponentialBackoff{InitialInterval: 100 * time.Millisecond, Jitter: 0.5}
+	for i := 0; i < 10; i++ {
+		if delay, _ := e.Backoff(1, 0); delay < 50*time.Millisecond || delay > 150*time.Millisecond {
+			t.Error("expected jitter within range", delay)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	fail := errors.New("fail")
+	retryable := func(err error) bool { return err == io.EOF }
+	policy := RetryPolicyFunc(func(attempt int, elapsed time.Duration) (time.Duration, bool) { return 0, attempt <= 2 })
+	if err := Retry(context.Background(), policy, retryable, func() error { calls++; return io.EOF }); err != io.EOF || calls != 3 {
+		t.Error("expected retries until the policy gives up", err, calls)
+	}
+
+	calls = 0
+	if err := Retry(context.Background(), policy, retryable, func() error { calls++; return fail }); err != fail || calls != 1 {
+		t.Error("expected only retryable errors to be retried", err, calls)
+	}
+
+	calls = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	wait := RetryPolicyFunc(func(int, time.Duration) (time.Duration, bool) { return time.Hour, true })
+	if err := Retry(ctx, wait, retryable, func() error { calls++; return io.EOF }); err != io.EOF || calls != 1 {
+		t.Error("expected waiting to stop when context is done", err, calls)
+	}
+}
+
+func TestIsEOF(t *testing.T) {
+	if !IsEOF(io.EOF) || !IsEOF(io.ErrUnexpectedEOF) || IsEOF(errors.New("fail")) {
+		t.Error("expected only EOF errors to match")
+	}
+}
+
+func TestIsBrokenPipe(t *testing.T) {
+	opErr := &net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)}
+	if !IsBrokenPipe(opErr) || !IsBrokenPipe(errors.New("write tcp: broken pipe")) || IsBrokenPipe(errors.New("fail")) || IsBrokenPipe(nil) {
+		t.Error("expected only broken pipe errors to match")
+	}
+}
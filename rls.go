@@ -0,0 +1,61 @@
+package onedb
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SetLocalRole switches tx's current role to role with SET LOCAL ROLE, so the row-level security policies for
+// role apply to the statements run after it. The role is reset when tx commits or rolls back, so it never leaks
+// to the next user of a pooled connection, including behind PgBouncer in transaction pooling mode. In a nested
+// transaction it lasts until the outermost one ends, unless the nested one is rolled back
+func SetLocalRole(tx Backender, role string) error {
+	if role == "" || strings.IndexByte(role, 0) >= 0 {
+		return errors.Errorf("invalid role %q", role)
+	}
+	return errors.Wrapf(Exec(tx, "set local role "+quoteIdentifier(role)), "Unable to set role %s", role)
+}
+
+// SetLocal sets the configuration parameter name, e.g. app.current_user, to value until tx ends, like SET LOCAL.
+// Row-level security policies can read it with current_setting('app.current_user'). The value is passed as an
+// argument rather than written into the statement, so it can come from untrusted input
+func SetLocal(tx Backender, name, value string) error {
+	if name == "" {
+		return errors.New("a configuration parameter name is required")
+	}
+	return errors.Wrapf(Exec(tx, "select set_config($1, $2, true)", name, value), "Unable to set %s", name)
+}
+
+// WithRole runs fn in a transaction begun on db with role as the current role, committing like WithTx. See
+// SetLocalRole
+func WithRole[T interface {
+	Txer
+	Backender
+}](db TxBeginner[T], role string, fn func(tx T) error) error {
+	return WithTx(db, func(tx T) error {
+		if err := SetLocalRole(tx, role); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// WithSessionVariable runs fn in a transaction begun on db with the configuration parameter name set to value,
+// committing like WithTx. Call SetLocalRole or SetLocal in fn to set more. See SetLocal
+func WithSessionVariable[T interface {
+	Txer
+	Backender
+}](db TxBeginner[T], name, value string, fn func(tx T) error) error {
+	return WithTx(db, func(tx T) error {
+		if err := SetLocal(tx, name, value); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// quoteIdentifier double quotes name so it can be used as an identifier in a statement
+func quoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,58 @@
+package onedb
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestWithRole(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.ExpectBegin()
+	m.ExpectQuery(`set local role "tenant ""a"""`)
+	m.ExpectQuery("select set_config($1, $2, true)").WithArgs("app.current_user", "42")
+	m.ExpectQuery("select * from invoices")
+	m.ExpectCommit()
+	err := WithRole[MockTxer](m, `tenant "a"`, func(tx MockTxer) error {
+		if err := SetLocal(tx, "app.current_user", "42"); err != nil {
+			return err
+		}
+		rows, err := tx.Query("select * from invoices")
+		if err == nil {
+			rows.Close()
+		}
+		return err
+	})
+	if err != nil {
+		t.Error("expected success", err)
+	}
+	m.VerifyExpectations(t)
+
+	called := false
+	if err := WithRole[MockTxer](NewMock(nil, nil), "", func(tx MockTxer) error {
+		called = true
+		return nil
+	}); err == nil || called {
+		t.Error("expected an empty role to be refused before fn runs", err)
+	}
+}
+
+func TestWithSessionVariable(t *testing.T) {
+	fail := errors.New("fail")
+	m := NewMock(nil, nil)
+	m.ExpectBegin()
+	m.ExpectQuery("select set_config($1, $2, true)").WithArgs("app.tenant_id", "acme").WillReturnError(fail)
+	m.ExpectRollback()
+	err := WithSessionVariable[MockTxer](m, "app.tenant_id", "acme", func(tx MockTxer) error {
+		t.Error("expected fn not to run")
+		return nil
+	})
+	if errors.Cause(err) != fail || err.Error() != "Unable to set app.tenant_id: fail" {
+		t.Error("expected the set_config error and a rollback", err)
+	}
+	m.VerifyExpectations(t)
+
+	if err := SetLocal(m, "", "value"); err == nil {
+		t.Error("expected a name to be required")
+	}
+}
@@ -0,0 +1,491 @@
+package onedb
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/gob"
+	"io"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EncodeGob reads rows and writes their columns and then each row to w with encoding/gob, to cache results or
+// hand them to another process. DecodeGob reads them back. Values must be nil, bool, integers, floats, string,
+// []byte, time.Time, a driver.Valuer of one of them or a pointer to one
+func EncodeGob(w io.Writer, rows RowsScanner) error {
+	enc := gob.NewEncoder(w)
+	return encodeRows(rows, func(columns []string) error {
+		return enc.Encode(columns)
+	}, func(values []interface{}) error {
+		row := make([]gobValue, len(values))
+		for i, v := range values {
+			row[i] = newGobValue(v)
+		}
+		return enc.Encode(row)
+	})
+}
+
+// DecodeGob reads a result written by EncodeGob. Integers are read as int64, or uint64 when they were unsigned
+// and too big for an int64, and floats as float64
+func DecodeGob(r io.Reader) (*ResultSet, error) {
+	dec := gob.NewDecoder(r)
+	result := &ResultSet{}
+	if err := dec.Decode(&result.Columns); err != nil {
+		return nil, errors.Wrap(err, "Unable to decode columns")
+	}
+	for {
+		var row []gobValue
+		if err := dec.Decode(&row); err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "Unable to decode row %d", len(result.Values)+1)
+		}
+		if len(row) != len(result.Columns) {
+			return nil, errors.Errorf("row %d has %d values for %d columns", len(result.Values)+1, len(row), len(result.Columns))
+		}
+		values := make([]interface{}, len(row))
+		for i, v := range row {
+			values[i] = v.value()
+		}
+		result.Values = append(result.Values, values)
+	}
+}
+
+// EncodeMsgpack reads rows and writes them to w as MessagePack: an array of the column names followed by an
+// array of values for each row. Times use the timestamp extension type and are read back in UTC. Values must be
+// the types EncodeGob takes
+func EncodeMsgpack(w io.Writer, rows RowsScanner) error {
+	bw := bufio.NewWriter(w)
+	err := encodeRows(rows, func(columns []string) error {
+		values := make([]interface{}, len(columns))
+		for i, c := range columns {
+			values[i] = c
+		}
+		return writeMsgpackArray(bw, values)
+	}, func(values []interface{}) error {
+		return writeMsgpackArray(bw, values)
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// DecodeMsgpack reads a result written by EncodeMsgpack
+func DecodeMsgpack(r io.Reader) (*ResultSet, error) {
+	br := bufio.NewReader(r)
+	header, err := readMsgpack(br)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to decode columns")
+	}
+	names, ok := header.([]interface{})
+	if !ok {
+		return nil, errors.New("expected an array of column names")
+	}
+	result := &ResultSet{Columns: make([]string, len(names))}
+	for i, name := range names {
+		if result.Columns[i], ok = name.(string); !ok {
+			return nil, errors.Errorf("expected column name %d to be a string, not %T", i+1, name)
+		}
+	}
+	for {
+		if _, err := br.Peek(1); err == io.EOF {
+			return result, nil
+		}
+		v, err := readMsgpack(br)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unable to decode row %d", len(result.Values)+1)
+		}
+		values, ok := v.([]interface{})
+		if !ok || len(values) != len(result.Columns) {
+			return nil, errors.Errorf("row %d isn't an array of %d values", len(result.Values)+1, len(result.Columns))
+		}
+		result.Values = append(result.Values, values)
+	}
+}
+
+// encodeRows reads and closes rows, calling header with the columns and then row with each row's values
+// normalized by encodableValue
+func encodeRows(rows RowsScanner, header func(columns []string) error, row func(values []interface{}) error) error {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if err := header(columns); err != nil {
+		return err
+	}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, v := range values {
+			if values[i], err = encodableValue(v); err != nil {
+				return errors.Wrapf(err, "Unable to encode column %s", columns[i])
+			}
+		}
+		if err := row(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// encodableValue dereferences pointers and converts v to nil, bool, int64, uint64, float64, string, []byte or time.Time
+func encodableValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case nil, bool, int64, float64, string, []byte, time.Time:
+		return v, nil
+	case int:
+		return int64(t), nil
+	case int8:
+		return int64(t), nil
+	case int16:
+		return int64(t), nil
+	case int32:
+		return int64(t), nil
+	case uint:
+		return encodableValue(uint64(t))
+	case uint8:
+		return int64(t), nil
+	case uint16:
+		return int64(t), nil
+	case uint32:
+		return int64(t), nil
+	case uint64:
+		if t > math.MaxInt64 {
+			return t, nil
+		}
+		return int64(t), nil
+	case float32:
+		return float64(t), nil
+	case driver.Valuer:
+		value, err := t.Value()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := value.(driver.Valuer); ok {
+			return nil, errors.Errorf("%T's value is a driver.Valuer", v)
+		}
+		return encodableValue(value)
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return encodableValue(rv.Elem().Interface())
+	}
+	return nil, errors.Errorf("unable to encode %T", v)
+}
+
+const (
+	gobNil uint8 = iota
+	gobBool
+	gobInt
+	gobUint
+	gobFloat
+	gobString
+	gobBytes
+	gobTime
+)
+
+// gobValue holds a value of any of the types encodableValue returns, since gob needs types registered to encode
+// them in an interface{}
+type gobValue struct {
+	Kind   uint8
+	Int    int64
+	Uint   uint64
+	Float  float64
+	String string
+	Bytes  []byte
+	Time   time.Time
+}
+
+func newGobValue(v interface{}) gobValue {
+	switch t := v.(type) {
+	case bool:
+		if t {
+			return gobValue{Kind: gobBool, Int: 1}
+		}
+		return gobValue{Kind: gobBool}
+	case int64:
+		return gobValue{Kind: gobInt, Int: t}
+	case uint64:
+		return gobValue{Kind: gobUint, Uint: t}
+	case float64:
+		return gobValue{Kind: gobFloat, Float: t}
+	case string:
+		return gobValue{Kind: gobString, String: t}
+	case []byte:
+		return gobValue{Kind: gobBytes, Bytes: t}
+	case time.Time:
+		return gobValue{Kind: gobTime, Time: t}
+	}
+	return gobValue{Kind: gobNil}
+}
+
+func (v gobValue) value() interface{} {
+	switch v.Kind {
+	case gobBool:
+		return v.Int == 1
+	case gobInt:
+		return v.Int
+	case gobUint:
+		return v.Uint
+	case gobFloat:
+		return v.Float
+	case gobString:
+		return v.String
+	case gobBytes:
+		if v.Bytes == nil { // gob doesn't send empty slices
+			return []byte{}
+		}
+		return v.Bytes
+	case gobTime:
+		return v.Time
+	}
+	return nil
+}
+
+// msgpackTimestamp is the MessagePack extension type of timestamps
+const msgpackTimestamp = -1
+
+func writeMsgpackArray(w *bufio.Writer, values []interface{}) error {
+	n := len(values)
+	switch {
+	case n < 16:
+		w.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		w.WriteByte(0xdc)
+		writeUint(w, uint64(n), 2)
+	default:
+		w.WriteByte(0xdd)
+		writeUint(w, uint64(n), 4)
+	}
+	for _, v := range values {
+		if err := writeMsgpack(w, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMsgpack(w *bufio.Writer, v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		return w.WriteByte(0xc0)
+	case bool:
+		if t {
+			return w.WriteByte(0xc3)
+		}
+		return w.WriteByte(0xc2)
+	case int64:
+		switch {
+		case t >= 0 && t <= 127, t >= -32 && t < 0:
+			return w.WriteByte(byte(t))
+		case t >= math.MinInt8 && t <= math.MaxInt8:
+			w.WriteByte(0xd0)
+			return writeUint(w, uint64(t), 1)
+		case t >= math.MinInt16 && t <= math.MaxInt16:
+			w.WriteByte(0xd1)
+			return writeUint(w, uint64(t), 2)
+		case t >= math.MinInt32 && t <= math.MaxInt32:
+			w.WriteByte(0xd2)
+			return writeUint(w, uint64(t), 4)
+		}
+		w.WriteByte(0xd3)
+		return writeUint(w, uint64(t), 8)
+	case uint64:
+		w.WriteByte(0xcf)
+		return writeUint(w, t, 8)
+	case float64:
+		w.WriteByte(0xcb)
+		return writeUint(w, math.Float64bits(t), 8)
+	case string:
+		writeMsgpackLength(w, len(t), 0xa0, 32, 0xd9)
+		_, err := w.WriteString(t)
+		return err
+	case []byte:
+		writeMsgpackLength(w, len(t), 0, 0, 0xc4)
+		_, err := w.Write(t)
+		return err
+	case time.Time:
+		w.Write([]byte{0xc7, 12, byte(msgpackTimestamp & 0xff)}) // timestamp 96
+		writeUint(w, uint64(t.Nanosecond()), 4)
+		return writeUint(w, uint64(t.Unix()), 8)
+	}
+	return errors.Errorf("unable to encode %T as msgpack", v)
+}
+
+// writeMsgpackLength writes the type and length of a string or binary. Strings shorter than fixMax use the fix
+// format. The 8, 16 and 32 bit length formats are format8, format8+1 and format8+2
+func writeMsgpackLength(w *bufio.Writer, n int, fix byte, fixMax int, format8 byte) {
+	switch {
+	case n < fixMax:
+		w.WriteByte(fix | byte(n))
+	case n <= math.MaxUint8:
+		w.WriteByte(format8)
+		writeUint(w, uint64(n), 1)
+	case n <= math.MaxUint16:
+		w.WriteByte(format8 + 1)
+		writeUint(w, uint64(n), 2)
+	default:
+		w.WriteByte(format8 + 2)
+		writeUint(w, uint64(n), 4)
+	}
+}
+
+// writeUint writes the low size bytes of v, big endian
+func writeUint(w *bufio.Writer, v uint64, size int) error {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	_, err := w.Write(b[8-size:])
+	return err
+}
+
+func readUint(r *bufio.Reader, size int) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[8-size:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+func readMsgpack(r *bufio.Reader) (interface{}, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x90:
+		return readMsgpackArray(r, int(b&0x0f))
+	case b&0xe0 == 0xa0:
+		return readMsgpackBytes(r, int(b&0x1f), true)
+	}
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6, 0xd9, 0xda, 0xdb:
+		size := 1 << (b - 0xd9) // 1, 2 or 4 byte length
+		if b <= 0xc6 {
+			size = 1 << (b - 0xc4)
+		}
+		n, err := readUint(r, size)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(r, int(n), b >= 0xd9)
+	case 0xca:
+		n, err := readUint(r, 4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := readUint(r, 8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce:
+		n, err := readUint(r, 1<<(b-0xcc))
+		return int64(n), err
+	case 0xcf:
+		n, err := readUint(r, 8)
+		if n <= math.MaxInt64 {
+			return int64(n), err
+		}
+		return n, err
+	case 0xd0:
+		n, err := readUint(r, 1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := readUint(r, 2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := readUint(r, 4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := readUint(r, 8)
+		return int64(n), err
+	case 0xdc, 0xdd:
+		n, err := readUint(r, 2<<(b-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(r, int(n))
+	case 0xd6:
+		return readMsgpackTimestamp(r, 4)
+	case 0xd7:
+		return readMsgpackTimestamp(r, 8)
+	case 0xc7:
+		n, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackTimestamp(r, int(n))
+	}
+	return nil, errors.Errorf("unsupported msgpack format 0x%x", b)
+}
+
+func readMsgpackArray(r *bufio.Reader, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	for i := range values {
+		v, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func readMsgpackBytes(r *bufio.Reader, n int, str bool) (interface{}, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	if str {
+		return string(b), nil
+	}
+	return b, nil
+}
+
+// readMsgpackTimestamp reads an extension of size bytes, which must be a timestamp in one of its 32, 64 or 96
+// bit formats
+func readMsgpackTimestamp(r *bufio.Reader, size int) (interface{}, error) {
+	ext, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if int8(ext) != msgpackTimestamp {
+		return nil, errors.Errorf("unsupported msgpack extension type %d", int8(ext))
+	}
+	switch size {
+	case 4:
+		sec, err := readUint(r, 4)
+		return time.Unix(int64(sec), 0).UTC(), err
+	case 8:
+		n, err := readUint(r, 8)
+		return time.Unix(int64(n&0x3ffffffff), int64(n>>34)).UTC(), err
+	case 12:
+		nsec, err := readUint(r, 4)
+		if err != nil {
+			return nil, err
+		}
+		sec, err := readUint(r, 8)
+		return time.Unix(int64(sec), int64(nsec)).UTC(), err
+	}
+	return nil, errors.Errorf("invalid msgpack timestamp length %d", size)
+}
@@ -0,0 +1,111 @@
+package onedb
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type codecRow struct {
+	ID      int32
+	Big     uint64
+	Name    *string
+	Score   float32
+	Active  bool
+	Avatar  []byte
+	Created time.Time
+	Parent  sql.NullInt64
+}
+
+func codecRows() RowsScanner {
+	name := strings.Repeat("a", 40)
+	created := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	return NewRowsScanner([]codecRow{
+		{-100000, math.MaxUint64, &name, 1.5, true, bytes.Repeat([]byte{1}, 300), created, sql.NullInt64{Int64: -5, Valid: true}},
+		{7, 200, nil, 0, false, []byte{}, time.Unix(0, 0).UTC(), sql.NullInt64{}},
+	})
+}
+
+func TestRowsCodecs(t *testing.T) {
+	codecs := map[string]struct {
+		encode func(io.Writer, RowsScanner) error
+		decode func(io.Reader) (*ResultSet, error)
+	}{
+		"gob":     {EncodeGob, DecodeGob},
+		"msgpack": {EncodeMsgpack, DecodeMsgpack},
+	}
+	name := strings.Repeat("a", 40)
+	expected := &ResultSet{
+		Columns: []string{"ID", "Big", "Name", "Score", "Active", "Avatar", "Created", "Parent"},
+		Values: [][]interface{}{
+			{int64(-100000), uint64(math.MaxUint64), name, float64(1.5), true, bytes.Repeat([]byte{1}, 300), time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), int64(-5)},
+			{int64(7), int64(200), nil, float64(0), false, []byte{}, time.Unix(0, 0).UTC(), nil},
+		},
+	}
+	for format, codec := range codecs {
+		var buf bytes.Buffer
+		if err := codec.encode(&buf, codecRows()); err != nil {
+			t.Fatal(format, "expected success", err)
+		}
+		result, err := codec.decode(&buf)
+		if err != nil {
+			t.Fatal(format, "expected success", err)
+		}
+		if !reflect.DeepEqual(result.Columns, expected.Columns) {
+			t.Error(format, "expected columns", result.Columns)
+		}
+		for i := range expected.Values {
+			for j := range expected.Values[i] {
+				if actual := result.Values[i][j]; !reflect.DeepEqual(actual, expected.Values[i][j]) {
+					t.Errorf("%s: expected %#v at row %d column %d, got %#v", format, expected.Values[i][j], i, j, actual)
+				}
+			}
+		}
+
+		var rows []codecRow
+		if err := QueryStruct(&resultSetBackend{result}, &rows, "select"); err != nil || len(rows) != 2 || *rows[0].Name != name || rows[1].Parent.Valid {
+			t.Error(format, "expected decoded result to scan into structs", rows, err)
+		}
+	}
+}
+
+func TestRowsCodecErrors(t *testing.T) {
+	type unsupported struct{ C chan int }
+	if err := EncodeMsgpack(io.Discard, NewRowsScanner([]unsupported{{}})); err == nil {
+		t.Error("expected error for a value which can't be encoded")
+	}
+	if _, err := DecodeMsgpack(bytes.NewReader([]byte{0x91, 0x01})); err == nil {
+		t.Error("expected error for column names which aren't strings")
+	}
+	if _, err := DecodeMsgpack(bytes.NewReader([]byte{0x91, 0xa1, 'a', 0x92, 0x01, 0x02})); err == nil {
+		t.Error("expected error for a row with the wrong number of values")
+	}
+	if _, err := DecodeGob(bytes.NewReader(nil)); err == nil {
+		t.Error("expected error for an empty stream")
+	}
+	if v, err := readMsgpack(bufio.NewReader(bytes.NewReader([]byte{0xd6, 0xff, 0, 0, 0, 10}))); err != nil || !v.(time.Time).Equal(time.Unix(10, 0)) {
+		t.Error("expected 32 bit timestamp", v, err)
+	}
+}
+
+/***************************** MOCKS ****************************/
+
+type resultSetBackend struct {
+	result *ResultSet
+}
+
+func (b *resultSetBackend) Query(query string, args ...interface{}) (RowsScanner, error) {
+	return b.result.Rows(), nil
+}
+
+func (b *resultSetBackend) QueryRow(query string, args ...interface{}) Scanner {
+	rows := b.result.Rows()
+	rows.Next()
+	return rows
+}
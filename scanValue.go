@@ -0,0 +1,148 @@
+package onedb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ScanValue stores src, a value returned by a driver, in dest the way database/sql's Scan does, for drivers
+// which only return values. dest can be an sql.Scanner, which is passed src or the value of a driver.Valuer, or
+// a pointer to any type src converts to: numbers, strings and bytes convert to each other when they fit, bools
+// parse from text, and pointers are allocated. NULL can only be stored in pointers, slices, maps and interfaces
+func ScanValue(src interface{}, dest interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.Errorf("ScanValue requires a non-nil pointer, not %T", dest)
+	}
+	if scanner, ok := dest.(sql.Scanner); ok {
+		if valuer, ok := src.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil {
+				return err
+			}
+			src = v
+		}
+		return scanner.Scan(src)
+	}
+	elem := value.Elem()
+	if src == nil {
+		switch elem.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			elem.Set(reflect.Zero(elem.Type()))
+			return nil
+		}
+		return errors.Errorf("Unable to scan NULL into %T", dest)
+	}
+	return scanValue(elem, src)
+}
+
+func scanValue(dest reflect.Value, src interface{}) error {
+	t := dest.Type()
+	value := reflect.ValueOf(src)
+	if value.Type().AssignableTo(t) {
+		if b, ok := src.([]byte); ok {
+			src = append([]byte(nil), b...) // drivers may reuse the buffer
+		}
+		dest.Set(reflect.ValueOf(src))
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		elem := reflect.New(t.Elem())
+		if err := scanValue(elem.Elem(), src); err != nil {
+			return err
+		}
+		dest.Set(elem)
+		return nil
+	}
+	if valuer, ok := src.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			return errors.Errorf("Unable to scan NULL into %s", t)
+		}
+		return scanValue(dest, v)
+	}
+	if b, ok := src.([]byte); ok {
+		src, value = string(b), reflect.ValueOf(string(b))
+	}
+	kind := value.Kind()
+	switch t.Kind() {
+	case reflect.String:
+		switch v := src.(type) {
+		case bool:
+			dest.SetString(strconv.FormatBool(v))
+			return nil
+		case time.Time:
+			dest.SetString(v.Format(time.RFC3339Nano))
+			return nil
+		}
+		if isNumber(kind) {
+			dest.SetString(numberText(value))
+			return nil
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && kind == reflect.String {
+			dest.SetBytes([]byte(value.String()))
+			return nil
+		}
+	case reflect.Bool:
+		if kind == reflect.String {
+			b, err := strconv.ParseBool(value.String())
+			if err != nil {
+				return errors.Errorf("Unable to scan %q into %s", value.String(), t)
+			}
+			dest.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isNumber(kind) || kind == reflect.String {
+			n, err := strconv.ParseInt(numberText(value), 10, t.Bits())
+			if err != nil {
+				return errors.Errorf("Unable to scan %s into %s", numberText(value), t)
+			}
+			dest.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if isNumber(kind) || kind == reflect.String {
+			n, err := strconv.ParseUint(numberText(value), 10, t.Bits())
+			if err != nil {
+				return errors.Errorf("Unable to scan %s into %s", numberText(value), t)
+			}
+			dest.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if isNumber(kind) || kind == reflect.String {
+			n, err := strconv.ParseFloat(numberText(value), t.Bits())
+			if err != nil {
+				return errors.Errorf("Unable to scan %s into %s", numberText(value), t)
+			}
+			dest.SetFloat(n)
+			return nil
+		}
+	}
+	return convertElement(dest, src)
+}
+
+// numberText formats a number the way database/sql does when scanning one into a string
+func numberText(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 64)
+	}
+	return value.String()
+}
@@ -0,0 +1,51 @@
+package onedb
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestScanValue(t *testing.T) {
+	var i int
+	var u uint16
+	var f float32
+	var s string
+	var b []byte
+	var ok bool
+	if ScanValue(int64(5), &i) != nil || ScanValue([]byte("6"), &u) != nil || ScanValue("1.5", &f) != nil || i != 5 || u != 6 || f != 1.5 {
+		t.Error("expected numbers converted", i, u, f)
+	}
+	if ScanValue(2.5, &s) != nil || s != "2.5" || ScanValue("text", &b) != nil || string(b) != "text" || ScanValue("t", &ok) != nil || !ok {
+		t.Error("expected text converted", s, b, ok)
+	}
+	src := []byte("buf")
+	if ScanValue(src, &b) != nil || &b[0] == &src[0] {
+		t.Error("expected bytes copied")
+	}
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var when *time.Time
+	if err := ScanValue(created, &when); err != nil || !when.Equal(created) {
+		t.Error("expected pointer allocated", when, err)
+	}
+	if err := ScanValue(nil, &when); err != nil || when != nil {
+		t.Error("expected NULL stored as nil", when, err)
+	}
+	var ns sql.NullInt64
+	if err := ScanValue(int32(3), &ns); err != nil || !ns.Valid || ns.Int64 != 3 {
+		t.Error("expected value passed to the scanner", ns, err)
+	}
+	var h Hstore
+	if err := ScanValue(Hstore{"a": nil}, &h); err != nil || len(h) != 1 {
+		t.Error("expected a driver.Valuer's value passed to the scanner", h, err)
+	}
+
+	var small int8
+	for _, err := range []error{ScanValue(nil, &i), ScanValue(int64(300), &small), ScanValue(1.5, &i), ScanValue(-1, &u),
+		ScanValue("maybe", &ok), ScanValue(1, i), ScanValue(struct{}{}, &s)} {
+		if err == nil {
+			t.Error("expected error")
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package schema describes the tables of a PostgreSQL database from information_schema and pg_catalog, for tools
+// such as code generators and schema validators built on onedb
+package schema
+
+import (
+	"github.com/EndFirstCorp/onedb"
+	"github.com/pkg/errors"
+)
+
+// ErrTableNotFound occurs when Describe is given a table which doesn't exist in the schema
+var ErrTableNotFound = errors.New("table not found")
+
+// ConstraintType is the kind of a table constraint
+type ConstraintType string
+
+// Constraint types
+const (
+	PrimaryKey ConstraintType = "PRIMARY KEY"
+	Unique     ConstraintType = "UNIQUE"
+	ForeignKey ConstraintType = "FOREIGN KEY"
+	Check      ConstraintType = "CHECK"
+	Exclude    ConstraintType = "EXCLUDE"
+)
+
+// Table is a table or view along with its columns, indexes and constraints
+type Table struct {
+	Schema      string       `db:"table_schema"`
+	Name        string       `db:"table_name"`
+	Type        string       `db:"table_type"` // BASE TABLE, VIEW, FOREIGN or LOCAL TEMPORARY
+	Columns     []Column     `db:"-"`
+	Indexes     []Index      `db:"-"`
+	Constraints []Constraint `db:"-"`
+}
+
+// Column returns the column called name
+func (t *Table) Column(name string) (Column, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// PrimaryKey returns the table's primary key constraint
+func (t *Table) PrimaryKey() (Constraint, bool) {
+	for _, c := range t.Constraints {
+		if c.Type == PrimaryKey {
+			return c, true
+		}
+	}
+	return Constraint{}, false
+}
+
+// Column is a column of a table
+type Column struct {
+	Table     string `db:"table_name"`
+	Name      string `db:"column_name"`
+	Position  int    `db:"ordinal_position"`
+	DataType  string `db:"data_type"` // the SQL type, e.g. character varying, or USER-DEFINED or ARRAY
+	UDTName   string `db:"udt_name"`  // the underlying type, e.g. varchar, the enum's name or _int4 for int[]
+	Nullable  bool   `db:"nullable"`
+	Default   string `db:"column_default"` // the default expression, empty when there isn't one
+	MaxLength int    `db:"max_length"`     // the length limit of character types, 0 when there isn't one
+}
+
+// Index is an index on a table
+type Index struct {
+	Table      string
+	Name       string
+	Columns    []string // in index order, empty for an expression
+	Unique     bool
+	Primary    bool
+	Definition string // the CREATE INDEX statement
+}
+
+// Constraint is a constraint on a table
+type Constraint struct {
+	Table          string
+	Name           string
+	Type           ConstraintType
+	Columns        []string
+	ForeignTable   string   // the table a foreign key references
+	ForeignColumns []string // the columns a foreign key references, matching Columns
+	Definition     string
+}
+
+// Tables describes every table and view in schema, such as public, ordered by name
+func Tables(db onedb.Backender, schema string) ([]Table, error) {
+	return describe(db, schema, "")
+}
+
+// Describe describes a single table or view
+func Describe(db onedb.Backender, schema, table string) (*Table, error) {
+	tables, err := describe(db, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, ErrTableNotFound
+	}
+	return &tables[0], nil
+}
+
+const tablesQuery = `select table_schema::text, table_name::text, table_type::text
+from information_schema.tables
+where table_schema = $1 and ($2 = '' or table_name = $2)
+order by table_name`
+
+const columnsQuery = `select table_name::text, column_name::text, ordinal_position::int, data_type::text, udt_name::text,
+	is_nullable = 'YES' as nullable, coalesce(column_default::text, '') as column_default,
+	coalesce(character_maximum_length::int, 0) as max_length
+from information_schema.columns
+where table_schema = $1 and ($2 = '' or table_name = $2)
+order by table_name, ordinal_position`
+
+const indexesQuery = `select t.relname::text as table_name, i.relname::text as index_name, ix.indisunique as is_unique,
+	ix.indisprimary as is_primary, pg_get_indexdef(ix.indexrelid) as definition, coalesce(a.attname::text, '') as column_name
+from pg_index ix
+join pg_class i on i.oid = ix.indexrelid
+join pg_class t on t.oid = ix.indrelid
+join pg_namespace n on n.oid = t.relnamespace
+cross join lateral unnest(ix.indkey::int2[]) with ordinality as k(attnum, position)
+left join pg_attribute a on a.attrelid = t.oid and a.attnum = k.attnum
+where n.nspname = $1 and ($2 = '' or t.relname = $2)
+order by t.relname, i.relname, k.position`
+
+const constraintsQuery = `select t.relname::text as table_name, c.conname::text as constraint_name,
+	case c.contype when 'p' then 'PRIMARY KEY' when 'u' then 'UNIQUE' when 'f' then 'FOREIGN KEY'
+		when 'c' then 'CHECK' when 'x' then 'EXCLUDE' else c.contype::text end as constraint_type,
+	pg_get_constraintdef(c.oid) as definition, coalesce(a.attname::text, '') as column_name,
+	coalesce(rt.relname::text, '') as foreign_table, coalesce(ra.attname::text, '') as foreign_column
+from pg_constraint c
+join pg_class t on t.oid = c.conrelid
+join pg_namespace n on n.oid = t.relnamespace
+left join lateral unnest(c.conkey) with ordinality as k(attnum, position) on true
+left join pg_attribute a on a.attrelid = t.oid and a.attnum = k.attnum
+left join pg_class rt on rt.oid = c.confrelid
+left join pg_attribute ra on ra.attrelid = c.confrelid and ra.attnum = c.confkey[k.position]
+where n.nspname = $1 and ($2 = '' or t.relname = $2)
+order by t.relname, c.conname, k.position`
+
+// indexRow is one column of an index. Indexes are read a row per column and grouped, rather than as arrays,
+// so any backend can scan them
+type indexRow struct {
+	Table      string `db:"table_name"`
+	Name       string `db:"index_name"`
+	Unique     bool   `db:"is_unique"`
+	Primary    bool   `db:"is_primary"`
+	Definition string `db:"definition"`
+	Column     string `db:"column_name"`
+}
+
+// constraintRow is one column of a constraint
+type constraintRow struct {
+	Table         string `db:"table_name"`
+	Name          string `db:"constraint_name"`
+	Type          string `db:"constraint_type"`
+	Definition    string `db:"definition"`
+	Column        string `db:"column_name"`
+	ForeignTable  string `db:"foreign_table"`
+	ForeignColumn string `db:"foreign_column"`
+}
+
+func describe(db onedb.Backender, schema, table string) ([]Table, error) {
+	tables, err := onedb.QueryRows[Table](db, tablesQuery, schema, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to query tables")
+	}
+	if len(tables) == 0 {
+		return tables, nil
+	}
+	byName := make(map[string]*Table, len(tables))
+	for i := range tables {
+		byName[tables[i].Name] = &tables[i]
+	}
+
+	columns, err := onedb.QueryRows[Column](db, columnsQuery, schema, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to query columns")
+	}
+	for _, c := range columns {
+		if t, ok := byName[c.Table]; ok {
+			t.Columns = append(t.Columns, c)
+		}
+	}
+
+	indexes, err := onedb.QueryRows[indexRow](db, indexesQuery, schema, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to query indexes")
+	}
+	for _, row := range indexes {
+		t, ok := byName[row.Table]
+		if !ok {
+			continue
+		}
+		if n := len(t.Indexes); n == 0 || t.Indexes[n-1].Name != row.Name {
+			t.Indexes = append(t.Indexes, Index{Table: row.Table, Name: row.Name, Unique: row.Unique, Primary: row.Primary, Definition: row.Definition})
+		}
+		if row.Column != "" {
+			index := &t.Indexes[len(t.Indexes)-1]
+			index.Columns = append(index.Columns, row.Column)
+		}
+	}
+
+	constraints, err := onedb.QueryRows[constraintRow](db, constraintsQuery, schema, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to query constraints")
+	}
+	for _, row := range constraints {
+		t, ok := byName[row.Table]
+		if !ok {
+			continue
+		}
+		if n := len(t.Constraints); n == 0 || t.Constraints[n-1].Name != row.Name {
+			t.Constraints = append(t.Constraints, Constraint{Table: row.Table, Name: row.Name, Type: ConstraintType(row.Type),
+				ForeignTable: row.ForeignTable, Definition: row.Definition})
+		}
+		constraint := &t.Constraints[len(t.Constraints)-1]
+		if row.Column != "" {
+			constraint.Columns = append(constraint.Columns, row.Column)
+		}
+		if row.ForeignColumn != "" {
+			constraint.ForeignColumns = append(constraint.ForeignColumns, row.ForeignColumn)
+		}
+	}
+	return tables, nil
+}
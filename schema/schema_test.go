@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+func expectDescribe(d onedb.Mocker, table string) {
+	d.ExpectQuery(tablesQuery).WithArgs("public", table).WillReturnRows([]Table{{Schema: "public", Name: "orders", Type: "BASE TABLE"}, {Schema: "public", Name: "users", Type: "BASE TABLE"}})
+	d.ExpectQuery(columnsQuery).WithArgs("public", table).WillReturnRows([]Column{
+		{Table: "orders", Name: "id", Position: 1, DataType: "integer", UDTName: "int4", Default: "nextval('orders_id_seq'::regclass)"},
+		{Table: "orders", Name: "user_id", Position: 2, DataType: "integer", UDTName: "int4", Nullable: true},
+		{Table: "users", Name: "id", Position: 1, DataType: "integer", UDTName: "int4"},
+	})
+	d.ExpectQuery(indexesQuery).WithArgs("public", table).WillReturnRows([]indexRow{
+		{Table: "orders", Name: "orders_pkey", Unique: true, Primary: true, Column: "id"},
+		{Table: "orders", Name: "orders_user_created", Column: "user_id"},
+		{Table: "orders", Name: "orders_user_created", Column: "id"},
+		{Table: "users", Name: "users_lower_email"},
+	})
+	d.ExpectQuery(constraintsQuery).WithArgs("public", table).WillReturnRows([]constraintRow{
+		{Table: "orders", Name: "orders_pkey", Type: "PRIMARY KEY", Column: "id"},
+		{Table: "orders", Name: "orders_user_id_fkey", Type: "FOREIGN KEY", Column: "user_id", ForeignTable: "users", ForeignColumn: "id"},
+	})
+}
+
+func TestTables(t *testing.T) {
+	d := onedb.NewMock(nil, nil)
+	expectDescribe(d, "")
+	tables, err := Tables(d, "public")
+	if err != nil || len(tables) != 2 {
+		t.Fatal("expected both tables", tables, err)
+	}
+	d.VerifyExpectations(t)
+
+	orders := tables[0]
+	if c, ok := orders.Column("user_id"); !ok || !c.Nullable || c.Position != 2 {
+		t.Error("expected columns grouped by table", orders.Columns)
+	}
+	if len(orders.Indexes) != 2 || !reflect.DeepEqual(orders.Indexes[1].Columns, []string{"user_id", "id"}) {
+		t.Error("expected index columns in order", orders.Indexes)
+	}
+	if pk, ok := orders.PrimaryKey(); !ok || pk.Name != "orders_pkey" {
+		t.Error("expected primary key", orders.Constraints)
+	}
+	if fk := orders.Constraints[1]; fk.Type != ForeignKey || fk.ForeignTable != "users" || !reflect.DeepEqual(fk.ForeignColumns, []string{"id"}) {
+		t.Error("expected foreign key", fk)
+	}
+	if users := tables[1]; len(users.Columns) != 1 || len(users.Indexes) != 1 || users.Indexes[0].Columns != nil {
+		t.Error("expected expression index without columns", users)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	d := onedb.NewMock(nil, nil)
+	d.ExpectQuery(tablesQuery).WithArgs("public", "missing")
+	if _, err := Describe(d, "public", "missing"); err != ErrTableNotFound {
+		t.Error("expected ErrTableNotFound", err)
+	}
+
+	d = onedb.NewMock(nil, nil)
+	expectDescribe(d, "orders")
+	if table, err := Describe(d, "public", "orders"); err != nil || table.Name != "orders" {
+		t.Error("expected table", table, err)
+	}
+
+	d = onedb.NewMock(nil, nil)
+	d.ExpectQuery(tablesQuery).WillReturnError(errors.New("fail"))
+	if _, err := Tables(d, "public"); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,154 @@
+package onedb
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// scriptStatement is a statement of a script and the line it starts on
+type scriptStatement struct {
+	sql  string
+	line int
+}
+
+// SplitStatements splits a SQL script into its statements at each semicolon which isn't inside a string, a
+// quoted identifier, a dollar-quoted body like a function's $$ ... $$ or a comment. Statements which are empty
+// or only comments are left out
+func SplitStatements(script string) []string {
+	statements := splitScript(script)
+	sqls := make([]string, len(statements))
+	for i, s := range statements {
+		sqls[i] = s.sql
+	}
+	return sqls
+}
+
+// ExecScript runs each statement of script through db in order, stopping at the first which fails, for bootstrap
+// and maintenance scripts. Each is run with Exec. Use ExecScriptTx to run the whole
+// script in a transaction
+func ExecScript(db Backender, script string) error {
+	for i, s := range splitScript(script) {
+		if err := Exec(db, s.sql); err != nil {
+			return errors.Wrapf(err, "Unable to run statement %d on line %d", i+1, s.line)
+		}
+	}
+	return nil
+}
+
+// ExecScriptTx runs script like ExecScript in a transaction begun on db, so a failing statement leaves none of
+// the script's changes behind
+func ExecScriptTx[T interface {
+	Txer
+	Backender
+}](db TxBeginner[T], script string) error {
+	return WithTx(db, func(tx T) error {
+		return ExecScript(tx, script)
+	})
+}
+
+func splitScript(script string) []scriptStatement {
+	var statements []scriptStatement
+	codeStart := -1 // the first character of the current statement which isn't whitespace or a comment
+	add := func(end int) {
+		if codeStart >= 0 {
+			statements = append(statements, scriptStatement{sql: strings.TrimSpace(script[codeStart:end]), line: 1 + strings.Count(script[:codeStart], "\n")})
+		}
+		codeStart = -1
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == ';':
+			add(i)
+			continue
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(script)
+			}
+			continue
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			i = blockCommentEnd(script, i)
+			continue
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			continue
+		}
+		if codeStart < 0 {
+			codeStart = i
+		}
+		switch {
+		case c == '\'':
+			i = quoteEnd(script, i, '\'', i > 0 && (script[i-1] == 'E' || script[i-1] == 'e') && (i < 2 || !isIdentByte(script[i-2])))
+		case c == '"':
+			i = quoteEnd(script, i, '"', false)
+		case c == '$' && (i == 0 || !isIdentByte(script[i-1])):
+			if tag := dollarTag(script[i:]); tag != "" {
+				if end := strings.Index(script[i+len(tag):], tag); end >= 0 {
+					i += end + 2*len(tag) - 1
+				} else {
+					i = len(script)
+				}
+			}
+		}
+	}
+	add(len(script))
+	return statements
+}
+
+// quoteEnd returns the index of the quote closing the string or identifier starting at i. Doubled quotes are
+// escaped quotes, as are quotes after a backslash when backslashes escape
+func quoteEnd(script string, i int, quote byte, backslashes bool) int {
+	for j := i + 1; j < len(script); j++ {
+		switch {
+		case backslashes && script[j] == '\\':
+			j++
+		case script[j] == quote && j+1 < len(script) && script[j+1] == quote:
+			j++
+		case script[j] == quote:
+			return j
+		}
+	}
+	return len(script) - 1
+}
+
+// blockCommentEnd returns the index of the end of the comment starting at i. Block comments nest
+func blockCommentEnd(script string, i int) int {
+	depth := 0
+	for j := i; j < len(script)-1; j++ {
+		switch script[j : j+2] {
+		case "/*":
+			depth++
+			j++
+		case "*/":
+			if depth--; depth == 0 {
+				return j + 1
+			}
+			j++
+		}
+	}
+	return len(script) - 1
+}
+
+// dollarTag returns the dollar quote, like $$ or $body$, s starts with, or "" if it doesn't start with one, e.g.
+// when it is a parameter like $1
+func dollarTag(s string) string {
+	for j := 1; j < len(s); j++ {
+		switch c := s[j]; {
+		case c == '$':
+			return s[:j+1]
+		case c >= '0' && c <= '9':
+			if j == 1 {
+				return ""
+			}
+		case !isIdentByte(c):
+			return ""
+		}
+	}
+	return ""
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
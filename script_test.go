@@ -0,0 +1,63 @@
+package onedb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- bootstrap; not a statement
+create table users (id int, name text default 'it''s; fine');
+/* a /* nested; */ comment */
+insert into users values (1, E'back\'slash;'), (2, "quoted;name");;
+create function f() returns int language sql as $body$ select 1; $$ still body; $body$;
+create function g() returns int language plpgsql as $$ begin return $1; end $$
+-- trailing comment;`
+	expected := []string{
+		"create table users (id int, name text default 'it''s; fine')",
+		`insert into users values (1, E'back\'slash;'), (2, "quoted;name")`,
+		"create function f() returns int language sql as $body$ select 1; $$ still body; $body$",
+		"create function g() returns int language plpgsql as $$ begin return $1; end $$\n-- trailing comment;",
+	}
+	if actual := SplitStatements(script); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	lines := []int{}
+	for _, s := range splitScript(script) {
+		lines = append(lines, s.line)
+	}
+	if !reflect.DeepEqual(lines, []int{2, 4, 5, 6}) {
+		t.Error("expected line each statement starts on", lines)
+	}
+	if len(SplitStatements(" -- nothing\n /* here */ ; ")) != 0 {
+		t.Error("expected no statements")
+	}
+	if actual := SplitStatements("select 'unterminated; "); len(actual) != 1 {
+		t.Error("expected unterminated string to run to the end", actual)
+	}
+}
+
+func TestExecScript(t *testing.T) {
+	fail := errors.New("fail")
+	m := NewMock(nil, nil)
+	m.ExpectQuery("create table users (id int)")
+	m.ExpectQuery("insert into users values (1)").WillReturnError(fail)
+	err := ExecScript(m, "create table users (id int);\n\ninsert into users values (1);\nselect 1;")
+	if errors.Cause(err) != fail || !strings.Contains(err.Error(), "statement 2 on line 3") {
+		t.Error("expected failing statement to stop the script", err)
+	}
+	m.VerifyExpectations(t)
+
+	m = NewMock(nil, nil)
+	m.ExpectBegin()
+	m.ExpectQuery("create table users (id int)")
+	m.ExpectQuery("create table roles (id int)")
+	m.ExpectCommit()
+	if err := ExecScriptTx[MockTxer](m, "create table users (id int); create table roles (id int)"); err != nil {
+		t.Error("expected success", err)
+	}
+	m.VerifyExpectations(t)
+}
@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"context"
 	sqllib "database/sql"
 	"io"
 
@@ -26,11 +27,13 @@ type sqllibBackend struct {
 
 // SQLer is the interface containing the capability available for a database/sql database
 type SQLer interface {
+	onedb.Pinger
 	onedb.DBer
 }
 
 type sqlLibBackender interface {
 	Ping() error
+	PingContext(ctx context.Context) error
 	Close() error
 	Exec(query string, args ...interface{}) (sqllib.Result, error)
 	Query(query string, args ...interface{}) (*sqllib.Rows, error)
@@ -50,6 +53,15 @@ func NewSqllib(driverName, connectionString string) (SQLer, error) {
 	return &sqllibBackend{db: sqlDb}, nil
 }
 
+// Ping verifies that a connection to the database is alive, establishing one if needed
+func (b *sqllibBackend) Ping() error {
+	return b.db.Ping()
+}
+
+func (b *sqllibBackend) PingContext(ctx context.Context) error {
+	return b.db.PingContext(ctx)
+}
+
 func (b *sqllibBackend) Close() error {
 	return b.db.Close()
 }
@@ -79,6 +91,10 @@ func (b *sqllibBackend) QueryJSONRow(query string, args ...interface{}) (string,
 	return onedb.QueryJSONRow(b, query, args...)
 }
 
+func (b *sqllibBackend) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, b, query, args...)
+}
+
 func (b *sqllibBackend) QueryStruct(result interface{}, query string, args ...interface{}) error {
 	return onedb.QueryStruct(b, result, query, args...)
 }
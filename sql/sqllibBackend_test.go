@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"context"
 	sqllib "database/sql"
 	"errors"
 	"reflect"
@@ -62,6 +63,17 @@ func TestSqllibQuery(t *testing.T) {
 	verifyArgs(t, c.MethodsRun[0].Arguments, "query", "arg1", "arg2")
 }
 
+func TestSqllibPing(t *testing.T) {
+	c := &mockSqllibBackend{PingErr: errors.New("fail")}
+	d := &sqllibBackend{db: c}
+	if d.Ping() != c.PingErr || d.PingContext(context.Background()) != c.PingErr {
+		t.Error("expected ping error")
+	}
+	if len(c.MethodsRun) != 1 || c.MethodsRun[0].MethodName != "PingContext" {
+		t.Error("expected PingContext method to be called on backend")
+	}
+}
+
 func TestSqllibExecute(t *testing.T) {
 	c := newMockSqllibBackend()
 	d := &sqllibBackend{db: c}
@@ -109,6 +121,11 @@ func (c *mockSqllibBackend) Ping() error {
 	return c.PingErr
 }
 
+func (c *mockSqllibBackend) PingContext(ctx context.Context) error {
+	c.SaveMethodCall("PingContext", nil)
+	return c.PingErr
+}
+
 func (c *mockSqllibBackend) Close() error {
 	c.SaveMethodCall("Close", nil)
 	return nil
@@ -0,0 +1,88 @@
+package onedb
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SQLCommentOptions adds sqlcommenter style comments such as /*app='billing',route='%2Finvoices'*/ to the
+// statements sent to the database, so the load seen in pg_stat_activity or a slow query log can be traced back to
+// the code path which ran it
+type SQLCommentOptions struct {
+	Tags map[string]string // added to every statement, e.g. app or db_driver
+
+	// ContextTags returns tags for a statement run with ctx, e.g. its route or traceparent. Tags added to ctx
+	// with WithSQLCommentTags take precedence over these, which take precedence over Tags
+	ContextTags func(ctx context.Context) map[string]string
+}
+
+type sqlCommentKey struct{}
+
+// WithSQLCommentTags returns a context carrying tags for the comments of the statements run with it, in addition
+// to any tags ctx already carries
+func WithSQLCommentTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string)
+	for k, v := range sqlCommentTags(ctx) {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, sqlCommentKey{}, merged)
+}
+
+func sqlCommentTags(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(sqlCommentKey{}).(map[string]string)
+	return tags
+}
+
+// Comment returns query with a comment holding the tags for ctx appended, before a trailing semicolon. query is
+// returned unchanged when there are no tags or it already has a comment. A nil *SQLCommentOptions only uses the
+// tags added with WithSQLCommentTags
+func (o *SQLCommentOptions) Comment(ctx context.Context, query string) string {
+	tags := make(map[string]string)
+	if o != nil {
+		for k, v := range o.Tags {
+			tags[k] = v
+		}
+		if o.ContextTags != nil {
+			for k, v := range o.ContextTags(ctx) {
+				tags[k] = v
+			}
+		}
+	}
+	for k, v := range sqlCommentTags(ctx) {
+		tags[k] = v
+	}
+	if len(tags) == 0 || strings.Contains(query, "/*") {
+		return query
+	}
+	trimmed := strings.TrimRight(query, " \t\r\n")
+	if strings.HasSuffix(trimmed, ";") {
+		return strings.TrimSuffix(trimmed, ";") + " " + SQLComment(tags) + ";"
+	}
+	return trimmed + " " + SQLComment(tags)
+}
+
+// SQLComment formats tags as a sqlcommenter comment: key='value' pairs sorted by key, with both URL encoded,
+// separated by commas
+func SQLComment(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = sqlCommentEscape(k) + "='" + sqlCommentEscape(tags[k]) + "'"
+	}
+	return "/*" + strings.Join(pairs, ",") + "*/"
+}
+
+// sqlCommentEscape URL encodes s with spaces as %20. Quotes and the characters of */ are encoded too, so the
+// result can't end the comment or the quoted value
+func sqlCommentEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,39 @@
+package onedb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSQLComment(t *testing.T) {
+	comment := SQLComment(map[string]string{"route": "/invoices/{id}", "app": "billing svc", "note": "it's */"})
+	if comment != "/*app='billing%20svc',note='it%27s%20%2A%2F',route='%2Finvoices%2F%7Bid%7D'*/" {
+		t.Error("expected sorted and encoded tags", comment)
+	}
+}
+
+func TestSQLCommentOptionsComment(t *testing.T) {
+	options := &SQLCommentOptions{
+		Tags: map[string]string{"app": "billing", "route": "default"},
+		ContextTags: func(ctx context.Context) map[string]string {
+			return map[string]string{"traceparent": "00-abc-def-01"}
+		},
+	}
+	ctx := WithSQLCommentTags(context.Background(), map[string]string{"route": "/invoices"})
+	ctx = WithSQLCommentTags(ctx, map[string]string{"action": "list"})
+
+	if q := options.Comment(ctx, "select 1;\n"); q != "select 1 /*action='list',app='billing',route='%2Finvoices',traceparent='00-abc-def-01'*/;" {
+		t.Error("expected comment before semicolon", q)
+	}
+	if q := options.Comment(ctx, "select /*+ hint */ 1"); q != "select /*+ hint */ 1" {
+		t.Error("expected commented query to be unchanged", q)
+	}
+
+	var none *SQLCommentOptions
+	if q := none.Comment(context.Background(), "select 1"); q != "select 1" {
+		t.Error("expected query unchanged without tags", q)
+	}
+	if q := none.Comment(ctx, "select 1"); q != "select 1 /*action='list',route='%2Finvoices'*/" {
+		t.Error("expected context tags", q)
+	}
+}
@@ -0,0 +1,265 @@
+package sqladapter
+
+import (
+	"context"
+	sqllib "database/sql"
+	"io"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+// Querier is the set of statements which can be run against either the database or a transaction
+type Querier interface {
+	Exec(query string, args ...interface{}) (sqllib.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sqllib.Result, error)
+	onedb.Backender
+	onedb.ContextBackender
+	onedb.DBer
+}
+
+// DBer is the interface containing the capability available for an adapted *sql.DB
+type DBer interface {
+	Begin() (Txer, error)
+	BeginTx(ctx context.Context, opts *sqllib.TxOptions) (Txer, error)
+	Close() error
+	DB() *sqllib.DB
+	onedb.Pinger
+	Querier
+}
+
+// Txer is a transaction on an adapted *sql.DB
+type Txer interface {
+	Commit() error
+	Rollback() error
+	Querier
+}
+
+// sqlQuerier is the part of *sql.DB and *sql.Tx used to run statements
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sqllib.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sqllib.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sqllib.Row
+}
+
+// Rewriter rewrites a query and its arguments before they are sent to the driver, e.g. to translate
+// placeholders to the driver's syntax
+type Rewriter func(query string, args []interface{}) (string, []interface{}, error)
+
+// Retrier runs fn, running it again while it fails in a way which is safe to retry, such as on a dead connection.
+// It wraps Begin and the statements run on the database, but never those in a transaction since the transaction
+// is lost with its connection
+type Retrier func(ctx context.Context, fn func() error) error
+
+// Options sets how statements are run on an adapted *sql.DB. Both are optional
+type Options struct {
+	Rewrite Rewriter
+	Retry   Retrier
+}
+
+type sqlBackend struct {
+	db *sqllib.DB
+	querier
+}
+
+// New adapts an open *sql.DB from any database/sql driver to onedb. The caller keeps ownership of the driver
+// registration and connection settings; Close closes db
+func New(db *sqllib.DB) DBer {
+	return NewWithRewriter(db, nil)
+}
+
+// NewWithRewriter adapts db like New, running every statement through rewrite first
+func NewWithRewriter(db *sqllib.DB, rewrite Rewriter) DBer {
+	return NewWithOptions(db, Options{Rewrite: rewrite})
+}
+
+// NewWithOptions adapts db like New, running statements as set by options
+func NewWithOptions(db *sqllib.DB, options Options) DBer {
+	return &sqlBackend{db: db, querier: querier{db: db, rewrite: options.Rewrite, retry: options.Retry}}
+}
+
+// Open opens a database with database/sql, verifies the connection with a ping and adapts it to onedb
+func Open(driverName, dataSourceName string) (DBer, error) {
+	db, err := sqllib.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return New(db), nil
+}
+
+func (b *sqlBackend) Begin() (Txer, error) {
+	return b.BeginTx(context.Background(), nil)
+}
+
+func (b *sqlBackend) BeginTx(ctx context.Context, opts *sqllib.TxOptions) (Txer, error) {
+	var tx *sqllib.Tx
+	err := b.run(ctx, func() error {
+		var err error
+		tx, err = b.db.BeginTx(ctx, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTx{tx: tx, querier: querier{db: tx, rewrite: b.rewrite}}, nil
+}
+
+func (b *sqlBackend) Close() error {
+	return b.db.Close()
+}
+
+// Ping verifies that a connection to the database is alive, establishing one if needed
+func (b *sqlBackend) Ping() error {
+	return b.db.Ping()
+}
+
+func (b *sqlBackend) PingContext(ctx context.Context) error {
+	return b.db.PingContext(ctx)
+}
+
+// DB returns the underlying *sql.DB for features onedb doesn't wrap
+func (b *sqlBackend) DB() *sqllib.DB {
+	return b.db
+}
+
+type sqlTx struct {
+	tx *sqllib.Tx
+	querier
+}
+
+func (t *sqlTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *sqlTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// querier runs statements for both the database and transactions. retry is nil in a transaction
+type querier struct {
+	db      sqlQuerier
+	rewrite Rewriter
+	retry   Retrier
+}
+
+// run runs fn with the retrier, or once without one
+func (q querier) run(ctx context.Context, fn func() error) error {
+	if q.retry == nil {
+		return fn()
+	}
+	return q.retry(ctx, fn)
+}
+
+func (q querier) prepare(query string, args []interface{}) (string, []interface{}, error) {
+	if q.rewrite == nil {
+		return query, args, nil
+	}
+	return q.rewrite(query, args)
+}
+
+func (q querier) Exec(query string, args ...interface{}) (sqllib.Result, error) {
+	return q.ExecContext(context.Background(), query, args...)
+}
+
+func (q querier) ExecContext(ctx context.Context, query string, args ...interface{}) (sqllib.Result, error) {
+	query, args, err := q.prepare(query, args)
+	if err != nil {
+		return nil, err
+	}
+	var result sqllib.Result
+	err = q.run(ctx, func() error {
+		result, err = q.db.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+func (q querier) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return q.QueryContext(context.Background(), query, args...)
+}
+
+func (q querier) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	query, args, err := q.prepare(query, args)
+	if err != nil {
+		return nil, err
+	}
+	var rows *sqllib.Rows
+	err = q.run(ctx, func() error {
+		rows, err = q.db.QueryContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err // avoid returning a non-nil interface holding a nil *sql.Rows
+	}
+	return rows, nil
+}
+
+func (q querier) QueryRow(query string, args ...interface{}) onedb.Scanner {
+	return q.QueryRowContext(context.Background(), query, args...)
+}
+
+func (q querier) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	query, args, err := q.prepare(query, args)
+	if err != nil {
+		return &errRow{err}
+	}
+	if q.retry != nil {
+		return &retryRow{q: q, ctx: ctx, query: query, args: args}
+	}
+	return q.db.QueryRowContext(ctx, query, args...)
+}
+
+func (q querier) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValues(q, query, result...)
+}
+
+func (q querier) QueryJSON(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSON(q, query, args...)
+}
+
+func (q querier) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRow(q, query, args...)
+}
+
+func (q querier) QueryJSONWriter(w io.Writer, query string, args ...interface{}) error {
+	return onedb.QueryJSONWriter(w, q, query, args...)
+}
+
+func (q querier) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStruct(q, result, query, args...)
+}
+
+func (q querier) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRow(q, result, query, args...)
+}
+
+func (q querier) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSV(w, options, q, query, args...)
+}
+
+// errRow is returned by QueryRow when the query can't be run so the error is reported by Scan
+type errRow struct {
+	err error
+}
+
+func (r *errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
+
+// retryRow defers running the query until Scan so that a query failing on a dead connection can be retried;
+// *sql.Row doesn't report errors until then
+type retryRow struct {
+	q     querier
+	ctx   context.Context
+	query string
+	args  []interface{}
+}
+
+func (r *retryRow) Scan(dest ...interface{}) error {
+	return r.q.retry(r.ctx, func() error {
+		return r.q.db.QueryRowContext(r.ctx, r.query, r.args...).Scan(dest...)
+	})
+}
@@ -0,0 +1,288 @@
+package sqladapter
+
+import (
+	"context"
+	sqllib "database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+func TestQueryStruct(t *testing.T) {
+	d, db := newFakeDB(t)
+	d.columns = []string{"id", "name"}
+	d.values = [][]driver.Value{{int64(1), "alice"}, {int64(2), "bob"}}
+
+	type user struct {
+		ID   int64
+		Name string
+	}
+	var users []user
+	if err := db.QueryStruct(&users, "select id, name from users where active = ?", true); err != nil {
+		t.Fatal("expected success", err)
+	}
+	if len(users) != 2 || users[0].ID != 1 || users[1].Name != "bob" {
+		t.Error("expected rows scanned into structs", users)
+	}
+	if d.lastQuery != "select id, name from users where active = ?" || len(d.lastArgs) != 1 {
+		t.Error("expected query and args passed to the driver", d.lastQuery, d.lastArgs)
+	}
+}
+
+func TestQueryValues(t *testing.T) {
+	d, db := newFakeDB(t)
+	d.columns = []string{"count"}
+	d.values = [][]driver.Value{{int64(3)}}
+
+	var count int
+	if err := db.QueryValues(onedb.NewQuery("select count(*) from users"), &count); err != nil || count != 3 {
+		t.Error("expected count to be scanned", count, err)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	d, db := newFakeDB(t)
+	d.err = errors.New("fail")
+	rows, err := db.Query("select 1")
+	if err == nil || rows != nil {
+		t.Error("expected error and a nil RowsScanner", rows, err)
+	}
+}
+
+func TestExec(t *testing.T) {
+	d, db := newFakeDB(t)
+	result, err := db.Exec("delete from users")
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 1 || d.lastQuery != "delete from users" {
+		t.Error("expected exec to run", affected, d.lastQuery)
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	d, db := newFakeDB(t)
+	err := onedb.WithTx[Txer](db, func(tx Txer) error {
+		_, err := tx.Exec("insert into users values (1)")
+		return err
+	})
+	if err != nil || d.commits != 1 || d.rollbacks != 0 {
+		t.Error("expected commit", err, d.commits, d.rollbacks)
+	}
+
+	err = onedb.WithTx[Txer](db, func(tx Txer) error {
+		return errors.New("fail")
+	})
+	if err == nil || d.commits != 1 || d.rollbacks != 1 {
+		t.Error("expected rollback", err, d.commits, d.rollbacks)
+	}
+}
+
+func TestRewriter(t *testing.T) {
+	d := &fakeDriver{columns: []string{"id"}, values: [][]driver.Value{{int64(1)}}}
+	sqllib.Register("sqladapter-rewrite", d)
+	sqlDb, _ := sqllib.Open("sqladapter-rewrite", "")
+	db := NewWithRewriter(sqlDb, func(query string, args []interface{}) (string, []interface{}, error) {
+		if query == "bad" {
+			return "", nil, errors.New("fail")
+		}
+		return strings.ToUpper(query), append(args, "extra"), nil
+	})
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal("expected begin success", err)
+	}
+	var id int
+	if err := tx.QueryRow("select id", 1).Scan(&id); err != nil || id != 1 {
+		t.Error("expected row", id, err)
+	}
+	if d.lastQuery != "SELECT ID" || len(d.lastArgs) != 2 {
+		t.Error("expected rewritten query in transaction", d.lastQuery, d.lastArgs)
+	}
+	tx.Rollback()
+
+	if err := db.QueryRow("bad").Scan(&id); err == nil {
+		t.Error("expected rewrite error from Scan")
+	}
+	if _, err := db.Exec("bad"); err == nil {
+		t.Error("expected rewrite error from Exec")
+	}
+}
+
+func TestRetrier(t *testing.T) {
+	d := &fakeDriver{columns: []string{"id"}, values: [][]driver.Value{{int64(1)}}}
+	sqllib.Register("sqladapter-retry", d)
+	sqlDb, _ := sqllib.Open("sqladapter-retry", "")
+	retries := 0
+	db := NewWithOptions(sqlDb, Options{Retry: func(ctx context.Context, fn func() error) error {
+		err := fn()
+		for err != nil && retries < 1 {
+			retries++
+			d.err = nil
+			err = fn()
+		}
+		return err
+	}})
+	defer db.Close()
+
+	d.err = errors.New("bad connection")
+	if _, err := db.Exec("delete from users"); err != nil || retries != 1 {
+		t.Error("expected Exec retried", retries, err)
+	}
+	retries = 0
+	d.err = errors.New("bad connection")
+	var id int
+	if err := db.QueryRow("select id").Scan(&id); err != nil || id != 1 || retries != 1 {
+		t.Error("expected QueryRow retried when scanned", id, retries, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal("expected begin success", err)
+	}
+	defer tx.Rollback()
+	retries = 0
+	d.err = errors.New("bad connection")
+	if _, err := tx.Exec("delete from users"); err == nil || retries != 0 {
+		t.Error("expected statements in a transaction not retried", retries)
+	}
+}
+
+func TestOpenPingFailure(t *testing.T) {
+	fake := &fakeDriver{pingErr: errors.New("fail")}
+	sqllib.Register("sqladapter-ping", fake)
+	if _, err := Open("sqladapter-ping", ""); err == nil {
+		t.Error("expected ping error")
+	}
+}
+
+func TestPing(t *testing.T) {
+	d, db := newFakeDB(t)
+	if err := db.Ping(); err != nil {
+		t.Error("expected ping success", err)
+	}
+	d.pingErr = errors.New("fail")
+	if err := db.PingContext(context.Background()); err != d.pingErr {
+		t.Error("expected ping error", err)
+	}
+}
+
+/***************************** MOCKS ****************************/
+func newFakeDB(t *testing.T) (*fakeDriver, DBer) {
+	d := &fakeDriver{}
+	name := "sqladapter-" + strings.ReplaceAll(t.Name(), "/", "-")
+	sqllib.Register(name, d)
+	db, err := Open(name, "")
+	if err != nil {
+		t.Fatal("expected open success", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return d, db
+}
+
+// fakeDriver is a minimal database/sql driver which returns the same columns and values for every query
+type fakeDriver struct {
+	columns   []string
+	values    [][]driver.Value
+	err       error
+	pingErr   error
+	lastQuery string
+	lastArgs  []driver.Value
+	commits   int
+	rollbacks int
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{d: c.d, query: query}, nil
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	return c.d.pingErr
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return &fakeTx{c.d}, nil
+}
+
+type fakeTx struct {
+	d *fakeDriver
+}
+
+func (t *fakeTx) Commit() error {
+	t.d.commits++
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.d.rollbacks++
+	return nil
+}
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.lastQuery, s.d.lastArgs = s.query, args
+	if s.d.err != nil {
+		return nil, s.d.err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.lastQuery, s.d.lastArgs = s.query, args
+	if s.d.err != nil {
+		return nil, s.d.err
+	}
+	return &fakeRows{columns: s.d.columns, values: s.d.values}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	sqllib "database/sql"
+
+	"github.com/EndFirstCorp/onedb/sqladapter"
+
+	// For importing the pure Go sqlite driver.
+	_ "modernc.org/sqlite"
+)
+
+// driverName is the database/sql driver registered by modernc.org/sqlite
+const driverName = "sqlite"
+
+// SQLiter is the interface containing the capability available for a sqlite database
+type SQLiter interface {
+	sqladapter.DBer
+}
+
+// Open opens the sqlite database file at path, creating it if needed. path may also be a file: URI with
+// query parameters such as _pragma=foreign_keys(1)
+func Open(path string) (SQLiter, error) {
+	db, err := sqllib.Open(driverName, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return sqladapter.New(db), nil
+}
+
+// OpenMemory opens a new, empty in-memory database. Every connection to ":memory:" gets its own database, so the
+// pool is limited to a single connection which is never closed while the database is open. Statements run
+// on the database block until an open transaction is committed or rolled back
+func OpenMemory() (SQLiter, error) {
+	db, err := sqllib.Open(driverName, ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return sqladapter.New(db), nil
+}
@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/EndFirstCorp/onedb/sqladapter"
+)
+
+type user struct {
+	ID   int64
+	Name string
+}
+
+func TestOpenMemory(t *testing.T) {
+	db := openMemory(t)
+	if _, err := db.Exec("insert into users (id, name) values ($1, $2), ($3, $4)", 1, "alice", 2, "bob"); err != nil {
+		t.Fatal("expected insert success", err)
+	}
+
+	var users []user
+	if err := db.QueryStruct(&users, "select id, name from users order by id"); err != nil {
+		t.Fatal("expected query success", err)
+	}
+	if len(users) != 2 || users[0].Name != "alice" || users[1].ID != 2 {
+		t.Error("expected inserted users", users)
+	}
+
+	var name string
+	if err := db.QueryRow("select name from users where id = $1", 2).Scan(&name); err != nil || name != "bob" {
+		t.Error("expected QueryRow to find bob", name, err)
+	}
+}
+
+func TestOpenMemoryIsolated(t *testing.T) {
+	openMemory(t)
+	other, err := OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if _, err := other.Query("select * from users"); err == nil {
+		t.Error("expected each in-memory database to be separate")
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	db := openMemory(t)
+	err := onedb.WithTx[sqladapter.Txer](db, func(tx sqladapter.Txer) error {
+		if _, err := tx.Exec("insert into users (id, name) values (1, 'alice')"); err != nil {
+			return err
+		}
+		return errors.New("fail")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var count int
+	if err := db.QueryValues(onedb.NewQuery("select count(*) from users"), &count); err != nil || count != 0 {
+		t.Error("expected insert to be rolled back", count, err)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal("expected open success", err)
+	}
+	if _, err := db.Exec("create table t (a int)"); err != nil {
+		t.Error("expected create success", err)
+	}
+	db.Close()
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatal("expected reopen success", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("insert into t values (1)"); err != nil {
+		t.Error("expected table to persist", err)
+	}
+}
+
+func openMemory(t *testing.T) SQLiter {
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatal("expected open success", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec("create table users (id integer primary key, name text not null)"); err != nil {
+		t.Fatal("expected create success", err)
+	}
+	return db
+}
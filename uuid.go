@@ -0,0 +1,86 @@
+package onedb
+
+import (
+	encodinghex "encoding/hex"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ScanUUID stores the value of a uuid column in dest, which must point to a 16 byte array such as a
+// github.com/google/uuid UUID, or to a string. src can be a UUID's text in any of its usual forms, 16 raw bytes,
+// or another 16 byte array, so UUIDs come back the same way from every backend
+func ScanUUID(src interface{}, dest interface{}) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.IsNil() || !isUUIDField(value.Elem().Type()) && value.Elem().Kind() != reflect.String {
+		return errors.Errorf("ScanUUID requires a pointer to a 16 byte array or a string, not %T", dest)
+	}
+	if src == nil {
+		value.Elem().Set(reflect.Zero(value.Elem().Type()))
+		return nil
+	}
+	id, ok, err := uuidValue(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("Unable to scan %T as a UUID", src)
+	}
+	if value.Elem().Kind() == reflect.String {
+		value.Elem().SetString(formatUUID(id))
+		return nil
+	}
+	setUUID(value.Elem(), id)
+	return nil
+}
+
+func isUUIDField(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8
+}
+
+// uuidValue converts the value of a uuid column, reporting false if it can't be one
+func uuidValue(src interface{}) ([16]byte, bool, error) {
+	var id [16]byte
+	switch v := src.(type) {
+	case string:
+		id, err := parseUUID(v)
+		return id, err == nil, err
+	case []byte:
+		if len(v) == 16 {
+			copy(id[:], v)
+			return id, true, nil
+		}
+		return uuidValue(string(v))
+	}
+	value := reflect.ValueOf(src)
+	if !isUUIDField(value.Type()) {
+		return id, false, nil
+	}
+	reflect.Copy(reflect.ValueOf(id[:]), value)
+	return id, true, nil
+}
+
+func setUUID(dest reflect.Value, id [16]byte) {
+	dest.Set(reflect.ValueOf(id).Convert(dest.Type()))
+}
+
+// parseUUID parses the text of a UUID with or without hyphens, braces or a urn:uuid: prefix
+func parseUUID(s string) ([16]byte, error) {
+	var id [16]byte
+	text := strings.TrimPrefix(strings.ToLower(s), "urn:uuid:")
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "{"), "}")
+	text = strings.ReplaceAll(text, "-", "")
+	if len(text) != 32 {
+		return id, errors.Errorf("invalid UUID %q", s)
+	}
+	if _, err := encodinghex.Decode(id[:], []byte(text)); err != nil {
+		return id, errors.Errorf("invalid UUID %q", s)
+	}
+	return id, nil
+}
+
+func formatUUID(id [16]byte) string {
+	text := encodinghex.EncodeToString(id[:])
+	return text[0:8] + "-" + text[8:12] + "-" + text[12:16] + "-" + text[16:20] + "-" + text[20:]
+}
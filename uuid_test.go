@@ -0,0 +1,74 @@
+package onedb
+
+import (
+	"testing"
+)
+
+type uuidType [16]byte
+
+var testUUID = uuidType{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+const testUUIDText = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+func TestScanUUID(t *testing.T) {
+	for _, src := range []interface{}{testUUIDText, "{6BA7B810-9DAD-11D1-80B4-00C04FD430C8}", "urn:uuid:" + testUUIDText,
+		"6ba7b8109dad11d180b400c04fd430c8", []byte(testUUIDText), testUUID[:], [16]byte(testUUID)} {
+		var id uuidType
+		if err := ScanUUID(src, &id); err != nil || id != testUUID {
+			t.Errorf("expected %v to scan as a UUID: %v %v", src, id, err)
+		}
+	}
+	var text string
+	if err := ScanUUID(testUUID[:], &text); err != nil || text != testUUIDText {
+		t.Error("expected UUID formatted as text", text, err)
+	}
+	id := testUUID
+	if err := ScanUUID(nil, &id); err != nil || id != (uuidType{}) {
+		t.Error("expected NULL to clear the UUID", id, err)
+	}
+	if err := ScanUUID("6ba7b810-9dad", &id); err == nil {
+		t.Error("expected error for invalid text")
+	}
+	if err := ScanUUID(1, &id); err == nil {
+		t.Error("expected error for a non-UUID")
+	}
+	var n int
+	if err := ScanUUID(testUUIDText, &n); err == nil {
+		t.Error("expected error for an invalid destination")
+	}
+}
+
+type uuidRow struct {
+	ID     string
+	Parent []byte
+	Others []interface{}
+}
+
+type uuidResult struct {
+	ID     uuidType
+	Parent uuidType
+	Others []uuidType
+}
+
+func TestGetStructUUID(t *testing.T) {
+	result := []uuidResult{}
+	rows := NewRowsScanner([]uuidRow{{testUUIDText, testUUID[:], []interface{}{testUUIDText}}})
+	if err := getStruct(rows, &result, StructOptions{}); err != nil || len(result) != 1 {
+		t.Fatal("expected success", err, result)
+	}
+	if r := result[0]; r.ID != testUUID || r.Parent != testUUID || len(r.Others) != 1 || r.Others[0] != testUUID {
+		t.Error("expected text and bytes converted into UUIDs", r)
+	}
+
+	rows = NewRowsScanner([]uuidRow{{ID: "nope"}})
+	if err := getStruct(rows, &result, StructOptions{}); err == nil {
+		t.Error("expected error for text which isn't a UUID")
+	}
+}
+
+func TestGetJSONValueUUID(t *testing.T) {
+	var v interface{} = [16]byte(testUUID)
+	if s := getJSONValue(&v); s != `"`+testUUIDText+`"` {
+		t.Error("expected UUID as a JSON string", s)
+	}
+}